@@ -0,0 +1,394 @@
+// Package abuse temporarily bans client IPs that show abusive patterns
+// Shared by the status API and the proxies
+package abuse
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"sauron/clientip"
+	"sauron/config"
+	"sauron/metrics"
+	"sauron/storage"
+
+	"go.uber.org/zap"
+)
+
+// Events counted towards a ban
+const (
+	EventRateLimited = "rate_limited" // request refused by a rate or connection limit (429)
+	EventAuthFailure = "auth_failure" // missing, invalid or expired credentials, or a bad ring signature (401)
+	EventMalformed   = "malformed"    // request the backend couldn't understand (400)
+
+	// ReasonManual is the reason of bans added through the admin API
+	ReasonManual = "manual"
+)
+
+// Abuse detection defaults (used when abuse_detection values are unset)
+const (
+	DefaultWindow       = time.Minute
+	DefaultBanDuration  = 15 * time.Minute
+	DefaultRateLimited  = 100
+	DefaultAuthFailures = 20
+	DefaultMalformed    = 50
+)
+
+// DefaultMaxEntries bounds the number of tracked client events when abuse_detection.max_entries is unset
+const DefaultMaxEntries = 10000
+
+// SyncInterval is how often bans are reloaded from Redis and stale counters dropped
+const SyncInterval = 30 * time.Second
+
+// Ban is a client IP refused until a point in time
+type Ban = storage.BanRecord
+
+// counter counts one client's events of one kind in a fixed window
+type counter struct {
+	key   string // event:ip
+	start time.Time
+	count int
+}
+
+// Detector counts abusive events per client IP and bans clients crossing a threshold
+// A nil *Detector records nothing and bans no one
+type Detector struct {
+	configLoader *config.Loader
+	cache        *storage.Cache
+	resolver     *clientip.Resolver
+	logger       *zap.Logger
+
+	mu         sync.Mutex
+	counters   map[string]*list.Element // event:ip -> element in lru (value: *counter)
+	lru        *list.List               // most recently counted at the front
+	bans       map[string]Ban           // ip -> ban
+	exemptFrom []string                 // abuse_detection.exempt the exempt networks were parsed from
+	exemptNets []*net.IPNet
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates a detector, restoring bans stored in Redis (cache may be disabled)
+// Client IPs honor forwarding headers from trusted_proxies
+func New(configLoader *config.Loader, cache *storage.Cache, logger *zap.Logger) *Detector {
+	resolver, err := clientip.NewResolver(false, configLoader.Get().TrustedProxies)
+	if err != nil {
+		// Validation rejects bad entries, so only reachable for hand-built configs
+		logger.Error("Invalid trusted_proxies, ignoring forwarding headers", zap.Error(err))
+		resolver, _ = clientip.NewResolver(false, nil)
+	}
+
+	d := &Detector{
+		configLoader: configLoader,
+		cache:        cache,
+		resolver:     resolver,
+		logger:       logger,
+		counters:     make(map[string]*list.Element),
+		lru:          list.New(),
+		bans:         make(map[string]Ban),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	d.sync()
+
+	go d.syncLoop()
+	return d
+}
+
+// Stop ends the background sync
+func (d *Detector) Stop() {
+	if d == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+}
+
+// ClientIP returns the IP a request is counted and banned under
+func (d *Detector) ClientIP(r *http.Request) string {
+	return d.resolver.ClientIP(r)
+}
+
+// Record counts an event of a request's client
+func (d *Detector) Record(r *http.Request, event string) {
+	if d == nil {
+		return
+	}
+	d.RecordIP(d.ClientIP(r), event)
+}
+
+// RecordIP counts an event of a client IP, banning it when the event's threshold is reached within the window
+// At most max_entries client events are counted; the least recently seen is dropped first
+func (d *Detector) RecordIP(ip, event string) {
+	if d == nil || ip == "" {
+		return
+	}
+	settings := d.configLoader.Get().AbuseDetection
+
+	threshold := threshold(settings, event)
+	window := settings.Window
+	if window == 0 {
+		window = DefaultWindow
+	}
+	maxEntries := settings.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	now := time.Now()
+	key := event + ":" + ip
+
+	d.mu.Lock()
+	if d.exempt(settings.Exempt, ip) {
+		d.mu.Unlock()
+		return
+	}
+	if _, banned := d.activeBan(ip, now); banned {
+		d.mu.Unlock()
+		return
+	}
+	var c *counter
+	if elem, ok := d.counters[key]; ok {
+		d.lru.MoveToFront(elem)
+		c = elem.Value.(*counter)
+		if now.Sub(c.start) >= window {
+			c.start, c.count = now, 0
+		}
+	} else {
+		c = &counter{key: key, start: now}
+		d.counters[key] = d.lru.PushFront(c)
+
+		// Evict the least recently seen clients once over the bound (e.g. floods from many addresses)
+		for len(d.counters) > maxEntries {
+			d.removeCounter(d.lru.Back())
+		}
+	}
+	c.count++
+	if c.count < threshold {
+		d.mu.Unlock()
+		return
+	}
+	d.removeCounter(d.counters[key])
+	d.mu.Unlock()
+
+	d.Ban(ip, event, 0)
+}
+
+// Banned reports whether a client IP is currently banned
+func (d *Detector) Banned(ip string) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, banned := d.activeBan(ip, time.Now())
+	return banned
+}
+
+// Ban bans a client IP for duration (0 = ban_duration); an existing ban is replaced
+func (d *Detector) Ban(ip, reason string, duration time.Duration) Ban {
+	if duration == 0 {
+		duration = d.configLoader.Get().AbuseDetection.BanDuration
+	}
+	if duration == 0 {
+		duration = DefaultBanDuration
+	}
+
+	now := time.Now()
+	ban := Ban{IP: ip, Reason: reason, Since: now, Until: now.Add(duration)}
+
+	d.mu.Lock()
+	d.bans[ip] = ban
+	metrics.AbuseBannedIPs.Set(float64(len(d.bans)))
+	d.mu.Unlock()
+
+	metrics.AbuseBans.WithLabelValues(reason).Inc()
+	d.logger.Warn("Client IP banned",
+		zap.String("ip", ip),
+		zap.String("reason", reason),
+		zap.Duration("duration", duration),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	d.cache.SaveBan(ctx, ban)
+	return ban
+}
+
+// Unban lifts the ban of a client IP; returns false if it wasn't banned
+func (d *Detector) Unban(ip string) bool {
+	d.mu.Lock()
+	_, banned := d.activeBan(ip, time.Now())
+	delete(d.bans, ip)
+	metrics.AbuseBannedIPs.Set(float64(len(d.bans)))
+	d.mu.Unlock()
+
+	if banned {
+		d.logger.Info("Client IP unbanned", zap.String("ip", ip))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	d.cache.DeleteBan(ctx, ip)
+	return banned
+}
+
+// Bans returns the active bans, the ones expiring first at the front
+func (d *Detector) Bans() []Ban {
+	now := time.Now()
+
+	d.mu.Lock()
+	bans := make([]Ban, 0, len(d.bans))
+	for ip := range d.bans {
+		if ban, ok := d.activeBan(ip, now); ok {
+			bans = append(bans, ban)
+		}
+	}
+	d.mu.Unlock()
+
+	sort.Slice(bans, func(i, j int) bool {
+		if !bans[i].Until.Equal(bans[j].Until) {
+			return bans[i].Until.Before(bans[j].Until)
+		}
+		return bans[i].IP < bans[j].IP
+	})
+	return bans
+}
+
+// Middleware refuses requests from banned client IPs with 403, before any other work
+// listener labels the rejections (status, api or rpc)
+func (d *Detector) Middleware(listener string, next http.Handler) http.Handler {
+	if d == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.Banned(d.ClientIP(r)) {
+			metrics.AbuseRejections.WithLabelValues(listener).Inc()
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// activeBan returns a client's ban unless it expired; expired bans are dropped
+// Must be called with d.mu held
+func (d *Detector) activeBan(ip string, now time.Time) (Ban, bool) {
+	ban, ok := d.bans[ip]
+	if !ok {
+		return Ban{}, false
+	}
+	if !now.Before(ban.Until) {
+		delete(d.bans, ip)
+		metrics.AbuseBannedIPs.Set(float64(len(d.bans)))
+		return Ban{}, false
+	}
+	return ban, true
+}
+
+// removeCounter drops a counted client event
+// Must be called with d.mu held
+func (d *Detector) removeCounter(elem *list.Element) {
+	d.lru.Remove(elem)
+	delete(d.counters, elem.Value.(*counter).key)
+}
+
+// exempt reports whether a client IP is listed in abuse_detection.exempt
+// The list is parsed again only when a reload changes it
+// Must be called with d.mu held
+func (d *Detector) exempt(exempt []string, ip string) bool {
+	if len(exempt) == 0 {
+		return false
+	}
+	if !slices.Equal(exempt, d.exemptFrom) {
+		nets, err := clientip.ParseCIDRs(exempt)
+		if err != nil {
+			// Validation rejects bad entries, so only reachable for hand-built configs
+			nets = nil
+		}
+		d.exemptFrom, d.exemptNets = exempt, nets
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range d.exemptNets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// threshold returns how many events of a kind within the window ban a client
+func threshold(settings config.AbuseDetection, event string) int {
+	var n, fallback int
+	switch event {
+	case EventRateLimited:
+		n, fallback = settings.RateLimited, DefaultRateLimited
+	case EventAuthFailure:
+		n, fallback = settings.AuthFailures, DefaultAuthFailures
+	default:
+		n, fallback = settings.Malformed, DefaultMalformed
+	}
+	if n == 0 {
+		return fallback
+	}
+	return n
+}
+
+// syncLoop periodically reloads bans and drops stale counters until Stop
+func (d *Detector) syncLoop() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			d.sync()
+		}
+	}
+}
+
+// sync replaces the bans with those stored in Redis, when available, so bans and unbans made by
+// other replicas apply here too; counters older than the window are dropped
+func (d *Detector) sync() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	stored, ok := d.cache.LoadBans(ctx)
+	cancel()
+
+	window := d.configLoader.Get().AbuseDetection.Window
+	if window == 0 {
+		window = DefaultWindow
+	}
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if ok {
+		d.bans = make(map[string]Ban, len(stored))
+		for _, ban := range stored {
+			d.bans[ban.IP] = ban
+		}
+	}
+	for ip := range d.bans {
+		d.activeBan(ip, now)
+	}
+	for _, elem := range d.counters {
+		if now.Sub(elem.Value.(*counter).start) >= window {
+			d.removeCounter(elem)
+		}
+	}
+	metrics.AbuseBannedIPs.Set(float64(len(d.bans)))
+}
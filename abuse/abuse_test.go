@@ -0,0 +1,154 @@
+package abuse
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sauron/config"
+	"sauron/storage"
+
+	"go.uber.org/zap"
+)
+
+// newTestDetector creates a detector without Redis for the given abuse_detection settings
+func newTestDetector(t *testing.T, settings config.AbuseDetection) (*Detector, *config.Loader) {
+	t.Helper()
+	settings.Enabled = true
+	loader, err := config.NewStaticLoader(&config.Config{
+		RPC:            true,
+		Listen:         ":3000",
+		Timeouts:       config.Timeouts{HealthCheck: 5 * time.Second, Proxy: time.Second},
+		Networks:       []config.Network{{Name: "pocket", RPCListen: ":8081"}},
+		Internals:      []config.Node{{Name: "node-1", Network: "pocket", RPC: "http://node-1:26657"}},
+		AbuseDetection: settings,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config loader: %v", err)
+	}
+
+	d := New(loader, storage.NewCache("", zap.NewNop()), zap.NewNop())
+	t.Cleanup(d.Stop)
+	return d, loader
+}
+
+// TestDetectorBansAtThreshold tests that a client is banned on the event reaching its threshold, not before
+func TestDetectorBansAtThreshold(t *testing.T) {
+	d, _ := newTestDetector(t, config.AbuseDetection{AuthFailures: 3})
+
+	d.RecordIP("203.0.113.7", EventAuthFailure)
+	d.RecordIP("203.0.113.7", EventAuthFailure)
+	d.RecordIP("203.0.113.7", EventRateLimited)
+	if d.Banned("203.0.113.7") {
+		t.Fatal("Expected no ban below the threshold")
+	}
+
+	d.RecordIP("203.0.113.7", EventAuthFailure)
+	if !d.Banned("203.0.113.7") {
+		t.Fatal("Expected a ban at the threshold")
+	}
+	if d.Banned("203.0.113.8") {
+		t.Error("Expected other clients not to be banned")
+	}
+
+	bans := d.Bans()
+	if len(bans) != 1 || bans[0].Reason != EventAuthFailure {
+		t.Errorf("Expected one auth_failure ban, got %+v", bans)
+	}
+}
+
+// TestDetectorWindowRollover tests that events from an elapsed window don't count towards a ban
+func TestDetectorWindowRollover(t *testing.T) {
+	d, _ := newTestDetector(t, config.AbuseDetection{Window: time.Second, Malformed: 2})
+
+	d.RecordIP("203.0.113.7", EventMalformed)
+
+	// Age the window instead of sleeping through it
+	d.mu.Lock()
+	d.counters[EventMalformed+":203.0.113.7"].Value.(*counter).start = time.Now().Add(-2 * time.Second)
+	d.mu.Unlock()
+
+	d.RecordIP("203.0.113.7", EventMalformed)
+	if d.Banned("203.0.113.7") {
+		t.Fatal("Expected the elapsed window to be forgotten")
+	}
+
+	d.RecordIP("203.0.113.7", EventMalformed)
+	if !d.Banned("203.0.113.7") {
+		t.Error("Expected a ban for two events within the new window")
+	}
+}
+
+// TestDetectorBanExpiry tests that bans lift once their duration passes and can be lifted early
+func TestDetectorBanExpiry(t *testing.T) {
+	d, _ := newTestDetector(t, config.AbuseDetection{})
+
+	d.Ban("203.0.113.7", ReasonManual, 20*time.Millisecond)
+	d.Ban("203.0.113.8", ReasonManual, time.Hour)
+	if !d.Banned("203.0.113.7") || !d.Banned("203.0.113.8") {
+		t.Fatal("Expected both clients to be banned")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if d.Banned("203.0.113.7") {
+		t.Error("Expected the short ban to have expired")
+	}
+	if bans := d.Bans(); len(bans) != 1 || bans[0].IP != "203.0.113.8" {
+		t.Errorf("Expected only the long ban to be listed, got %+v", bans)
+	}
+
+	if !d.Unban("203.0.113.8") || d.Banned("203.0.113.8") {
+		t.Error("Expected the long ban to be lifted")
+	}
+	if d.Unban("203.0.113.8") {
+		t.Error("Expected lifting a missing ban to report false")
+	}
+}
+
+// TestDetectorExempt tests that exempt clients are never banned and that a reload changes the exemptions
+func TestDetectorExempt(t *testing.T) {
+	d, loader := newTestDetector(t, config.AbuseDetection{AuthFailures: 1, Exempt: []string{"10.0.0.0/8", "2001:db8::1"}})
+
+	for _, ip := range []string{"10.1.2.3", "2001:db8::1"} {
+		d.RecordIP(ip, EventAuthFailure)
+		if d.Banned(ip) {
+			t.Errorf("Expected exempt %s not to be banned", ip)
+		}
+	}
+
+	cfg := loader.Get()
+	cfg.AbuseDetection.Exempt = []string{"192.168.0.0/16"}
+	if err := loader.Update(cfg); err != nil {
+		t.Fatalf("Failed to update exemptions: %v", err)
+	}
+
+	d.RecordIP("10.1.2.3", EventAuthFailure)
+	if !d.Banned("10.1.2.3") {
+		t.Error("Expected 10.1.2.3 to be banned once no longer exempt")
+	}
+	d.RecordIP("192.168.1.1", EventAuthFailure)
+	if d.Banned("192.168.1.1") {
+		t.Error("Expected the reloaded exemption to apply")
+	}
+}
+
+// TestDetectorMaxEntries tests that counters are bounded, dropping the least recently seen client
+func TestDetectorMaxEntries(t *testing.T) {
+	d, _ := newTestDetector(t, config.AbuseDetection{MaxEntries: 10})
+
+	for i := 0; i < 100; i++ {
+		d.RecordIP(fmt.Sprintf("2001:db8::%x", i), EventRateLimited)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.counters) != 10 || d.lru.Len() != 10 {
+		t.Fatalf("Expected 10 tracked counters, got %d (lru %d)", len(d.counters), d.lru.Len())
+	}
+	if _, ok := d.counters[EventRateLimited+":2001:db8::63"]; !ok {
+		t.Error("Expected the most recent client to be kept")
+	}
+	if _, ok := d.counters[EventRateLimited+":2001:db8::0"]; ok {
+		t.Error("Expected the least recent client to be evicted")
+	}
+}
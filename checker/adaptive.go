@@ -0,0 +1,120 @@
+package checker
+
+import (
+	"sync"
+	"time"
+
+	"sauron/config"
+	"sauron/storage"
+)
+
+// Adaptive check defaults (used when adaptive_checks values are unset)
+const (
+	// DefaultAdaptiveFastInterval is how often unstable nodes are re-checked
+	DefaultAdaptiveFastInterval = 5 * time.Second
+	// DefaultAdaptiveLagThreshold is how many blocks behind counts as lagging
+	DefaultAdaptiveLagThreshold = 2
+	// DefaultAdaptiveFlapWindow is the window in which up/down transitions are counted
+	DefaultAdaptiveFlapWindow = 5 * time.Minute
+	// DefaultAdaptiveFlapCount is how many transitions within the window count as flapping
+	DefaultAdaptiveFlapCount = 3
+	// DefaultAdaptiveRecoveryPeriod is how long a recovered node stays under close watch
+	DefaultAdaptiveRecoveryPeriod = 2 * time.Minute
+)
+
+// checkState remembers recent check outcomes for one node endpoint
+type checkState struct {
+	ok          bool
+	transitions []time.Time // up/down changes within the flap window
+	recoveredAt time.Time
+}
+
+// checkTracker decides which internal endpoints deserve closer watch
+// The Eye lingers where the shadows stir
+type checkTracker struct {
+	store         *storage.HeightStore
+	endpointStore *storage.ExternalEndpointStore
+	mu            sync.Mutex
+	states        map[string]*checkState
+}
+
+// newCheckTracker creates a new check tracker
+func newCheckTracker(store *storage.HeightStore, endpointStore *storage.ExternalEndpointStore) *checkTracker {
+	return &checkTracker{
+		store:         store,
+		endpointStore: endpointStore,
+		states:        make(map[string]*checkState),
+	}
+}
+
+// record stores the outcome of a check, tracking transitions and recoveries
+func (t *checkTracker) record(network, node, endpointType string, ok bool, cfg config.Adaptive) {
+	key := network + ":" + node + ":" + endpointType
+	now := time.Now()
+	window := cfg.FlapWindow
+	if window == 0 {
+		window = DefaultAdaptiveFlapWindow
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, exists := t.states[key]
+	if !exists {
+		// First observation is not a transition
+		t.states[key] = &checkState{ok: ok}
+		return
+	}
+
+	if state.ok != ok {
+		state.transitions = append(state.transitions, now)
+		if ok {
+			state.recoveredAt = now
+		}
+		state.ok = ok
+	}
+
+	// Drop transitions outside the flap window
+	cutoff := now.Add(-window)
+	for len(state.transitions) > 0 && state.transitions[0].Before(cutoff) {
+		state.transitions = state.transitions[1:]
+	}
+}
+
+// isUnstable reports whether a node endpoint is lagging, flapping or recently recovered
+func (t *checkTracker) isUnstable(network, node, endpointType string, cfg config.Adaptive) bool {
+	flapCount := cfg.FlapCount
+	if flapCount == 0 {
+		flapCount = DefaultAdaptiveFlapCount
+	}
+	recovery := cfg.RecoveryPeriod
+	if recovery == 0 {
+		recovery = DefaultAdaptiveRecoveryPeriod
+	}
+	lagThreshold := cfg.LagThreshold
+	if lagThreshold == 0 {
+		lagThreshold = DefaultAdaptiveLagThreshold
+	}
+
+	key := network + ":" + node + ":" + endpointType
+
+	t.mu.Lock()
+	state, exists := t.states[key]
+	var flapping, recovering bool
+	if exists {
+		flapping = len(state.transitions) >= flapCount
+		recovering = !state.recoveredAt.IsZero() && time.Since(state.recoveredAt) < recovery
+	}
+	t.mu.Unlock()
+
+	if flapping || recovering {
+		return true
+	}
+
+	// Lagging: behind the estimated network head (a median, so one bogus height can't flag everyone)
+	metrics, ok := t.store.Get(network, node, endpointType)
+	if !ok {
+		return false
+	}
+	return storage.EstimateHead(t.store, t.endpointStore, network)-metrics.Height > lagThreshold
+}
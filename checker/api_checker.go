@@ -19,10 +19,12 @@ import (
 // APIChecker checks node heights via CosmosSDK REST API
 // The Eye gazing upon the API realm
 type APIChecker struct {
-	store  *storage.HeightStore
-	cache  *storage.Cache
-	client *http.Client
-	logger *zap.Logger
+	store    *storage.HeightStore
+	cache    *storage.Cache
+	client   *http.Client
+	identity Identity
+	budget   *probeBudget // set by the scheduler; nil probes WebSocket on every check
+	logger   *zap.Logger
 }
 
 // APIBlockResponse represents the CosmosSDK /cosmos/base/tendermint/v1beta1/blocks/latest response
@@ -40,19 +42,13 @@ type APIBlockResponse struct {
 }
 
 // NewAPIChecker creates a new API checker
-func NewAPIChecker(store *storage.HeightStore, cache *storage.Cache, logger *zap.Logger) *APIChecker {
+func NewAPIChecker(store *storage.HeightStore, cache *storage.Cache, transport config.HTTPTransport, identity Identity, logger *zap.Logger) *APIChecker {
 	return &APIChecker{
-		store: store,
-		cache: cache,
-		client: &http.Client{
-			Transport: &http.Transport{
-				MaxIdleConns:        HTTPMaxIdleConns,
-				MaxIdleConnsPerHost: HTTPMaxIdleConnsPerHost,
-				MaxConnsPerHost:     HTTPMaxConnsPerHost,
-				IdleConnTimeout:     HTTPIdleConnTimeout,
-			},
-		},
-		logger: logger,
+		store:    store,
+		cache:    cache,
+		client:   newHTTPClient(transport.WithDefaults(defaultTransport), identity),
+		identity: identity,
+		logger:   logger,
 	}
 }
 
@@ -127,6 +123,19 @@ func (c *APIChecker) CheckNode(ctx context.Context, node config.Node) error {
 	// Update storage
 	c.store.Update(node.Network, node.Name, "api", height, latency, "internal")
 
+	// Check WebSocket connectivity for nodes with a ws URL (e.g. EVM JSON-RPC)
+	if node.WS != "" && c.budget.webSocketDue(node, "api") {
+		wsAvailable := c.CheckWebSocketConnectivity(ctx, node)
+		c.store.UpdateWebSocketAvailability(node.Network, node.Name, "api", wsAvailable)
+
+		if wsAvailable {
+			metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "api").Set(1)
+		} else {
+			metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "api").Set(0)
+			metrics.WebSocketCheckErrors.WithLabelValues(node.Network, node.Name, "api", "connectivity_failed").Inc()
+		}
+	}
+
 	// Update cache if enabled
 	if c.cache.IsEnabled() {
 		c.cache.SetHeight(ctx, node.Network, node.Name, "api", height, 30*time.Second)
@@ -159,9 +168,33 @@ func (c *APIChecker) recordError(node config.Node, errorType string, err error)
 	)
 }
 
+// CheckWebSocketConnectivity tests if a node's API WebSocket endpoint accepts connections
+// Returns true if the ws URL completes a WebSocket handshake
+func (c *APIChecker) CheckWebSocketConnectivity(ctx context.Context, node config.Node) bool {
+	if node.WS == "" {
+		return false
+	}
+
+	if err := checkWebSocketHandshake(ctx, node.WS, c.identity); err != nil {
+		c.logger.Debug("API WebSocket connection failed",
+			zap.String("node", node.Name),
+			zap.String("network", node.Network),
+			zap.String("url", node.WS),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	c.logger.Debug("API WebSocket check successful",
+		zap.String("node", node.Name),
+		zap.String("network", node.Network),
+		zap.String("url", node.WS),
+	)
+
+	return true
+}
+
 // Close shuts down the HTTP client and closes idle connections
 func (c *APIChecker) Close() {
-	if transport, ok := c.client.Transport.(*http.Transport); ok {
-		transport.CloseIdleConnections()
-	}
+	c.client.CloseIdleConnections()
 }
@@ -0,0 +1,59 @@
+package checker
+
+import (
+	"sync"
+
+	"sauron/config"
+	"sauron/metrics"
+)
+
+// probeBudget spaces out probes of nodes with a probe_budget by counting their check rounds and height checks
+type probeBudget struct {
+	mu     sync.Mutex
+	rounds map[string]uint64 // network:node -> regular check rounds
+	checks map[string]uint64 // network:node:type -> height checks, which WebSocket probes follow
+}
+
+// newProbeBudget creates an empty probe budget tracker
+func newProbeBudget() *probeBudget {
+	return &probeBudget{
+		rounds: make(map[string]uint64),
+		checks: make(map[string]uint64),
+	}
+}
+
+// checkDue counts a regular check round of a node and reports whether it checks the node
+// The first round always checks, so a new node gets a height right away
+func (b *probeBudget) checkDue(node config.Node) bool {
+	if node.ProbeBudget.CheckEvery <= 1 {
+		return true
+	}
+	if b.due(b.rounds, node.Network+":"+node.Name, node.ProbeBudget.CheckEvery) {
+		return true
+	}
+	metrics.ProbesSkipped.WithLabelValues(node.Network, node.Name, "height").Inc()
+	return false
+}
+
+// webSocketDue counts a height check of a node endpoint type and reports whether it also probes WebSocket
+// Without a budget (standalone checkers) every check probes
+func (b *probeBudget) webSocketDue(node config.Node, endpointType string) bool {
+	if b == nil || node.ProbeBudget.WebSocketEvery <= 1 {
+		return true
+	}
+	if b.due(b.checks, node.Network+":"+node.Name+":"+endpointType, node.ProbeBudget.WebSocketEvery) {
+		return true
+	}
+	metrics.ProbesSkipped.WithLabelValues(node.Network, node.Name, "websocket").Inc()
+	return false
+}
+
+// due increments a counter and reports whether it landed on one in every
+func (b *probeBudget) due(counters map[string]uint64, key string, every int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := counters[key]
+	counters[key] = count + 1
+	return count%uint64(every) == 0
+}
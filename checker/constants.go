@@ -4,7 +4,7 @@ import "time"
 
 // HTTP client connection pool constants
 const (
-	// HTTPMaxIdleConns is the maximum number of idle connections across all hosts
+	// HTTPMaxIdleConns is the maximum number of idle connections of a pool (checkers keep one pool per host)
 	HTTPMaxIdleConns = 100
 	// HTTPMaxIdleConnsPerHost is the maximum number of idle connections per host
 	HTTPMaxIdleConnsPerHost = 100
@@ -17,4 +17,24 @@ const (
 	ExternalHTTPMaxIdleConns = 50
 	// ExternalHTTPMaxIdleConnsPerHost is the per-host pool size for external rings
 	ExternalHTTPMaxIdleConnsPerHost = 50
+	// HTTPTLSHandshakeTimeout bounds the TLS handshake with a node or ring
+	HTTPTLSHandshakeTimeout = 10 * time.Second
+)
+
+// Clock jump protection constants
+const (
+	// ClockWatchInterval is how often the wall clock is compared against the monotonic clock
+	ClockWatchInterval = 5 * time.Second
+	// ClockJumpThreshold is how far the clocks may drift apart in one interval before it counts as a jump
+	ClockJumpThreshold = 2 * time.Second
+)
+
+// External ring selection constants
+const (
+	// DefaultMismatchFactor is how far (as a ratio) a ring's height may be from the reference before
+	// the ring is assumed to serve another network
+	DefaultMismatchFactor = 2.0
+
+	// RingProbeInterval is how often non-preferred rings are still queried to keep their score current
+	RingProbeInterval = time.Minute
 )
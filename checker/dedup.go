@@ -0,0 +1,152 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"sauron/config"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// sharedProbeOrder lists endpoint types from cheapest to most expensive height probe
+// RPC /status is a tiny response, gRPC reuses a pooled connection, and the REST
+// blocks/latest call returns a whole block
+var sharedProbeOrder = []string{"rpc", "grpc", "api"}
+
+// endpointHost extracts the hostname from an endpoint (URL or host:port)
+func endpointHost(endpoint string) string {
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		endpoint = endpoint[i+3:]
+	}
+	if i := strings.IndexAny(endpoint, "/?#"); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(endpoint)
+}
+
+// sharedCheckTypes returns the enabled endpoint types of a node when they all point
+// at the same host, cheapest probe first
+// Returns nil when fewer than two types are checked or hosts differ
+func sharedCheckTypes(cfg *config.Config, node config.Node) []string {
+	endpoints := map[string]string{}
+	if cfg.API && node.API != "" {
+		endpoints["api"] = node.API
+	}
+	if cfg.RPC && node.RPC != "" {
+		endpoints["rpc"] = node.RPC
+	}
+	if cfg.GRPC && node.GRPC != "" {
+		endpoints["grpc"] = node.GRPC
+	}
+	if len(endpoints) < 2 {
+		return nil
+	}
+
+	host := ""
+	for _, endpoint := range endpoints {
+		h := endpointHost(endpoint)
+		if host != "" && h != host {
+			return nil
+		}
+		host = h
+	}
+
+	types := make([]string, 0, len(endpoints))
+	for _, endpointType := range sharedProbeOrder {
+		if _, ok := endpoints[endpointType]; ok {
+			types = append(types, endpointType)
+		}
+	}
+	return types
+}
+
+// checkShared probes a node once via its cheapest endpoint type and shares the height
+// with the node's other endpoint types
+func (s *Scheduler) checkShared(ctx context.Context, cfg *config.Config, node config.Node, types []string) {
+	canonical := types[0]
+
+	var err error
+	switch canonical {
+	case "rpc":
+		err = s.rpcChecker.CheckNode(ctx, node)
+	case "grpc":
+		err = s.grpcChecker.CheckNode(ctx, node, s.grpcInsecure(cfg, node.Network))
+	case "api":
+		err = s.apiChecker.CheckNode(ctx, node)
+	}
+
+	for _, endpointType := range types {
+		s.tracker.record(node.Network, node.Name, endpointType, err == nil, cfg.AdaptiveChecks)
+	}
+
+	if err != nil {
+		for _, endpointType := range types[1:] {
+			metrics.NodeAvailable.WithLabelValues(node.Network, node.Name, endpointType).Set(0)
+		}
+		for _, endpointType := range types {
+			s.readThrough(node, endpointType)
+			s.reportResult(cfg, node, endpointType, err)
+		}
+		s.logger.Debug("Shared height check failed",
+			zap.String("node", node.Name),
+			zap.String("probe", canonical),
+			zap.Error(err),
+		)
+		return
+	}
+
+	result, ok := s.store.Get(node.Network, node.Name, canonical)
+	if !ok {
+		return
+	}
+	var latency time.Duration
+	if n := len(result.LatencyHistory); n > 0 {
+		latency = result.LatencyHistory[n-1]
+	}
+
+	for _, endpointType := range types[1:] {
+		s.store.Update(node.Network, node.Name, endpointType, result.Height, latency, "internal")
+		metrics.NodeHeight.WithLabelValues(node.Network, node.Name, endpointType, "internal").Set(float64(result.Height))
+		metrics.NodeAvailable.WithLabelValues(node.Network, node.Name, endpointType).Set(1)
+	}
+	for _, endpointType := range types {
+		s.reportResult(cfg, node, endpointType, nil)
+	}
+
+	// The API WebSocket endpoint is not covered by the shared probe
+	if node.WS != "" && canonical != "api" && containsType(types, "api") && s.budget.webSocketDue(node, "api") {
+		wsAvailable := s.apiChecker.CheckWebSocketConnectivity(ctx, node)
+		s.store.UpdateWebSocketAvailability(node.Network, node.Name, "api", wsAvailable)
+		if wsAvailable {
+			metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "api").Set(1)
+		} else {
+			metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "api").Set(0)
+			metrics.WebSocketCheckErrors.WithLabelValues(node.Network, node.Name, "api", "connectivity_failed").Inc()
+		}
+	}
+
+	s.logger.Debug("Shared height check successful",
+		zap.String("node", node.Name),
+		zap.String("network", node.Network),
+		zap.String("probe", canonical),
+		zap.Strings("shared_with", types[1:]),
+		zap.Int64("height", result.Height),
+	)
+}
+
+// containsType reports whether an endpoint type is in the list
+func containsType(types []string, endpointType string) bool {
+	for _, t := range types {
+		if t == endpointType {
+			return true
+		}
+	}
+	return false
+}
@@ -3,13 +3,14 @@ package checker
 import (
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"sauron/client"
 	"sauron/config"
 	"sauron/metrics"
 	"sauron/storage"
@@ -30,112 +31,129 @@ type ExternalChecker struct {
 	store           *storage.HeightStore
 	endpointStore   *storage.ExternalEndpointStore
 	client          *http.Client
+	identity        Identity
 	logger          *zap.Logger
 	grpcConnections *xsync.Map[string, *grpc.ClientConn] // url -> connection pool for external gRPC endpoints
 }
 
 // ExternalStatusResponse represents the response from another Sauron's status API
 // Contains the max height and advertised connection endpoints
-type ExternalStatusResponse struct {
-	Height       int64  `json:"height"`                  // Maximum height reported by external ring
-	API          string `json:"api,omitempty"`           // External API endpoint URL (if advertised)
-	RPC          string `json:"rpc,omitempty"`           // External RPC endpoint URL (if advertised)
-	GRPC         string `json:"grpc,omitempty"`          // External gRPC endpoint URL (if advertised)
-	GRPCInsecure bool   `json:"grpc_insecure,omitempty"` // Whether advertised gRPC endpoint uses insecure (no TLS)
-}
+type ExternalStatusResponse = client.StatusResponse
 
 // NewExternalChecker creates a new external checker
-func NewExternalChecker(store *storage.HeightStore, endpointStore *storage.ExternalEndpointStore, logger *zap.Logger) *ExternalChecker {
+func NewExternalChecker(store *storage.HeightStore, endpointStore *storage.ExternalEndpointStore, transport config.HTTPTransport, identity Identity, logger *zap.Logger) *ExternalChecker {
+	// External rings get a smaller pool unless configured otherwise
+	defaults := defaultTransport
+	defaults.MaxIdleConns = ExternalHTTPMaxIdleConns
+	defaults.MaxIdleConnsPerHost = ExternalHTTPMaxIdleConnsPerHost
+
 	return &ExternalChecker{
-		store:         store,
-		endpointStore: endpointStore,
-		client: &http.Client{
-			Transport: &http.Transport{
-				MaxIdleConns:        ExternalHTTPMaxIdleConns,
-				MaxIdleConnsPerHost: ExternalHTTPMaxIdleConnsPerHost,
-				MaxConnsPerHost:     HTTPMaxConnsPerHost,
-				IdleConnTimeout:     HTTPIdleConnTimeout,
-			},
-		},
+		store:           store,
+		endpointStore:   endpointStore,
+		client:          newHTTPClient(transport.WithDefaults(defaults), identity),
+		identity:        identity,
 		logger:          logger,
 		grpcConnections: xsync.NewMap[string, *grpc.ClientConn](),
 	}
 }
 
 // CheckExternal queries an external Sauron ring for a specific network
-func (c *ExternalChecker) CheckExternal(ctx context.Context, external config.External, network string) error {
+// Advertised endpoints the external's allowed_types or the external policy refuse are never stored
+func (c *ExternalChecker) CheckExternal(ctx context.Context, external config.External, policy config.ExternalPolicy, network string) error {
 	if len(external.Rings) == 0 {
 		return fmt.Errorf("external %s has no rings configured", external.Name)
 	}
+	// An expired token would only be refused by the ring; /admin/credentials lists it for rotation
+	if external.Token != "" && !external.TokenActive(time.Now()) {
+		return fmt.Errorf("external %s token expired or not yet valid", external.Name)
+	}
+
+	// Query the healthiest ring first, failing over to the others
+	// Non-preferred rings are still probed now and then so their scores stay current
+	answered := false
+	for _, ring := range c.endpointStore.RankRings(external.Name, network, external.Rings) {
+		if answered && time.Since(ring.LastChecked) < RingProbeInterval {
+			continue
+		}
 
-	// Query each ring URL
-	for _, ringURL := range external.Rings {
-		if err := c.queryRing(ctx, external, ringURL, network); err != nil {
+		if err := c.queryRing(ctx, external, policy, ring.RingURL, network); err != nil {
+			c.endpointStore.RecordRingCheck(external.Name, ring.RingURL, network, 0, 0, err)
 			c.logger.Warn("Failed to query external ring",
 				zap.String("external", external.Name),
-				zap.String("ring", ringURL),
+				zap.String("ring", ring.RingURL),
 				zap.String("network", network),
+				zap.Float64("success_rate", ring.SuccessRate),
 				zap.Error(err),
 			)
 			continue // Try next ring
 		}
+		answered = true
 	}
 
 	return nil
 }
 
-func (c *ExternalChecker) queryRing(ctx context.Context, external config.External, ringURL, network string) error {
-	// Build URL: {ring}/{network}/status
-	url := ringURL
-	if len(url) > 0 && url[len(url)-1] == '/' {
-		url = url[:len(url)-1]
-	}
-	url = fmt.Sprintf("%s/%s/status", url, network)
+func (c *ExternalChecker) queryRing(ctx context.Context, external config.External, policy config.ExternalPolicy, ringURL, network string) error {
+	// Rings are polled every round and already fail over to each other, so no retries here
+	ring := client.New(ringURL,
+		client.WithToken(external.Token),
+		client.WithHTTPClient(c.client),
+		client.WithRetry(1, 0),
+	)
 
 	start := time.Now()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		c.recordError(external.Name, ringURL, "request_creation", err)
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Add Bearer token if configured (non-empty)
-	if external.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+external.Token)
-	}
-
-	resp, err := c.client.Do(req)
+	status, err := ring.Status(ctx, network)
 	latency := time.Since(start)
 
-	if err != nil {
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		c.recordError(external.Name, ringURL, "http_status", err)
+		metrics.ExternalRingAvailable.WithLabelValues(external.Name, ringURL).Set(0)
+		return err
+	case errors.Is(err, client.ErrInvalidResponse):
+		c.recordError(external.Name, ringURL, "json_parse", err)
+		return err
+	case err != nil:
 		c.recordError(external.Name, ringURL, "network", err)
 		metrics.ExternalRingAvailable.WithLabelValues(external.Name, ringURL).Set(0)
-		return fmt.Errorf("failed to fetch status: %w", err)
+		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		c.recordError(external.Name, ringURL, "http_status", fmt.Errorf("status code %d", resp.StatusCode))
-		metrics.ExternalRingAvailable.WithLabelValues(external.Name, ringURL).Set(0)
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	// Validate we got a height
+	if status.Height == 0 {
+		c.recordError(external.Name, ringURL, "zero_height", fmt.Errorf("external ring returned zero height"))
+		return fmt.Errorf("external ring returned zero height")
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.recordError(external.Name, ringURL, "read_body", err)
-		return fmt.Errorf("failed to read response: %w", err)
+	// Track the peer's federation format; newer peers are fine (unknown fields are ignored)
+	if err := c.checkProtocol(external.Name, ringURL, network, status.ProtocolVersion); err != nil {
+		c.recordError(external.Name, ringURL, "incompatible_protocol", err)
+		return err
 	}
 
-	var status ExternalStatusResponse
-	if err := json.Unmarshal(body, &status); err != nil {
-		c.recordError(external.Name, ringURL, "json_parse", err)
-		return fmt.Errorf("failed to parse JSON: %w", err)
+	// Refuse malformed advertisements before any endpoint is stored
+	if err := status.Validate(); err != nil {
+		c.recordError(external.Name, ringURL, "invalid_status", err)
+		return fmt.Errorf("invalid status from ring: %w", err)
 	}
 
-	// Validate we got a height
-	if status.Height == 0 {
-		c.recordError(external.Name, ringURL, "zero_height", fmt.Errorf("external ring returned zero height"))
-		return fmt.Errorf("external ring returned zero height")
+	// A height far off from our internals (or other rings) means the ring serves another network,
+	// typically a copy-pasted ring URL; keep its endpoints out of rotation while that lasts
+	if reference := c.referenceHeight(external.Name, ringURL, network); heightMismatch(status.Height, reference, external.MismatchFactor) {
+		removed := c.endpointStore.RemoveAdvertised(external.Name, ringURL, network)
+		metrics.ExternalNetworkMismatches.WithLabelValues(external.Name, network).Inc()
+		err := fmt.Errorf("ring height %d is implausible for network %s (reference %d)", status.Height, network, reference)
+		c.recordError(external.Name, ringURL, "network_mismatch", err)
+		if removed > 0 {
+			c.logger.Warn("Removed endpoints of ring advertising the wrong network",
+				zap.String("external", external.Name),
+				zap.String("ring", ringURL),
+				zap.String("network", network),
+				zap.Int("removed", removed),
+			)
+		}
+		return err
 	}
 
 	// Store advertised endpoints in endpoint store
@@ -144,15 +162,25 @@ func (c *ExternalChecker) queryRing(ctx context.Context, external config.Externa
 	// in the ExternalEndpointStore. The selector will add them to the candidate pool
 	// with the "ext:{url}" prefix when needed.
 	advertisedTypes := []string{}
-	if status.API != "" {
+	if status.API != "" && c.consumable(external, policy, ringURL, network, "api", status.API) {
 		c.endpointStore.StoreAdvertised(external.Name, ringURL, network, "api", status.API)
 		metrics.NodeHeight.WithLabelValues(network, external.Name, "api", "external").Set(float64(status.Height))
 		advertisedTypes = append(advertisedTypes, "api")
 
 		// Validate endpoint (connectivity check only, insecure=false for HTTP)
 		c.validateEndpoint(ctx, external.Name, ringURL, network, "api", status.API, status.Height, false)
+
+		// EVM-style API endpoints may advertise a WebSocket variant; a denied one leaves the endpoint without WebSocket
+		if _, denied := policy.DeniedURL(status.APIWS); status.APIWS != "" && denied {
+			c.endpointStore.SetWebSocketURL(external.Name, ringURL, network, "api", status.API, "")
+			c.endpointStore.UpdateWebSocketAvailability(external.Name, ringURL, network, "api", status.API, false)
+			metrics.ExternalEndpointsRefused.WithLabelValues(external.Name, network, "api", "url_denied").Inc()
+		} else if status.APIWS != "" {
+			c.endpointStore.SetWebSocketURL(external.Name, ringURL, network, "api", status.API, status.APIWS)
+			c.validateAPIWebSocketEndpoint(ctx, external.Name, ringURL, network, status.API, status.APIWS)
+		}
 	}
-	if status.RPC != "" {
+	if status.RPC != "" && c.consumable(external, policy, ringURL, network, "rpc", status.RPC) {
 		c.endpointStore.StoreAdvertised(external.Name, ringURL, network, "rpc", status.RPC)
 		metrics.NodeHeight.WithLabelValues(network, external.Name, "rpc", "external").Set(float64(status.Height))
 		advertisedTypes = append(advertisedTypes, "rpc")
@@ -160,7 +188,7 @@ func (c *ExternalChecker) queryRing(ctx context.Context, external config.Externa
 		// Validate endpoint (insecure=false for HTTP)
 		c.validateEndpoint(ctx, external.Name, ringURL, network, "rpc", status.RPC, status.Height, false)
 	}
-	if status.GRPC != "" {
+	if status.GRPC != "" && c.consumable(external, policy, ringURL, network, "grpc", status.GRPC) {
 		c.endpointStore.StoreAdvertised(external.Name, ringURL, network, "grpc", status.GRPC)
 		metrics.NodeHeight.WithLabelValues(network, external.Name, "grpc", "external").Set(float64(status.Height))
 		advertisedTypes = append(advertisedTypes, "grpc")
@@ -169,6 +197,8 @@ func (c *ExternalChecker) queryRing(ctx context.Context, external config.Externa
 		c.validateEndpoint(ctx, external.Name, ringURL, network, "grpc", status.GRPC, status.Height, status.GRPCInsecure)
 	}
 
+	c.endpointStore.RecordRingCheck(external.Name, ringURL, network, status.Height, latency, nil)
+
 	// Update metrics
 	metrics.ExternalRingLatency.WithLabelValues(external.Name, ringURL).Observe(latency.Seconds())
 	metrics.ExternalRingAvailable.WithLabelValues(external.Name, ringURL).Set(1)
@@ -185,6 +215,108 @@ func (c *ExternalChecker) queryRing(ctx context.Context, external config.Externa
 	return nil
 }
 
+// consumable reports whether an advertised endpoint passes the external's allowed_types and the external policy
+// A refused endpoint is dropped from the store, so a policy tightened on reload takes effect on the next check
+func (c *ExternalChecker) consumable(external config.External, policy config.ExternalPolicy, ringURL, network, endpointType, url string) bool {
+	reason := ""
+	pattern, denied := policy.DeniedURL(url)
+	switch {
+	case !external.AllowsType(endpointType):
+		reason = "type_not_allowed"
+	case denied:
+		reason = "url_denied"
+	default:
+		return true
+	}
+
+	c.endpointStore.RemoveEndpoint(external.Name, ringURL, network, endpointType, url)
+	metrics.ExternalEndpointsRefused.WithLabelValues(external.Name, network, endpointType, reason).Inc()
+	c.logger.Debug("Refused advertised endpoint",
+		zap.String("external", external.Name),
+		zap.String("ring", ringURL),
+		zap.String("network", network),
+		zap.String("type", endpointType),
+		zap.String("url", url),
+		zap.String("reason", reason),
+		zap.String("pattern", pattern),
+	)
+	return false
+}
+
+// checkProtocol records a ring's protocol version and warns when it changes or can't be interpreted
+func (c *ExternalChecker) checkProtocol(externalName, ringURL, network string, version int) error {
+	previous := c.endpointStore.SetRingProtocolVersion(externalName, ringURL, network, version)
+	metrics.ExternalRingProtocolVersion.WithLabelValues(externalName, ringURL).Set(float64(version))
+
+	err := client.CheckProtocol(version)
+	if previous == version {
+		return err // already reported
+	}
+
+	switch {
+	case err != nil:
+		c.logger.Warn("External ring speaks an incompatible protocol, ignoring it",
+			zap.String("external", externalName),
+			zap.String("ring", ringURL),
+			zap.String("network", network),
+			zap.Int("protocol_version", version),
+			zap.Int("min_protocol_version", client.MinProtocolVersion),
+		)
+	case version > client.ProtocolVersion:
+		c.logger.Warn("External ring speaks a newer protocol, fields unknown to this build are ignored",
+			zap.String("external", externalName),
+			zap.String("ring", ringURL),
+			zap.String("network", network),
+			zap.Int("protocol_version", version),
+			zap.Int("local_protocol_version", client.ProtocolVersion),
+		)
+	}
+	return err
+}
+
+// referenceHeight returns the height a ring is expected to be near for a network
+// Our own internals are trusted first; without them, the median of the other healthy rings is used
+// Returns 0 when there is nothing to compare against
+func (c *ExternalChecker) referenceHeight(externalName, ringURL, network string) int64 {
+	var internal int64
+	for _, endpointType := range []string{"api", "rpc", "grpc"} {
+		if h := c.store.GetHighestHeight(network, endpointType); h > internal {
+			internal = h
+		}
+	}
+	if internal > 0 {
+		return internal
+	}
+
+	var heights []int64
+	for _, rs := range c.endpointStore.GetRingStatuses() {
+		if rs.Network != network || !rs.Healthy || rs.Height <= 0 {
+			continue
+		}
+		if rs.ExternalName == externalName && rs.RingURL == ringURL {
+			continue
+		}
+		heights = append(heights, rs.Height)
+	}
+	if len(heights) == 0 {
+		return 0
+	}
+	slices.Sort(heights)
+	return heights[len(heights)/2]
+}
+
+// heightMismatch reports whether a height is off from the reference by more than the factor
+func heightMismatch(height, reference int64, factor float64) bool {
+	if height <= 0 || reference <= 0 {
+		return false
+	}
+	if factor <= 1 {
+		factor = DefaultMismatchFactor
+	}
+	hi, lo := float64(max(height, reference)), float64(min(height, reference))
+	return hi/lo > factor
+}
+
 func (c *ExternalChecker) recordError(externalName, ringURL, errorType string, err error) {
 	metrics.ExternalRingErrors.WithLabelValues(externalName, ringURL, errorType).Inc()
 	c.logger.Warn("External ring check failed",
@@ -353,7 +485,7 @@ func (c *ExternalChecker) validateWebSocketEndpoint(ctx context.Context, url str
 	}
 
 	// Connect to WebSocket
-	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
+	conn, _, err := dialer.DialContext(ctx, wsURL, c.identity.Header())
 	if err != nil {
 		c.logger.Debug("WebSocket connection failed for external endpoint",
 			zap.String("url", wsURL),
@@ -406,6 +538,25 @@ func (c *ExternalChecker) validateWebSocketEndpoint(ctx context.Context, url str
 	return true
 }
 
+// validateAPIWebSocketEndpoint checks an advertised API WebSocket URL and records its availability
+func (c *ExternalChecker) validateAPIWebSocketEndpoint(ctx context.Context, externalName, ringURL, network, url, wsURL string) {
+	err := checkWebSocketHandshake(ctx, wsURL, c.identity)
+	wsAvailable := err == nil
+	c.endpointStore.UpdateWebSocketAvailability(externalName, ringURL, network, "api", url, wsAvailable)
+
+	if wsAvailable {
+		metrics.NodeWebSocketAvailable.WithLabelValues(network, externalName, "api").Set(1)
+	} else {
+		metrics.NodeWebSocketAvailable.WithLabelValues(network, externalName, "api").Set(0)
+		metrics.WebSocketCheckErrors.WithLabelValues(network, externalName, "api", "connectivity_failed").Inc()
+		c.logger.Debug("WebSocket connection failed for external API endpoint",
+			zap.String("external", externalName),
+			zap.String("url", wsURL),
+			zap.Error(err),
+		)
+	}
+}
+
 // getGRPCConnection returns an existing connection or creates a new one
 // useInsecure parameter controls whether to use TLS (false) or not (true)
 func (c *ExternalChecker) getGRPCConnection(url string, useInsecure bool) (*grpc.ClientConn, error) {
@@ -457,6 +608,7 @@ func (c *ExternalChecker) getGRPCConnection(url string, useInsecure bool) (*grpc
 	}
 
 	// Create connection
+	opts = append(opts, c.identity.grpcOptions()...)
 	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, err
@@ -502,6 +654,11 @@ func (c *ExternalChecker) RecoverFailedEndpoints(ctx context.Context) {
 	)
 
 	for _, ep := range failed {
+		// Wait out the quarantine backoff before probing again
+		if time.Now().Before(ep.QuarantinedUntil) {
+			continue
+		}
+
 		// Attempt to re-validate the endpoint
 		var err error
 		var latency time.Duration
@@ -544,6 +701,11 @@ func (c *ExternalChecker) RecoverFailedEndpoints(ctx context.Context) {
 			}
 		}
 
+		// API endpoints that advertised a WebSocket variant are re-checked too
+		if ep.Type == "api" && ep.WebSocketURL != "" {
+			c.validateAPIWebSocketEndpoint(ctx, ep.ExternalName, ep.RingURL, ep.Network, ep.URL, ep.WebSocketURL)
+		}
+
 		// Record recovery metric
 		metrics.ExternalEndpointRecoveries.WithLabelValues(ep.Network, ep.Type, ep.ExternalName).Inc()
 
@@ -565,9 +727,7 @@ func (c *ExternalChecker) UpdateEndpointMetrics() {
 
 // Close shuts down the HTTP client and closes idle connections
 func (c *ExternalChecker) Close() {
-	if transport, ok := c.client.Transport.(*http.Transport); ok {
-		transport.CloseIdleConnections()
-	}
+	c.client.CloseIdleConnections()
 
 	// Close all gRPC connections
 	c.grpcConnections.Range(func(url string, conn *grpc.ClientConn) bool {
@@ -25,15 +25,17 @@ import (
 type GRPCChecker struct {
 	store       *storage.HeightStore
 	cache       *storage.Cache
+	identity    Identity
 	logger      *zap.Logger
 	connections *xsync.Map[string, *grpc.ClientConn] // node name -> connection
 }
 
 // NewGRPCChecker creates a new gRPC checker
-func NewGRPCChecker(store *storage.HeightStore, cache *storage.Cache, logger *zap.Logger) *GRPCChecker {
+func NewGRPCChecker(store *storage.HeightStore, cache *storage.Cache, identity Identity, logger *zap.Logger) *GRPCChecker {
 	return &GRPCChecker{
 		store:       store,
 		cache:       cache,
+		identity:    identity,
 		logger:      logger,
 		connections: xsync.NewMap[string, *grpc.ClientConn](),
 	}
@@ -136,6 +138,7 @@ func (c *GRPCChecker) getConnection(node config.Node, insecure bool) (*grpc.Clie
 			MinConnectTimeout: 10 * time.Second, // Give connection time to establish
 		}),
 	)
+	opts = append(opts, c.identity.grpcOptions()...)
 
 	// Use passthrough:/// resolver to avoid DNS resolver IPv6 timeout issues with Cloudflare
 	target := node.GRPC
@@ -149,6 +152,13 @@ func (c *GRPCChecker) getConnection(node config.Node, insecure bool) (*grpc.Clie
 		return nil, err
 	}
 
+	// Nodes billed per request can skip the warmup; the first health check opens the connection
+	if node.ProbeBudget.DisableGRPCWarmup {
+		metrics.ProbesSkipped.WithLabelValues(node.Network, node.Name, "grpc_warmup").Inc()
+		c.connections.Store(node.Name, conn)
+		return conn, nil
+	}
+
 	// Warm up the connection by making a test RPC call (best effort, non-blocking)
 	// This is an optimization to force connection establishment immediately
 	// If it fails, we still return the connection and let the first health check establish it
@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"context"
+	"net/http"
+
+	"sauron/config"
+	"sauron/version"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RingHeader names this ring on Sauron's own requests, as on signed forwarded requests (proxy.RingNameHeader)
+// gRPC probes carry it as metadata (lowercase)
+const RingHeader = "X-Sauron-Ring"
+
+// Identity is how health checks, endpoint validations and ring queries present themselves:
+// a "sauron/{version}" User-Agent, and X-Sauron-Ring with this ring's name when one is configured
+// Backend operators and peer rings can then tell probes from client traffic and rate-limit them apart
+type Identity struct {
+	Ring string // ring_signing.name (empty = X-Sauron-Ring not sent)
+}
+
+// NewIdentity returns the identity of a configuration (applied at startup, like the checker transport)
+func NewIdentity(cfg *config.Config) Identity {
+	return Identity{Ring: cfg.RingSigning.Name}
+}
+
+// Header returns the identification headers, e.g. for WebSocket handshakes
+func (id Identity) Header() http.Header {
+	h := make(http.Header)
+	id.apply(h)
+	return h
+}
+
+// apply sets the identification headers a request doesn't set itself
+func (id Identity) apply(h http.Header) {
+	if h.Get("User-Agent") == "" {
+		h.Set("User-Agent", version.UserAgent)
+	}
+	if id.Ring != "" && h.Get(RingHeader) == "" {
+		h.Set(RingHeader, id.Ring)
+	}
+}
+
+// grpcOptions makes gRPC probes send the User-Agent (ahead of grpc-go's own) and the ring name
+func (id Identity) grpcOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithUserAgent(version.UserAgent)}
+	if id.Ring != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, RingHeader, id.Ring), method, req, reply, cc, callOpts...)
+		}))
+	}
+	return opts
+}
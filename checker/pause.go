@@ -0,0 +1,131 @@
+package checker
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// CheckerTypes are the health checks that can be paused: internal node checks per endpoint type,
+// and external ring queries with failed endpoint recovery
+var CheckerTypes = []string{"api", "rpc", "grpc", "external"}
+
+// PauseState is a paused checker type
+type PauseState struct {
+	Type  string
+	Since time.Time
+	Until time.Time // zero until resumed
+}
+
+// pauses tracks paused checker types; heights and endpoints already known keep routing while paused
+type pauses struct {
+	mu     sync.Mutex
+	paused map[string]PauseState
+}
+
+// Pause stops the given checker types (all when empty) until Resume, or for d when d > 0
+// Pausing a paused type replaces its deadline
+func (s *Scheduler) Pause(types []string, d time.Duration) ([]PauseState, error) {
+	types, err := checkerTypes(types)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var until time.Time
+	if d > 0 {
+		until = now.Add(d)
+	}
+
+	s.pauses.mu.Lock()
+	if s.pauses.paused == nil {
+		s.pauses.paused = make(map[string]PauseState)
+	}
+	for _, checkerType := range types {
+		since := now
+		if state, ok := s.pauses.paused[checkerType]; ok && s.pauses.active(state, now) {
+			since = state.Since
+		}
+		s.pauses.paused[checkerType] = PauseState{Type: checkerType, Since: since, Until: until}
+		metrics.SchedulerPaused.WithLabelValues(checkerType).Set(1)
+	}
+	s.pauses.mu.Unlock()
+
+	s.logger.Warn("Health checks paused",
+		zap.Strings("types", types),
+		zap.Duration("duration", d),
+	)
+	return s.Paused(), nil
+}
+
+// Resume restarts the given checker types (all when empty); the next scheduled round checks them again
+func (s *Scheduler) Resume(types []string) ([]PauseState, error) {
+	types, err := checkerTypes(types)
+	if err != nil {
+		return nil, err
+	}
+
+	s.pauses.mu.Lock()
+	for _, checkerType := range types {
+		delete(s.pauses.paused, checkerType)
+		metrics.SchedulerPaused.WithLabelValues(checkerType).Set(0)
+	}
+	s.pauses.mu.Unlock()
+
+	s.logger.Info("Health checks resumed", zap.Strings("types", types))
+	return s.Paused(), nil
+}
+
+// Paused returns the paused checker types in CheckerTypes order
+func (s *Scheduler) Paused() []PauseState {
+	now := time.Now()
+
+	s.pauses.mu.Lock()
+	defer s.pauses.mu.Unlock()
+
+	states := []PauseState{}
+	for _, checkerType := range CheckerTypes {
+		if state, ok := s.pauses.paused[checkerType]; ok && s.pauses.active(state, now) {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// isPaused reports whether a checker type is paused right now
+func (s *Scheduler) isPaused(checkerType string) bool {
+	s.pauses.mu.Lock()
+	defer s.pauses.mu.Unlock()
+
+	state, ok := s.pauses.paused[checkerType]
+	return ok && s.pauses.active(state, time.Now())
+}
+
+// active reports whether a pause still holds, dropping it once its deadline passed
+// Must be called with mu held
+func (p *pauses) active(state PauseState, now time.Time) bool {
+	if state.Until.IsZero() || now.Before(state.Until) {
+		return true
+	}
+	delete(p.paused, state.Type)
+	metrics.SchedulerPaused.WithLabelValues(state.Type).Set(0)
+	return false
+}
+
+// checkerTypes validates checker types, expanding none to all
+func checkerTypes(types []string) ([]string, error) {
+	if len(types) == 0 {
+		return CheckerTypes, nil
+	}
+	for _, checkerType := range types {
+		if !slices.Contains(CheckerTypes, checkerType) {
+			return nil, fmt.Errorf("unknown checker type %q (expected api, rpc, grpc or external)", checkerType)
+		}
+	}
+	return types, nil
+}
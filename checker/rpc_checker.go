@@ -22,10 +22,12 @@ import (
 // RPCChecker checks node heights via Tendermint RPC /status endpoint
 // The Eye gazing upon the RPC realm
 type RPCChecker struct {
-	store  *storage.HeightStore
-	cache  *storage.Cache
-	client *http.Client
-	logger *zap.Logger
+	store    *storage.HeightStore
+	cache    *storage.Cache
+	client   *http.Client
+	identity Identity
+	budget   *probeBudget // set by the scheduler; nil probes WebSocket on every check
+	logger   *zap.Logger
 }
 
 // RPCStatusResponse represents the Tendermint RPC /status response
@@ -40,19 +42,13 @@ type RPCStatusResponse struct {
 }
 
 // NewRPCChecker creates a new RPC checker
-func NewRPCChecker(store *storage.HeightStore, cache *storage.Cache, logger *zap.Logger) *RPCChecker {
+func NewRPCChecker(store *storage.HeightStore, cache *storage.Cache, transport config.HTTPTransport, identity Identity, logger *zap.Logger) *RPCChecker {
 	return &RPCChecker{
-		store: store,
-		cache: cache,
-		client: &http.Client{
-			Transport: &http.Transport{
-				MaxIdleConns:        HTTPMaxIdleConns,
-				MaxIdleConnsPerHost: HTTPMaxIdleConnsPerHost,
-				MaxConnsPerHost:     HTTPMaxConnsPerHost,
-				IdleConnTimeout:     HTTPIdleConnTimeout,
-			},
-		},
-		logger: logger,
+		store:    store,
+		cache:    cache,
+		client:   newHTTPClient(transport.WithDefaults(defaultTransport), identity),
+		identity: identity,
+		logger:   logger,
 	}
 }
 
@@ -118,16 +114,23 @@ func (c *RPCChecker) CheckNode(ctx context.Context, node config.Node) error {
 	// Update storage
 	c.store.Update(node.Network, node.Name, "rpc", height, latency, "internal")
 
-	// Check WebSocket connectivity
-	wsAvailable := c.CheckWebSocketConnectivity(ctx, node)
-	c.store.UpdateWebSocketAvailability(node.Network, node.Name, "rpc", wsAvailable)
-
-	// Update WebSocket availability metric
-	if wsAvailable {
-		metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "rpc").Set(1)
+	// Check WebSocket connectivity (a probe budget keeps the last result between probes)
+	var wsAvailable bool
+	if !c.budget.webSocketDue(node, "rpc") {
+		if m, ok := c.store.Get(node.Network, node.Name, "rpc"); ok {
+			wsAvailable = m.WebSocketAvailable
+		}
 	} else {
-		metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "rpc").Set(0)
-		metrics.WebSocketCheckErrors.WithLabelValues(node.Network, node.Name, "rpc", "connectivity_failed").Inc()
+		wsAvailable = c.CheckWebSocketConnectivity(ctx, node)
+		c.store.UpdateWebSocketAvailability(node.Network, node.Name, "rpc", wsAvailable)
+
+		// Update WebSocket availability metric
+		if wsAvailable {
+			metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "rpc").Set(1)
+		} else {
+			metrics.NodeWebSocketAvailable.WithLabelValues(node.Network, node.Name, "rpc").Set(0)
+			metrics.WebSocketCheckErrors.WithLabelValues(node.Network, node.Name, "rpc", "connectivity_failed").Inc()
+		}
 	}
 
 	// Update cache if enabled
@@ -165,9 +168,7 @@ func (c *RPCChecker) recordError(node config.Node, errorType string, err error)
 
 // Close shuts down the HTTP client and closes idle connections
 func (c *RPCChecker) Close() {
-	if transport, ok := c.client.Transport.(*http.Transport); ok {
-		transport.CloseIdleConnections()
-	}
+	c.client.CloseIdleConnections()
 }
 
 // CheckWebSocketConnectivity tests if a node's WebSocket endpoint is working
@@ -177,8 +178,11 @@ func (c *RPCChecker) CheckWebSocketConnectivity(ctx context.Context, node config
 		return false
 	}
 
-	// Build WebSocket URL
+	// Build WebSocket URL (dedicated rpc_ws override takes precedence)
 	wsURL := node.RPC
+	if node.RPCWS != "" {
+		wsURL = node.RPCWS
+	}
 	if len(wsURL) > 0 && wsURL[len(wsURL)-1] == '/' {
 		wsURL = wsURL[:len(wsURL)-1]
 	}
@@ -201,7 +205,7 @@ func (c *RPCChecker) CheckWebSocketConnectivity(ctx context.Context, node config
 	}
 
 	// Connect to WebSocket
-	conn, _, err := dialer.DialContext(ctx, wsURL, nil)
+	conn, _, err := dialer.DialContext(ctx, wsURL, c.identity.Header())
 	if err != nil {
 		c.logger.Debug("WebSocket connection failed",
 			zap.String("node", node.Name),
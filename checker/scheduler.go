@@ -2,9 +2,12 @@ package checker
 
 import (
 	"context"
+	"slices"
 	"time"
 
+	"sauron/clock"
 	"sauron/config"
+	"sauron/metrics"
 	"sauron/storage"
 
 	"github.com/alitto/pond/v2"
@@ -15,15 +18,23 @@ import (
 // Scheduler coordinates periodic height checks
 // The Eye that never sleeps
 type Scheduler struct {
-	cron         *cron.Cron
-	pool         pond.Pool
-	apiChecker   *APIChecker
-	rpcChecker   *RPCChecker
-	grpcChecker  *GRPCChecker
-	extChecker   *ExternalChecker
-	configLoader *config.Loader
-	logger       *zap.Logger
-	timeout      time.Duration
+	cron          *cron.Cron
+	store         *storage.HeightStore
+	cache         *storage.Cache
+	endpointStore *storage.ExternalEndpointStore
+	pool          pond.Pool
+	apiChecker    *APIChecker
+	rpcChecker    *RPCChecker
+	grpcChecker   *GRPCChecker
+	extChecker    *ExternalChecker
+	configLoader  *config.Loader
+	logger        *zap.Logger
+	timeout       time.Duration
+	tracker       *checkTracker
+	webhooks      *webhookNotifier
+	stopWatch     func()       // stops the clock jump watcher
+	pauses        pauses       // checker types paused through the admin API
+	budget        *probeBudget // spaces out probes of nodes with a probe_budget
 }
 
 // NewScheduler creates a new scheduler
@@ -35,11 +46,18 @@ func NewScheduler(
 	pool pond.Pool,
 	logger *zap.Logger,
 ) *Scheduler {
-	// Create checkers
-	apiChecker := NewAPIChecker(store, cache, logger)
-	rpcChecker := NewRPCChecker(store, cache, logger)
-	grpcChecker := NewGRPCChecker(store, cache, logger)
-	extChecker := NewExternalChecker(store, endpointStore, logger)
+	// Create checkers (connection pools are sized once, at startup)
+	transport := configLoader.Get().Transport.Checker
+	identity := NewIdentity(configLoader.Get())
+	apiChecker := NewAPIChecker(store, cache, transport, identity, logger)
+	rpcChecker := NewRPCChecker(store, cache, transport, identity, logger)
+	grpcChecker := NewGRPCChecker(store, cache, identity, logger)
+	extChecker := NewExternalChecker(store, endpointStore, transport, identity, logger)
+
+	// Probe budgets are shared, so WebSocket probes follow the height checks of the budgeted nodes
+	budget := newProbeBudget()
+	apiChecker.budget = budget
+	rpcChecker.budget = budget
 
 	// Create cron with seconds support and panic recovery
 	cronScheduler := cron.New(
@@ -50,15 +68,21 @@ func NewScheduler(
 	)
 
 	s := &Scheduler{
-		cron:         cronScheduler,
-		pool:         pool,
-		apiChecker:   apiChecker,
-		rpcChecker:   rpcChecker,
-		grpcChecker:  grpcChecker,
-		extChecker:   extChecker,
-		configLoader: configLoader,
-		logger:       logger,
-		timeout:      5 * time.Second, // Default, will be updated from config
+		cron:          cronScheduler,
+		store:         store,
+		cache:         cache,
+		endpointStore: endpointStore,
+		pool:          pool,
+		apiChecker:    apiChecker,
+		rpcChecker:    rpcChecker,
+		grpcChecker:   grpcChecker,
+		extChecker:    extChecker,
+		configLoader:  configLoader,
+		logger:        logger,
+		timeout:       5 * time.Second, // Default, will be updated from config
+		tracker:       newCheckTracker(store, endpointStore),
+		budget:        budget,
+		webhooks:      newWebhookNotifier(newHTTPClient(transport.WithDefaults(defaultTransport), identity), logger),
 	}
 
 	return s
@@ -69,6 +93,9 @@ func (s *Scheduler) Start() error {
 	cfg := s.configLoader.Get()
 	s.timeout = cfg.Timeouts.HealthCheck
 
+	// Seed heights from the cache so routing works before the first check round
+	s.warmFromCache(cfg)
+
 	// Schedule internal node checks every 30 seconds (aligned with block time)
 	_, err := s.cron.AddFunc("*/30 * * * * *", func() {
 		s.checkInternalNodes()
@@ -77,6 +104,18 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 
+	// Schedule extra checks for unstable internal nodes (lagging, flapping, recently recovered)
+	fastInterval := cfg.AdaptiveChecks.FastInterval
+	if fastInterval == 0 {
+		fastInterval = DefaultAdaptiveFastInterval
+	}
+	_, err = s.cron.AddFunc("@every "+fastInterval.String(), func() {
+		s.checkUnstableNodes()
+	})
+	if err != nil {
+		return err
+	}
+
 	// Schedule external ring checks every 10 seconds
 	_, err = s.cron.AddFunc("*/10 * * * * *", func() {
 		s.checkExternalRings()
@@ -85,6 +124,32 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 
+	// Persist external endpoint reputation if configured
+	if cfg.Reputation.Path != "" {
+		flushInterval := cfg.Reputation.FlushInterval
+		if flushInterval == 0 {
+			flushInterval = 30 * time.Second
+		}
+		_, err = s.cron.AddFunc("@every "+flushInterval.String(), func() {
+			s.saveReputation()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Post queued check results to health webhooks (a no-op while none are configured)
+	webhookInterval := cfg.HealthWebhooks.Interval
+	if webhookInterval == 0 {
+		webhookInterval = DefaultWebhookInterval
+	}
+	_, err = s.cron.AddFunc("@every "+webhookInterval.String(), func() {
+		s.flushWebhooks()
+	})
+	if err != nil {
+		return err
+	}
+
 	// Schedule health check recovery for failed endpoints every 10 seconds
 	_, err = s.cron.AddFunc("*/10 * * * * *", func() {
 		s.recoverFailedEndpoints()
@@ -94,8 +159,15 @@ func (s *Scheduler) Start() error {
 	}
 
 	s.cron.Start()
+
+	// Cron computes the next runs from the wall clock: a backward step would stall checks until the
+	// clock catches up, a forward one would skip them, so reschedule whenever the wall clock jumps
+	s.stopWatch = clock.Watch(ClockWatchInterval, ClockJumpThreshold, s.handleClockJump)
+
 	s.logger.Info("Scheduler started - The Eye never sleeps",
 		zap.Duration("health_check_timeout", s.timeout),
+		zap.Bool("adaptive_checks", cfg.AdaptiveChecks.Enabled),
+		zap.Duration("adaptive_fast_interval", fastInterval),
 	)
 
 	return nil
@@ -104,6 +176,9 @@ func (s *Scheduler) Start() error {
 // Stop halts the scheduler
 func (s *Scheduler) Stop() {
 	s.logger.Info("Stopping scheduler...")
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
 	ctx := s.cron.Stop()
 	<-ctx.Done()
 
@@ -117,24 +192,89 @@ func (s *Scheduler) Stop() {
 	s.rpcChecker.Close()
 	s.extChecker.Close()
 
+	// Final reputation flush so nothing since the last interval is lost
+	s.saveReputation()
+	s.flushWebhooks()
+
 	s.logger.Info("Scheduler stopped")
 }
 
 // checkInternalNodes checks all internal nodes
 func (s *Scheduler) checkInternalNodes() {
+	s.runInternalChecks(nil)
+}
+
+// checkUnstableNodes re-checks only internal nodes that are lagging, flapping or recently recovered
+// Stable nodes wait for the regular round, so total check load barely grows
+func (s *Scheduler) checkUnstableNodes() {
+	cfg := s.configLoader.Get()
+	if !cfg.AdaptiveChecks.Enabled {
+		return
+	}
+
+	s.runInternalChecks(func(node config.Node, endpointType string) bool {
+		return s.tracker.isUnstable(node.Network, node.Name, endpointType, cfg.AdaptiveChecks)
+	})
+}
+
+// runInternalChecks checks internal node endpoints, optionally limited by a filter
+func (s *Scheduler) runInternalChecks(filter func(node config.Node, endpointType string) bool) {
 	cfg := s.configLoader.Get()
 	s.timeout = cfg.Timeouts.HealthCheck // Update timeout in case config changed
 
+	paused := make(map[string]bool)
+	for _, endpointType := range []string{"api", "rpc", "grpc"} {
+		paused[endpointType] = s.isPaused(endpointType)
+	}
+	if paused["api"] && paused["rpc"] && paused["grpc"] {
+		return
+	}
+
+	selected := func(node config.Node, endpointType string) bool {
+		return !paused[endpointType] && (filter == nil || filter(node, endpointType))
+	}
+
 	for _, node := range cfg.Internals {
 		node := node // Capture for goroutine
+		timeout := s.nodeTimeout(node)
+
+		// Budgeted nodes are checked every check_every regular rounds and never in adaptive ones
+		if filter == nil && !s.budget.checkDue(node) || filter != nil && node.ProbeBudget.CheckEvery > 1 {
+			continue
+		}
+
+		// Probe once and share the height when all endpoint types hit the same host
+		if cfg.SharedHeightChecks {
+			if types := sharedCheckTypes(cfg, node); types != nil {
+				// Paused types keep their last height; the others share one probe
+				types = slices.DeleteFunc(types, func(endpointType string) bool { return paused[endpointType] })
+				anySelected := false
+				for _, endpointType := range types {
+					anySelected = anySelected || selected(node, endpointType)
+				}
+				if anySelected {
+					_ = s.pool.Go(func() {
+						ctx, cancel := context.WithTimeout(context.Background(), timeout)
+						defer cancel()
+
+						s.checkShared(ctx, cfg, node, types)
+					})
+				}
+				continue
+			}
+		}
 
 		// Check API if enabled and configured
-		if cfg.API && node.API != "" {
+		if cfg.API && node.API != "" && selected(node, "api") {
 			_ = s.pool.Go(func() {
-				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
 				defer cancel()
 
-				if err := s.apiChecker.CheckNode(ctx, node); err != nil {
+				err := s.apiChecker.CheckNode(ctx, node)
+				s.tracker.record(node.Network, node.Name, "api", err == nil, cfg.AdaptiveChecks)
+				s.reportResult(cfg, node, "api", err)
+				if err != nil {
+					s.readThrough(node, "api")
 					s.logger.Debug("API check failed",
 						zap.String("node", node.Name),
 						zap.Error(err),
@@ -144,12 +284,16 @@ func (s *Scheduler) checkInternalNodes() {
 		}
 
 		// Check RPC if enabled and configured
-		if cfg.RPC && node.RPC != "" {
+		if cfg.RPC && node.RPC != "" && selected(node, "rpc") {
 			_ = s.pool.Go(func() {
-				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
 				defer cancel()
 
-				if err := s.rpcChecker.CheckNode(ctx, node); err != nil {
+				err := s.rpcChecker.CheckNode(ctx, node)
+				s.tracker.record(node.Network, node.Name, "rpc", err == nil, cfg.AdaptiveChecks)
+				s.reportResult(cfg, node, "rpc", err)
+				if err != nil {
+					s.readThrough(node, "rpc")
 					s.logger.Debug("RPC check failed",
 						zap.String("node", node.Name),
 						zap.Error(err),
@@ -159,21 +303,16 @@ func (s *Scheduler) checkInternalNodes() {
 		}
 
 		// Check gRPC if enabled and configured
-		if cfg.GRPC && node.GRPC != "" {
+		if cfg.GRPC && node.GRPC != "" && selected(node, "grpc") {
 			_ = s.pool.Go(func() {
-				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
 				defer cancel()
 
-				// Find the network config for this node to get grpc_insecure setting
-				grpcInsecure := false
-				for _, network := range cfg.Networks {
-					if network.Name == node.Network {
-						grpcInsecure = network.GRPCInsecure
-						break
-					}
-				}
-
-				if err := s.grpcChecker.CheckNode(ctx, node, grpcInsecure); err != nil {
+				err := s.grpcChecker.CheckNode(ctx, node, s.grpcInsecure(cfg, node.Network))
+				s.tracker.record(node.Network, node.Name, "grpc", err == nil, cfg.AdaptiveChecks)
+				s.reportResult(cfg, node, "grpc", err)
+				if err != nil {
+					s.readThrough(node, "grpc")
 					s.logger.Debug("gRPC check failed",
 						zap.String("node", node.Name),
 						zap.Error(err),
@@ -184,8 +323,96 @@ func (s *Scheduler) checkInternalNodes() {
 	}
 }
 
+// saveReputation writes external endpoint reputation to disk if a path is configured
+func (s *Scheduler) saveReputation() {
+	path := s.configLoader.Get().Reputation.Path
+	if path == "" {
+		return
+	}
+
+	if err := s.endpointStore.SaveReputation(path); err != nil {
+		s.logger.Warn("Failed to save endpoint reputation",
+			zap.String("path", path),
+			zap.Error(err),
+		)
+	}
+}
+
+// warmFromCache loads cached heights for every internal node endpoint missing from the store
+func (s *Scheduler) warmFromCache(cfg *config.Config) {
+	if !s.cache.IsEnabled() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	loaded := 0
+	for _, node := range cfg.Internals {
+		for _, endpointType := range nodeTypes(cfg, node) {
+			if s.cache.ReadThrough(ctx, s.store, node.Network, node.Name, endpointType) {
+				loaded++
+			}
+		}
+	}
+
+	s.logger.Info("Heights loaded from cache",
+		zap.Int("entries", loaded),
+	)
+}
+
+// readThrough falls back to the cache after a failed check when the store has no data for the node
+// Another replica sharing the cache may have reached the node
+func (s *Scheduler) readThrough(node config.Node, endpointType string) {
+	if !s.cache.IsEnabled() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.cache.ReadThrough(ctx, s.store, node.Network, node.Name, endpointType)
+}
+
+// nodeTypes returns the enabled endpoint types configured for a node
+func nodeTypes(cfg *config.Config, node config.Node) []string {
+	var types []string
+	if cfg.API && node.API != "" {
+		types = append(types, "api")
+	}
+	if cfg.RPC && node.RPC != "" {
+		types = append(types, "rpc")
+	}
+	if cfg.GRPC && node.GRPC != "" {
+		types = append(types, "grpc")
+	}
+	return types
+}
+
+// grpcInsecure finds the network config for a node to get the grpc_insecure setting
+func (s *Scheduler) grpcInsecure(cfg *config.Config, network string) bool {
+	for _, n := range cfg.Networks {
+		if n.Name == network {
+			return n.GRPCInsecure
+		}
+	}
+	return false
+}
+
+// nodeTimeout returns the health check timeout for a node
+// Slow-but-legitimate backends (e.g. cross-continent providers) may override the global timeout
+func (s *Scheduler) nodeTimeout(node config.Node) time.Duration {
+	if node.HealthCheckTimeout > 0 {
+		return node.HealthCheckTimeout
+	}
+	return s.timeout
+}
+
 // checkExternalRings queries all external Sauron rings
 func (s *Scheduler) checkExternalRings() {
+	if s.isPaused("external") {
+		return
+	}
 	cfg := s.configLoader.Get()
 	s.timeout = cfg.Timeouts.HealthCheck
 
@@ -203,7 +430,7 @@ func (s *Scheduler) checkExternalRings() {
 				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 				defer cancel()
 
-				if err := s.extChecker.CheckExternal(ctx, external, network); err != nil {
+				if err := s.extChecker.CheckExternal(ctx, external, cfg.ExternalPolicy, network); err != nil {
 					s.logger.Debug("External check failed",
 						zap.String("external", external.Name),
 						zap.String("network", network),
@@ -215,6 +442,23 @@ func (s *Scheduler) checkExternalRings() {
 	}
 }
 
+// handleClockJump reschedules cron jobs after the wall clock jumped
+// Restarting cron recomputes every job's next run from the current time; running jobs are not interrupted
+func (s *Scheduler) handleClockJump(skew time.Duration) {
+	direction := "forward"
+	if skew < 0 {
+		direction = "backward"
+	}
+	metrics.ClockJumps.WithLabelValues(direction).Inc()
+	s.logger.Warn("Wall clock jumped, rescheduling health checks",
+		zap.String("direction", direction),
+		zap.Duration("skew", skew),
+	)
+
+	s.cron.Stop()
+	s.cron.Start()
+}
+
 // getAllNetworks returns a list of all networks from internal nodes and config.Networks
 func (s *Scheduler) getAllNetworks(cfg *config.Config) []string {
 	networksMap := make(map[string]bool)
@@ -244,8 +488,19 @@ func (s *Scheduler) recoverFailedEndpoints() {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	s.extChecker.RecoverFailedEndpoints(ctx)
+	if !s.isPaused("external") {
+		s.extChecker.RecoverFailedEndpoints(ctx)
+	}
 
 	// Also update aggregate metrics (leveraging the same 10-second schedule)
 	s.extChecker.UpdateEndpointMetrics()
+
+	// Staleness uses the monotonic reading of the update time, so clock steps don't fake stale nodes
+	for _, entry := range s.store.GetAll() {
+		metrics.NodeHeightStaleness.WithLabelValues(entry.Network, entry.Node, entry.Type).Set(time.Since(entry.Metrics.Timestamp).Seconds())
+	}
+
+	for _, network := range s.getAllNetworks(cfg) {
+		metrics.NetworkHeadHeight.WithLabelValues(network).Set(float64(storage.EstimateHead(s.store, s.endpointStore, network)))
+	}
 }
@@ -0,0 +1,57 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sauron/client"
+)
+
+// ProbeAdvertised validates a tower's advertised endpoints the way a peer ring would
+// Nothing is recorded in the stores or metrics, so it is safe to run against ourselves
+func (c *ExternalChecker) ProbeAdvertised(ctx context.Context, status *client.StatusResponse) []client.EndpointProbe {
+	var probes []client.EndpointProbe
+
+	if status.API != "" {
+		latency, err := c.validateHTTPEndpoint(ctx, status.API)
+		probes = append(probes, newProbe("api", status.API, latency, err))
+	}
+	if status.APIWS != "" {
+		start := time.Now()
+		err := checkWebSocketHandshake(ctx, status.APIWS, c.identity)
+		probes = append(probes, newProbe("api_ws", status.APIWS, time.Since(start), err))
+	}
+	if status.RPC != "" {
+		latency, err := c.validateHTTPEndpoint(ctx, status.RPC)
+		probes = append(probes, newProbe("rpc", status.RPC, latency, err))
+
+		// Peers also look for the RPC WebSocket, it is optional for routing
+		start := time.Now()
+		var wsErr error
+		if !c.validateWebSocketEndpoint(ctx, status.RPC) {
+			wsErr = fmt.Errorf("websocket subscription failed")
+		}
+		probes = append(probes, newProbe("rpc_ws", status.RPC, time.Since(start), wsErr))
+	}
+	if status.GRPC != "" {
+		latency, err := c.validateGRPCEndpoint(ctx, status.GRPC, status.GRPCInsecure)
+		probes = append(probes, newProbe("grpc", status.GRPC, latency, err))
+	}
+
+	return probes
+}
+
+// newProbe converts a validation outcome into a probe result
+func newProbe(typ, url string, latency time.Duration, err error) client.EndpointProbe {
+	probe := client.EndpointProbe{
+		Type:      typ,
+		URL:       url,
+		OK:        err == nil,
+		LatencyMs: latency.Milliseconds(),
+	}
+	if err != nil {
+		probe.Error = err.Error()
+	}
+	return probe
+}
@@ -0,0 +1,71 @@
+package checker
+
+import (
+	"net/http"
+
+	"sauron/config"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// defaultTransport is the checker connection pool used when transport.checker leaves values unset
+var defaultTransport = config.HTTPTransport{
+	MaxIdleConns:        HTTPMaxIdleConns,
+	MaxIdleConnsPerHost: HTTPMaxIdleConnsPerHost,
+	MaxConnsPerHost:     HTTPMaxConnsPerHost,
+	IdleConnTimeout:     HTTPIdleConnTimeout,
+	TLSHandshakeTimeout: HTTPTLSHandshakeTimeout,
+}
+
+// newHTTPClient creates a client whose connection pools follow the transport settings, one pool per host
+// Requests carry the identification headers of identity
+func newHTTPClient(settings config.HTTPTransport, identity Identity) *http.Client {
+	return &http.Client{
+		Transport: &hostTransports{
+			settings:   settings,
+			identity:   identity,
+			transports: xsync.NewMap[string, *http.Transport](),
+		},
+	}
+}
+
+// hostTransports gives every backend host its own connection pool
+// A node stuck in TLS handshakes or holding connections open only exhausts its own pool,
+// so checks of every other node keep their connections and deadlines
+type hostTransports struct {
+	settings   config.HTTPTransport
+	identity   Identity
+	transports *xsync.Map[string, *http.Transport] // host:port -> pool
+}
+
+// RoundTrip sends the request through its host's pool, creating the pool on first use
+func (h *hostTransports) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, _ := h.transports.LoadOrCompute(req.URL.Host, func() (*http.Transport, bool) {
+		return newTransport(h.settings), false
+	})
+
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	h.identity.apply(req.Header)
+	return transport.RoundTrip(req)
+}
+
+// CloseIdleConnections closes idle connections of every host's pool
+func (h *hostTransports) CloseIdleConnections() {
+	h.transports.Range(func(_ string, transport *http.Transport) bool {
+		transport.CloseIdleConnections()
+		return true
+	})
+}
+
+// newTransport creates one connection pool from the transport settings
+func newTransport(settings config.HTTPTransport) *http.Transport {
+	return &http.Transport{
+		MaxIdleConns:        settings.MaxIdleConns,
+		MaxIdleConnsPerHost: settings.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     settings.MaxConnsPerHost,
+		IdleConnTimeout:     settings.IdleConnTimeout,
+		TLSHandshakeTimeout: settings.TLSHandshakeTimeout,
+		DisableKeepAlives:   settings.DisableKeepAlives,
+	}
+}
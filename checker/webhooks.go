@@ -0,0 +1,170 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"sauron/config"
+
+	"go.uber.org/zap"
+)
+
+// Health webhook defaults
+const (
+	// DefaultWebhookInterval is how often queued results are sent when health_webhooks.interval is unset
+	DefaultWebhookInterval = 30 * time.Second
+	// DefaultWebhookTimeout bounds one delivery when health_webhooks.timeout is unset
+	DefaultWebhookTimeout = 5 * time.Second
+	// maxPendingResults caps results queued between deliveries; the oldest are dropped beyond it
+	maxPendingResults = 10000
+)
+
+// HealthResult is the outcome of one internal node health check, as posted to health webhooks
+type HealthResult struct {
+	Time      time.Time `json:"time"`
+	Network   string    `json:"network"`
+	Node      string    `json:"node"`
+	Type      string    `json:"type"`                 // api, rpc or grpc
+	Status    string    `json:"status"`               // ok or failed
+	Height    int64     `json:"height,omitempty"`     // set when status is ok
+	LatencyMs int64     `json:"latency_ms,omitempty"` // set when status is ok
+	Error     string    `json:"error,omitempty"`      // set when status is failed
+}
+
+// HealthReport is the JSON body POSTed to every health webhook
+type HealthReport struct {
+	Time    time.Time      `json:"time"`
+	Results []HealthResult `json:"results"`
+}
+
+// webhookNotifier queues health check results and posts them in batches
+type webhookNotifier struct {
+	client *http.Client
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	pending []HealthResult
+}
+
+// newWebhookNotifier creates a notifier sending through client
+func newWebhookNotifier(client *http.Client, logger *zap.Logger) *webhookNotifier {
+	return &webhookNotifier{
+		client: client,
+		logger: logger,
+	}
+}
+
+// record queues a result for the next delivery; nothing is kept while no webhook is configured
+func (n *webhookNotifier) record(cfg config.HealthWebhooks, result HealthResult) {
+	if len(cfg.URLs) == 0 {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.pending) >= maxPendingResults {
+		n.pending = n.pending[1:]
+	}
+	n.pending = append(n.pending, result)
+}
+
+// flush posts the queued results to every webhook, once each; a failed delivery is logged and not retried
+func (n *webhookNotifier) flush(cfg config.HealthWebhooks) {
+	n.mu.Lock()
+	results := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	if len(results) == 0 || len(cfg.URLs) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(HealthReport{Time: time.Now(), Results: results})
+	if err != nil {
+		n.logger.Error("Failed to encode health webhook report", zap.Error(err))
+		return
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultWebhookTimeout
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range cfg.URLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := n.deliver(target, cfg.Headers, body, timeout); err != nil {
+				n.logger.Warn("Health webhook delivery failed",
+					zap.String("url", target),
+					zap.Int("results", len(results)),
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver POSTs one report to one webhook
+func (n *webhookNotifier) deliver(target string, headers map[string]string, body []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sauron-health-webhook")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// reportResult queues a check result for health_webhooks, with the height and latency just stored on success
+func (s *Scheduler) reportResult(cfg *config.Config, node config.Node, endpointType string, err error) {
+	if len(cfg.HealthWebhooks.URLs) == 0 {
+		return
+	}
+
+	result := HealthResult{
+		Time:    time.Now(),
+		Network: node.Network,
+		Node:    node.Name,
+		Type:    endpointType,
+		Status:  "ok",
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+	} else if metrics, ok := s.store.Get(node.Network, node.Name, endpointType); ok {
+		result.Height = metrics.Height
+		if n := len(metrics.LatencyHistory); n > 0 {
+			result.LatencyMs = metrics.LatencyHistory[n-1].Milliseconds()
+		}
+	}
+	s.webhooks.record(cfg.HealthWebhooks, result)
+}
+
+// flushWebhooks posts the results queued since the last delivery
+func (s *Scheduler) flushWebhooks() {
+	s.webhooks.flush(s.configLoader.Get().HealthWebhooks)
+}
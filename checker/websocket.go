@@ -0,0 +1,31 @@
+package checker
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// checkWebSocketHandshake verifies a ws(s):// endpoint accepts a WebSocket upgrade
+// Used for API-type backends (e.g. EVM JSON-RPC) where the message protocol is not
+// Tendermint's, so a successful handshake is the only protocol-agnostic signal
+func checkWebSocketHandshake(ctx context.Context, wsURL string, identity Identity) error {
+	// Create isolated WebSocket dialer with timeout (avoid race on DefaultDialer)
+	dialer := &websocket.Dialer{
+		HandshakeTimeout: 3 * time.Second,
+		Proxy:            websocket.DefaultDialer.Proxy,
+	}
+
+	conn, _, err := dialer.DialContext(ctx, wsURL, identity.Header())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Close gracefully so the backend doesn't log an abnormal closure
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+
+	return nil
+}
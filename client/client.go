@@ -0,0 +1,484 @@
+// Package client is a typed Go client for the Sauron status and admin API
+// For services that watch a ring, and for the external checker peering into other rings
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retry defaults (used when WithRetry is not given)
+const (
+	// DefaultAttempts is how many times a request is tried in total
+	DefaultAttempts = 3
+	// DefaultBackoff is the wait before the first retry; it doubles on every retry
+	DefaultBackoff = 200 * time.Millisecond
+	// DefaultTimeout bounds each attempt of the default HTTP client
+	DefaultTimeout = 10 * time.Second
+	// maxErrorBody is how much of an error response is kept in APIError
+	maxErrorBody = 512
+)
+
+// ErrInvalidResponse is returned when a 200 response can't be decoded
+var ErrInvalidResponse = errors.New("invalid response")
+
+// APIError is a non-2xx answer from the API
+type APIError struct {
+	StatusCode int
+	Message    string // trimmed response body
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code: %d: %s", e.StatusCode, e.Message)
+}
+
+// retryable reports whether the same request may succeed later
+func (e *APIError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// Client calls one Sauron status API
+type Client struct {
+	baseURL  string
+	token    string
+	http     *http.Client
+	attempts int
+	backoff  time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithToken sends "Authorization: Bearer <token>" on every request
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces the default HTTP client (e.g. to share a connection pool)
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetry sets the total attempts per request and the first backoff (1 = no retries)
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.attempts = max(attempts, 1)
+		c.backoff = backoff
+	}
+}
+
+// New creates a client for the Sauron at baseURL (e.g. "https://sauron.example.com:3000")
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		http:     &http.Client{Timeout: DefaultTimeout},
+		attempts: DefaultAttempts,
+		backoff:  DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Status returns the height and advertised endpoints of a network
+// GET /{network}/status
+func (c *Client) Status(ctx context.Context, network string) (*StatusResponse, error) {
+	var resp struct {
+		StatusResponse
+		Height *int64 `json:"height"` // present in every Sauron status response
+	}
+	if err := c.getJSON(ctx, "/"+url.PathEscape(network)+"/status", &resp); err != nil {
+		return nil, err
+	}
+
+	// Anything answering 200 with JSON would decode; a status without height is not a Sauron
+	if resp.Height == nil {
+		return nil, fmt.Errorf("%w: not a Sauron status response (no height)", ErrInvalidResponse)
+	}
+	resp.StatusResponse.Height = *resp.Height
+	return &resp.StatusResponse, nil
+}
+
+// AllStatus returns the status of every configured network in one call
+// GET /status
+func (c *Client) AllStatus(ctx context.Context) (*AllStatusResponse, error) {
+	var resp AllStatusResponse
+	if err := c.getJSON(ctx, "/status", &resp); err != nil {
+		return nil, err
+	}
+	if resp.Networks == nil {
+		return nil, fmt.Errorf("%w: not a Sauron status response (no networks)", ErrInvalidResponse)
+	}
+	return &resp, nil
+}
+
+// Rings returns the externals, rings and advertised endpoints the Sauron knows about (admin)
+// GET /admin/rings
+func (c *Client) Rings(ctx context.Context) (*RingsResponse, error) {
+	var resp RingsResponse
+	if err := c.getJSON(ctx, "/admin/rings", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ConfigStatus returns when the configuration was applied and how the last reload went (admin)
+// GET /admin/config/status
+func (c *Client) ConfigStatus(ctx context.Context) (*ConfigStatusResponse, error) {
+	var resp ConfigStatusResponse
+	if err := c.getJSON(ctx, "/admin/config/status", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Decisions returns recent selection decisions, newest first (admin)
+// network and endpointType filter the decisions when not empty; limit 0 uses the server default
+// GET /admin/decisions
+func (c *Client) Decisions(ctx context.Context, network, endpointType string, limit int) (*DecisionsResponse, error) {
+	query := url.Values{}
+	if network != "" {
+		query.Set("network", network)
+	}
+	if endpointType != "" {
+		query.Set("type", endpointType)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/admin/decisions"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp DecisionsResponse
+	if err := c.getJSON(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SelfCheck asks the Sauron to probe its own advertised endpoints like a peer ring would (admin)
+// GET /admin/self-check
+func (c *Client) SelfCheck(ctx context.Context) (*SelfCheckResponse, error) {
+	var resp SelfCheckResponse
+	if err := c.getJSON(ctx, "/admin/self-check", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Credentials returns user and external tokens that are expired, not valid yet, or expire within the window (admin)
+// within 0 uses the server default
+// GET /admin/credentials
+func (c *Client) Credentials(ctx context.Context, within time.Duration) (*CredentialsResponse, error) {
+	path := "/admin/credentials"
+	if within > 0 {
+		path += "?within=" + url.QueryEscape(within.String())
+	}
+
+	var resp CredentialsResponse
+	if err := c.getJSON(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Bans returns the client IPs banned by abuse detection, the ones expiring first at the front (admin)
+// GET /admin/bans
+func (c *Client) Bans(ctx context.Context) (*BansResponse, error) {
+	var resp BansResponse
+	if err := c.getJSON(ctx, "/admin/bans", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// BanIP bans a client IP; duration 0 uses the server's ban_duration (admin)
+// POST /admin/bans
+func (c *Client) BanIP(ctx context.Context, ip string, duration time.Duration, reason string) (*BanView, error) {
+	req := BanRequest{IP: ip, Reason: reason}
+	if duration > 0 {
+		req.Duration = duration.String()
+	}
+
+	var ban BanView
+	if err := c.send(ctx, http.MethodPost, "/admin/bans", req, &ban); err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// UnbanIP lifts the ban of a client IP; an *APIError with status 404 means it wasn't banned (admin)
+// DELETE /admin/bans?ip=
+func (c *Client) UnbanIP(ctx context.Context, ip string) error {
+	return c.send(ctx, http.MethodDelete, "/admin/bans?ip="+url.QueryEscape(ip), nil, nil)
+}
+
+// Scheduler returns the paused health checker types (admin)
+// GET /admin/scheduler
+func (c *Client) Scheduler(ctx context.Context) (*SchedulerResponse, error) {
+	var resp SchedulerResponse
+	if err := c.getJSON(ctx, "/admin/scheduler", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PauseChecks pauses health checks of the given types (api, rpc, grpc, external; all when none)
+// for d, or until resumed when d is 0 (admin)
+// POST /admin/scheduler/pause
+func (c *Client) PauseChecks(ctx context.Context, d time.Duration, types ...string) (*SchedulerResponse, error) {
+	query := url.Values{}
+	for _, checkerType := range types {
+		query.Add("type", checkerType)
+	}
+	if d > 0 {
+		query.Set("for", d.String())
+	}
+	path := "/admin/scheduler/pause"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp SchedulerResponse
+	if err := c.send(ctx, http.MethodPost, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ResumeChecks resumes health checks of the given types (all when none) (admin)
+// POST /admin/scheduler/resume
+func (c *Client) ResumeChecks(ctx context.Context, types ...string) (*SchedulerResponse, error) {
+	query := url.Values{}
+	for _, checkerType := range types {
+		query.Add("type", checkerType)
+	}
+	path := "/admin/scheduler/resume"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp SchedulerResponse
+	if err := c.send(ctx, http.MethodPost, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Pins returns the nodes pinned through the admin API (admin)
+// GET /admin/pins
+func (c *Client) Pins(ctx context.Context) (*PinsResponse, error) {
+	var resp PinsResponse
+	if err := c.getJSON(ctx, "/admin/pins", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PinNode routes every request of a network endpoint type to one node for d, bypassing selection (admin)
+// POST /admin/pins
+func (c *Client) PinNode(ctx context.Context, network, endpointType, node string, d time.Duration) (*PinView, error) {
+	req := PinRequest{Network: network, Type: endpointType, Node: node, Duration: d.String()}
+
+	var pin PinView
+	if err := c.send(ctx, http.MethodPost, "/admin/pins", req, &pin); err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+// UnpinNode lifts the manual pin of a network endpoint type; an *APIError with status 404 means
+// there was none (admin)
+// DELETE /admin/pins?network=&type=
+func (c *Client) UnpinNode(ctx context.Context, network, endpointType string) error {
+	query := url.Values{"network": {network}, "type": {endpointType}}
+	return c.send(ctx, http.MethodDelete, "/admin/pins?"+query.Encode(), nil, nil)
+}
+
+// Drains returns the internal nodes drained through the admin API (admin)
+// GET /admin/drains
+func (c *Client) Drains(ctx context.Context) (*DrainsResponse, error) {
+	var resp DrainsResponse
+	if err := c.getJSON(ctx, "/admin/drains", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DrainNode stops selecting an internal node for new requests, in one network or every network it
+// serves when network is empty (admin)
+// POST /admin/nodes/{name}/drain?network=
+func (c *Client) DrainNode(ctx context.Context, network, node string) (*DrainsResponse, error) {
+	var resp DrainsResponse
+	if err := c.send(ctx, http.MethodPost, drainPath(network, node), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UndrainNode returns a drained internal node to selection; an *APIError with status 404 means it
+// wasn't drained (admin)
+// DELETE /admin/nodes/{name}/drain?network=
+func (c *Client) UndrainNode(ctx context.Context, network, node string) error {
+	return c.send(ctx, http.MethodDelete, drainPath(network, node), nil, nil)
+}
+
+// drainPath builds the drain endpoint of a node, restricted to a network unless empty
+func drainPath(network, node string) string {
+	path := "/admin/nodes/" + url.PathEscape(node) + "/drain"
+	if network != "" {
+		path += "?" + url.Values{"network": {network}}.Encode()
+	}
+	return path
+}
+
+// Scaling returns the load signal of a network, or of all networks when network is empty (admin)
+// GET /admin/scaling
+func (c *Client) Scaling(ctx context.Context, network string) (*ScalingResponse, error) {
+	path := "/admin/scaling"
+	if network != "" {
+		path += "?" + url.Values{"network": {network}}.Encode()
+	}
+
+	var resp ScalingResponse
+	if err := c.getJSON(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health returns nil when the Sauron process is up
+// GET /health
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.get(ctx, "/health")
+	return err
+}
+
+// DeepHealth returns Sauron's own goroutine, file descriptor and GC figures against runtime_limits
+// GET /health/deep
+func (c *Client) DeepHealth(ctx context.Context) (*DeepHealthResponse, error) {
+	var resp DeepHealthResponse
+	if err := c.getJSON(ctx, "/health/deep", &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Ready returns nil when the Sauron can route requests
+// GET /ready
+func (c *Client) Ready(ctx context.Context) error {
+	_, err := c.get(ctx, "/ready")
+	return err
+}
+
+// getJSON fetches path and decodes the JSON body into out
+func (c *Client) getJSON(ctx context.Context, path string, out any) error {
+	body, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// get fetches path, retrying network errors, 429 and 5xx with exponential backoff
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	backoff := c.backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var body []byte
+		body, err = c.do(ctx, http.MethodGet, path, nil)
+		if err == nil {
+			return body, nil
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !apiErr.retryable() {
+			return nil, err
+		}
+		if attempt >= c.attempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send performs a change once (no retries), encoding in as the JSON body and decoding the answer into out
+// in and out may be nil
+func (c *Client) send(ctx context.Context, method, path string, in, out any) error {
+	var payload io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	body, err := c.do(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// do performs a single request
+func (c *Client) do(ctx context.Context, method, path string, payload io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestClientStatus tests that Status sends the token and decodes the response
+func TestClientStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pocket/status" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"height":100,"api":"https://api.example.com","grpc":"grpc.example.com:443"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", WithToken("secret"))
+	status, err := c.Status(context.Background(), "pocket")
+	if err != nil {
+		t.Fatalf("Expected status, got error: %v", err)
+	}
+	if status.Height != 100 || status.API != "https://api.example.com" || status.GRPC != "grpc.example.com:443" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+// TestClientAllStatus tests that AllStatus decodes every network with its degradation state
+func TestClientAllStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"networks":{"pocket":{"height":100,"network_head":110,"api":"https://api.example.com","available":true,"blocks_behind":10,"degraded":true},"osmosis":{"height":0,"available":false,"degraded":false}}}`))
+	}))
+	defer server.Close()
+
+	resp, err := New(server.URL).AllStatus(context.Background())
+	if err != nil {
+		t.Fatalf("Expected status, got error: %v", err)
+	}
+	pocket := resp.Networks["pocket"]
+	if !pocket.Available || !pocket.Degraded || pocket.Height != 100 || pocket.BlocksBehind != 10 || pocket.API != "https://api.example.com" {
+		t.Errorf("Unexpected pocket status: %+v", pocket)
+	}
+	if osmosis, ok := resp.Networks["osmosis"]; !ok || osmosis.Available {
+		t.Errorf("Expected osmosis listed as unavailable, got %+v", osmosis)
+	}
+}
+
+// TestClientRetriesServerErrors tests that 5xx answers are retried with backoff until one succeeds
+func TestClientRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"height":7}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetry(3, time.Millisecond))
+	status, err := c.Status(context.Background(), "pocket")
+	if err != nil {
+		t.Fatalf("Expected success on third attempt, got: %v", err)
+	}
+	if status.Height != 7 || calls.Load() != 3 {
+		t.Errorf("Expected height 7 after 3 calls, got height %d after %d calls", status.Height, calls.Load())
+	}
+}
+
+// TestClientDoesNotRetryClientErrors tests that 4xx answers fail at once with an APIError
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "No height data available for network: nope", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetry(3, time.Millisecond))
+	_, err := c.Status(context.Background(), "nope")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404 APIError, got: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single attempt, got %d", calls.Load())
+	}
+}
+
+// TestClientBans tests that bans are added with a JSON body and lifted by IP, without retrying changes
+func TestClientBans(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/bans" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			posts.Add(1)
+			var req BanRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.IP != "203.0.113.7" || req.Duration != "1h0m0s" {
+				http.Error(w, "Unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"ip":"203.0.113.7","reason":"manual","since":"2026-01-01T00:00:00Z","until":"2026-01-01T01:00:00Z"}`))
+		case http.MethodDelete:
+			if r.URL.Query().Get("ip") != "203.0.113.7" {
+				http.Error(w, "IP not banned", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetry(3, time.Millisecond))
+	ban, err := c.BanIP(context.Background(), "203.0.113.7", time.Hour, "")
+	if err != nil {
+		t.Fatalf("Expected ban, got error: %v", err)
+	}
+	if ban.Reason != "manual" || ban.Until.Sub(ban.Since) != time.Hour {
+		t.Errorf("Unexpected ban: %+v", ban)
+	}
+
+	if _, err := c.BanIP(context.Background(), "203.0.113.7", 2*time.Hour, ""); err == nil {
+		t.Error("Expected the 503 to be returned")
+	}
+	if posts.Load() != 2 {
+		t.Errorf("Expected one attempt per ban, got %d", posts.Load())
+	}
+
+	if err := c.UnbanIP(context.Background(), "203.0.113.7"); err != nil {
+		t.Errorf("Expected unban, got error: %v", err)
+	}
+	var apiErr *APIError
+	if err := c.UnbanIP(context.Background(), "198.51.100.1"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 APIError, got: %v", err)
+	}
+}
+
+// TestClientInvalidResponse tests that undecodable bodies are reported as ErrInvalidResponse
+func TestClientInvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html>not sauron</html>`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).Status(context.Background(), "pocket")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse, got: %v", err)
+	}
+}
+
+// TestClientStatusRejectsForeignJSON tests that JSON without a height (e.g. a node's own /status) is refused
+func TestClientStatusRejectsForeignJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","result":{"sync_info":{"latest_block_height":"100"}}}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).Status(context.Background(), "pocket")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("Expected ErrInvalidResponse, got: %v", err)
+	}
+}
+
+// TestStatusResponseValidate tests schema validation of advertised endpoints
+func TestStatusResponseValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  StatusResponse
+		wantErr bool
+	}{
+		{"valid", StatusResponse{Height: 1, API: "https://api.example.com", APIWS: "wss://api.example.com/ws", RPC: "rpc.example.com", GRPC: "grpc.example.com:443"}, false},
+		{"zero height", StatusResponse{API: "https://api.example.com"}, true},
+		{"negative head", StatusResponse{Height: 1, NetworkHead: -1}, true},
+		{"api without host", StatusResponse{Height: 1, API: "https://"}, true},
+		{"api with ws scheme", StatusResponse{Height: 1, API: "ws://api.example.com"}, true},
+		{"api_ws with http scheme", StatusResponse{Height: 1, API: "https://api.example.com", APIWS: "https://api.example.com"}, true},
+		{"api_ws without api", StatusResponse{Height: 1, APIWS: "wss://api.example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.status.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCheckProtocol tests that unversioned and newer peers are accepted
+func TestCheckProtocol(t *testing.T) {
+	for _, version := range []int{0, ProtocolVersion, ProtocolVersion + 1} {
+		if err := CheckProtocol(version); err != nil {
+			t.Errorf("Expected protocol version %d to be accepted, got: %v", version, err)
+		}
+	}
+	if err := CheckProtocol(-1); !errors.Is(err, ErrIncompatibleProtocol) {
+		t.Errorf("Expected ErrIncompatibleProtocol, got: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProtocolVersion is the federation format this build speaks in /{network}/status
+// Bump it when the payload changes meaning; adding fields alone does not need a bump
+const ProtocolVersion = 1
+
+// MinProtocolVersion is the oldest peer format this build can still interpret
+const MinProtocolVersion = 1
+
+// ErrIncompatibleProtocol is returned for peers whose format is too old to interpret
+var ErrIncompatibleProtocol = errors.New("incompatible protocol version")
+
+// CheckProtocol reports whether a peer's protocol version can be used
+// Towers from before versioning send no version and speak version 1; newer peers are
+// accepted since unknown fields are ignored when decoding
+func CheckProtocol(version int) error {
+	if version == 0 {
+		version = 1
+	}
+	if version < MinProtocolVersion {
+		return fmt.Errorf("%w: peer speaks %d, this build needs at least %d", ErrIncompatibleProtocol, version, MinProtocolVersion)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package client
+
+import "time"
+
+// StatusResponse is what a Sauron ring reports for a network at /{network}/status
+type StatusResponse struct {
+	ProtocolVersion int    `json:"protocol_version,omitempty"` // Federation format version (0 = tower from before versioning)
+	Height          int64  `json:"height"`                     // Maximum height across all endpoint types
+	NetworkHead     int64  `json:"network_head,omitempty"`     // Estimated network head (median of internal nodes and external rings)
+	API             string `json:"api,omitempty"`              // Advertised API endpoint URL
+	APIWS           string `json:"api_ws,omitempty"`           // Advertised API WebSocket URL (ws:// or wss://)
+	RPC             string `json:"rpc,omitempty"`              // Advertised RPC endpoint URL
+	GRPC            string `json:"grpc,omitempty"`             // Advertised gRPC endpoint URL
+	GRPCInsecure    bool   `json:"grpc_insecure,omitempty"`    // Whether advertised gRPC endpoint uses insecure (no TLS)
+}
+
+// AllStatusResponse is the status of every configured network at /status, in one round trip
+type AllStatusResponse struct {
+	Networks map[string]NetworkStatus `json:"networks"`
+}
+
+// NetworkStatus is one network's status and how far it lags the network head
+type NetworkStatus struct {
+	StatusResponse
+	Available    bool  `json:"available"`               // false while no height is known; no endpoints are advertised then
+	BlocksBehind int64 `json:"blocks_behind,omitempty"` // network_head - height
+	Degraded     bool  `json:"degraded"`                // height lags the network head by more than stale_threshold
+}
+
+// RingsResponse lists the federation topology as seen by a Sauron
+type RingsResponse struct {
+	Externals []ExternalView `json:"externals"`
+}
+
+// ExternalView is one configured external Sauron deployment
+type ExternalView struct {
+	Name  string     `json:"name"`
+	Rings []RingView `json:"rings"`
+}
+
+// RingView is one ring of an external, per network
+type RingView struct {
+	URL         string         `json:"url"`
+	Network     string         `json:"network"`
+	Checked     bool           `json:"checked"` // false until the first query completes
+	Healthy     bool           `json:"healthy"`
+	Height      int64          `json:"height,omitempty"`
+	Protocol    int            `json:"protocol_version,omitempty"` // protocol version the ring reported
+	LatencyMs   int64          `json:"latency_ms,omitempty"`
+	LastChecked *time.Time     `json:"last_checked,omitempty"`
+	LastSuccess *time.Time     `json:"last_success,omitempty"`
+	LastError   string         `json:"last_error,omitempty"`
+	SuccessRate float64        `json:"success_rate"`
+	AvgLatency  int64          `json:"avg_latency_ms,omitempty"`
+	Endpoints   []EndpointView `json:"endpoints"`
+}
+
+// EndpointView is an endpoint advertised by a ring and its validation state
+type EndpointView struct {
+	Type               string     `json:"type"`
+	URL                string     `json:"url"`
+	WebSocketURL       string     `json:"ws_url,omitempty"`
+	Validated          bool       `json:"validated"`
+	Working            bool       `json:"working"`
+	WebSocketAvailable bool       `json:"websocket_available"`
+	Height             int64      `json:"height"`
+	LatencyMs          int64      `json:"latency_ms"`
+	ErrorCount         int        `json:"error_count"`
+	LastValidated      *time.Time `json:"last_validated,omitempty"`
+	QuarantinedUntil   *time.Time `json:"quarantined_until,omitempty"`
+}
+
+// ConfigStatusResponse reports the active configuration and the last reload attempt
+type ConfigStatusResponse struct {
+	LoadedAt   time.Time  `json:"loaded_at"`             // when the active configuration was applied
+	LastReload *time.Time `json:"last_reload,omitempty"` // last reload attempt, omitted until the first one
+	Result     string     `json:"result,omitempty"`      // success | failure
+	Error      string     `json:"error,omitempty"`       // validation error of a rejected reload
+	Warnings   []string   `json:"warnings,omitempty"`    // lint warnings of the active configuration
+}
+
+// DecisionsResponse lists recent selection decisions, newest first
+type DecisionsResponse struct {
+	Decisions []DecisionView `json:"decisions"`
+}
+
+// DecisionView is one selection decision: which node a request went to and why
+type DecisionView struct {
+	Time              time.Time `json:"time"`
+	Network           string    `json:"network"`
+	Type              string    `json:"type"`
+	Node              string    `json:"node"`
+	Reason            string    `json:"reason"` // height_winner, round_robin, weighted, only_available, internal_preferred, external_overflow, session_pinned, manual_pin, cost_preferred, lowest_latency, least_connections, sticky
+	Candidates        int       `json:"candidates"`
+	MaxHeight         int64     `json:"max_height"`
+	LatencyMs         int64     `json:"latency_ms"` // average latency of the selected node
+	MaxInternalHeight int64     `json:"max_internal_height"`
+	MaxExternalHeight int64     `json:"max_external_height,omitempty"`
+	ExternalFailover  bool      `json:"external_failover"` // externals were in the candidate pool
+	NetworkHead       int64     `json:"network_head,omitempty"`
+	BlocksBehind      int64     `json:"blocks_behind,omitempty"`
+	Stale             bool      `json:"stale"` // every candidate lagged the network head
+}
+
+// SelfCheckResponse reports whether peer rings could use this tower, probed outside-in
+type SelfCheckResponse struct {
+	Usable   bool               `json:"usable"` // every network advertises at least one working endpoint
+	Networks []NetworkSelfCheck `json:"networks"`
+}
+
+// NetworkSelfCheck is the outside-in view of one network's advertisement
+type NetworkSelfCheck struct {
+	Network   string          `json:"network"`
+	Usable    bool            `json:"usable"`
+	Error     string          `json:"error,omitempty"` // why peers would reject the status itself
+	Endpoints []EndpointProbe `json:"endpoints"`
+}
+
+// EndpointProbe is the result of validating one advertised endpoint like a peer would
+type EndpointProbe struct {
+	Type      string `json:"type"` // api | api_ws | rpc | rpc_ws | grpc
+	URL       string `json:"url"`
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// CredentialsResponse lists tokens that need rotating: expired, not valid yet, or expiring within the window
+type CredentialsResponse struct {
+	Within      string           `json:"within"` // look-ahead window, e.g. "168h0m0s"
+	Credentials []CredentialView `json:"credentials"`
+}
+
+// CredentialView is one user or external token with a validity window; the token itself is never included
+type CredentialView struct {
+	Kind      string     `json:"kind"` // user | external
+	Name      string     `json:"name"`
+	Status    string     `json:"status"` // expired | not_yet_valid | expiring
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BansResponse lists the client IPs banned by abuse detection
+type BansResponse struct {
+	Bans []BanView `json:"bans"`
+}
+
+// BanView is one banned client IP
+type BanView struct {
+	IP     string    `json:"ip"`
+	Reason string    `json:"reason"` // rate_limited | auth_failure | malformed | manual (or the reason given when banned)
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+}
+
+// BanRequest bans a client IP through POST /admin/bans
+type BanRequest struct {
+	IP       string `json:"ip"`
+	Duration string `json:"duration,omitempty"` // e.g. "1h"; ban_duration when empty
+	Reason   string `json:"reason,omitempty"`   // "manual" when empty
+}
+
+// SchedulerResponse lists the health checker types paused through the admin API
+type SchedulerResponse struct {
+	Paused []PausedCheckerView `json:"paused"`
+}
+
+// PinsResponse lists the nodes pinned through the admin API
+type PinsResponse struct {
+	Pins []PinView `json:"pins"`
+}
+
+// PinView is one manual pin: every request of the network type goes to the node until it expires
+type PinView struct {
+	Network string    `json:"network"`
+	Type    string    `json:"type"` // api | rpc | grpc
+	Node    string    `json:"node"` // internal node name or "ext:{url}"
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+}
+
+// PinRequest pins a network type to a node through POST /admin/pins
+type PinRequest struct {
+	Network  string `json:"network"`
+	Type     string `json:"type"`
+	Node     string `json:"node"`
+	Duration string `json:"duration"` // e.g. "15m", at most 24h
+}
+
+// DrainsResponse lists the internal nodes drained through the admin API
+type DrainsResponse struct {
+	Drains []DrainView `json:"drains"`
+}
+
+// DrainView is one drained node: selection skips it for new requests until it is undrained
+type DrainView struct {
+	Network string    `json:"network"`
+	Node    string    `json:"node"`
+	Since   time.Time `json:"since"`
+}
+
+// DeepHealthResponse reports Sauron's own runtime resources against runtime_limits
+type DeepHealthResponse struct {
+	Status        string   `json:"status"` // ok | degraded (a soft limit is exceeded)
+	Goroutines    int      `json:"goroutines"`
+	OpenFDs       int      `json:"open_fds"` // -1 where unknown (not Linux)
+	HeapAllocMB   float64  `json:"heap_alloc_mb"`
+	NumGC         uint32   `json:"num_gc"`
+	LastGCPauseMs float64  `json:"last_gc_pause_ms"`
+	MaxGoroutines int      `json:"max_goroutines"` // 0 = no limit
+	MaxOpenFDs    int      `json:"max_open_fds"`   // 0 = no limit
+	Exceeded      []string `json:"exceeded"`       // goroutines | open_fds
+}
+
+// ScalingResponse is a compact load signal for autoscalers (KEDA metrics-api scaler, HPA adapters)
+type ScalingResponse struct {
+	Network       string  `json:"network,omitempty"` // absent when covering all networks
+	WindowSeconds float64 `json:"window_seconds"`    // what request_rate and p95_ms are measured over
+	RequestRate   float64 `json:"request_rate"`      // proxied requests per second
+	P95Ms         int64   `json:"p95_ms"`            // p95 proxy latency (0 without requests)
+	InFlight      int64   `json:"in_flight"`         // requests being proxied now
+	Capacity      int64   `json:"capacity"`          // healthy internal nodes x egress.internal_capacity
+	Saturation    float64 `json:"saturation"`        // in_flight / capacity
+	HealthyNodes  int     `json:"healthy_nodes"`     // internal nodes at a height above 0
+	Nodes         int     `json:"nodes"`             // internal nodes configured
+}
+
+// PausedCheckerView is one paused checker type; nodes keep their last known height meanwhile
+type PausedCheckerView struct {
+	Type  string     `json:"type"` // api | rpc | grpc | external
+	Since time.Time  `json:"since"`
+	Until *time.Time `json:"until,omitempty"` // absent when paused until resumed
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Validate checks that a status response is usable before its endpoints are trusted
+// Mirrors what a ring's own config validation accepts for advertised endpoints
+func (s *StatusResponse) Validate() error {
+	if s.Height <= 0 {
+		return fmt.Errorf("height must be positive: %d", s.Height)
+	}
+	if s.NetworkHead < 0 {
+		return fmt.Errorf("network_head cannot be negative: %d", s.NetworkHead)
+	}
+
+	if s.API != "" {
+		if err := validateHost(s.API, "api", "http://", "https://"); err != nil {
+			return err
+		}
+	}
+	if s.APIWS != "" {
+		if s.API == "" {
+			return fmt.Errorf("api_ws advertised without api")
+		}
+		if !strings.HasPrefix(s.APIWS, "ws://") && !strings.HasPrefix(s.APIWS, "wss://") {
+			return fmt.Errorf("invalid api_ws URL: scheme must be ws:// or wss://")
+		}
+		if err := validateHost(s.APIWS, "api_ws"); err != nil {
+			return err
+		}
+	}
+	if s.RPC != "" {
+		if err := validateHost(s.RPC, "rpc", "http://", "https://"); err != nil {
+			return err
+		}
+	}
+	if s.GRPC != "" {
+		if err := validateHost(s.GRPC, "grpc", "http://", "https://"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateHost parses an advertised URL, assuming https when it has none of the accepted schemes
+func validateHost(raw, typ string, schemes ...string) error {
+	hasScheme := len(schemes) == 0
+	for _, scheme := range schemes {
+		if strings.HasPrefix(raw, scheme) {
+			hasScheme = true
+			break
+		}
+	}
+	if !hasScheme {
+		if strings.Contains(raw, "://") {
+			return fmt.Errorf("invalid %s URL: unsupported scheme", typ)
+		}
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s URL: %w", typ, err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid %s URL: missing host", typ)
+	}
+
+	return nil
+}
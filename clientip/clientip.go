@@ -0,0 +1,154 @@
+// Package clientip resolves the real client address of a request behind reverse proxies
+// Forwarding headers are only believed when they come from a trusted peer
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver decides which peers may forward client addresses and extracts the client IP
+// The watchers at the gate, who know which messengers speak true
+type Resolver struct {
+	trustAll bool         // honor forwarding headers from any peer (legacy trust_proxy behavior)
+	nets     []*net.IPNet // peers allowed to forward client addresses
+}
+
+// NewResolver creates a resolver from a list of trusted proxy CIDRs or bare IPs
+// trustAll honors forwarding headers from any peer; with neither, headers are ignored
+func NewResolver(trustAll bool, trustedProxies []string) (*Resolver, error) {
+	nets, err := ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{trustAll: trustAll, nets: nets}, nil
+}
+
+// ParseCIDRs parses CIDRs, accepting bare IPs as single-host networks
+func ParseCIDRs(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: not an IP or CIDR", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// PeerIP returns the address of the directly connected peer
+func PeerIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// Trusted reports whether the given peer may forward client addresses
+func (res *Resolver) Trusted(peer string) bool {
+	if res == nil {
+		return false
+	}
+	if res.trustAll {
+		return true
+	}
+	return res.inList(peer)
+}
+
+// inList reports whether ip falls within a configured trusted proxy network
+func (res *Resolver) inList(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range res.nets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP extracts the real client IP from the request
+// This handles various proxy scenarios (HAProxy, Nginx, Cloudflare, etc.)
+func (res *Resolver) ClientIP(r *http.Request) string {
+	peer := PeerIP(r)
+
+	// Untrusted peers are the client as far as we can tell
+	if !res.Trusted(peer) {
+		return peer
+	}
+
+	// X-Forwarded-For: Contains chain of IPs (client, proxy1, proxy2, ...)
+	if ip := res.fromForwardedFor(r.Header.Values("X-Forwarded-For")); ip != "" {
+		return ip
+	}
+
+	// X-Real-IP (Nginx), CF-Connecting-IP and True-Client-IP (Cloudflare) carry a single IP
+	for _, header := range []string{"X-Real-IP", "CF-Connecting-IP", "True-Client-IP"} {
+		if v := strings.TrimSpace(r.Header.Get(header)); v != "" && net.ParseIP(v) != nil {
+			return v
+		}
+	}
+
+	// Fallback to the peer if no valid proxy headers found
+	return peer
+}
+
+// fromForwardedFor picks the client from X-Forwarded-For values
+// With a trusted proxy list the chain is walked right to left, skipping our own proxies,
+// so a client can't spoof its address by prepending entries
+func (res *Resolver) fromForwardedFor(values []string) string {
+	var chain []string
+	for _, v := range values {
+		for _, ip := range strings.Split(v, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				chain = append(chain, ip)
+			}
+		}
+	}
+	if len(chain) == 0 {
+		return ""
+	}
+
+	// Trusting every peer: take the leftmost (original client) IP
+	if res.trustAll {
+		if net.ParseIP(chain[0]) != nil {
+			return chain[0]
+		}
+		return ""
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if net.ParseIP(chain[i]) == nil {
+			return ""
+		}
+		if !res.inList(chain[i]) {
+			return chain[i]
+		}
+	}
+
+	// Every hop is one of ours; the leftmost is the closest thing to a client
+	return chain[0]
+}
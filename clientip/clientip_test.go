@@ -0,0 +1,66 @@
+package clientip
+
+import (
+	"net/http"
+	"testing"
+)
+
+func newRequest(remoteAddr string, headers map[string]string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "http://sauron/", nil)
+	r.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestResolverIgnoresHeadersFromUntrustedPeer(t *testing.T) {
+	res, err := NewResolver(false, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newRequest("203.0.113.5:4000", map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "1.2.3.4"})
+	if got := res.ClientIP(r); got != "203.0.113.5" {
+		t.Errorf("Expected peer address for untrusted peer, got %s", got)
+	}
+}
+
+func TestResolverWalksForwardedForFromTrustedPeer(t *testing.T) {
+	res, err := NewResolver(false, []string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The client prepended a spoofed address; the first untrusted hop from the right wins
+	r := newRequest("10.0.0.2:4000", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7, 192.168.1.1"})
+	if got := res.ClientIP(r); got != "198.51.100.7" {
+		t.Errorf("Expected 198.51.100.7, got %s", got)
+	}
+
+	r = newRequest("10.0.0.2:4000", map[string]string{"X-Real-IP": "198.51.100.8"})
+	if got := res.ClientIP(r); got != "198.51.100.8" {
+		t.Errorf("Expected X-Real-IP from trusted peer, got %s", got)
+	}
+}
+
+func TestResolverTrustAllUsesLeftmost(t *testing.T) {
+	res, err := NewResolver(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newRequest("203.0.113.5:4000", map[string]string{"X-Forwarded-For": "1.2.3.4, 5.6.7.8"})
+	if got := res.ClientIP(r); got != "1.2.3.4" {
+		t.Errorf("Expected leftmost address, got %s", got)
+	}
+}
+
+func TestParseCIDRsRejectsGarbage(t *testing.T) {
+	if _, err := ParseCIDRs([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for invalid entry")
+	}
+	if _, err := ParseCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("Expected error for invalid CIDR")
+	}
+}
@@ -0,0 +1,47 @@
+// Package clock provides monotonic time for rolling windows and detects wall-clock jumps
+// NTP steps, manual clock changes and VM pauses move the wall clock but not the monotonic one
+package clock
+
+import (
+	"time"
+)
+
+// start anchors Elapsed; time.Now carries a monotonic reading, so durations since it never jump
+var start = time.Now()
+
+// Elapsed returns the monotonic time since the process started
+// Use it instead of wall-clock Unix times to bucket rolling windows
+func Elapsed() time.Duration {
+	return time.Since(start)
+}
+
+// Watch checks every interval whether the wall clock moved differently from the monotonic clock
+// and calls onJump with the difference (positive = wall clock jumped forward) when it exceeds threshold
+// The returned function stops the watcher
+func Watch(interval, threshold time.Duration, onJump func(skew time.Duration)) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		last := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				// Round(0) strips the monotonic reading, so Sub compares wall-clock times
+				skew := now.Round(0).Sub(last.Round(0)) - now.Sub(last)
+				if skew > threshold || skew < -threshold {
+					onJump(skew)
+				}
+				last = now
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
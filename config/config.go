@@ -2,25 +2,55 @@ package config
 
 import (
 	"crypto/subtle"
+	"slices"
 	"time"
 )
 
 // Config represents the complete Sauron configuration
 // The Dark Tower's ancient scrolls
 type Config struct {
-	API                       bool       `mapstructure:"api"`
-	RPC                       bool       `mapstructure:"rpc"`
-	GRPC                      bool       `mapstructure:"grpc"`
-	Auth                      bool       `mapstructure:"auth"`
-	Listen                    string     `mapstructure:"listen"`
-	ExternalFailoverThreshold int64      `mapstructure:"external_failover_threshold"` // Blocks behind before using externals (default: 2)
-	Timeouts                  Timeouts   `mapstructure:"timeouts"`
-	Redis                     Redis      `mapstructure:"redis"`
-	RateLimit                 RateLimit  `mapstructure:"rate_limit"`
-	Networks                  []Network  `mapstructure:"networks"`
-	Internals                 []Node     `mapstructure:"internals"`
-	Externals                 []External `mapstructure:"externals"`
-	Users                     []User     `mapstructure:"users"`
+	Version                   int                `mapstructure:"version"` // Schema version (see CurrentVersion); older files are migrated on load
+	API                       bool               `mapstructure:"api"`
+	RPC                       bool               `mapstructure:"rpc"`
+	GRPC                      bool               `mapstructure:"grpc"`
+	Auth                      bool               `mapstructure:"auth"`
+	Listen                    string             `mapstructure:"listen"`
+	Logging                   Logging            `mapstructure:"logging"`
+	ExternalFailoverThreshold int64              `mapstructure:"external_failover_threshold"` // Blocks behind before using externals (default: 2)
+	StaleThreshold            int64              `mapstructure:"stale_threshold"`             // Blocks behind the network head before responses are flagged stale (default: 5)
+	Timeouts                  Timeouts           `mapstructure:"timeouts"`
+	Redis                     Redis              `mapstructure:"redis"`
+	RateLimit                 RateLimit          `mapstructure:"rate_limit"`
+	AbuseDetection            AbuseDetection     `mapstructure:"abuse_detection"`
+	Forwarding                Forwarding         `mapstructure:"forwarding"`
+	TrustedProxies            []string           `mapstructure:"trusted_proxies"` // CIDRs/IPs of reverse proxies allowed to forward client addresses
+	AdaptiveChecks            Adaptive           `mapstructure:"adaptive_checks"`
+	SharedHeightChecks        bool               `mapstructure:"shared_height_checks"` // Probe nodes once when api/rpc/grpc share a host and reuse the height
+	Reputation                Reputation         `mapstructure:"reputation"`
+	HealthWebhooks            HealthWebhooks     `mapstructure:"health_webhooks"`
+	RingSigning               RingSigning        `mapstructure:"ring_signing"`
+	ErrorBudget               ErrorBudget        `mapstructure:"error_budget"`
+	SlowEjection              SlowEjection       `mapstructure:"slow_ejection"`
+	SelectorLog               SelectorLog        `mapstructure:"selector_log"`
+	DecisionAudit             DecisionAudit      `mapstructure:"decision_audit"`
+	Egress                    Egress             `mapstructure:"egress"`
+	CostAware                 CostAware          `mapstructure:"cost_aware"`
+	LatencyEWMA               LatencyEWMA        `mapstructure:"latency_ewma"`
+	LatencyPercentiles        LatencyPercentiles `mapstructure:"latency_percentiles"`
+	ReadYourWrites            ReadYourWrites     `mapstructure:"read_your_writes"`
+	Retry                     Retry              `mapstructure:"retry"`
+	ConnectionLimits          ConnectionLimits   `mapstructure:"connection_limits"`
+	Transport                 Transport          `mapstructure:"transport"`
+	Metrics                   Metrics            `mapstructure:"metrics"`
+	RuntimeLimits             RuntimeLimits      `mapstructure:"runtime_limits"`
+	StreamLeaks               StreamLeaks        `mapstructure:"stream_leaks"`
+	ExternalPolicy            ExternalPolicy     `mapstructure:"external_policy"`
+	SLOs                      []SLO              `mapstructure:"slos"`
+	Networks                  []Network          `mapstructure:"networks"`
+	Internals                 []Node             `mapstructure:"internals"`
+	NodeGroups                []NodeGroup        `mapstructure:"node_groups"`
+	Externals                 []External         `mapstructure:"externals"`
+	Users                     []User             `mapstructure:"users"`
 }
 
 // Timeouts configuration for health checks and proxying
@@ -28,6 +58,13 @@ type Config struct {
 type Timeouts struct {
 	HealthCheck time.Duration `mapstructure:"health_check"`
 	Proxy       time.Duration `mapstructure:"proxy"`
+	Dial        time.Duration `mapstructure:"dial"` // time to connect to an API/RPC or WebSocket backend (default 10s)
+
+	// Listener timeouts for all HTTP servers (status, metrics, API and RPC proxies), applied at startup
+	ReadHeader time.Duration `mapstructure:"read_header"` // time to read request headers (default 10s, guards against slowloris)
+	Read       time.Duration `mapstructure:"read"`        // time to read the whole request including body (0 = no limit)
+	Write      time.Duration `mapstructure:"write"`       // time to write the response (0 = no limit; must cover the proxy timeout)
+	Idle       time.Duration `mapstructure:"idle"`        // keep-alive idle time between requests (default 120s)
 }
 
 // Redis configuration (optional distributed cache)
@@ -43,14 +80,392 @@ type RateLimit struct {
 	Enabled           bool `mapstructure:"enabled"`             // whether rate limiting is enabled
 	RequestsPerSecond int  `mapstructure:"requests_per_second"` // requests allowed per second per IP
 	Burst             int  `mapstructure:"burst"`               // burst capacity
+	MaxEntries        int  `mapstructure:"max_entries"`         // maximum tracked client IPs, least recently seen evicted first (default 10000)
 	TrustProxy        bool `mapstructure:"trust_proxy"`         // trust X-Forwarded-For and proxy headers
 }
 
+// AbuseDetection configuration for temporarily banning client IPs that show abusive patterns
+// Bans are kept in Redis when it is enabled, so they survive restarts and are shared by replicas
+// The Eye turns upon those who hammer at the gates
+type AbuseDetection struct {
+	Enabled      bool          `mapstructure:"enabled"`       // applied at startup; thresholds are reloaded
+	Window       time.Duration `mapstructure:"window"`        // period events are counted over (default 1m)
+	BanDuration  time.Duration `mapstructure:"ban_duration"`  // how long an IP stays banned (default 15m)
+	RateLimited  int           `mapstructure:"rate_limited"`  // rate limited requests (429) within window before a ban (default 100)
+	AuthFailures int           `mapstructure:"auth_failures"` // failed authentications (401) within window before a ban (default 20)
+	Malformed    int           `mapstructure:"malformed"`     // malformed requests (400) within window before a ban (default 50)
+	Exempt       []string      `mapstructure:"exempt"`        // IPs/CIDRs never banned (e.g. monitoring, partners)
+	MaxEntries   int           `mapstructure:"max_entries"`   // maximum tracked client events, least recently seen evicted first (default 10000)
+}
+
+// Forwarding configuration for headers added to proxied HTTP requests
+// The tidings carried by each messenger
+type Forwarding struct {
+	ClientIP   string `mapstructure:"client_ip"`   // append (default) | replace | omit - how the client IP is forwarded
+	DisableVia bool   `mapstructure:"disable_via"` // do not add the Via header to requests and responses
+}
+
+// Adaptive configuration for re-checking unstable internal nodes more often
+// The Eye lingers where the shadows stir
+type Adaptive struct {
+	Enabled        bool          `mapstructure:"enabled"`         // whether unstable nodes get extra checks between regular rounds
+	FastInterval   time.Duration `mapstructure:"fast_interval"`   // interval for unstable nodes (default 5s, applied at startup)
+	LagThreshold   int64         `mapstructure:"lag_threshold"`   // blocks behind the network's best internal height to count as lagging (default 2)
+	FlapWindow     time.Duration `mapstructure:"flap_window"`     // window in which up/down transitions are counted (default 5m)
+	FlapCount      int           `mapstructure:"flap_count"`      // transitions within flap_window to count as flapping (default 3)
+	RecoveryPeriod time.Duration `mapstructure:"recovery_period"` // how long a recovered node stays under close watch (default 2m)
+}
+
+// Reputation configuration for persisting external endpoint health across restarts
+// The grudges the Eye keeps
+type Reputation struct {
+	Path          string        `mapstructure:"path"`           // local file for endpoint reputation (empty = in-memory only)
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // how often reputation is written to disk (default 30s)
+}
+
+// HealthWebhooks configuration for posting batched health check results to external systems
+// Messengers riding out from the tower with every watch
+type HealthWebhooks struct {
+	URLs     []string          `mapstructure:"urls"`     // endpoints receiving a JSON POST per batch (empty = disabled)
+	Headers  map[string]string `mapstructure:"headers"`  // headers sent with every POST (e.g. Authorization)
+	Interval time.Duration     `mapstructure:"interval"` // how often queued results are sent (default 30s, one check round)
+	Timeout  time.Duration     `mapstructure:"timeout"`  // time allowed per delivery (default 5s)
+}
+
+// RingSigning configuration for signing requests forwarded to peer rings and verifying theirs
+// Requests to an external's endpoints are signed when the external has a signing_secret;
+// networks verify incoming ones with the ring_signature middleware or interceptor
+// Sealed letters, so each tower knows the hand that wrote them
+type RingSigning struct {
+	Name    string        `mapstructure:"name"`     // this ring's name, sent with signed requests (required to sign)
+	MaxSkew time.Duration `mapstructure:"max_skew"` // accepted clock difference of incoming signatures (default 5m)
+	Trusted []TrustedRing `mapstructure:"trusted"`  // peers whose signed requests are accepted
+}
+
+// TrustedRing is a peer ring allowed to send signed requests
+type TrustedRing struct {
+	Name   string `mapstructure:"name"`   // the name the peer signs with (its ring_signing.name)
+	Secret string `mapstructure:"secret"` // shared with the peer, which sets it as our external's signing_secret
+}
+
+// FindTrustedRing returns the trusted peer ring with the given name
+func (r *RingSigning) FindTrustedRing(name string) *TrustedRing {
+	for i := range r.Trusted {
+		if r.Trusted[i].Name == name {
+			return &r.Trusted[i]
+		}
+	}
+	return nil
+}
+
+// ConnectionLimits configuration for capping simultaneous proxy work per client
+// A client is the user of a valid bearer token, otherwise its IP (trusted_proxies honored)
+// No single servant may crowd the gates
+type ConnectionLimits struct {
+	MaxRequests    int `mapstructure:"max_requests"`     // simultaneous API/RPC requests per client (0 = unlimited)
+	MaxWebSockets  int `mapstructure:"max_websockets"`   // simultaneous WebSocket connections per client (0 = unlimited)
+	MaxGRPCStreams int `mapstructure:"max_grpc_streams"` // simultaneous gRPC calls and streams per client (0 = unlimited)
+}
+
+// Transport configuration for outbound HTTP connection pools, applied at startup
+// The roads leading out of the tower
+type Transport struct {
+	Checker HTTPTransport `mapstructure:"checker"` // height checks against internals and external rings
+	Proxy   HTTPTransport `mapstructure:"proxy"`   // proxied API/RPC requests
+
+	BufferSize int `mapstructure:"buffer_size"` // pooled buffer proxied response bodies are copied through, in bytes (default 32KB)
+
+	Rebalance Rebalance `mapstructure:"rebalance"` // periodic recycling of long-lived proxy backend connections
+}
+
+// Rebalance configuration for recycling long-lived proxy backend connections a slice at a time
+// Replacements are dialed (and resolved) afresh, so traffic spreads onto backends added behind a name
+// Old roads are closed a few at a time, so travellers find the new ones
+type Rebalance struct {
+	Interval time.Duration `mapstructure:"interval"` // how often a slice of connections is recycled (0 = never, applied at startup)
+	Fraction float64       `mapstructure:"fraction"` // share of eligible connections recycled each interval (default 0.25)
+	MinAge   time.Duration `mapstructure:"min_age"`  // connections younger than this are left alone (default: interval)
+}
+
+// Bounds of transport.buffer_size
+const (
+	MinProxyBufferSize = 4 << 10
+	MaxProxyBufferSize = 4 << 20
+)
+
+// HTTPTransport tunes one outbound connection pool; zero values keep the built-in defaults
+type HTTPTransport struct {
+	MaxIdleConns        int           `mapstructure:"max_idle_conns"`          // idle connections kept across all hosts
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"` // idle connections kept per host
+	MaxConnsPerHost     int           `mapstructure:"max_conns_per_host"`      // total connections per host (0 = unlimited)
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`       // how long idle connections are kept
+	TLSHandshakeTimeout time.Duration `mapstructure:"tls_handshake_timeout"`   // time allowed for the TLS handshake
+	DisableKeepAlives   bool          `mapstructure:"disable_keepalives"`      // open a new connection for every request
+}
+
+// WithDefaults returns the settings with unset values taken from defaults
+func (t HTTPTransport) WithDefaults(defaults HTTPTransport) HTTPTransport {
+	if t.MaxIdleConns == 0 {
+		t.MaxIdleConns = defaults.MaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost == 0 {
+		t.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+	if t.MaxConnsPerHost == 0 {
+		t.MaxConnsPerHost = defaults.MaxConnsPerHost
+	}
+	if t.IdleConnTimeout == 0 {
+		t.IdleConnTimeout = defaults.IdleConnTimeout
+	}
+	if t.TLSHandshakeTimeout == 0 {
+		t.TLSHandshakeTimeout = defaults.TLSHandshakeTimeout
+	}
+	return t
+}
+
+// ErrorBudget configuration for sending fewer requests to internal nodes whose proxied requests fail
+// Servants who fail the Dark Lord are sent to the gates less often
+type ErrorBudget struct {
+	Enabled     bool          `mapstructure:"enabled"`      // whether selection weight follows each node's rolling proxy error rate
+	Window      time.Duration `mapstructure:"window"`       // rolling window proxy outcomes are counted over (default 5m)
+	MinRequests int           `mapstructure:"min_requests"` // requests in the window before a node is weighted (default 20)
+	MinWeight   float64       `mapstructure:"min_weight"`   // lowest weight a failing node keeps so it can prove recovery (default 0.1)
+}
+
+// SlowEjection configuration for taking internal nodes out of rotation while they answer far slower than their peers
+// A servant who crawls to the gates is sent back to the forge, however high his tower
+type SlowEjection struct {
+	Enabled     bool          `mapstructure:"enabled"`      // whether slow internal nodes are ejected from selection
+	Window      time.Duration `mapstructure:"window"`       // sliding window each node's p99 proxy latency is measured over (default 1m)
+	Multiplier  float64       `mapstructure:"multiplier"`   // eject when a node's p99 exceeds this multiple of the network median p99 (default 3)
+	MinRequests int           `mapstructure:"min_requests"` // requests in the window before a node's p99 is judged (default 20)
+	EjectFor    time.Duration `mapstructure:"eject_for"`    // how long an ejected node stays out of rotation (default 30s)
+}
+
+// SelectorLog configuration for how much of each routing decision is logged
+// The Eye keeps its tally always, but writes down its judgments only when asked
+type SelectorLog struct {
+	Mode        string `mapstructure:"mode"`         // off (default), sampled or debug
+	SampleEvery int    `mapstructure:"sample_every"` // with sampled, one selection in this many is logged (default 100)
+}
+
+// Logging configuration for Sauron's own logs (applied at startup)
+// The voice of the tower, loud in the forge and measured on the battlefield
+type Logging struct {
+	Level    string      `mapstructure:"level"`    // debug | info (default) | warn | error
+	Encoding string      `mapstructure:"encoding"` // json (default) | console
+	Outputs  []string    `mapstructure:"outputs"`  // stderr (default), stdout or file paths
+	Sampling LogSampling `mapstructure:"sampling"`
+}
+
+// LogSampling caps repeated log lines per second: the first initial of each message are kept,
+// then one in thereafter
+type LogSampling struct {
+	Disabled   bool `mapstructure:"disabled"`   // log every line, e.g. in development
+	Initial    int  `mapstructure:"initial"`    // lines per message and second logged before sampling (default 100)
+	Thereafter int  `mapstructure:"thereafter"` // then one line in this many (default 100)
+}
+
+// Log levels and encodings accepted in logging
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+
+	LogEncodingJSON    = "json"
+	LogEncodingConsole = "console"
+)
+
+// DecisionAudit configuration for keeping recent selection decisions, served at GET /admin/decisions
+// The Eye forgets nothing it was asked to remember
+type DecisionAudit struct {
+	Enabled bool `mapstructure:"enabled"` // whether decisions are kept
+	Size    int  `mapstructure:"size"`    // decisions kept per network and endpoint type (default 1000)
+}
+
+// Selector logging modes; routing metrics are recorded in every mode
+const (
+	SelectorLogOff     = "off"     // per-request selection details are not logged
+	SelectorLogSampled = "sampled" // one selection in sample_every is logged at Info
+	SelectorLogDebug   = "debug"   // every selection is logged at Debug
+)
+
+// Selection strategies: how a node is picked among the candidates at max height
+const (
+	SelectionStrategyWeighted      = "weighted"          // rotate, each node's share following its weight (default)
+	SelectionStrategyRoundRobin    = "round_robin"       // rotate evenly, ignoring weight
+	SelectionStrategyHeightLatency = "height_latency"    // lowest health check latency (average, or p95 per latency_percentiles)
+	SelectionStrategyLeastConns    = "least_connections" // fewest requests in flight and WebSockets open
+)
+
+// Egress configuration for how traffic is split between internal nodes and externals
+// The Eye's own servants answer first; allies only take what they cannot carry
+type Egress struct {
+	PreferInternals  bool `mapstructure:"prefer_internals"`  // during failover internals win, externals only take overflow beyond internal_capacity
+	InternalCapacity int  `mapstructure:"internal_capacity"` // in-flight requests per internal node before overflow goes to externals (default 100)
+}
+
+// CostAware configuration for preferring cheaper nodes (lower cost_weight) among equally good candidates
+// Candidates at the max height whose latency is within latency_band of the fastest count as equally good
+// Tribute flows first to the Dark Lord's own forges, and only then to hired hands
+type CostAware struct {
+	Enabled     bool          `mapstructure:"enabled"`      // whether selection prefers the cheapest in-band candidates
+	LatencyBand time.Duration `mapstructure:"latency_band"` // latency above the fastest max-height candidate still considered equal (default 50ms)
+}
+
+// LatencyEWMA configuration for the node latency average the selector compares
+// Each health check measurement weighs more than the one before, so spikes show within a check or two
+// The Eye forgets yesterday's roads quicker than today's
+type LatencyEWMA struct {
+	HalfLife float64 `mapstructure:"half_life"` // health checks after which a measurement weighs half as much (default 2)
+}
+
+// Latency tiebreak metrics: which node latency selection compares
+const (
+	LatencyTiebreakAverage = "average" // the health check latency average (default)
+	LatencyTiebreakP95     = "p95"     // the p95 of health check latencies within latency_percentiles.window
+)
+
+// LatencyPercentiles configuration for node latency percentiles (p50/p95/p99) and which latency breaks ties
+// An average hides the slow requests clients feel; p95 does not
+// The Eye judges a road by its worst stretches, not its mean
+type LatencyPercentiles struct {
+	Window   time.Duration `mapstructure:"window"`   // how far back percentiles look (default 5m)
+	Tiebreak string        `mapstructure:"tiebreak"` // latency height_latency and cost_aware compare: average (default) or p95
+}
+
+// ReadYourWrites configuration for pinning a client to the node that took its transaction
+// A client is the user of a valid bearer token, otherwise its IP (trusted_proxies honored)
+// What the Eye has been told, it does not forget moments later
+type ReadYourWrites struct {
+	Enabled bool          `mapstructure:"enabled"` // pin clients after a successful tx broadcast
+	Window  time.Duration `mapstructure:"window"`  // how long queries stay pinned after a broadcast (default 30s)
+}
+
+// Retry configuration for retrying failed API/RPC requests on another node
+// Requests safe to repeat (reads) are retried on any transport failure; others only when the backend was never reached
+// A messenger turned away at one gate rides on to the next, unless he already delivered his message
+type Retry struct {
+	Enabled     bool        `mapstructure:"enabled"`      // whether failed requests are retried
+	MaxAttempts int         `mapstructure:"max_attempts"` // attempts per request, the first included (default 2)
+	Rules       []RetryRule `mapstructure:"rules"`        // override the built-in safe/unsafe classification, first match wins
+}
+
+// MaxRetryAttempts caps retry.max_attempts, so one failing request can't walk every node
+const MaxRetryAttempts = 5
+
+// RetryRule marks matching requests as safe or unsafe to retry once they reached a backend
+type RetryRule struct {
+	Path       string   `mapstructure:"path"`        // exact path, or prefix ending in "*" (empty = any)
+	Methods    []string `mapstructure:"methods"`     // HTTP methods (empty = any)
+	RPCMethods []string `mapstructure:"rpc_methods"` // JSON-RPC methods, rpc only (empty = any)
+	Type       string   `mapstructure:"type"`        // api or rpc (empty = both)
+	Safe       bool     `mapstructure:"safe"`        // whether matching requests may be retried
+}
+
+// Metrics configuration for protecting the Prometheus /metrics endpoint
+// Heights and backend URLs are not for every wandering eye
+type Metrics struct {
+	Listen   string `mapstructure:"listen"`   // separate listener for /metrics; when set, it is no longer served on the status port
+	Token    string `mapstructure:"token"`    // require "Authorization: Bearer <token>" (independent of users)
+	Username string `mapstructure:"username"` // require HTTP basic auth (with password)
+	Password string `mapstructure:"password"`
+
+	Push          MetricsPush `mapstructure:"push"`           // push metrics where Prometheus can't scrape (applied at startup)
+	StatsD        StatsD      `mapstructure:"statsd"`         // also emit metrics to a DogStatsD agent (applied at startup)
+	DisableScrape bool        `mapstructure:"disable_scrape"` // don't serve /metrics, e.g. when metrics only go to StatsD
+}
+
+// RuntimeLimits configuration for soft limits on Sauron's own goroutines and file descriptors
+// Crossing one logs a warning, sets sauron_runtime_soft_limit_exceeded and shows in /health/deep; nothing is refused
+// The tower counts its own torches, lest a forgotten one burn it down
+type RuntimeLimits struct {
+	MaxGoroutines int           `mapstructure:"max_goroutines"` // warn above this many goroutines (0 = no limit)
+	MaxOpenFDs    int           `mapstructure:"max_open_fds"`   // warn above this many open file descriptors (0 = no limit; Linux only)
+	CheckInterval time.Duration `mapstructure:"check_interval"` // how often limits are checked (default 15s, applied at startup)
+}
+
+// StreamLeaks configuration for detecting proxied gRPC streams left open without traffic
+// Leaked bidirectional streams hold buffers and goroutines on both sides until someone notices
+// Roads no one walks anymore are still watched, and closed if need be
+type StreamLeaks struct {
+	Enabled       bool          `mapstructure:"enabled"`        // whether idle streams are reported
+	MaxIdle       time.Duration `mapstructure:"max_idle"`       // how long a stream may go without a message in either direction (default 10m)
+	ForceClose    bool          `mapstructure:"force_close"`    // cancel idle streams instead of only reporting them
+	CheckInterval time.Duration `mapstructure:"check_interval"` // how often open streams are checked (default 30s, applied at startup)
+}
+
+// ExternalPolicy configuration for which endpoints advertised by external rings may be consumed
+// Per-external endpoint types are set with allowed_types on each external
+// Not every banner raised by an ally is one the Eye will march under
+type ExternalPolicy struct {
+	// Advertised endpoints never consumed from any external; "*" matches any characters
+	// Patterns with "://" match the whole URL, others its host (e.g. "*.badcloud.example", "http://*")
+	DenyURLs []string `mapstructure:"deny_urls"`
+}
+
+// StatsD configuration for emitting sauron_* metrics as DogStatsD over UDP, for Datadog-native stacks
+type StatsD struct {
+	Address  string        `mapstructure:"address"`  // agent host:port, e.g. "127.0.0.1:8125" (empty = disabled)
+	Prefix   string        `mapstructure:"prefix"`   // prepended to metric names (default "sauron.")
+	Tags     []string      `mapstructure:"tags"`     // added to every metric, e.g. "env:prod"
+	Interval time.Duration `mapstructure:"interval"` // how often metrics are emitted (default 10s)
+}
+
+// Metrics push modes
+const (
+	MetricsPushGateway     = "pushgateway"  // PUT to a Prometheus Pushgateway, replacing the job's metrics
+	MetricsPushRemoteWrite = "remote_write" // POST to a Prometheus remote-write endpoint
+)
+
+// MetricsPush configuration for pushing sauron_* metrics from deployments that can't be scraped (e.g. behind NAT)
+type MetricsPush struct {
+	Mode     string            `mapstructure:"mode"`     // pushgateway or remote_write (empty = disabled)
+	URL      string            `mapstructure:"url"`      // Pushgateway base URL, or remote-write endpoint
+	Job      string            `mapstructure:"job"`      // job label (default "sauron")
+	Labels   map[string]string `mapstructure:"labels"`   // added to every series, e.g. instance (grouping labels for the Pushgateway)
+	Headers  map[string]string `mapstructure:"headers"`  // sent with every push (e.g. Authorization)
+	Interval time.Duration     `mapstructure:"interval"` // how often metrics are pushed (default 15s)
+	Timeout  time.Duration     `mapstructure:"timeout"`  // time allowed per push (default 10s)
+}
+
+// AuthEnabled reports whether /metrics requires credentials
+func (m Metrics) AuthEnabled() bool {
+	return m.Token != "" || m.Username != ""
+}
+
+// Authorized checks a bearer token or basic auth credentials in constant time
+func (m Metrics) Authorized(bearer, username, password string) bool {
+	if m.Token != "" && bearer != "" && subtle.ConstantTimeCompare([]byte(m.Token), []byte(bearer)) == 1 {
+		return true
+	}
+	if m.Username != "" && username != "" {
+		userOK := subtle.ConstantTimeCompare([]byte(m.Username), []byte(username)) == 1
+		passOK := subtle.ConstantTimeCompare([]byte(m.Password), []byte(password)) == 1
+		return userOK && passOK
+	}
+	return false
+}
+
+// SLO configuration for a latency and/or error rate objective on proxied traffic
+// The promises the tower keeps
+type SLO struct {
+	Network         string        `mapstructure:"network"`
+	Type            string        `mapstructure:"type"`             // api | rpc | grpc
+	Method          string        `mapstructure:"method"`           // only requests with this method label, e.g. "abci_query" (empty = all)
+	Latency         time.Duration `mapstructure:"latency"`          // latency target, e.g. 500ms (0 = no latency objective)
+	LatencyQuantile float64       `mapstructure:"latency_quantile"` // share of requests that must meet the target (default 0.99)
+	ErrorRate       float64       `mapstructure:"error_rate"`       // allowed share of 5xx/failed requests, e.g. 0.01 (0 = no error objective)
+	Window          time.Duration `mapstructure:"window"`           // sliding window burn rates are computed over (default 1h)
+}
+
 // Network configuration for per-network proxy listeners
 // Each gate leads to a different realm
 type Network struct {
 	Name               string `mapstructure:"name"`
 	API                string `mapstructure:"api"`
+	APIWS              string `mapstructure:"api_ws"` // Advertised ws(s):// URL for API WebSocket traffic
 	APIListen          string `mapstructure:"api_listen"`
 	RPC                string `mapstructure:"rpc"`
 	RPCListen          string `mapstructure:"rpc_listen"`
@@ -59,17 +474,128 @@ type Network struct {
 	GRPCInsecure       bool   `mapstructure:"grpc_insecure"`
 	GRPCMaxRecvMsgSize int    `mapstructure:"grpc_max_recv_msg_size"` // Max message size in bytes (0 = unlimited, default 100MB)
 	GRPCMaxSendMsgSize int    `mapstructure:"grpc_max_send_msg_size"` // Max message size in bytes (0 = unlimited, default 100MB)
+
+	Aliases    []string `mapstructure:"aliases"`     // Other public names accepted for this network (e.g. "poktroll", "pocket-mainnet")
+	NodeGroups []string `mapstructure:"node_groups"` // Node groups whose nodes serve this network, added to internals
+
+	SelectionStrategy string         `mapstructure:"selection_strategy"` // How a node is picked among those at max height: weighted (default), round_robin, height_latency or least_connections
+	StickySessions    StickySessions `mapstructure:"sticky_sessions"`    // Keep each client on one node while it stays at max height (overrides selection_strategy)
+
+	// Defaults for node URLs written as bare hostnames (applied on load)
+	DefaultScheme   string `mapstructure:"default_scheme"`    // http or https for api/rpc without a scheme (default https)
+	DefaultAPIPort  int    `mapstructure:"default_api_port"`  // Port added to api URLs without one (0 = scheme default)
+	DefaultRPCPort  int    `mapstructure:"default_rpc_port"`  // Port added to rpc URLs without one (0 = scheme default)
+	DefaultGRPCPort int    `mapstructure:"default_grpc_port"` // Port added to grpc addresses without one (0 = port required)
+
+	GRPCInterceptors []string          `mapstructure:"grpc_interceptors"` // Ordered gRPC interceptor chain: auth, rate_limit, logging, metadata (applied at startup)
+	GRPCMetadata     map[string]string `mapstructure:"grpc_metadata"`     // Metadata set on forwarded gRPC calls by the metadata interceptor ("" removes the key)
+
+	GRPCAllowServices []string `mapstructure:"grpc_allow_services"` // Only these service prefixes are proxied (e.g. "cosmos.bank"; empty = all)
+	GRPCDenyServices  []string `mapstructure:"grpc_deny_services"`  // Service prefixes refused with PermissionDenied (e.g. "cosmos.tx"; wins over allow)
+
+	GRPCServer GRPCServer `mapstructure:"grpc_server"` // Stream and connection limits of the gRPC proxy server (applied at startup)
+
+	HTTPMiddleware []string          `mapstructure:"http_middleware"` // Ordered API/RPC proxy middleware: auth, rate_limit, cors, headers, access_log, ring_signature, rpc_validation (applied at startup)
+	CORSOrigins    []string          `mapstructure:"cors_origins"`    // Origins allowed by the cors middleware ("*" = any)
+	HTTPHeaders    map[string]string `mapstructure:"http_headers"`    // Headers set on forwarded requests by the headers middleware ("" removes the header)
+	RPCValidation  RPCValidation     `mapstructure:"rpc_validation"`  // JSON-RPC checks of the rpc_validation middleware
+
+	Rules []RouteRule `mapstructure:"rules"` // API/RPC request rules, first match wins
+}
+
+// StickySessions hashes each client to one of the nodes at max height, API/RPC and gRPC alike
+// A client is the value of the configured header when sent, otherwise its IP (trusted_proxies honored)
+// Each traveller is led to the same gate, as long as that gate still stands
+type StickySessions struct {
+	Enabled bool   `mapstructure:"enabled"` // whether clients are hashed to a node
+	Header  string `mapstructure:"header"`  // request header (gRPC metadata key) identifying a client, e.g. "X-Client-ID" (empty = client IP only)
+}
+
+// RPCValidation configures the JSON-RPC envelope checks of the rpc_validation middleware
+// Only words of the Black Speech pass the gate
+type RPCValidation struct {
+	ExtraMethods []string `mapstructure:"extra_methods"` // methods accepted on top of the CometBFT RPC methods
+	AnyMethod    bool     `mapstructure:"any_method"`    // accept any method name (the envelope is still checked)
+	MaxBatch     int      `mapstructure:"max_batch"`     // calls allowed in a batch (0 = unlimited)
+}
+
+// GRPCServer limits what clients of the gRPC proxy may hold open; zero values keep gRPC's defaults
+// Guards at the gate, so no single caller can tie up the tower's streams
+type GRPCServer struct {
+	MaxConcurrentStreams  uint32        `mapstructure:"max_concurrent_streams"`   // streams per client connection (0 = unlimited)
+	KeepaliveMinTime      time.Duration `mapstructure:"keepalive_min_time"`       // clients pinging more often are disconnected (0 = 5m)
+	KeepaliveWithoutCalls bool          `mapstructure:"keepalive_without_calls"`  // allow client pings while no stream is open
+	MaxConnectionIdle     time.Duration `mapstructure:"max_connection_idle"`      // close connections idle this long (0 = never)
+	MaxConnectionAge      time.Duration `mapstructure:"max_connection_age"`       // close connections older than this, with GOAWAY (0 = never)
+	MaxConnectionAgeGrace time.Duration `mapstructure:"max_connection_age_grace"` // time open streams get after max_connection_age (0 = unlimited)
+}
+
+// Route rule actions
+const (
+	RuleActionRoute   = "route"   // send to internal nodes carrying tag
+	RuleActionDeny    = "deny"    // refuse with status (default 403)
+	RuleActionCache   = "cache"   // serve successful GET responses from memory for cache_ttl
+	RuleActionRewrite = "rewrite" // replace the matched path prefix with rewrite
+)
+
+// RouteRule matches API/RPC requests by path and method and applies one action
+// The Eye's decrees for each road into the tower
+type RouteRule struct {
+	Path     string        `mapstructure:"path"`      // exact path, or prefix ending in "*" (e.g. "/cosmos/gov/*")
+	Methods  []string      `mapstructure:"methods"`   // HTTP methods (empty = any)
+	Type     string        `mapstructure:"type"`      // api or rpc (empty = both)
+	Action   string        `mapstructure:"action"`    // route, deny, cache or rewrite
+	Tag      string        `mapstructure:"tag"`       // route: node tag to send matching requests to
+	Status   int           `mapstructure:"status"`    // deny: response status (default 403)
+	CacheTTL time.Duration `mapstructure:"cache_ttl"` // cache: how long responses are reused
+	Rewrite  string        `mapstructure:"rewrite"`   // rewrite: replacement for the matched path or prefix
 }
 
 // Node represents an internal node to monitor
 // The kingdoms under the Eye's gaze
 type Node struct {
-	Name         string `mapstructure:"name"`
-	API          string `mapstructure:"api"`
-	RPC          string `mapstructure:"rpc"`
-	GRPC         string `mapstructure:"grpc"`
-	GRPCInsecure bool   `mapstructure:"grpc_insecure"` // Whether this node's gRPC endpoint uses insecure (no TLS)
-	Network      string `mapstructure:"network"`
+	Name         string   `mapstructure:"name"`
+	API          string   `mapstructure:"api"`
+	WS           string   `mapstructure:"ws"` // Optional ws(s):// URL for API WebSocket traffic (e.g. EVM JSON-RPC)
+	RPC          string   `mapstructure:"rpc"`
+	RPCWS        string   `mapstructure:"rpc_ws"` // Optional ws(s):// base URL for RPC WebSocket when it differs from rpc ("/websocket" is appended)
+	GRPC         string   `mapstructure:"grpc"`
+	GRPCInsecure bool     `mapstructure:"grpc_insecure"` // Whether this node's gRPC endpoint uses insecure (no TLS)
+	Network      string   `mapstructure:"network"`
+	Tags         []string `mapstructure:"tags"` // Pools this node belongs to (e.g. "archive"), targeted by route rules
+
+	HealthCheckTimeout time.Duration `mapstructure:"health_check_timeout"` // Overrides timeouts.health_check for this node (0 = use global)
+	ProbeBudget        ProbeBudget   `mapstructure:"probe_budget"`         // Limits health check traffic, e.g. for endpoints billed per request
+	CostWeight         float64       `mapstructure:"cost_weight"`          // Relative cost of traffic, preferred low with cost_aware (0 = 1; externals count as 1)
+	Weight             float64       `mapstructure:"weight"`               // Relative capacity: share of traffic among nodes at max height (0 = 1; externals count as 1)
+	Tier               int           `mapstructure:"tier"`                 // Priority tier: higher tiers only serve when no lower-tier node is healthy and caught up (0 = first)
+
+	Group string `mapstructure:"-"` // Node group this node was expanded from ("" = listed under internals)
+}
+
+// NodeGroup is a named set of internal nodes with shared settings, referenced by networks
+// One provider's fortresses, pledged to many realms at once
+type NodeGroup struct {
+	Name  string `mapstructure:"name"`
+	Nodes []Node `mapstructure:"nodes"` // network is left empty; each referencing network gets its own copy
+
+	// Settings shared by every node of the group; a node's own value wins
+	GRPCInsecure       bool          `mapstructure:"grpc_insecure"`        // gRPC endpoints use insecure (no TLS)
+	Tags               []string      `mapstructure:"tags"`                 // added to each node's own tags
+	HealthCheckTimeout time.Duration `mapstructure:"health_check_timeout"` // used by nodes without their own
+	ProbeBudget        ProbeBudget   `mapstructure:"probe_budget"`         // used for the values a node leaves unset
+	CostWeight         float64       `mapstructure:"cost_weight"`          // used by nodes without their own
+	Weight             float64       `mapstructure:"weight"`               // used by nodes without their own
+	Tier               int           `mapstructure:"tier"`                 // used by nodes without their own
+}
+
+// ProbeBudget limits the health check traffic sent to a node, for commercial endpoints billed per request
+// Between probes the node keeps its last known height and WebSocket availability
+// The Eye glances at costly allies instead of staring
+type ProbeBudget struct {
+	CheckEvery        int  `mapstructure:"check_every"`         // height checks every N check rounds; adaptive extra checks are skipped (default 1 = every round)
+	WebSocketEvery    int  `mapstructure:"websocket_every"`     // WebSocket probes every N height checks of the endpoint type (default 1)
+	DisableGRPCWarmup bool `mapstructure:"disable_grpc_warmup"` // skip the warmup call made when a gRPC connection is opened
 }
 
 // External represents other Sauron deployments
@@ -78,6 +604,26 @@ type External struct {
 	Name  string   `mapstructure:"name"`
 	Token string   `mapstructure:"token"`
 	Rings []string `mapstructure:"rings"`
+
+	// Rings whose height differs from internals (or other rings) by more than this factor are
+	// treated as serving another network and their endpoints dropped (default 2)
+	MismatchFactor float64 `mapstructure:"mismatch_factor"`
+
+	// Secret shared with this external (its ring_signing.trusted entry for us); when set, requests
+	// forwarded to its advertised endpoints are signed with ring_signing.name
+	SigningSecret string `mapstructure:"signing_secret"`
+
+	// Optional validity window of the token; outside it the external isn't queried (RFC 3339, zero = unbounded)
+	NotBefore time.Time `mapstructure:"not_before"`
+	ExpiresAt time.Time `mapstructure:"expires_at"`
+
+	// Endpoint types consumed from this external's rings (api, rpc, grpc); others it advertises are ignored (empty = all)
+	AllowedTypes []string `mapstructure:"allowed_types"`
+}
+
+// TokenActive reports whether the external's token may be used at now
+func (e *External) TokenActive(now time.Time) bool {
+	return credentialActive(e.NotBefore, e.ExpiresAt, now)
 }
 
 // User represents an authenticated user for the status API
@@ -88,6 +634,29 @@ type User struct {
 	API   bool   `mapstructure:"api"`
 	RPC   bool   `mapstructure:"rpc"`
 	GRPC  bool   `mapstructure:"grpc"`
+	Admin bool   `mapstructure:"admin"` // Can access /admin/* endpoints
+
+	// Optional allowlists narrowing the granted types (empty = everything the type allows)
+	Methods      []string `mapstructure:"methods"`       // JSON-RPC methods on rpc (e.g. "broadcast_tx_sync"); batches need every method listed
+	Paths        []string `mapstructure:"paths"`         // api/rpc paths, exact or prefix ending in "*" (e.g. "/cosmos/bank/*")
+	GRPCServices []string `mapstructure:"grpc_services"` // gRPC service prefixes (e.g. "cosmos.bank")
+
+	// Optional validity window of the token, for planned rotations (RFC 3339, zero = unbounded)
+	NotBefore time.Time `mapstructure:"not_before"` // refused before this time
+	ExpiresAt time.Time `mapstructure:"expires_at"` // refused from this time on
+}
+
+// Active reports whether the user's token is accepted at now
+func (u *User) Active(now time.Time) bool {
+	return credentialActive(u.NotBefore, u.ExpiresAt, now)
+}
+
+// credentialActive reports whether now falls in [notBefore, expiresAt), zero bounds being open
+func credentialActive(notBefore, expiresAt, now time.Time) bool {
+	if !notBefore.IsZero() && now.Before(notBefore) {
+		return false
+	}
+	return expiresAt.IsZero() || now.Before(expiresAt)
 }
 
 // GetEnabledTypes returns which endpoint types are globally enabled
@@ -105,6 +674,16 @@ func (c *Config) GetEnabledTypes() []string {
 	return types
 }
 
+// ResolveNetwork returns the canonical name of a network given its name or one of its aliases
+func (c *Config) ResolveNetwork(name string) (string, bool) {
+	for _, network := range c.Networks {
+		if network.Name == name || slices.Contains(network.Aliases, name) {
+			return network.Name, true
+		}
+	}
+	return "", false
+}
+
 // GetUserPermissions returns the enabled types for a specific user
 // If not overridden, returns global enabled types
 func (c *Config) GetUserPermissions(token string) []string {
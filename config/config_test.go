@@ -0,0 +1,381 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestResolveNetworkAliases tests that aliases resolve to the canonical network and may not collide
+func TestResolveNetworkAliases(t *testing.T) {
+	loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg := loader.Get()
+	cfg.Networks[0].Aliases = []string{"poktroll", "pocket-mainnet"}
+
+	for _, name := range []string{"pocket", "poktroll", "pocket-mainnet"} {
+		if canonical, ok := cfg.ResolveNetwork(name); !ok || canonical != "pocket" {
+			t.Errorf("Expected %s to resolve to pocket, got %q (%v)", name, canonical, ok)
+		}
+	}
+	if _, ok := cfg.ResolveNetwork("osmosis"); ok {
+		t.Error("Expected unknown network not to resolve")
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Expected aliases to validate, got: %v", err)
+	}
+
+	cfg.Networks = append(cfg.Networks, Network{Name: "poktroll", APIListen: ":9080", RPCListen: ":9081"})
+	err = Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "duplicate network name 'poktroll'") {
+		t.Errorf("Expected a network named like an alias to be rejected, got: %v", err)
+	}
+}
+
+// TestNodeGroupsExpand tests that group nodes are copied into every referencing network with group settings
+func TestNodeGroupsExpand(t *testing.T) {
+	loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg := loader.Get()
+	cfg.NodeGroups = []NodeGroup{{
+		Name:               "provider-a",
+		Tags:               []string{"provider-a"},
+		HealthCheckTimeout: 10 * time.Second,
+		ProbeBudget:        ProbeBudget{CheckEvery: 3, DisableGRPCWarmup: true},
+		Nodes: []Node{
+			{Name: "a-1", API: "https://a-1.example.com", Tags: []string{"archive"}},
+			{Name: "a-2", API: "https://a-2.example.com", HealthCheckTimeout: 3 * time.Second, ProbeBudget: ProbeBudget{CheckEvery: 2, WebSocketEvery: 10}},
+		},
+	}}
+	cfg.Networks[0].NodeGroups = []string{"provider-a"}
+	cfg.Networks = append(cfg.Networks, Network{Name: "osmosis", APIListen: ":9080", RPCListen: ":9081", NodeGroups: []string{"provider-a"}})
+
+	if err := loader.Update(cfg); err != nil {
+		t.Fatalf("Expected node groups to validate, got: %v", err)
+	}
+	// Expanding an already expanded config must not duplicate nodes
+	if err := loader.Update(loader.Get()); err != nil {
+		t.Fatalf("Expected re-applied config to validate, got: %v", err)
+	}
+
+	got := loader.Get()
+	if len(got.Internals) != 5 {
+		t.Fatalf("Expected node-1 plus 2 group nodes in 2 networks, got %d internals", len(got.Internals))
+	}
+	for _, node := range got.Internals[1:] {
+		if node.Group != "provider-a" || (node.Network != "pocket" && node.Network != "osmosis") {
+			t.Errorf("Unexpected expanded node: %+v", node)
+		}
+		if node.Name == "a-1" && (!reflect.DeepEqual(node.Tags, []string{"provider-a", "archive"}) || node.HealthCheckTimeout != 10*time.Second) {
+			t.Errorf("Expected a-1 with group tags and timeout, got %+v", node)
+		}
+		if node.Name == "a-2" && node.HealthCheckTimeout != 3*time.Second {
+			t.Errorf("Expected a-2 to keep its own timeout, got %s", node.HealthCheckTimeout)
+		}
+		if node.Name == "a-1" && node.ProbeBudget != (ProbeBudget{CheckEvery: 3, DisableGRPCWarmup: true}) {
+			t.Errorf("Expected a-1 with the group probe budget, got %+v", node.ProbeBudget)
+		}
+		if node.Name == "a-2" && node.ProbeBudget != (ProbeBudget{CheckEvery: 2, WebSocketEvery: 10, DisableGRPCWarmup: true}) {
+			t.Errorf("Expected a-2 to keep its own probe budget values, got %+v", node.ProbeBudget)
+		}
+	}
+
+	cfg.Networks[0].NodeGroups = []string{"provider-b"}
+	if err := loader.Update(cfg); err == nil || !strings.Contains(err.Error(), "unknown node group 'provider-b'") {
+		t.Errorf("Expected unknown node group to be rejected, got: %v", err)
+	}
+}
+
+// TestNetworkDefaultsCompleteBareHostnames tests that network defaults fill in scheme and ports
+func TestNetworkDefaultsCompleteBareHostnames(t *testing.T) {
+	loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg := loader.Get()
+	cfg.Networks[0].DefaultScheme = "http"
+	cfg.Networks[0].DefaultAPIPort = 1317
+	cfg.Networks[0].DefaultRPCPort = 26657
+	cfg.Networks[0].DefaultGRPCPort = 9090
+	cfg.Internals = append(cfg.Internals,
+		Node{Name: "node-2", Network: "pocket", API: "node-2.internal", RPC: "https://node-2.internal", GRPC: "node-2.internal"},
+		Node{Name: "node-3", Network: "pocket", API: "node-3.internal:8080/base", GRPC: "node-3.internal:9191"},
+	)
+
+	if err := loader.Update(cfg); err != nil {
+		t.Fatalf("Expected network defaults to validate, got: %v", err)
+	}
+
+	got := loader.Get().Internals
+	want := []Node{
+		{API: "http://node-1.internal:1317", RPC: "http://node-1.internal:26657"},
+		{API: "http://node-2.internal:1317", RPC: "https://node-2.internal:26657", GRPC: "node-2.internal:9090"},
+		{API: "http://node-3.internal:8080/base", GRPC: "node-3.internal:9191"},
+	}
+	for i, node := range got {
+		if node.API != want[i].API || node.RPC != want[i].RPC || node.GRPC != want[i].GRPC {
+			t.Errorf("Node %s: expected api=%q rpc=%q grpc=%q, got api=%q rpc=%q grpc=%q",
+				node.Name, want[i].API, want[i].RPC, want[i].GRPC, node.API, node.RPC, node.GRPC)
+		}
+	}
+}
+
+// TestValidateCrossReferences tests that references between sections are checked
+func TestValidateCrossReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(cfg *Config)
+		wantErr string
+	}{
+		{"node on undeclared network", func(cfg *Config) { cfg.Internals[0].Network = "osmosis" }, "internal node 0 (node-1): network 'osmosis' is not declared"},
+		{"node on alias", func(cfg *Config) {
+			cfg.Networks[0].Aliases = []string{"poktroll"}
+			cfg.Internals[0].Network = "poktroll"
+		}, "internal node 0 (node-1): network 'poktroll' is an alias, use 'pocket'"},
+		{"user granted disabled type", func(cfg *Config) { cfg.Users[0].GRPC = true }, "user 0 (relayer): grpc is granted but globally disabled"},
+		{"duplicate external", func(cfg *Config) {
+			ring := External{Name: "partner", Rings: []string{"https://partner.example.com"}}
+			cfg.Externals = []External{ring, ring}
+		}, "external 1 (partner): duplicate external name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			cfg := loader.Get()
+			tt.mutate(cfg)
+
+			if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateRetry tests that retry attempts are bounded and retry rules need something to match
+func TestValidateRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   Retry
+		wantErr string
+	}{
+		{"valid", Retry{Enabled: true, Rules: []RetryRule{{Path: "/cosmos/gov/*", Methods: []string{"POST"}, Safe: true}}}, ""},
+		{"too many attempts", Retry{Enabled: true, MaxAttempts: 6}, "retry.max_attempts must be between 0 and 5"},
+		{"empty rule", Retry{Enabled: true, Rules: []RetryRule{{Safe: true}}}, "retry rule 0: needs a path, methods or rpc_methods to match"},
+		{"rpc methods on api", Retry{Enabled: true, Rules: []RetryRule{{Type: "api", RPCMethods: []string{"status"}}}}, "retry rule 0: rpc_methods only apply to rpc requests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			cfg := loader.Get()
+			cfg.Retry = tt.retry
+
+			err = Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid retry config, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateLogging tests that only known log levels and encodings are accepted
+func TestValidateLogging(t *testing.T) {
+	tests := []struct {
+		name    string
+		logging Logging
+		wantErr string
+	}{
+		{"defaults", Logging{}, ""},
+		{"console debug", Logging{Level: "debug", Encoding: "console", Outputs: []string{"stdout"}, Sampling: LogSampling{Disabled: true}}, ""},
+		{"unknown level", Logging{Level: "verbose"}, "invalid logging level 'verbose'"},
+		{"unknown encoding", Logging{Encoding: "logfmt"}, "invalid logging encoding 'logfmt'"},
+		{"empty output", Logging{Outputs: []string{""}}, "logging outputs cannot contain an empty path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			cfg := loader.Get()
+			cfg.Logging = tt.logging
+
+			err = Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid logging config, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateListenAddress tests that listen addresses bind any IP (IPv6 included) or hostname, that errors
+// name what is wrong, and that binds of one port conflict
+func TestValidateListenAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr string
+	}{
+		{":8080", ""},
+		{"0.0.0.0:8080", ""},
+		{"10.0.0.5:8080", ""},
+		{"[::]:8080", ""},
+		{"[::1]:8080", ""},
+		{"[fe80::1%eth0]:8080", ""},
+		{"127.0.0.1:0", ""},
+		{"localhost:8080", ""},
+		{"sauron.internal.example.com:8080", ""},
+		{"10.0.0.5", "missing port"},
+		{"10.0.0.5:", "missing port after ':'"},
+		{"::1:8080", "must be in brackets"},
+		{"10.0.0.5:http", "is not a number"},
+		{"10.0.0.5:70000", "out of range"},
+		{"10.0.0:8080", "is not an IPv4 address"},
+		{"[sauron]:8080", "is not an IPv6 address"},
+		{"-sauron:8080", "neither an IP address nor a valid hostname"},
+		{"sau_ron:8080", "neither an IP address nor a valid hostname"},
+	}
+	for _, tt := range tests {
+		err := validateListenAddress(tt.addr, "api_listen")
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("validateListenAddress(%q) = %v, expected valid", tt.addr, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("validateListenAddress(%q) = %v, expected error containing %q", tt.addr, err, tt.wantErr)
+		}
+	}
+
+	conflicts := []struct {
+		a, b     string
+		conflict bool
+	}{
+		{":8080", "[::]:8080", true},
+		{"0.0.0.0:8080", "10.0.0.5:8080", true},
+		{"10.0.0.5:8080", "10.0.0.5:8080", true},
+		{"[::1]:8080", "[0:0::1]:8080", true},
+		{"localhost:8080", "LOCALHOST:8080", true},
+		{"localhost:8080", ":8080", true},
+		{"localhost:8080", "10.0.0.5:8080", false},
+		{"10.0.0.5:8080", "10.0.0.6:8080", false},
+		{"10.0.0.5:8080", "10.0.0.5:8081", false},
+		{":0", ":0", false},
+	}
+	for _, tt := range conflicts {
+		if got := listenConflict(tt.a, tt.b); got != tt.conflict {
+			t.Errorf("listenConflict(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.conflict)
+		}
+	}
+}
+
+// TestNormalizeListenAddresses tests that bare ports bind all interfaces and other addresses are kept
+func TestNormalizeListenAddresses(t *testing.T) {
+	cfg := &Config{
+		Listen:   "3000",
+		Metrics:  Metrics{Listen: "127.0.0.1:9100"},
+		Networks: []Network{{APIListen: "8080", RPCListen: ":8081", GRPCListen: "localhost:9090"}},
+	}
+	cfg.normalizeListenAddresses()
+
+	if cfg.Listen != ":3000" || cfg.Networks[0].APIListen != ":8080" {
+		t.Errorf("Expected bare ports to become ':port', got listen %q and api_listen %q", cfg.Listen, cfg.Networks[0].APIListen)
+	}
+	if cfg.Metrics.Listen != "127.0.0.1:9100" || cfg.Networks[0].RPCListen != ":8081" || cfg.Networks[0].GRPCListen != "localhost:9090" {
+		t.Errorf("Expected host:port addresses unchanged, got %q, %q, %q", cfg.Metrics.Listen, cfg.Networks[0].RPCListen, cfg.Networks[0].GRPCListen)
+	}
+}
+
+// TestExternalPolicy tests allowed_types per external and deny_urls patterns on URLs, hosts and gRPC host:port
+func TestExternalPolicy(t *testing.T) {
+	ext := External{Name: "pnf", AllowedTypes: []string{"api", "rpc"}}
+	if !ext.AllowsType("rpc") || ext.AllowsType("grpc") {
+		t.Errorf("Expected api and rpc allowed but not grpc")
+	}
+	if all := (External{Name: "pnf"}); !all.AllowsType("grpc") {
+		t.Errorf("Expected every type allowed without allowed_types")
+	}
+
+	policy := ExternalPolicy{DenyURLs: []string{"*.badcloud.example", "http://*", "10.*"}}
+	tests := []struct {
+		endpoint string
+		denied   bool
+	}{
+		{"https://api.badcloud.example", true},
+		{"grpc.BADCLOUD.example:443", true},
+		{"http://rpc.good.example:26657", true},
+		{"https://rpc.good.example", false},
+		{"10.0.0.5:9090", true},
+		{"https://badcloud.example.org", false},
+	}
+	for _, tt := range tests {
+		if _, denied := policy.DeniedURL(tt.endpoint); denied != tt.denied {
+			t.Errorf("DeniedURL(%q) = %v, expected %v", tt.endpoint, denied, tt.denied)
+		}
+	}
+
+	if err := validateExternal(&External{Name: "pnf", Rings: []string{"https://ring.example.com"}, AllowedTypes: []string{"ws"}}, 0); err == nil ||
+		!strings.Contains(err.Error(), "invalid allowed_types entry") {
+		t.Errorf("Expected an unknown type to be refused, got %v", err)
+	}
+}
+
+// TestValidateRuntimeLimits tests that soft limits can't be negative and checks can't run too often
+func TestValidateRuntimeLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  RuntimeLimits
+		wantErr string
+	}{
+		{"unset", RuntimeLimits{}, ""},
+		{"valid", RuntimeLimits{MaxGoroutines: 20000, MaxOpenFDs: 50000, CheckInterval: 30 * time.Second}, ""},
+		{"negative goroutines", RuntimeLimits{MaxGoroutines: -1}, "runtime_limits max_goroutines cannot be negative"},
+		{"interval too short", RuntimeLimits{CheckInterval: 100 * time.Millisecond}, "runtime_limits check_interval too short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			cfg := loader.Get()
+			cfg.RuntimeLimits = tt.limits
+
+			err = Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid runtime_limits, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// redacted replaces secret values in a diff
+const redacted = "<redacted>"
+
+// secretKeys are settings whose values must never be logged
+var secretKeys = map[string]bool{
+	"token":    true,
+	"password": true,
+	"uri":      true, // Redis URIs may carry credentials
+	"secret":   true,
+}
+
+// Diff describes what changed between two configurations
+// Only names and redacted settings are kept, so it is safe to log
+type Diff struct {
+	NodesAdded       []string
+	NodesRemoved     []string
+	NodesChanged     []string
+	ExternalsAdded   []string
+	ExternalsRemoved []string
+	ExternalsChanged []string
+	NetworksAdded    []string
+	NetworksRemoved  []string
+	NetworksChanged  []string
+	UsersAdded       []string
+	UsersRemoved     []string
+	UsersChanged     []string
+	Settings         []string // "timeouts.proxy: 30s -> 1m0s"
+}
+
+// Compare computes the differences between the old and the new configuration
+func Compare(oldCfg, newCfg *Config) *Diff {
+	d := &Diff{}
+
+	d.NodesAdded, d.NodesRemoved, d.NodesChanged = diffNamed(oldCfg.Internals, newCfg.Internals, nodeKey)
+	d.ExternalsAdded, d.ExternalsRemoved, d.ExternalsChanged = diffNamed(oldCfg.Externals, newCfg.Externals, func(e External) string { return e.Name })
+	d.NetworksAdded, d.NetworksRemoved, d.NetworksChanged = diffNamed(oldCfg.Networks, newCfg.Networks, func(n Network) string { return n.Name })
+	d.UsersAdded, d.UsersRemoved, d.UsersChanged = diffNamed(oldCfg.Users, newCfg.Users, func(u User) string { return u.Name })
+
+	// Everything else is compared setting by setting
+	oldV, newV := reflect.ValueOf(*oldCfg), reflect.ValueOf(*newCfg)
+	t := oldV.Type()
+	for i := range t.NumField() {
+		switch t.Field(i).Name {
+		case "Internals", "Externals", "Networks", "Users", "NodeGroups": // node groups show up as expanded nodes
+			continue
+		}
+		d.Settings = diffValue(d.Settings, t.Field(i).Tag.Get("mapstructure"), oldV.Field(i), newV.Field(i))
+	}
+
+	return d
+}
+
+// nodeKey names a node in the diff; group nodes repeat in every network referencing the group
+func nodeKey(n Node) string {
+	if n.Group != "" {
+		return n.Network + "/" + n.Name
+	}
+	return n.Name
+}
+
+// Empty reports whether the configurations are equivalent
+func (d *Diff) Empty() bool {
+	return len(d.NodesAdded)+len(d.NodesRemoved)+len(d.NodesChanged)+
+		len(d.ExternalsAdded)+len(d.ExternalsRemoved)+len(d.ExternalsChanged)+
+		len(d.NetworksAdded)+len(d.NetworksRemoved)+len(d.NetworksChanged)+
+		len(d.UsersAdded)+len(d.UsersRemoved)+len(d.UsersChanged)+
+		len(d.Settings) == 0
+}
+
+// Fields returns the non-empty parts of the diff as log fields
+func (d *Diff) Fields() []zap.Field {
+	var fields []zap.Field
+	add := func(key string, values []string) {
+		if len(values) > 0 {
+			fields = append(fields, zap.Strings(key, values))
+		}
+	}
+
+	add("nodes_added", d.NodesAdded)
+	add("nodes_removed", d.NodesRemoved)
+	add("nodes_changed", d.NodesChanged)
+	add("externals_added", d.ExternalsAdded)
+	add("externals_removed", d.ExternalsRemoved)
+	add("externals_changed", d.ExternalsChanged)
+	add("networks_added", d.NetworksAdded)
+	add("networks_removed", d.NetworksRemoved)
+	add("networks_changed", d.NetworksChanged)
+	add("users_added", d.UsersAdded)
+	add("users_removed", d.UsersRemoved)
+	add("users_changed", d.UsersChanged)
+	add("settings_changed", d.Settings)
+
+	return fields
+}
+
+// diffNamed compares two lists of named entries by name
+func diffNamed[T any](oldList, newList []T, name func(T) string) (added, removed, changed []string) {
+	oldByName := make(map[string]T, len(oldList))
+	for _, item := range oldList {
+		oldByName[name(item)] = item
+	}
+
+	seen := make(map[string]bool, len(newList))
+	for _, item := range newList {
+		n := name(item)
+		seen[n] = true
+		prev, ok := oldByName[n]
+		if !ok {
+			added = append(added, n)
+		} else if !reflect.DeepEqual(prev, item) {
+			changed = append(changed, n)
+		}
+	}
+
+	for _, item := range oldList {
+		if n := name(item); !seen[n] {
+			removed = append(removed, n)
+		}
+	}
+
+	slices.Sort(added)
+	slices.Sort(removed)
+	slices.Sort(changed)
+	return added, removed, changed
+}
+
+// diffValue appends "path: old -> new" for every changed setting under a value
+func diffValue(out []string, path string, oldV, newV reflect.Value) []string {
+	if oldV.Kind() == reflect.Struct {
+		t := oldV.Type()
+		for i := range t.NumField() {
+			out = diffValue(out, path+"."+t.Field(i).Tag.Get("mapstructure"), oldV.Field(i), newV.Field(i))
+		}
+		return out
+	}
+
+	if reflect.DeepEqual(oldV.Interface(), newV.Interface()) {
+		return out
+	}
+
+	switch {
+	case secretKeys[path[strings.LastIndex(path, ".")+1:]]:
+		return append(out, path+": "+redacted)
+	case oldV.Kind() == reflect.Slice && oldV.Type().Elem().Kind() == reflect.Struct:
+		// Lists of objects (e.g. slos) are too noisy to print in full
+		return append(out, fmt.Sprintf("%s: %d -> %d entries", path, oldV.Len(), newV.Len()))
+	default:
+		return append(out, fmt.Sprintf("%s: %v -> %v", path, oldV.Interface(), newV.Interface()))
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// AllowsType reports whether endpoints of a type advertised by the external's rings may be consumed
+func (e *External) AllowsType(endpointType string) bool {
+	return len(e.AllowedTypes) == 0 || slices.Contains(e.AllowedTypes, endpointType)
+}
+
+// DeniedURL returns the deny_urls pattern an advertised endpoint matches, if any
+// gRPC endpoints are advertised as host:port; their host is matched like a URL's
+func (p *ExternalPolicy) DeniedURL(endpoint string) (string, bool) {
+	host := endpointHost(endpoint)
+	for _, pattern := range p.DenyURLs {
+		target := host
+		if strings.Contains(pattern, "://") {
+			target = endpoint
+		}
+		if wildcardMatch(strings.ToLower(pattern), strings.ToLower(target)) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// endpointHost returns the hostname of an advertised URL or host:port
+func endpointHost(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		if u, err := url.Parse(endpoint); err == nil {
+			return u.Hostname()
+		}
+		return endpoint
+	}
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return endpoint
+}
+
+// wildcardMatch reports whether s matches pattern, where "*" matches any run of characters (including none)
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return s == pattern
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
@@ -0,0 +1,60 @@
+package config
+
+import "slices"
+
+// expandNodeGroups adds a copy of every group node to internals for each network referencing the group
+// Idempotent: nodes expanded earlier are replaced, so an already expanded config can be expanded again
+// Unknown group references are left to Validate
+func (c *Config) expandNodeGroups() {
+	internals := make([]Node, 0, len(c.Internals))
+	for _, node := range c.Internals {
+		if node.Group == "" {
+			internals = append(internals, node)
+		}
+	}
+
+	for _, network := range c.Networks {
+		for _, name := range network.NodeGroups {
+			i := slices.IndexFunc(c.NodeGroups, func(g NodeGroup) bool { return g.Name == name })
+			if i < 0 {
+				continue
+			}
+			group := c.NodeGroups[i]
+
+			for _, node := range group.Nodes {
+				node.Network = network.Name
+				node.Group = group.Name
+				node.GRPCInsecure = node.GRPCInsecure || group.GRPCInsecure
+				if node.HealthCheckTimeout == 0 {
+					node.HealthCheckTimeout = group.HealthCheckTimeout
+				}
+				if node.ProbeBudget.CheckEvery == 0 {
+					node.ProbeBudget.CheckEvery = group.ProbeBudget.CheckEvery
+				}
+				if node.ProbeBudget.WebSocketEvery == 0 {
+					node.ProbeBudget.WebSocketEvery = group.ProbeBudget.WebSocketEvery
+				}
+				node.ProbeBudget.DisableGRPCWarmup = node.ProbeBudget.DisableGRPCWarmup || group.ProbeBudget.DisableGRPCWarmup
+				if node.CostWeight == 0 {
+					node.CostWeight = group.CostWeight
+				}
+				if node.Weight == 0 {
+					node.Weight = group.Weight
+				}
+				if node.Tier == 0 {
+					node.Tier = group.Tier
+				}
+				tags := append([]string(nil), group.Tags...)
+				for _, tag := range node.Tags {
+					if !slices.Contains(tags, tag) {
+						tags = append(tags, tag)
+					}
+				}
+				node.Tags = tags
+				internals = append(internals, node)
+			}
+		}
+	}
+
+	c.Internals = internals
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// zeroMeansDefault lists settings where an explicit 0 falls back to a default rather than meaning zero
+var zeroMeansDefault = []struct {
+	key, fallback string
+}{
+	{"external_failover_threshold", "2 blocks"},
+	{"stale_threshold", "5 blocks"},
+}
+
+// Lint reports configuration that is valid but likely a mistake
+// Warnings never block loading; `sauron validate --strict` turns them into failures
+func Lint(cfg *Config) []string {
+	var warnings []string
+
+	// Plain HTTP sends traffic (and for externals, the ring token) in clear text
+	for i, node := range cfg.Internals {
+		for _, endpoint := range []struct{ field, url string }{
+			{"api", node.API}, {"ws", node.WS}, {"rpc", node.RPC}, {"rpc_ws", node.RPCWS},
+		} {
+			if strings.HasPrefix(endpoint.url, "http://") || strings.HasPrefix(endpoint.url, "ws://") {
+				warnings = append(warnings, fmt.Sprintf("internal node %d (%s): %s uses plain HTTP (%s)", i, node.Name, endpoint.field, endpoint.url))
+			}
+		}
+	}
+	for i, ext := range cfg.Externals {
+		for j, ring := range ext.Rings {
+			if strings.HasPrefix(ring, "http://") {
+				warnings = append(warnings, fmt.Sprintf("external %d (%s): ring %d uses plain HTTP, the token is sent in clear text (%s)", i, ext.Name, j, ring))
+			}
+		}
+	}
+
+	// The same endpoint listed twice in a network gets double its share of traffic
+	type seenEndpoint struct {
+		network, field, url string
+	}
+	seen := make(map[seenEndpoint]int)
+	for i, node := range cfg.Internals {
+		for _, endpoint := range []struct{ field, url string }{
+			{"api", node.API}, {"rpc", node.RPC}, {"grpc", node.GRPC},
+		} {
+			if endpoint.url == "" {
+				continue
+			}
+			key := seenEndpoint{node.Network, endpoint.field, strings.TrimSuffix(strings.ToLower(endpoint.url), "/")}
+			if first, ok := seen[key]; ok {
+				warnings = append(warnings, fmt.Sprintf("internal node %d (%s): %s duplicates internal node %d (%s) in network %s (%s)",
+					i, node.Name, endpoint.field, first, cfg.Internals[first].Name, node.Network, endpoint.url))
+				continue
+			}
+			seen[key] = i
+		}
+	}
+
+	// Without auth every request is accepted, users are never consulted
+	if !cfg.Auth {
+		for i, user := range cfg.Users {
+			warnings = append(warnings, fmt.Sprintf("user %d (%s): unused, auth is disabled", i, user.Name))
+		}
+	}
+
+	// A group no network references expands to nothing
+	for i, group := range cfg.NodeGroups {
+		referenced := slices.ContainsFunc(cfg.Networks, func(n Network) bool { return slices.Contains(n.NodeGroups, group.Name) })
+		if !referenced {
+			warnings = append(warnings, fmt.Sprintf("node group %d (%s): unused, no network references it", i, group.Name))
+		}
+	}
+
+	return warnings
+}
+
+// lintSettings reports settings explicitly set to 0 where 0 means "use the default"
+// Needs the raw settings: once decoded, an explicit 0 can't be told apart from an unset value
+func lintSettings(v *viper.Viper) []string {
+	var warnings []string
+	for _, setting := range zeroMeansDefault {
+		if v.IsSet(setting.key) && v.GetInt64(setting.key) == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: 0 means the default (%s), not zero", setting.key, setting.fallback))
+		}
+	}
+	return warnings
+}
+
+// LoadFile reads, migrates and validates a configuration file without watching it
+// Returns the lint warnings alongside the configuration; schema migrations are reported as warnings too
+func LoadFile(configPath string) (*Config, []string, error) {
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType(configType(configPath))
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg, migrated, err := decode(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := Validate(cfg); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	warnings := make([]string, 0, len(migrated))
+	for _, w := range migrated {
+		warnings = append(warnings, "schema migrated: "+w)
+	}
+	warnings = append(warnings, lintSettings(v)...)
+	warnings = append(warnings, Lint(cfg)...)
+	return cfg, warnings, nil
+}
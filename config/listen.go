@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// normalizeListenAddresses completes listen addresses written as a bare port ("8080") to bind all interfaces (":8080")
+// Idempotent: addresses with a host part are left alone
+func (c *Config) normalizeListenAddresses() {
+	c.Listen = portOnlyListen(c.Listen)
+	c.Metrics.Listen = portOnlyListen(c.Metrics.Listen)
+	for i := range c.Networks {
+		network := &c.Networks[i]
+		network.APIListen = portOnlyListen(network.APIListen)
+		network.RPCListen = portOnlyListen(network.RPCListen)
+		network.GRPCListen = portOnlyListen(network.GRPCListen)
+	}
+}
+
+// portOnlyListen turns a bare port into ":port"; anything else is returned as written for validation
+func portOnlyListen(addr string) string {
+	if addr == "" || strings.TrimLeft(addr, "0123456789") != "" {
+		return addr
+	}
+	return ":" + addr
+}
+
+// validateListenAddress checks a listen address is host:port (or a bare port, normalized on load)
+// The host is empty (all interfaces), an IP or a hostname resolved when binding
+// IPv6 literals go in brackets, with the interface as zone for link-local ones: "[::]:8080", "[fe80::1%eth0]:8080"
+func validateListenAddress(addr, fieldName string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		var addrErr *net.AddrError
+		switch {
+		case strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "["):
+			return fmt.Errorf("invalid %s '%s': IPv6 addresses must be in brackets, e.g. '[::1]:8080'", fieldName, addr)
+		case errors.As(err, &addrErr) && addrErr.Err == "missing port in address":
+			return fmt.Errorf("invalid %s '%s': missing port, expected host:port or :port", fieldName, addr)
+		}
+		return fmt.Errorf("invalid %s '%s': %w", fieldName, addr, err)
+	}
+	if port == "" {
+		return fmt.Errorf("invalid %s '%s': missing port after ':'", fieldName, addr)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid %s '%s': port '%s' is not a number", fieldName, addr, port)
+	}
+	if n < 0 || n > 65535 {
+		return fmt.Errorf("invalid %s '%s': port %d out of range 0-65535", fieldName, addr, n)
+	}
+	if host == "" {
+		return nil
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+	if strings.HasPrefix(addr, "[") {
+		return fmt.Errorf("invalid %s '%s': '%s' is not an IPv6 address", fieldName, addr, host)
+	}
+	if looksLikeIPv4(host) {
+		return fmt.Errorf("invalid %s '%s': '%s' is not an IPv4 address", fieldName, addr, host)
+	}
+	if !validHostname(host) {
+		return fmt.Errorf("invalid %s '%s': host '%s' is neither an IP address nor a valid hostname", fieldName, addr, host)
+	}
+	return nil
+}
+
+// looksLikeIPv4 reports whether a host is made of digits and dots only, so it was meant as an IPv4 address
+func looksLikeIPv4(host string) bool {
+	return strings.Trim(host, "0123456789.") == "" && strings.Contains(host, ".")
+}
+
+// validHostname reports whether host is a DNS name: dot-separated labels of letters, digits and hyphens,
+// no label longer than 63 or starting/ending with a hyphen, 253 characters at most
+func validHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// listenConflict reports whether two valid listen addresses bind the same port:
+// the same host, or either one on all interfaces ("", 0.0.0.0 or ::)
+// Hostnames only conflict with the same hostname; one resolving to an address in use fails when binding
+func listenConflict(a, b string) bool {
+	hostA, portA, _ := net.SplitHostPort(a)
+	hostB, portB, _ := net.SplitHostPort(b)
+	numA, _ := strconv.Atoi(portA)
+	numB, _ := strconv.Atoi(portB)
+	if numA == 0 || numA != numB { // port 0 picks a free port
+		return false
+	}
+	if wildcardHost(hostA) || wildcardHost(hostB) {
+		return true
+	}
+	addrA, errA := netip.ParseAddr(hostA)
+	addrB, errB := netip.ParseAddr(hostB)
+	if errA != nil || errB != nil {
+		return errA != nil && errB != nil && strings.EqualFold(strings.TrimSuffix(hostA, "."), strings.TrimSuffix(hostB, "."))
+	}
+	return addrA == addrB
+}
+
+// conflictingListen returns the network already listening where addr would bind, if any
+func conflictingListen(listenAddrs map[string]string, addr string) (string, bool) {
+	for existing, network := range listenAddrs {
+		if listenConflict(existing, addr) {
+			return network, true
+		}
+	}
+	return "", false
+}
+
+// wildcardHost reports whether a listen host binds all interfaces
+func wildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsUnspecified()
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
@@ -16,6 +19,24 @@ type Loader struct {
 	mu     sync.RWMutex
 	logger *zap.Logger
 	v      *viper.Viper
+
+	onReload []func(err error) // called after every reload attempt, in registration order
+	status   ReloadStatus
+}
+
+// Reload results reported by ReloadStatus
+const (
+	ReloadSuccess = "success"
+	ReloadFailure = "failure"
+)
+
+// ReloadStatus describes the configuration currently in use and the last reload attempt
+type ReloadStatus struct {
+	LoadedAt   time.Time // when the active configuration was applied
+	LastReload time.Time // last reload attempt (zero if none yet)
+	Result     string    // ReloadSuccess or ReloadFailure of the last attempt
+	Error      string    // why the last attempt was rejected
+	Warnings   []string  // lint warnings of the active configuration
 }
 
 // NewLoader creates a new configuration loader
@@ -27,25 +48,28 @@ func NewLoader(configPath string, logger *zap.Logger) (*Loader, error) {
 
 	// Configure Viper
 	l.v.SetConfigFile(configPath)
-	l.v.SetConfigType("yaml")
+	l.v.SetConfigType(configType(configPath))
 
 	// Load initial configuration
 	if err := l.v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	// Unmarshal into struct
-	var cfg Config
-	if err := l.v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	// Migrate older schemas and unmarshal into struct
+	cfg, warnings, err := decode(l.v)
+	if err != nil {
+		return nil, err
 	}
+	l.warnMigrated(warnings)
 
 	// Validate configuration
-	if err := Validate(&cfg); err != nil {
+	if err := Validate(cfg); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	l.config = &cfg
+	l.config = cfg
+	l.status.LoadedAt = time.Now()
+	l.status.Warnings = l.warnLint(cfg, lintSettings(l.v))
 	logger.Info("Configuration loaded successfully",
 		zap.String("path", configPath),
 		zap.Int("internal_nodes", len(cfg.Internals)),
@@ -60,30 +84,170 @@ func NewLoader(configPath string, logger *zap.Logger) (*Loader, error) {
 	return l, nil
 }
 
+// configType picks the config format from the file extension (YAML unless .toml or .json)
+func configType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return "toml"
+	case ".json":
+		return "json"
+	default:
+		return "yaml"
+	}
+}
+
+// NewStaticLoader creates a loader from an in-memory configuration (no file, no hot reload)
+// Intended for embedding Sauron as a library and for in-process tests
+func NewStaticLoader(cfg *Config, logger *zap.Logger) (*Loader, error) {
+	cfg = cloneConfig(cfg)
+	cfg.expand()
+	if err := Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	l := &Loader{
+		logger: logger,
+	}
+	l.config = cfg
+	if l.config.Version == 0 {
+		// Built in Go against this package, so already in the current layout
+		l.config.Version = CurrentVersion
+	}
+	l.status.LoadedAt = time.Now()
+	l.status.Warnings = l.warnLint(cfg, nil)
+
+	logger.Info("Configuration loaded successfully",
+		zap.String("path", "(static)"),
+		zap.Int("internal_nodes", len(cfg.Internals)),
+		zap.Int("external_rings", len(cfg.Externals)),
+		zap.Int("users", len(cfg.Users)),
+	)
+
+	return l, nil
+}
+
+// OnReload registers a callback run after every reload attempt, file or Update
+// err is nil when the new configuration was applied; earlier callbacks keep running
+func (l *Loader) OnReload(fn func(err error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onReload = append(l.onReload, fn)
+}
+
+// Update validates and swaps in a new configuration
+// Lets embedders reload configuration without a file watcher
+func (l *Loader) Update(cfg *Config) error {
+	newCfg := cloneConfig(cfg)
+	newCfg.expand()
+	if err := Validate(newCfg); err != nil {
+		err = fmt.Errorf("invalid configuration: %w", err)
+		l.reloaded(err)
+		return err
+	}
+
+	diff := l.swap(newCfg, l.warnLint(newCfg, nil))
+
+	l.logger.Info("Configuration updated", l.reloadFields(newCfg, diff)...)
+	l.reloaded(nil)
+
+	return nil
+}
+
 // onConfigChange handles configuration file changes
 func (l *Loader) onConfigChange(e fsnotify.Event) {
 	l.logger.Info("Configuration file changed, reloading...", zap.String("event", e.String()))
 
-	var newCfg Config
-	if err := l.v.Unmarshal(&newCfg); err != nil {
-		l.logger.Error("Failed to unmarshal new config", zap.Error(err))
+	newCfg, warnings, err := decode(l.v)
+	if err != nil {
+		l.logger.Error("Failed to load new config", zap.Error(err))
+		l.reloaded(err)
 		return
 	}
+	l.warnMigrated(warnings)
 
-	if err := Validate(&newCfg); err != nil {
+	if err := Validate(newCfg); err != nil {
 		l.logger.Error("Invalid new configuration", zap.Error(err))
+		l.reloaded(err)
 		return
 	}
 
+	diff := l.swap(newCfg, l.warnLint(newCfg, lintSettings(l.v)))
+
+	l.logger.Info("Configuration reloaded successfully", l.reloadFields(newCfg, diff)...)
+	l.reloaded(nil)
+}
+
+// warnMigrated logs what was upgraded from an older config schema
+func (l *Loader) warnMigrated(warnings []string) {
+	for _, w := range warnings {
+		l.logger.Warn("Config schema migrated", zap.String("detail", w), zap.Int("version", CurrentVersion))
+	}
+}
+
+// warnLint logs the lint warnings of a configuration about to be applied and returns them
+// settings are the raw-settings warnings, only available when loading from a file
+func (l *Loader) warnLint(cfg *Config, settings []string) []string {
+	warnings := append(settings, Lint(cfg)...)
+	for _, w := range warnings {
+		l.logger.Warn("Config lint warning", zap.String("detail", w))
+	}
+	return warnings
+}
+
+// swap installs a validated configuration with its lint warnings and returns what changed
+func (l *Loader) swap(newCfg *Config, warnings []string) *Diff {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	diff := Compare(l.config, newCfg)
+	l.config = newCfg
+	l.status.Warnings = warnings
+	return diff
+}
+
+// reloadFields builds the log fields for an applied reload
+func (l *Loader) reloadFields(cfg *Config, diff *Diff) []zap.Field {
+	fields := []zap.Field{
+		zap.Int("internal_nodes", len(cfg.Internals)),
+		zap.Int("external_rings", len(cfg.Externals)),
+		zap.Int("users", len(cfg.Users)),
+	}
+	if diff.Empty() {
+		return append(fields, zap.Bool("unchanged", true))
+	}
+	return append(fields, diff.Fields()...)
+}
+
+// ReloadStatus returns when the configuration was applied and how the last reload went
+func (l *Loader) ReloadStatus() ReloadStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	status := l.status
+	status.Warnings = append([]string(nil), l.status.Warnings...)
+	return status
+}
+
+// reloaded records a reload attempt and reports it to the registered callbacks
+func (l *Loader) reloaded(err error) {
 	l.mu.Lock()
-	l.config = &newCfg
+	now := time.Now()
+	l.status.LastReload = now
+	if err != nil {
+		l.status.Result = ReloadFailure
+		l.status.Error = err.Error()
+	} else {
+		l.status.LoadedAt = now
+		l.status.Result = ReloadSuccess
+		l.status.Error = ""
+	}
+	callbacks := l.onReload
 	l.mu.Unlock()
 
-	l.logger.Info("Configuration reloaded successfully",
-		zap.Int("internal_nodes", len(newCfg.Internals)),
-		zap.Int("external_rings", len(newCfg.Externals)),
-		zap.Int("users", len(newCfg.Users)),
-	)
+	for _, fn := range callbacks {
+		fn(err)
+	}
 }
 
 // Get returns the current configuration (thread-safe)
@@ -91,35 +255,153 @@ func (l *Loader) Get() *Config {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	// Deep copy to prevent external modifications to slices
+	return cloneConfig(l.config)
+}
+
+// expand fills in what a config file leaves implicit: node group members, network URL defaults and bare listen ports
+// Runs before validation on every load, reload and Update
+func (c *Config) expand() {
+	c.expandNodeGroups()
+	c.applyNetworkDefaults()
+	c.normalizeListenAddresses()
+}
+
+// cloneConfig deep copies a configuration to prevent external modifications to slices
+func cloneConfig(src *Config) *Config {
 	cfg := Config{
-		API:                       l.config.API,
-		RPC:                       l.config.RPC,
-		GRPC:                      l.config.GRPC,
-		Auth:                      l.config.Auth,
-		Listen:                    l.config.Listen,
-		ExternalFailoverThreshold: l.config.ExternalFailoverThreshold,
-		Timeouts:                  l.config.Timeouts,
-		Redis:                     l.config.Redis,
-		RateLimit:                 l.config.RateLimit,
+		Version:                   src.Version,
+		API:                       src.API,
+		RPC:                       src.RPC,
+		GRPC:                      src.GRPC,
+		Auth:                      src.Auth,
+		Listen:                    src.Listen,
+		Logging:                   src.Logging,
+		ExternalFailoverThreshold: src.ExternalFailoverThreshold,
+		StaleThreshold:            src.StaleThreshold,
+		Timeouts:                  src.Timeouts,
+		Redis:                     src.Redis,
+		RateLimit:                 src.RateLimit,
+		AbuseDetection:            src.AbuseDetection,
+		Forwarding:                src.Forwarding,
+		AdaptiveChecks:            src.AdaptiveChecks,
+		SharedHeightChecks:        src.SharedHeightChecks,
+		Reputation:                src.Reputation,
+		HealthWebhooks:            src.HealthWebhooks,
+		RingSigning:               src.RingSigning,
+		ErrorBudget:               src.ErrorBudget,
+		SlowEjection:              src.SlowEjection,
+		SelectorLog:               src.SelectorLog,
+		DecisionAudit:             src.DecisionAudit,
+		ConnectionLimits:          src.ConnectionLimits,
+		Transport:                 src.Transport,
+		Egress:                    src.Egress,
+		CostAware:                 src.CostAware,
+		LatencyEWMA:               src.LatencyEWMA,
+		LatencyPercentiles:        src.LatencyPercentiles,
+		ReadYourWrites:            src.ReadYourWrites,
+		Retry:                     src.Retry,
+		Metrics:                   src.Metrics,
+		RuntimeLimits:             src.RuntimeLimits,
+		StreamLeaks:               src.StreamLeaks,
+		ExternalPolicy:            ExternalPolicy{DenyURLs: append([]string(nil), src.ExternalPolicy.DenyURLs...)},
 		// Deep copy slices
-		Networks:  make([]Network, len(l.config.Networks)),
-		Internals: make([]Node, len(l.config.Internals)),
-		Externals: make([]External, len(l.config.Externals)),
-		Users:     make([]User, len(l.config.Users)),
+		TrustedProxies: append([]string(nil), src.TrustedProxies...),
+		SLOs:           append([]SLO(nil), src.SLOs...),
+		Networks:       make([]Network, len(src.Networks)),
+		Internals:      make([]Node, len(src.Internals)),
+		NodeGroups:     append([]NodeGroup(nil), src.NodeGroups...),
+		Externals:      make([]External, len(src.Externals)),
+		Users:          make([]User, len(src.Users)),
 	}
 
 	// Copy slice elements
-	copy(cfg.Networks, l.config.Networks)
-	copy(cfg.Internals, l.config.Internals)
-	copy(cfg.Externals, l.config.Externals)
-	copy(cfg.Users, l.config.Users)
+	copy(cfg.Networks, src.Networks)
+	copy(cfg.Internals, src.Internals)
+	copy(cfg.Externals, src.Externals)
+	copy(cfg.Users, src.Users)
+
+	// Deep copy nested slices and maps in Networks
+	for i := range cfg.Networks {
+		cfg.Networks[i].Aliases = append([]string(nil), src.Networks[i].Aliases...)
+		cfg.Networks[i].NodeGroups = append([]string(nil), src.Networks[i].NodeGroups...)
+		cfg.Networks[i].GRPCInterceptors = append([]string(nil), src.Networks[i].GRPCInterceptors...)
+		cfg.Networks[i].GRPCMetadata = cloneStringMap(src.Networks[i].GRPCMetadata)
+		cfg.Networks[i].GRPCAllowServices = append([]string(nil), src.Networks[i].GRPCAllowServices...)
+		cfg.Networks[i].GRPCDenyServices = append([]string(nil), src.Networks[i].GRPCDenyServices...)
+		cfg.Networks[i].HTTPMiddleware = append([]string(nil), src.Networks[i].HTTPMiddleware...)
+		cfg.Networks[i].CORSOrigins = append([]string(nil), src.Networks[i].CORSOrigins...)
+		cfg.Networks[i].HTTPHeaders = cloneStringMap(src.Networks[i].HTTPHeaders)
+		cfg.Networks[i].RPCValidation.ExtraMethods = append([]string(nil), src.Networks[i].RPCValidation.ExtraMethods...)
+		cfg.Networks[i].Rules = append([]RouteRule(nil), src.Networks[i].Rules...)
+		for j := range cfg.Networks[i].Rules {
+			cfg.Networks[i].Rules[j].Methods = append([]string(nil), src.Networks[i].Rules[j].Methods...)
+		}
+	}
+
+	// Deep copy user allowlists
+	for i := range cfg.Users {
+		cfg.Users[i].Methods = append([]string(nil), src.Users[i].Methods...)
+		cfg.Users[i].Paths = append([]string(nil), src.Users[i].Paths...)
+		cfg.Users[i].GRPCServices = append([]string(nil), src.Users[i].GRPCServices...)
+	}
+
+	// Deep copy metrics push labels and headers, and StatsD tags
+	cfg.Metrics.Push.Labels = cloneStringMap(src.Metrics.Push.Labels)
+	cfg.Metrics.Push.Headers = cloneStringMap(src.Metrics.Push.Headers)
+	cfg.Metrics.StatsD.Tags = append([]string(nil), src.Metrics.StatsD.Tags...)
+
+	// Deep copy health webhook targets
+	cfg.HealthWebhooks.URLs = append([]string(nil), src.HealthWebhooks.URLs...)
+	cfg.HealthWebhooks.Headers = cloneStringMap(src.HealthWebhooks.Headers)
+
+	// Deep copy log outputs
+	cfg.Logging.Outputs = append([]string(nil), src.Logging.Outputs...)
+
+	// Deep copy abuse detection exemptions
+	cfg.AbuseDetection.Exempt = append([]string(nil), src.AbuseDetection.Exempt...)
+
+	// Deep copy trusted rings
+	cfg.RingSigning.Trusted = append([]TrustedRing(nil), src.RingSigning.Trusted...)
 
-	// Deep copy nested slices in Externals (Rings field)
+	// Deep copy retry rules
+	cfg.Retry.Rules = append([]RetryRule(nil), src.Retry.Rules...)
+	for i := range cfg.Retry.Rules {
+		cfg.Retry.Rules[i].Methods = append([]string(nil), src.Retry.Rules[i].Methods...)
+		cfg.Retry.Rules[i].RPCMethods = append([]string(nil), src.Retry.Rules[i].RPCMethods...)
+	}
+
+	// Deep copy nested slices in Internals (Tags field)
+	for i := range cfg.Internals {
+		cfg.Internals[i].Tags = append([]string(nil), src.Internals[i].Tags...)
+	}
+
+	// Deep copy nodes and tags of node groups
+	for i := range cfg.NodeGroups {
+		cfg.NodeGroups[i].Tags = append([]string(nil), src.NodeGroups[i].Tags...)
+		cfg.NodeGroups[i].Nodes = append([]Node(nil), src.NodeGroups[i].Nodes...)
+		for j := range cfg.NodeGroups[i].Nodes {
+			cfg.NodeGroups[i].Nodes[j].Tags = append([]string(nil), src.NodeGroups[i].Nodes[j].Tags...)
+		}
+	}
+
+	// Deep copy nested slices in Externals (Rings and AllowedTypes fields)
 	for i := range cfg.Externals {
-		cfg.Externals[i].Rings = make([]string, len(l.config.Externals[i].Rings))
-		copy(cfg.Externals[i].Rings, l.config.Externals[i].Rings)
+		cfg.Externals[i].Rings = make([]string, len(src.Externals[i].Rings))
+		copy(cfg.Externals[i].Rings, src.Externals[i].Rings)
+		cfg.Externals[i].AllowedTypes = append([]string(nil), src.Externals[i].AllowedTypes...)
 	}
 
 	return &cfg
 }
+
+// cloneStringMap copies a map so callers can't modify the loaded configuration
+func cloneStringMap(src map[string]string) map[string]string {
+	if src == nil {
+		return nil
+	}
+	dst := make(map[string]string, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestLoaderFormats tests that YAML, TOML and JSON files load into the same configuration
+func TestLoaderFormats(t *testing.T) {
+	var want *Config
+
+	for _, name := range []string{"config.yaml", "config.toml", "config.json"} {
+		t.Run(name, func(t *testing.T) {
+			loader, err := NewLoader("testdata/"+name, zap.NewNop())
+			if err != nil {
+				t.Fatalf("Failed to load %s: %v", name, err)
+			}
+			cfg := loader.Get()
+
+			if cfg.Timeouts.Proxy != 30*time.Second {
+				t.Errorf("Expected proxy timeout 30s, got %s", cfg.Timeouts.Proxy)
+			}
+			if cfg.StaleThreshold != 12 {
+				t.Errorf("Expected stale_threshold 12, got %d", cfg.StaleThreshold)
+			}
+			if len(cfg.Internals) != 1 || !reflect.DeepEqual(cfg.Internals[0].Tags, []string{"archive"}) {
+				t.Errorf("Expected node-1 tagged archive, got %+v", cfg.Internals)
+			}
+			if want := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC); !cfg.Users[0].ExpiresAt.Equal(want) {
+				t.Errorf("Expected expires_at %s, got %s", want, cfg.Users[0].ExpiresAt)
+			}
+			if cfg.Networks[0].HTTPHeaders["x-sauron-network"] != "pocket" {
+				t.Errorf("Expected http_headers to be loaded, got %v", cfg.Networks[0].HTTPHeaders)
+			}
+
+			if want == nil {
+				want = cfg
+			} else if diff := Compare(want, cfg); !diff.Empty() {
+				t.Errorf("Expected same config as config.yaml, got differences %+v", *diff)
+			}
+		})
+	}
+}
+
+// TestLoaderFormatsValidate tests that every format goes through validation
+func TestLoaderFormatsValidate(t *testing.T) {
+	for _, name := range []string{"invalid.yaml", "invalid.toml", "invalid.json"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewLoader("testdata/"+name, zap.NewNop())
+			if err == nil {
+				t.Fatalf("Expected %s to be rejected", name)
+			}
+			if !strings.Contains(err.Error(), "api_listen cannot be empty") {
+				t.Errorf("Expected api_listen validation error, got: %v", err)
+			}
+		})
+	}
+}
+
+// TestLoaderOnReloadCallbacks tests that every registered callback sees each reload attempt
+func TestLoaderOnReloadCallbacks(t *testing.T) {
+	loader, err := NewLoader("testdata/config.yaml", zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	var first, second []error
+	loader.OnReload(func(err error) { first = append(first, err) })
+	loader.OnReload(func(err error) { second = append(second, err) })
+
+	cfg := loader.Get()
+	if err := loader.Update(cfg); err != nil {
+		t.Fatalf("Failed to update config: %v", err)
+	}
+	cfg.Internals = nil
+	cfg.Externals = nil
+	if err := loader.Update(cfg); err == nil {
+		t.Fatal("Expected a config without nodes to be rejected")
+	}
+
+	for name, got := range map[string][]error{"first": first, "second": second} {
+		if len(got) != 2 || got[0] != nil || got[1] == nil {
+			t.Errorf("Expected the %s callback to see a success then a failure, got %v", name, got)
+		}
+	}
+}
+
+// TestLint tests that valid but suspicious configuration produces warnings, not errors
+func TestLint(t *testing.T) {
+	cfg, warnings, err := LoadFile("testdata/config.yaml")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	want := []string{
+		"internal node 0 (node-1): api uses plain HTTP (http://node-1.internal:1317)",
+		"internal node 0 (node-1): rpc uses plain HTTP (http://node-1.internal:26657)",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Expected warnings %q, got %q", want, warnings)
+	}
+
+	cfg.Auth = false
+	dup := cfg.Internals[0]
+	dup.Name = "node-2"
+	cfg.Internals = append(cfg.Internals, dup)
+
+	warnings = Lint(cfg)
+	for _, w := range []string{
+		"internal node 1 (node-2): api duplicates internal node 0 (node-1) in network pocket (http://node-1.internal:1317)",
+		"user 0 (relayer): unused, auth is disabled",
+	} {
+		if !slices.Contains(warnings, w) {
+			t.Errorf("Expected warning %q, got %q", w, warnings)
+		}
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Expected lint findings to stay valid, got: %v", err)
+	}
+}
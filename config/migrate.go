@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// CurrentVersion is the configuration schema version this build understands
+// Bump it together with a new entry in migrations whenever a config change breaks older files
+const CurrentVersion = 1
+
+// migration upgrades raw settings by one schema version in place
+// It returns warnings describing what the operator should change in the file
+type migration func(settings map[string]any) []string
+
+// migrations[v] upgrades a version v configuration to v+1
+var migrations = map[int]migration{
+	// Files written before versioning have the version 1 layout, only the field is missing
+	0: func(map[string]any) []string {
+		return []string{"config has no version, assuming version 1 (add \"version: 1\" to silence this warning)"}
+	},
+}
+
+// Migrate upgrades raw settings to CurrentVersion in place
+// Versions newer than this build are refused instead of being half understood
+func Migrate(settings map[string]any) ([]string, error) {
+	var version int
+	switch v := settings["version"].(type) {
+	case nil:
+		// unversioned file
+	case int:
+		version = v
+	case int64:
+		version = int(v)
+	case float64:
+		version = int(v)
+	default:
+		return nil, fmt.Errorf("invalid config version %v", v)
+	}
+	if version < 0 || version > CurrentVersion {
+		return nil, fmt.Errorf("unsupported config version %d (this build supports up to %d)", version, CurrentVersion)
+	}
+
+	var warnings []string
+	for ; version < CurrentVersion; version++ {
+		warnings = append(warnings, migrations[version](settings)...)
+		settings["version"] = version + 1
+	}
+
+	return warnings, nil
+}
+
+// decode migrates the settings read by v and unmarshals them into a Config
+func decode(v *viper.Viper) (*Config, []string, error) {
+	settings := v.AllSettings()
+
+	warnings, err := Migrate(settings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A scratch Viper keeps the usual decode hooks (durations, string slices), plus RFC 3339 times
+	migrated := viper.New()
+	if err := migrated.MergeConfigMap(settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to load migrated config: %w", err)
+	}
+
+	var cfg Config
+	if err := migrated.Unmarshal(&cfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	))); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	cfg.expand()
+
+	return &cfg, warnings, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// applyNetworkDefaults completes node URLs written as bare hostnames with their network's default scheme and ports
+// Idempotent: URLs that already carry a scheme and port are left alone
+func (c *Config) applyNetworkDefaults() {
+	for i := range c.Internals {
+		node := &c.Internals[i]
+
+		var network *Network
+		for j := range c.Networks {
+			if c.Networks[j].Name == node.Network {
+				network = &c.Networks[j]
+				break
+			}
+		}
+		if network == nil {
+			continue
+		}
+
+		node.API = withDefaults(node.API, network.DefaultScheme, network.DefaultAPIPort)
+		node.RPC = withDefaults(node.RPC, network.DefaultScheme, network.DefaultRPCPort)
+		if node.GRPC != "" && network.DefaultGRPCPort != 0 && !strings.Contains(node.GRPC, ":") {
+			node.GRPC = net.JoinHostPort(node.GRPC, strconv.Itoa(network.DefaultGRPCPort))
+		}
+	}
+}
+
+// withDefaults adds scheme to a URL without one, then port when the host has none
+// An empty scheme keeps the URL as written (https is assumed wherever it is used)
+func withDefaults(raw, scheme string, port int) string {
+	if raw == "" || (scheme == "" && port == 0) {
+		return raw
+	}
+
+	hasScheme := strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://")
+	if !hasScheme && scheme == "" {
+		scheme = "https"
+	}
+	if !hasScheme {
+		raw = scheme + "://" + raw
+	}
+	if port == 0 {
+		return raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Port() != "" {
+		// Left for validation to report
+		return raw
+	}
+	u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	return u.String()
+}
@@ -2,20 +2,29 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
+
+	"sauron/clientip"
 )
 
 // Validate checks if the configuration is valid
 // The Dark Lord's judgment upon the scrolls
 func Validate(cfg *Config) error {
+	// Validate schema version (older files are migrated before this point)
+	if cfg.Version < 0 || cfg.Version > CurrentVersion {
+		return fmt.Errorf("unsupported config version %d (this build supports up to %d)", cfg.Version, CurrentVersion)
+	}
+
 	// Validate listen address
 	if cfg.Listen == "" {
 		return fmt.Errorf("listen address cannot be empty")
 	}
-	if !strings.HasPrefix(cfg.Listen, ":") && !strings.HasPrefix(cfg.Listen, "0.0.0.0:") && !strings.HasPrefix(cfg.Listen, "127.0.0.1:") {
-		return fmt.Errorf("invalid listen address format: %s", cfg.Listen)
+	if err := validateListenAddress(cfg.Listen, "listen"); err != nil {
+		return err
 	}
 
 	// Validate timeouts
@@ -32,6 +41,16 @@ func Validate(cfg *Config) error {
 		return fmt.Errorf("proxy timeout too short: %s (minimum 1s)", cfg.Timeouts.Proxy)
 	}
 
+	if cfg.Timeouts.Dial < 0 {
+		return fmt.Errorf("dial timeout cannot be negative: %s", cfg.Timeouts.Dial)
+	}
+	if cfg.Timeouts.ReadHeader < 0 || cfg.Timeouts.Read < 0 || cfg.Timeouts.Write < 0 || cfg.Timeouts.Idle < 0 {
+		return fmt.Errorf("listener timeouts cannot be negative")
+	}
+	if cfg.Timeouts.Write != 0 && cfg.Timeouts.Write < cfg.Timeouts.Proxy {
+		return fmt.Errorf("write timeout %s is shorter than proxy timeout %s (slow backend responses would be cut off)", cfg.Timeouts.Write, cfg.Timeouts.Proxy)
+	}
+
 	// Validate Redis if enabled
 	if cfg.Redis.Enabled {
 		if cfg.Redis.URI == "" {
@@ -42,6 +61,204 @@ func Validate(cfg *Config) error {
 		}
 	}
 
+	// Validate client IP forwarding mode
+	switch cfg.Forwarding.ClientIP {
+	case "", "append", "replace", "omit":
+	default:
+		return fmt.Errorf("invalid forwarding client_ip mode: %s (expected append, replace or omit)", cfg.Forwarding.ClientIP)
+	}
+
+	// Validate adaptive health checks
+	if cfg.AdaptiveChecks.FastInterval != 0 && cfg.AdaptiveChecks.FastInterval < time.Second {
+		return fmt.Errorf("adaptive_checks fast_interval too short: %s (minimum 1s)", cfg.AdaptiveChecks.FastInterval)
+	}
+	if cfg.AdaptiveChecks.LagThreshold < 0 || cfg.AdaptiveChecks.FlapCount < 0 ||
+		cfg.AdaptiveChecks.FlapWindow < 0 || cfg.AdaptiveChecks.RecoveryPeriod < 0 {
+		return fmt.Errorf("adaptive_checks values cannot be negative")
+	}
+
+	// Validate reputation persistence
+	if cfg.Reputation.FlushInterval != 0 && cfg.Reputation.FlushInterval < time.Second {
+		return fmt.Errorf("reputation flush_interval too short: %s (minimum 1s)", cfg.Reputation.FlushInterval)
+	}
+
+	// Validate health webhooks
+	for i, target := range cfg.HealthWebhooks.URLs {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("health_webhooks url %d: expected an http(s) URL: %s", i, target)
+		}
+	}
+	if cfg.HealthWebhooks.Interval != 0 && cfg.HealthWebhooks.Interval < time.Second {
+		return fmt.Errorf("health_webhooks interval too short: %s (minimum 1s)", cfg.HealthWebhooks.Interval)
+	}
+	if cfg.HealthWebhooks.Timeout < 0 {
+		return fmt.Errorf("health_webhooks timeout cannot be negative: %s", cfg.HealthWebhooks.Timeout)
+	}
+
+	// Validate abuse detection
+	if cfg.AbuseDetection.Window != 0 && cfg.AbuseDetection.Window < time.Second {
+		return fmt.Errorf("abuse_detection window too short: %s (minimum 1s)", cfg.AbuseDetection.Window)
+	}
+	if cfg.AbuseDetection.BanDuration < 0 {
+		return fmt.Errorf("abuse_detection ban_duration cannot be negative: %s", cfg.AbuseDetection.BanDuration)
+	}
+	if cfg.AbuseDetection.RateLimited < 0 || cfg.AbuseDetection.AuthFailures < 0 || cfg.AbuseDetection.Malformed < 0 {
+		return fmt.Errorf("abuse_detection thresholds cannot be negative")
+	}
+	if cfg.AbuseDetection.MaxEntries < 0 {
+		return fmt.Errorf("abuse_detection max_entries cannot be negative: %d", cfg.AbuseDetection.MaxEntries)
+	}
+	if _, err := clientip.ParseCIDRs(cfg.AbuseDetection.Exempt); err != nil {
+		return fmt.Errorf("abuse_detection exempt: %w", err)
+	}
+
+	// Validate ring signing
+	if cfg.RingSigning.MaxSkew < 0 {
+		return fmt.Errorf("ring_signing max_skew cannot be negative: %s", cfg.RingSigning.MaxSkew)
+	}
+	trustedNames := make(map[string]bool)
+	for i, ring := range cfg.RingSigning.Trusted {
+		if ring.Name == "" {
+			return fmt.Errorf("ring_signing trusted %d: name cannot be empty", i)
+		}
+		if ring.Secret == "" {
+			return fmt.Errorf("ring_signing trusted %d (%s): secret cannot be empty", i, ring.Name)
+		}
+		if trustedNames[ring.Name] {
+			return fmt.Errorf("ring_signing trusted %d: duplicate name '%s'", i, ring.Name)
+		}
+		trustedNames[ring.Name] = true
+	}
+	for i, ext := range cfg.Externals {
+		if ext.SigningSecret != "" && cfg.RingSigning.Name == "" {
+			return fmt.Errorf("external %d (%s): signing_secret requires ring_signing.name", i, ext.Name)
+		}
+	}
+
+	// Validate error budget
+	if cfg.ErrorBudget.Window != 0 && cfg.ErrorBudget.Window < 10*time.Second {
+		return fmt.Errorf("error_budget window too short: %s (minimum 10s)", cfg.ErrorBudget.Window)
+	}
+	if cfg.ErrorBudget.MinRequests < 0 {
+		return fmt.Errorf("error_budget min_requests cannot be negative: %d", cfg.ErrorBudget.MinRequests)
+	}
+	if cfg.ErrorBudget.MinWeight < 0 || cfg.ErrorBudget.MinWeight > 1 {
+		return fmt.Errorf("error_budget min_weight must be between 0 and 1: %g", cfg.ErrorBudget.MinWeight)
+	}
+
+	// Validate slow ejection
+	if cfg.SlowEjection.Window != 0 && cfg.SlowEjection.Window < 10*time.Second {
+		return fmt.Errorf("slow_ejection window too short: %s (minimum 10s)", cfg.SlowEjection.Window)
+	}
+	if cfg.SlowEjection.Multiplier != 0 && cfg.SlowEjection.Multiplier <= 1 {
+		return fmt.Errorf("slow_ejection multiplier must be greater than 1: %g", cfg.SlowEjection.Multiplier)
+	}
+	if cfg.SlowEjection.MinRequests < 0 {
+		return fmt.Errorf("slow_ejection min_requests cannot be negative: %d", cfg.SlowEjection.MinRequests)
+	}
+	if cfg.SlowEjection.EjectFor < 0 {
+		return fmt.Errorf("slow_ejection eject_for cannot be negative: %s", cfg.SlowEjection.EjectFor)
+	}
+
+	// Validate selector logging
+	switch cfg.Logging.Level {
+	case "", LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		return fmt.Errorf("invalid logging level '%s' (expected debug, info, warn or error)", cfg.Logging.Level)
+	}
+	switch cfg.Logging.Encoding {
+	case "", LogEncodingJSON, LogEncodingConsole:
+	default:
+		return fmt.Errorf("invalid logging encoding '%s' (expected json or console)", cfg.Logging.Encoding)
+	}
+	for _, output := range cfg.Logging.Outputs {
+		if output == "" {
+			return fmt.Errorf("logging outputs cannot contain an empty path")
+		}
+	}
+	if cfg.Logging.Sampling.Initial < 0 || cfg.Logging.Sampling.Thereafter < 0 {
+		return fmt.Errorf("logging sampling initial and thereafter cannot be negative")
+	}
+
+	switch cfg.SelectorLog.Mode {
+	case "", SelectorLogOff, SelectorLogSampled, SelectorLogDebug:
+	default:
+		return fmt.Errorf("invalid selector_log mode '%s' (expected off, sampled or debug)", cfg.SelectorLog.Mode)
+	}
+	if cfg.SelectorLog.SampleEvery < 0 {
+		return fmt.Errorf("selector_log sample_every cannot be negative: %d", cfg.SelectorLog.SampleEvery)
+	}
+
+	if cfg.DecisionAudit.Size < 0 {
+		return fmt.Errorf("decision_audit size cannot be negative: %d", cfg.DecisionAudit.Size)
+	}
+
+	// Validate connection limits
+	if cfg.ConnectionLimits.MaxRequests < 0 || cfg.ConnectionLimits.MaxWebSockets < 0 || cfg.ConnectionLimits.MaxGRPCStreams < 0 {
+		return fmt.Errorf("connection_limits values cannot be negative")
+	}
+
+	if cfg.Transport.BufferSize != 0 && (cfg.Transport.BufferSize < MinProxyBufferSize || cfg.Transport.BufferSize > MaxProxyBufferSize) {
+		return fmt.Errorf("transport.buffer_size must be between %d and %d bytes: %d", MinProxyBufferSize, MaxProxyBufferSize, cfg.Transport.BufferSize)
+	}
+	for name, t := range map[string]HTTPTransport{"checker": cfg.Transport.Checker, "proxy": cfg.Transport.Proxy} {
+		if t.MaxIdleConns < 0 || t.MaxIdleConnsPerHost < 0 || t.MaxConnsPerHost < 0 || t.IdleConnTimeout < 0 || t.TLSHandshakeTimeout < 0 {
+			return fmt.Errorf("transport.%s values cannot be negative", name)
+		}
+	}
+	if rebalance := cfg.Transport.Rebalance; rebalance.Interval != 0 && rebalance.Interval < time.Second {
+		return fmt.Errorf("transport.rebalance.interval too short: %s (minimum 1s)", rebalance.Interval)
+	}
+	if rebalance := cfg.Transport.Rebalance; rebalance.Fraction < 0 || rebalance.Fraction > 1 {
+		return fmt.Errorf("transport.rebalance.fraction must be between 0 and 1: %g", rebalance.Fraction)
+	}
+	if cfg.Transport.Rebalance.MinAge < 0 {
+		return fmt.Errorf("transport.rebalance.min_age cannot be negative: %s", cfg.Transport.Rebalance.MinAge)
+	}
+
+	if cfg.ReadYourWrites.Window < 0 {
+		return fmt.Errorf("read_your_writes.window cannot be negative")
+	}
+	if cfg.Retry.MaxAttempts < 0 || cfg.Retry.MaxAttempts > MaxRetryAttempts {
+		return fmt.Errorf("retry.max_attempts must be between 0 and %d: %d", MaxRetryAttempts, cfg.Retry.MaxAttempts)
+	}
+	for i, rule := range cfg.Retry.Rules {
+		if err := validateRetryRule(&rule); err != nil {
+			return fmt.Errorf("retry rule %d: %w", i, err)
+		}
+	}
+	if cfg.StaleThreshold < 0 {
+		return fmt.Errorf("stale_threshold cannot be negative")
+	}
+	if cfg.Egress.InternalCapacity < 0 {
+		return fmt.Errorf("egress.internal_capacity cannot be negative")
+	}
+	if cfg.CostAware.LatencyBand < 0 {
+		return fmt.Errorf("cost_aware.latency_band cannot be negative: %s", cfg.CostAware.LatencyBand)
+	}
+	if cfg.LatencyEWMA.HalfLife < 0 {
+		return fmt.Errorf("latency_ewma.half_life cannot be negative: %g", cfg.LatencyEWMA.HalfLife)
+	}
+	if cfg.LatencyPercentiles.Window < 0 {
+		return fmt.Errorf("latency_percentiles.window cannot be negative: %s", cfg.LatencyPercentiles.Window)
+	}
+	switch cfg.LatencyPercentiles.Tiebreak {
+	case "", LatencyTiebreakAverage, LatencyTiebreakP95:
+	default:
+		return fmt.Errorf("latency_percentiles.tiebreak must be %q or %q, got %q", LatencyTiebreakAverage, LatencyTiebreakP95, cfg.LatencyPercentiles.Tiebreak)
+	}
+
+	// Validate rate limiting
+	if cfg.RateLimit.MaxEntries < 0 {
+		return fmt.Errorf("rate_limit max_entries cannot be negative: %d", cfg.RateLimit.MaxEntries)
+	}
+
+	// Validate trusted proxies
+	if _, err := clientip.ParseCIDRs(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("trusted_proxies: %w", err)
+	}
+
 	// Validate networks configuration
 	if len(cfg.Networks) == 0 {
 		return fmt.Errorf("at least one network must be configured")
@@ -57,6 +274,87 @@ func Validate(cfg *Config) error {
 		}
 	}
 
+	// Validate metrics endpoint
+	if cfg.Metrics.Username != "" && cfg.Metrics.Password == "" {
+		return fmt.Errorf("metrics password is required when username is set")
+	}
+	if cfg.Metrics.Listen != "" {
+		if err := validateListenAddress(cfg.Metrics.Listen, "metrics listen"); err != nil {
+			return err
+		}
+		if listenConflict(cfg.Metrics.Listen, cfg.Listen) {
+			return fmt.Errorf("metrics listen '%s' conflicts with status listen", cfg.Metrics.Listen)
+		}
+		if existingNet, exists := conflictingListen(listenAddrs, cfg.Metrics.Listen); exists {
+			return fmt.Errorf("metrics listen '%s' conflicts with network '%s'", cfg.Metrics.Listen, existingNet)
+		}
+	}
+
+	if err := validateMetricsPush(&cfg.Metrics.Push); err != nil {
+		return err
+	}
+	if cfg.Metrics.StatsD.Address != "" {
+		if _, _, err := net.SplitHostPort(cfg.Metrics.StatsD.Address); err != nil {
+			return fmt.Errorf("metrics statsd address must be host:port: %s", cfg.Metrics.StatsD.Address)
+		}
+	}
+	if cfg.Metrics.StatsD.Interval != 0 && cfg.Metrics.StatsD.Interval < time.Second {
+		return fmt.Errorf("metrics statsd interval too short: %s (minimum 1s)", cfg.Metrics.StatsD.Interval)
+	}
+	if cfg.Metrics.DisableScrape && cfg.Metrics.Listen != "" {
+		return fmt.Errorf("metrics listen is set but disable_scrape turns /metrics off")
+	}
+
+	// Validate runtime soft limits
+	if cfg.RuntimeLimits.MaxGoroutines < 0 {
+		return fmt.Errorf("runtime_limits max_goroutines cannot be negative: %d", cfg.RuntimeLimits.MaxGoroutines)
+	}
+	if cfg.RuntimeLimits.MaxOpenFDs < 0 {
+		return fmt.Errorf("runtime_limits max_open_fds cannot be negative: %d", cfg.RuntimeLimits.MaxOpenFDs)
+	}
+	if cfg.RuntimeLimits.CheckInterval != 0 && cfg.RuntimeLimits.CheckInterval < time.Second {
+		return fmt.Errorf("runtime_limits check_interval too short: %s (minimum 1s)", cfg.RuntimeLimits.CheckInterval)
+	}
+
+	// Validate the gRPC stream leak detector
+	if cfg.StreamLeaks.MaxIdle < 0 {
+		return fmt.Errorf("stream_leaks max_idle cannot be negative: %s", cfg.StreamLeaks.MaxIdle)
+	}
+	if cfg.StreamLeaks.CheckInterval != 0 && cfg.StreamLeaks.CheckInterval < time.Second {
+		return fmt.Errorf("stream_leaks check_interval too short: %s (minimum 1s)", cfg.StreamLeaks.CheckInterval)
+	}
+
+	// Validate SLOs
+	for i, slo := range cfg.SLOs {
+		if err := validateSLO(&slo, i, cfg); err != nil {
+			return err
+		}
+	}
+
+	// Validate node groups and the networks referencing them
+	groupNames := make(map[string]bool)
+	for i, group := range cfg.NodeGroups {
+		if err := validateNodeGroup(&group, i, groupNames); err != nil {
+			return err
+		}
+	}
+	for i, network := range cfg.Networks {
+		for _, name := range network.NodeGroups {
+			if !groupNames[name] {
+				return fmt.Errorf("network %d (%s): unknown node group '%s'", i, network.Name, name)
+			}
+		}
+	}
+
+	// Group nodes keep their name in every network, so it can't also name a node elsewhere
+	owners := make(map[string]string) // node name -> group ("" = listed under internals)
+	for _, node := range cfg.Internals {
+		if owner, seen := owners[node.Name]; seen && owner != node.Group {
+			return fmt.Errorf("node name '%s' is used by a node group and by another node", node.Name)
+		}
+		owners[node.Name] = node.Group
+	}
+
 	// Validate that at least one internal node OR external ring is configured
 	if len(cfg.Internals) == 0 && len(cfg.Externals) == 0 {
 		return fmt.Errorf("at least one internal node or external ring must be configured")
@@ -64,16 +362,26 @@ func Validate(cfg *Config) error {
 
 	// Validate internal nodes (if any)
 	for i, node := range cfg.Internals {
-		if err := validateNode(&node, i); err != nil {
+		if err := validateNode(&node, i, cfg); err != nil {
 			return err
 		}
 	}
 
 	// Validate external rings (if any)
+	externalNames := make(map[string]bool)
 	for i, ext := range cfg.Externals {
 		if err := validateExternal(&ext, i); err != nil {
 			return err
 		}
+		if externalNames[ext.Name] {
+			return fmt.Errorf("external %d (%s): duplicate external name '%s'", i, ext.Name, ext.Name)
+		}
+		externalNames[ext.Name] = true
+	}
+	for i, pattern := range cfg.ExternalPolicy.DenyURLs {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("external_policy deny_urls %d: pattern cannot be empty", i)
+		}
 	}
 
 	// Validate users if auth is enabled
@@ -81,7 +389,7 @@ func Validate(cfg *Config) error {
 		return fmt.Errorf("at least one user must be configured when auth is enabled")
 	}
 	for i, user := range cfg.Users {
-		if err := validateUser(&user, i); err != nil {
+		if err := validateUser(&user, i, cfg); err != nil {
 			return err
 		}
 	}
@@ -89,7 +397,49 @@ func Validate(cfg *Config) error {
 	return nil
 }
 
-func validateNode(node *Node, index int) error {
+func validateNodeGroup(group *NodeGroup, index int, groupNames map[string]bool) error {
+	if group.Name == "" {
+		return fmt.Errorf("node group %d: name cannot be empty", index)
+	}
+	if groupNames[group.Name] {
+		return fmt.Errorf("node group %d: duplicate node group name '%s'", index, group.Name)
+	}
+	groupNames[group.Name] = true
+
+	if len(group.Nodes) == 0 {
+		return fmt.Errorf("node group %d (%s): at least one node must be configured", index, group.Name)
+	}
+	if group.HealthCheckTimeout != 0 && group.HealthCheckTimeout < time.Second {
+		return fmt.Errorf("node group %d (%s): health_check_timeout too short: %s (minimum 1s)", index, group.Name, group.HealthCheckTimeout)
+	}
+	if err := validateProbeBudget(group.ProbeBudget); err != nil {
+		return fmt.Errorf("node group %d (%s): %w", index, group.Name, err)
+	}
+	if group.CostWeight < 0 {
+		return fmt.Errorf("node group %d (%s): cost_weight cannot be negative: %g", index, group.Name, group.CostWeight)
+	}
+	if group.Weight < 0 {
+		return fmt.Errorf("node group %d (%s): weight cannot be negative: %g", index, group.Name, group.Weight)
+	}
+	if group.Tier < 0 {
+		return fmt.Errorf("node group %d (%s): tier cannot be negative: %d", index, group.Name, group.Tier)
+	}
+
+	nodeNames := make(map[string]bool)
+	for i, node := range group.Nodes {
+		if node.Network != "" {
+			return fmt.Errorf("node group %d (%s), node %d (%s): network is set by the networks referencing the group", index, group.Name, i, node.Name)
+		}
+		if nodeNames[node.Name] {
+			return fmt.Errorf("node group %d (%s): duplicate node name '%s'", index, group.Name, node.Name)
+		}
+		nodeNames[node.Name] = true
+	}
+
+	return nil
+}
+
+func validateNode(node *Node, index int, cfg *Config) error {
 	if node.Name == "" {
 		return fmt.Errorf("internal node %d: name cannot be empty", index)
 	}
@@ -97,6 +447,15 @@ func validateNode(node *Node, index int) error {
 		return fmt.Errorf("internal node %d (%s): network cannot be empty", index, node.Name)
 	}
 
+	// Heights are stored and looked up under canonical network names
+	canonical, ok := cfg.ResolveNetwork(node.Network)
+	if !ok {
+		return fmt.Errorf("internal node %d (%s): network '%s' is not declared under networks", index, node.Name, node.Network)
+	}
+	if canonical != node.Network {
+		return fmt.Errorf("internal node %d (%s): network '%s' is an alias, use '%s'", index, node.Name, node.Network, canonical)
+	}
+
 	// At least one endpoint type must be configured
 	if node.API == "" && node.RPC == "" && node.GRPC == "" {
 		return fmt.Errorf("internal node %d (%s): at least one endpoint (api/rpc/grpc) must be configured", index, node.Name)
@@ -108,11 +467,42 @@ func validateNode(node *Node, index int) error {
 			return fmt.Errorf("internal node %d (%s): %w", index, node.Name, err)
 		}
 	}
+	if node.WS != "" {
+		if node.API == "" {
+			return fmt.Errorf("internal node %d (%s): ws requires an api endpoint", index, node.Name)
+		}
+		if err := validateWebSocketURL(node.WS, "ws"); err != nil {
+			return fmt.Errorf("internal node %d (%s): %w", index, node.Name, err)
+		}
+	}
 	if node.RPC != "" {
 		if err := validateURL(node.RPC, "rpc"); err != nil {
 			return fmt.Errorf("internal node %d (%s): %w", index, node.Name, err)
 		}
 	}
+	if node.HealthCheckTimeout != 0 && node.HealthCheckTimeout < time.Second {
+		return fmt.Errorf("internal node %d (%s): health_check_timeout too short: %s (minimum 1s)", index, node.Name, node.HealthCheckTimeout)
+	}
+	if err := validateProbeBudget(node.ProbeBudget); err != nil {
+		return fmt.Errorf("internal node %d (%s): %w", index, node.Name, err)
+	}
+	if node.CostWeight < 0 {
+		return fmt.Errorf("internal node %d (%s): cost_weight cannot be negative: %g", index, node.Name, node.CostWeight)
+	}
+	if node.Weight < 0 {
+		return fmt.Errorf("internal node %d (%s): weight cannot be negative: %g", index, node.Name, node.Weight)
+	}
+	if node.Tier < 0 {
+		return fmt.Errorf("internal node %d (%s): tier cannot be negative: %d", index, node.Name, node.Tier)
+	}
+	if node.RPCWS != "" {
+		if node.RPC == "" {
+			return fmt.Errorf("internal node %d (%s): rpc_ws requires an rpc endpoint", index, node.Name)
+		}
+		if err := validateWebSocketURL(node.RPCWS, "rpc_ws"); err != nil {
+			return fmt.Errorf("internal node %d (%s): %w", index, node.Name, err)
+		}
+	}
 	if node.GRPC != "" {
 		// GRPC can be host:port or https://host:port
 		if !strings.Contains(node.GRPC, ":") {
@@ -131,6 +521,19 @@ func validateExternal(ext *External, index int) error {
 		return fmt.Errorf("external %d (%s): at least one ring URL must be configured", index, ext.Name)
 	}
 
+	if ext.MismatchFactor != 0 && ext.MismatchFactor <= 1 {
+		return fmt.Errorf("external %d (%s): mismatch_factor must be greater than 1", index, ext.Name)
+	}
+	if !ext.NotBefore.IsZero() && !ext.ExpiresAt.IsZero() && !ext.ExpiresAt.After(ext.NotBefore) {
+		return fmt.Errorf("external %d (%s): expires_at must be after not_before", index, ext.Name)
+	}
+
+	for _, endpointType := range ext.AllowedTypes {
+		if endpointType != "api" && endpointType != "rpc" && endpointType != "grpc" {
+			return fmt.Errorf("external %d (%s): invalid allowed_types entry %q (expected api, rpc or grpc)", index, ext.Name, endpointType)
+		}
+	}
+
 	for i, ring := range ext.Rings {
 		if ring == "" {
 			return fmt.Errorf("external %d (%s): ring %d URL cannot be empty", index, ext.Name, i)
@@ -143,7 +546,7 @@ func validateExternal(ext *External, index int) error {
 	return nil
 }
 
-func validateUser(user *User, index int) error {
+func validateUser(user *User, index int, cfg *Config) error {
 	if user.Name == "" {
 		return fmt.Errorf("user %d: name cannot be empty", index)
 	}
@@ -152,8 +555,84 @@ func validateUser(user *User, index int) error {
 	}
 
 	// At least one permission must be granted
-	if !user.API && !user.RPC && !user.GRPC {
-		return fmt.Errorf("user %d (%s): at least one permission (api/rpc/grpc) must be granted", index, user.Name)
+	if !user.API && !user.RPC && !user.GRPC && !user.Admin {
+		return fmt.Errorf("user %d (%s): at least one permission (api/rpc/grpc/admin) must be granted", index, user.Name)
+	}
+
+	// A grant for a globally disabled type would silently do nothing
+	for _, grant := range []struct {
+		typ              string
+		granted, enabled bool
+	}{{"api", user.API, cfg.API}, {"rpc", user.RPC, cfg.RPC}, {"grpc", user.GRPC, cfg.GRPC}} {
+		if grant.granted && !grant.enabled {
+			return fmt.Errorf("user %d (%s): %s is granted but globally disabled", index, user.Name, grant.typ)
+		}
+	}
+
+	if !user.NotBefore.IsZero() && !user.ExpiresAt.IsZero() && !user.ExpiresAt.After(user.NotBefore) {
+		return fmt.Errorf("user %d (%s): expires_at must be after not_before", index, user.Name)
+	}
+
+	// Allowlists only narrow a granted type
+	if len(user.Methods) > 0 && !user.RPC {
+		return fmt.Errorf("user %d (%s): methods requires rpc", index, user.Name)
+	}
+	for _, method := range user.Methods {
+		if strings.TrimSpace(method) == "" {
+			return fmt.Errorf("user %d (%s): empty method", index, user.Name)
+		}
+	}
+	if len(user.Paths) > 0 && !user.API && !user.RPC {
+		return fmt.Errorf("user %d (%s): paths requires api or rpc", index, user.Name)
+	}
+	for _, path := range user.Paths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("user %d (%s): path '%s' must start with '/'", index, user.Name, path)
+		}
+		if strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+			return fmt.Errorf("user %d (%s): path '%s' may only end with '*'", index, user.Name, path)
+		}
+	}
+	if len(user.GRPCServices) > 0 && !user.GRPC {
+		return fmt.Errorf("user %d (%s): grpc_services requires grpc", index, user.Name)
+	}
+	for _, prefix := range user.GRPCServices {
+		if strings.Trim(prefix, "/. ") == "" {
+			return fmt.Errorf("user %d (%s): empty grpc service prefix", index, user.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateSLO(slo *SLO, index int, cfg *Config) error {
+	canonical, ok := cfg.ResolveNetwork(slo.Network)
+	if !ok {
+		return fmt.Errorf("slo %d: unknown network '%s'", index, slo.Network)
+	}
+	// SLO metrics are labelled with canonical names, an alias would never match
+	if canonical != slo.Network {
+		return fmt.Errorf("slo %d: network '%s' is an alias, use '%s'", index, slo.Network, canonical)
+	}
+	switch slo.Type {
+	case "api", "rpc", "grpc":
+	default:
+		return fmt.Errorf("slo %d (%s): invalid type '%s' (expected api, rpc or grpc)", index, slo.Network, slo.Type)
+	}
+	if slo.Latency == 0 && slo.ErrorRate == 0 {
+		return fmt.Errorf("slo %d (%s/%s): at least one objective (latency/error_rate) must be set", index, slo.Network, slo.Type)
+	}
+	if slo.Latency < 0 {
+		return fmt.Errorf("slo %d (%s/%s): latency cannot be negative", index, slo.Network, slo.Type)
+	}
+	if slo.LatencyQuantile < 0 || slo.LatencyQuantile >= 1 {
+		return fmt.Errorf("slo %d (%s/%s): latency_quantile must be between 0 and 1: %g", index, slo.Network, slo.Type, slo.LatencyQuantile)
+	}
+	if slo.ErrorRate < 0 || slo.ErrorRate >= 1 {
+		return fmt.Errorf("slo %d (%s/%s): error_rate must be between 0 and 1: %g", index, slo.Network, slo.Type, slo.ErrorRate)
+	}
+	if slo.Window != 0 && slo.Window < time.Minute {
+		return fmt.Errorf("slo %d (%s/%s): window too short: %s (minimum 1m)", index, slo.Network, slo.Type, slo.Window)
 	}
 
 	return nil
@@ -170,6 +649,41 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 	}
 	networkNames[network.Name] = true
 
+	switch network.SelectionStrategy {
+	case "", SelectionStrategyWeighted, SelectionStrategyRoundRobin, SelectionStrategyHeightLatency, SelectionStrategyLeastConns:
+	default:
+		return fmt.Errorf("network %d (%s): unknown selection_strategy '%s' (expected weighted, round_robin, height_latency or least_connections)", index, network.Name, network.SelectionStrategy)
+	}
+	if strings.ContainsAny(network.StickySessions.Header, " \t:") {
+		return fmt.Errorf("network %d (%s): sticky_sessions.header '%s' is not a valid header name", index, network.Name, network.StickySessions.Header)
+	}
+
+	// Validate node URL defaults
+	switch network.DefaultScheme {
+	case "", "http", "https":
+	default:
+		return fmt.Errorf("network %d (%s): default_scheme must be http or https: %s", index, network.Name, network.DefaultScheme)
+	}
+	for _, port := range []struct {
+		name  string
+		value int
+	}{{"default_api_port", network.DefaultAPIPort}, {"default_rpc_port", network.DefaultRPCPort}, {"default_grpc_port", network.DefaultGRPCPort}} {
+		if port.value < 0 || port.value > 65535 {
+			return fmt.Errorf("network %d (%s): %s out of range: %d", index, network.Name, port.name, port.value)
+		}
+	}
+
+	// Aliases share the namespace of network names
+	for _, alias := range network.Aliases {
+		if alias == "" || strings.Contains(alias, "/") {
+			return fmt.Errorf("network %d (%s): invalid alias '%s'", index, network.Name, alias)
+		}
+		if networkNames[alias] {
+			return fmt.Errorf("network %d (%s): alias '%s' is already a network name or alias", index, network.Name, alias)
+		}
+		networkNames[alias] = true
+	}
+
 	// Validate API configuration
 	if cfg.API {
 		if network.APIListen == "" {
@@ -179,7 +693,7 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 			return fmt.Errorf("network %d (%s): %w", index, network.Name, err)
 		}
 		// Check for duplicate listen addresses
-		if existingNet, exists := listenAddrs[network.APIListen]; exists {
+		if existingNet, exists := conflictingListen(listenAddrs, network.APIListen); exists {
 			return fmt.Errorf("network %d (%s): api_listen '%s' conflicts with network '%s'", index, network.Name, network.APIListen, existingNet)
 		}
 		listenAddrs[network.APIListen] = network.Name
@@ -190,6 +704,11 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 				return fmt.Errorf("network %d (%s): advertised %w", index, network.Name, err)
 			}
 		}
+		if network.APIWS != "" {
+			if err := validateWebSocketURL(network.APIWS, "api_ws"); err != nil {
+				return fmt.Errorf("network %d (%s): advertised %w", index, network.Name, err)
+			}
+		}
 	}
 
 	// Validate RPC configuration
@@ -201,7 +720,7 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 			return fmt.Errorf("network %d (%s): %w", index, network.Name, err)
 		}
 		// Check for duplicate listen addresses
-		if existingNet, exists := listenAddrs[network.RPCListen]; exists {
+		if existingNet, exists := conflictingListen(listenAddrs, network.RPCListen); exists {
 			return fmt.Errorf("network %d (%s): rpc_listen '%s' conflicts with network '%s'", index, network.Name, network.RPCListen, existingNet)
 		}
 		listenAddrs[network.RPCListen] = network.Name
@@ -214,6 +733,47 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 		}
 	}
 
+	// Validate HTTP middleware chain (API and RPC proxies)
+	seenMiddleware := make(map[string]bool)
+	for _, name := range network.HTTPMiddleware {
+		switch name {
+		case "auth", "rate_limit", "cors", "headers", "access_log", "ring_signature", "rpc_validation":
+		default:
+			return fmt.Errorf("network %d (%s): unknown http middleware '%s' (expected auth, rate_limit, cors, headers, access_log, ring_signature or rpc_validation)", index, network.Name, name)
+		}
+		if seenMiddleware[name] {
+			return fmt.Errorf("network %d (%s): duplicate http middleware '%s'", index, network.Name, name)
+		}
+		seenMiddleware[name] = true
+	}
+	if len(network.CORSOrigins) > 0 && !seenMiddleware["cors"] {
+		return fmt.Errorf("network %d (%s): cors_origins requires the cors middleware", index, network.Name)
+	}
+	if len(network.HTTPHeaders) > 0 && !seenMiddleware["headers"] {
+		return fmt.Errorf("network %d (%s): http_headers requires the headers middleware", index, network.Name)
+	}
+	if network.RPCValidation.MaxBatch < 0 {
+		return fmt.Errorf("network %d (%s): rpc_validation max_batch cannot be negative: %d", index, network.Name, network.RPCValidation.MaxBatch)
+	}
+	for _, method := range network.RPCValidation.ExtraMethods {
+		if method == "" {
+			return fmt.Errorf("network %d (%s): rpc_validation extra_methods cannot contain an empty method", index, network.Name)
+		}
+	}
+	if seenMiddleware["auth"] && len(cfg.Users) == 0 {
+		return fmt.Errorf("network %d (%s): http auth middleware requires at least one user", index, network.Name)
+	}
+	if seenMiddleware["ring_signature"] && len(cfg.RingSigning.Trusted) == 0 {
+		return fmt.Errorf("network %d (%s): http ring_signature middleware requires at least one ring_signing.trusted ring", index, network.Name)
+	}
+
+	// Validate request rules
+	for i, rule := range network.Rules {
+		if err := validateRouteRule(&rule, network.Name, cfg); err != nil {
+			return fmt.Errorf("network %d (%s), rule %d: %w", index, network.Name, i, err)
+		}
+	}
+
 	// Validate GRPC configuration
 	if cfg.GRPC {
 		if network.GRPCListen == "" {
@@ -223,7 +783,7 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 			return fmt.Errorf("network %d (%s): %w", index, network.Name, err)
 		}
 		// Check for duplicate listen addresses
-		if existingNet, exists := listenAddrs[network.GRPCListen]; exists {
+		if existingNet, exists := conflictingListen(listenAddrs, network.GRPCListen); exists {
 			return fmt.Errorf("network %d (%s): grpc_listen '%s' conflicts with network '%s'", index, network.Name, network.GRPCListen, existingNet)
 		}
 		listenAddrs[network.GRPCListen] = network.Name
@@ -232,18 +792,173 @@ func validateNetwork(network *Network, cfg *Config, index int, networkNames map[
 		if network.GRPC != "" && !strings.Contains(network.GRPC, ":") {
 			return fmt.Errorf("network %d (%s): advertised grpc endpoint must include port", index, network.Name)
 		}
+
+		// Validate gRPC interceptor chain
+		seen := make(map[string]bool)
+		for _, name := range network.GRPCInterceptors {
+			switch name {
+			case "auth", "rate_limit", "logging", "metadata", "ring_signature":
+			default:
+				return fmt.Errorf("network %d (%s): unknown grpc interceptor '%s' (expected auth, rate_limit, logging, metadata or ring_signature)", index, network.Name, name)
+			}
+			if seen[name] {
+				return fmt.Errorf("network %d (%s): duplicate grpc interceptor '%s'", index, network.Name, name)
+			}
+			seen[name] = true
+		}
+		if len(network.GRPCMetadata) > 0 && !seen["metadata"] {
+			return fmt.Errorf("network %d (%s): grpc_metadata requires the metadata interceptor", index, network.Name)
+		}
+		if seen["auth"] && len(cfg.Users) == 0 {
+			return fmt.Errorf("network %d (%s): grpc auth interceptor requires at least one user", index, network.Name)
+		}
+		if seen["ring_signature"] && len(cfg.RingSigning.Trusted) == 0 {
+			return fmt.Errorf("network %d (%s): grpc ring_signature interceptor requires at least one ring_signing.trusted ring", index, network.Name)
+		}
+
+		// Validate gRPC server limits
+		limits := network.GRPCServer
+		if limits.KeepaliveMinTime < 0 || limits.MaxConnectionIdle < 0 || limits.MaxConnectionAge < 0 || limits.MaxConnectionAgeGrace < 0 {
+			return fmt.Errorf("network %d (%s): grpc_server durations cannot be negative", index, network.Name)
+		}
+
+		// Validate gRPC service allow/deny prefixes
+		for _, prefix := range append(append([]string(nil), network.GRPCAllowServices...), network.GRPCDenyServices...) {
+			if strings.Trim(prefix, "/. ") == "" {
+				return fmt.Errorf("network %d (%s): empty grpc service prefix", index, network.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRouteRule(rule *RouteRule, network string, cfg *Config) error {
+	if !strings.HasPrefix(rule.Path, "/") {
+		return fmt.Errorf("path must start with '/'")
+	}
+	if strings.Contains(strings.TrimSuffix(rule.Path, "*"), "*") {
+		return fmt.Errorf("path may only end with '*'")
+	}
+	if rule.Type != "" && rule.Type != "api" && rule.Type != "rpc" {
+		return fmt.Errorf("type must be api or rpc")
+	}
+	for _, method := range rule.Methods {
+		if method == "" || strings.ToUpper(method) != method {
+			return fmt.Errorf("invalid method '%s' (expected upper case, e.g. GET)", method)
+		}
+	}
+
+	switch rule.Action {
+	case RuleActionRoute:
+		if rule.Tag == "" {
+			return fmt.Errorf("route action requires a tag")
+		}
+		tagged := false
+		for _, node := range cfg.Internals {
+			if node.Network == network && slices.Contains(node.Tags, rule.Tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return fmt.Errorf("no internal node of the network has tag '%s'", rule.Tag)
+		}
+	case RuleActionDeny:
+		if rule.Status != 0 && (rule.Status < 400 || rule.Status > 599) {
+			return fmt.Errorf("deny status must be a 4xx or 5xx code")
+		}
+	case RuleActionCache:
+		if rule.CacheTTL <= 0 {
+			return fmt.Errorf("cache action requires a positive cache_ttl")
+		}
+	case RuleActionRewrite:
+		if !strings.HasPrefix(rule.Rewrite, "/") {
+			return fmt.Errorf("rewrite action requires a rewrite path starting with '/'")
+		}
+	default:
+		return fmt.Errorf("unknown action '%s' (expected route, deny, cache or rewrite)", rule.Action)
+	}
+
+	return nil
+}
+
+func validateRetryRule(rule *RetryRule) error {
+	if rule.Path == "" && len(rule.Methods) == 0 && len(rule.RPCMethods) == 0 {
+		return fmt.Errorf("needs a path, methods or rpc_methods to match")
 	}
+	if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+		return fmt.Errorf("path must start with '/'")
+	}
+	if strings.Contains(strings.TrimSuffix(rule.Path, "*"), "*") {
+		return fmt.Errorf("path may only end with '*'")
+	}
+	if rule.Type != "" && rule.Type != "api" && rule.Type != "rpc" {
+		return fmt.Errorf("type must be api or rpc")
+	}
+	if len(rule.RPCMethods) > 0 && rule.Type == "api" {
+		return fmt.Errorf("rpc_methods only apply to rpc requests")
+	}
+	for _, method := range rule.Methods {
+		if method == "" || strings.ToUpper(method) != method {
+			return fmt.Errorf("invalid method '%s' (expected upper case, e.g. GET)", method)
+		}
+	}
+	return nil
+}
 
+// validateProbeBudget checks that probe intervals are not negative
+func validateProbeBudget(budget ProbeBudget) error {
+	if budget.CheckEvery < 0 {
+		return fmt.Errorf("probe_budget.check_every cannot be negative: %d", budget.CheckEvery)
+	}
+	if budget.WebSocketEvery < 0 {
+		return fmt.Errorf("probe_budget.websocket_every cannot be negative: %d", budget.WebSocketEvery)
+	}
 	return nil
 }
 
-func validateListenAddress(addr, fieldName string) error {
-	if !strings.HasPrefix(addr, ":") && !strings.HasPrefix(addr, "0.0.0.0:") && !strings.HasPrefix(addr, "127.0.0.1:") {
-		return fmt.Errorf("invalid %s format: %s", fieldName, addr)
+// validateMetricsPush checks the metrics push mode, target and timings
+func validateMetricsPush(p *MetricsPush) error {
+	switch p.Mode {
+	case "":
+		return nil
+	case MetricsPushGateway, MetricsPushRemoteWrite:
+	default:
+		return fmt.Errorf("invalid metrics push mode '%s' (expected pushgateway or remote_write)", p.Mode)
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("metrics push url: expected an http(s) URL: %s", p.URL)
+	}
+	if p.Interval != 0 && p.Interval < time.Second {
+		return fmt.Errorf("metrics push interval too short: %s (minimum 1s)", p.Interval)
+	}
+	if p.Timeout < 0 {
+		return fmt.Errorf("metrics push timeout cannot be negative: %s", p.Timeout)
+	}
+	for name := range p.Labels {
+		if !validLabelName(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("metrics push label '%s' is not a valid label name", name)
+		}
 	}
 	return nil
 }
 
+// validLabelName reports whether name is a Prometheus label name ([a-zA-Z_][a-zA-Z0-9_]*)
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
 func validateURL(urlStr, typ string) error {
 	// Handle cases where URL might not have a scheme
 	if !strings.HasPrefix(urlStr, "http://") && !strings.HasPrefix(urlStr, "https://") {
@@ -262,3 +977,20 @@ func validateURL(urlStr, typ string) error {
 
 	return nil
 }
+
+func validateWebSocketURL(urlStr, typ string) error {
+	if !strings.HasPrefix(urlStr, "ws://") && !strings.HasPrefix(urlStr, "wss://") {
+		return fmt.Errorf("invalid %s URL: scheme must be ws:// or wss://", typ)
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return fmt.Errorf("invalid %s URL: %w", typ, err)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("invalid %s URL: missing host", typ)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package dashboards
+
+import (
+	"bytes"
+	"fmt"
+
+	"sauron/config"
+	"sauron/selector"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleFile is a Prometheus rule file
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// newRule creates an alert with a severity and a summary
+func newRule(alert, expr, wait, severity, summary string) rule {
+	return rule{
+		Alert:       alert,
+		Expr:        expr,
+		For:         wait,
+		Labels:      map[string]string{"severity": severity},
+		Annotations: map[string]string{"summary": summary},
+	}
+}
+
+// AlertRules returns Prometheus alerting rules for the configured networks, nodes, externals and SLOs, as YAML
+// One group per network keeps thresholds (stale_threshold) and selectors specific to what is deployed
+func AlertRules(cfg *config.Config) ([]byte, error) {
+	staleThreshold := cfg.StaleThreshold
+	if staleThreshold == 0 {
+		staleThreshold = selector.DefaultStaleThreshold
+	}
+
+	file := ruleFile{}
+	for _, network := range cfg.Networks {
+		sel := fmt.Sprintf(`network="%s"`, network.Name)
+		group := ruleGroup{Name: "sauron-" + network.Name}
+
+		if hasInternals(cfg, network.Name) {
+			group.Rules = append(group.Rules,
+				newRule("SauronNodeDown",
+					fmt.Sprintf(`sauron_node_available{%s} == 0`, sel), "2m", "warning",
+					"{{ $labels.node }} {{ $labels.type }} failed its health checks in "+network.Name),
+				newRule("SauronNodeBehind",
+					fmt.Sprintf(`sauron_network_head_height{%s} - on (network) group_right sauron_node_height{%s, source="internal"} > %d`, sel, sel, staleThreshold), "5m", "warning",
+					fmt.Sprintf("{{ $labels.node }} {{ $labels.type }} is more than %d blocks behind the %s head", staleThreshold, network.Name)),
+				newRule("SauronAllNodesDown",
+					fmt.Sprintf(`max by (network, type) (sauron_node_available{%s}) == 0`, sel), "1m", "critical",
+					"No internal "+network.Name+" {{ $labels.type }} node passes health checks"),
+			)
+		}
+		if len(cfg.Externals) > 0 {
+			group.Rules = append(group.Rules,
+				newRule("SauronExternalFailover",
+					fmt.Sprintf(`sauron_external_failover_active{%s} == 1`, sel), "10m", "warning",
+					network.Name+" {{ $labels.type }} traffic has been failing over to external endpoints"),
+			)
+		}
+		group.Rules = append(group.Rules,
+			newRule("SauronRoutingFailures",
+				fmt.Sprintf(`sum by (network, type) (rate(sauron_routing_failures_total{%s}[5m])) > 0`, sel), "5m", "critical",
+				network.Name+" {{ $labels.type }} requests are failing: no node can serve them"),
+			newRule("SauronHighErrorRate",
+				fmt.Sprintf(`sum by (network, type) (rate(sauron_proxy_errors_total{%s, status_code=~"5.."}[5m])) / sum by (network, type) (rate(sauron_node_requests_total{%s}[5m])) > 0.05`, sel, sel), "10m", "warning",
+				"More than 5% of "+network.Name+" {{ $labels.type }} requests fail with 5xx"),
+		)
+
+		for _, slo := range cfg.SLOs {
+			if slo.Network != network.Name {
+				continue
+			}
+			method := slo.Method
+			if method == "" {
+				method = "all"
+			}
+			group.Rules = append(group.Rules,
+				newRule("SauronSLOViolated",
+					fmt.Sprintf(`sauron_slo_violated{%s, type="%s", method="%s"} == 1`, sel, slo.Type, method), "5m", "warning",
+					fmt.Sprintf("%s %s SLO (method %s) is burning its {{ $labels.objective }} budget too fast", network.Name, slo.Type, method)),
+			)
+		}
+
+		file.Groups = append(file.Groups, group)
+	}
+
+	global := ruleGroup{Name: "sauron"}
+	global.Rules = append(global.Rules,
+		newRule("SauronConfigReloadFailed",
+			`increase(sauron_config_reloads_total{result="failure"}[15m]) > 0`, "", "warning",
+			"A configuration reload failed; the previous configuration is still in use"),
+	)
+	if len(cfg.Externals) > 0 {
+		global.Rules = append(global.Rules,
+			newRule("SauronExternalRingDown",
+				`sauron_external_ring_available == 0`, "10m", "warning",
+				"External ring {{ $labels.ring_name }} is unreachable"),
+		)
+	}
+	file.Groups = append(file.Groups, global)
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), enc.Close()
+}
+
+// hasInternals reports whether any internal node serves the network
+func hasInternals(cfg *config.Config, network string) bool {
+	for _, node := range cfg.Internals {
+		if node.Network == network {
+			return true
+		}
+	}
+	return false
+}
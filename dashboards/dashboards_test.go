@@ -0,0 +1,104 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"sauron/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Networks:  []config.Network{{Name: "pocket"}, {Name: "osmosis"}},
+		Internals: []config.Node{{Name: "node-1", Network: "pocket"}},
+		Externals: []config.External{{Name: "partner"}},
+		SLOs:      []config.SLO{{Network: "pocket", Type: "rpc", ErrorRate: 0.01}},
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	out, err := Dashboard(testConfig())
+	if err != nil {
+		t.Fatalf("Dashboard: %v", err)
+	}
+
+	var dashboard struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Type    string `json:"type"`
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+		Templating struct {
+			List []struct {
+				Name  string `json:"name"`
+				Query string `json:"query"`
+			} `json:"list"`
+		} `json:"templating"`
+	}
+	if err := json.Unmarshal(out, &dashboard); err != nil {
+		t.Fatalf("Dashboard is not valid JSON: %v", err)
+	}
+	if dashboard.UID != DashboardUID {
+		t.Errorf("Expected uid %q, got %q", DashboardUID, dashboard.UID)
+	}
+
+	rows := map[string]bool{}
+	for _, p := range dashboard.Panels {
+		if p.Type == "row" {
+			rows[p.Title] = true
+			continue
+		}
+		for _, target := range p.Targets {
+			if !strings.Contains(target.Expr, "sauron_") {
+				t.Errorf("Panel %q queries no Sauron metric: %s", p.Title, target.Expr)
+			}
+		}
+	}
+	for _, row := range []string{"Nodes", "Traffic", "Externals", "SLOs"} {
+		if !rows[row] {
+			t.Errorf("Expected a %s row", row)
+		}
+	}
+
+	for _, v := range dashboard.Templating.List {
+		if v.Name == "network" && v.Query != "pocket,osmosis" {
+			t.Errorf("Expected the network variable to list configured networks, got %q", v.Query)
+		}
+	}
+}
+
+func TestAlertRules(t *testing.T) {
+	out, err := AlertRules(testConfig())
+	if err != nil {
+		t.Fatalf("AlertRules: %v", err)
+	}
+
+	var file ruleFile
+	if err := yaml.Unmarshal(out, &file); err != nil {
+		t.Fatalf("Rules are not valid YAML: %v", err)
+	}
+
+	alerts := map[string][]string{}
+	for _, group := range file.Groups {
+		for _, r := range group.Rules {
+			alerts[group.Name] = append(alerts[group.Name], r.Alert)
+		}
+	}
+
+	// Node alerts only where internals serve the network; SLO alerts only for configured SLOs
+	if got := strings.Join(alerts["sauron-pocket"], ","); got != "SauronNodeDown,SauronNodeBehind,SauronAllNodesDown,SauronExternalFailover,SauronRoutingFailures,SauronHighErrorRate,SauronSLOViolated" {
+		t.Errorf("Unexpected pocket alerts: %s", got)
+	}
+	if got := strings.Join(alerts["sauron-osmosis"], ","); got != "SauronExternalFailover,SauronRoutingFailures,SauronHighErrorRate" {
+		t.Errorf("Unexpected osmosis alerts: %s", got)
+	}
+	if got := strings.Join(alerts["sauron"], ","); got != "SauronConfigReloadFailed,SauronExternalRingDown" {
+		t.Errorf("Unexpected global alerts: %s", got)
+	}
+}
@@ -0,0 +1,195 @@
+// Package dashboards generates Grafana dashboards and Prometheus alerting rules for a Sauron configuration
+// The Eye, drawn on the wall for those who keep watch
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sauron/config"
+)
+
+// DashboardUID is the uid of the generated dashboard, so re-imports replace it instead of adding a copy
+const DashboardUID = "sauron-overview"
+
+// panel is a Grafana panel with its Prometheus queries, or a row grouping the panels below it
+type panel struct {
+	ID          int            `json:"id"`
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	GridPos     gridPos        `json:"gridPos"`
+	Datasource  datasource     `json:"datasource"`
+	Targets     []target       `json:"targets,omitempty"`
+	FieldConfig map[string]any `json:"fieldConfig,omitempty"`
+	Collapsed   *bool          `json:"collapsed,omitempty"` // rows only
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type target struct {
+	RefID        string     `json:"refId"`
+	Expr         string     `json:"expr"`
+	LegendFormat string     `json:"legendFormat,omitempty"`
+	Datasource   datasource `json:"datasource"`
+}
+
+// prometheus is the datasource every panel reads, chosen when the dashboard is imported
+var prometheus = datasource{Type: "prometheus", UID: "${datasource}"}
+
+// dashboardBuilder lays panels out two per row
+type dashboardBuilder struct {
+	panels []panel
+	y, x   int
+	nextID int
+}
+
+// row starts a new titled row
+func (b *dashboardBuilder) row(title string) {
+	if b.x != 0 {
+		b.y += 8
+		b.x = 0
+	}
+	b.nextID++
+	collapsed := false
+	b.panels = append(b.panels, panel{
+		ID: b.nextID, Type: "row", Title: title, Collapsed: &collapsed,
+		GridPos: gridPos{H: 1, W: 24, X: 0, Y: b.y}, Datasource: prometheus,
+	})
+	b.y++
+}
+
+// add places a panel in the next half-width slot
+func (b *dashboardBuilder) add(kind, title, description, unit string, targets ...target) {
+	b.nextID++
+	for i := range targets {
+		targets[i].RefID = string(rune('A' + i))
+		targets[i].Datasource = prometheus
+	}
+	p := panel{
+		ID: b.nextID, Type: kind, Title: title, Description: description,
+		GridPos: gridPos{H: 8, W: 12, X: b.x, Y: b.y}, Datasource: prometheus, Targets: targets,
+	}
+	if unit != "" {
+		p.FieldConfig = map[string]any{"defaults": map[string]any{"unit": unit}, "overrides": []any{}}
+	}
+	b.panels = append(b.panels, p)
+
+	if b.x == 0 {
+		b.x = 12
+	} else {
+		b.x = 0
+		b.y += 8
+	}
+}
+
+// Dashboard returns a Grafana dashboard for the configured networks and nodes, as JSON ready to import
+func Dashboard(cfg *config.Config) ([]byte, error) {
+	b := &dashboardBuilder{}
+	sel := `network=~"$network"`
+	nodeSel := `network=~"$network", node=~"$node"`
+
+	b.row("Nodes")
+	b.add("timeseries", "Node height", "Block height reported by each node endpoint", "none",
+		target{Expr: fmt.Sprintf(`sauron_node_height{%s}`, nodeSel), LegendFormat: "{{node}} {{type}}"})
+	b.add("timeseries", "Blocks behind network head", "How far each internal node lags the estimated network head", "none",
+		target{Expr: fmt.Sprintf(`sauron_network_head_height{%s} - on (network) group_right sauron_node_height{%s, source="internal"}`, sel, nodeSel), LegendFormat: "{{node}} {{type}}"})
+	b.add("timeseries", "Node availability", "1 when the last health check succeeded", "none",
+		target{Expr: fmt.Sprintf(`sauron_node_available{%s}`, nodeSel), LegendFormat: "{{node}} {{type}}"})
+	b.add("timeseries", "Health check latency p99", "", "s",
+		target{Expr: fmt.Sprintf(`sauron_node_latency_p99_seconds{%s}`, nodeSel), LegendFormat: "{{node}} {{type}}"})
+
+	b.row("Traffic")
+	b.add("timeseries", "Requests per node", "", "reqps",
+		target{Expr: fmt.Sprintf(`sum by (node, type) (rate(sauron_node_requests_total{%s}[5m]))`, nodeSel), LegendFormat: "{{node}} {{type}}"})
+	b.add("timeseries", "Request latency p95", "End-to-end proxied request duration", "s",
+		target{Expr: fmt.Sprintf(`histogram_quantile(0.95, sum by (le, type) (rate(sauron_proxy_request_duration_seconds_bucket{%s}[5m])))`, sel), LegendFormat: "{{type}}"})
+	b.add("timeseries", "Proxy errors", "By error class (timeout, connection_refused, backend_error, ...)", "reqps",
+		target{Expr: fmt.Sprintf(`sum by (type, error_type) (rate(sauron_proxy_errors_total{%s}[5m]))`, sel), LegendFormat: "{{type}} {{error_type}}"})
+	b.add("timeseries", "Routing failures", "Requests no node could serve", "reqps",
+		target{Expr: fmt.Sprintf(`sum by (type, reason) (rate(sauron_routing_failures_total{%s}[5m]))`, sel), LegendFormat: "{{type}} {{reason}}"})
+	b.add("timeseries", "Backend connection reuse", "Share of proxied requests sent on a pooled connection", "percentunit",
+		target{Expr: fmt.Sprintf(`sum by (node) (rate(sauron_proxy_backend_connections_total{%s, reused="true"}[5m])) / sum by (node) (rate(sauron_proxy_backend_connections_total{%s}[5m]))`, nodeSel, nodeSel), LegendFormat: "{{node}}"})
+	b.add("timeseries", "Open streams", "WebSocket connections and gRPC streams", "none",
+		target{Expr: fmt.Sprintf(`sum by (kind) (sauron_proxy_open_streams{%s})`, sel), LegendFormat: "{{kind}}"})
+
+	if len(cfg.Externals) > 0 {
+		b.row("Externals")
+		b.add("timeseries", "External failover", "1 while traffic fails over to external endpoints", "none",
+			target{Expr: fmt.Sprintf(`sauron_external_failover_active{%s}`, sel), LegendFormat: "{{network}} {{type}}"})
+		b.add("timeseries", "Upstream latency p95 by source", "Cost of failing over: externals vs own nodes", "s",
+			target{Expr: fmt.Sprintf(`histogram_quantile(0.95, sum by (le, source) (rate(sauron_proxy_upstream_latency_seconds_bucket{%s}[5m])))`, sel), LegendFormat: "{{source}}"})
+		b.add("timeseries", "External rings available", "", "none",
+			target{Expr: `sauron_external_ring_available`, LegendFormat: "{{ring_name}}"})
+		b.add("timeseries", "Validated external endpoints", "", "none",
+			target{Expr: fmt.Sprintf(`sum by (type) (sauron_external_endpoints_validated{%s})`, sel), LegendFormat: "{{type}}"})
+	}
+
+	if len(cfg.SLOs) > 0 {
+		b.row("SLOs")
+		b.add("timeseries", "SLO burn rate", "Above 1 the error budget runs out before the window ends", "none",
+			target{Expr: fmt.Sprintf(`sauron_slo_burn_rate{%s}`, sel), LegendFormat: "{{network}} {{type}} {{method}} {{objective}}"})
+		b.add("timeseries", "SLO violated", "", "none",
+			target{Expr: fmt.Sprintf(`sauron_slo_violated{%s}`, sel), LegendFormat: "{{network}} {{type}} {{method}} {{objective}}"})
+	}
+
+	networks := make([]string, 0, len(cfg.Networks))
+	for _, network := range cfg.Networks {
+		networks = append(networks, network.Name)
+	}
+	nodes := make([]string, 0, len(cfg.Internals))
+	for _, node := range cfg.Internals {
+		nodes = append(nodes, node.Name)
+	}
+
+	dashboard := map[string]any{
+		"uid":           DashboardUID,
+		"title":         "Sauron",
+		"tags":          []string{"sauron"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"panels":        b.panels,
+		"templating": map[string]any{
+			"list": []any{
+				map[string]any{"name": "datasource", "label": "Datasource", "type": "datasource", "query": "prometheus"},
+				customVariable("network", "Network", networks),
+				customVariable("node", "Node", nodes),
+			},
+		},
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// customVariable is a multi-select dashboard variable over fixed values, with an "All" option
+func customVariable(name, label string, values []string) map[string]any {
+	options := make([]map[string]any, 0, len(values)+1)
+	options = append(options, map[string]any{"text": "All", "value": "$__all", "selected": true})
+	for _, v := range values {
+		options = append(options, map[string]any{"text": v, "value": v, "selected": false})
+	}
+
+	return map[string]any{
+		"name":       name,
+		"label":      label,
+		"type":       "custom",
+		"query":      strings.Join(values, ","),
+		"multi":      true,
+		"includeAll": true,
+		"allValue":   ".*",
+		"current":    map[string]any{"text": "All", "value": "$__all"},
+		"options":    options,
+	}
+}
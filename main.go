@@ -1,11 +1,23 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
 
+	"sauron/config"
+	"sauron/dashboards"
+	"sauron/selector"
 	"sauron/server"
+	"sauron/version"
+
+	"go.uber.org/zap"
 )
 
 const banner = `
@@ -21,14 +33,25 @@ The All-Seeing Oracle for Pocket Network
  One Sauron to route them all, and in the metrics bind them"`
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(validate(os.Args[2:]))
+		case "gen-dashboards":
+			os.Exit(genDashboards(os.Args[2:]))
+		case "simulate":
+			os.Exit(simulate(os.Args[2:]))
+		}
+	}
+
 	// Parse flags
-	configPath := flag.String("config", "config.yaml", "Path to configuration file")
-	version := flag.Bool("version", false, "Print version information")
+	configPath := flag.String("config", "config.yaml", "Path to configuration file (.yaml, .toml or .json)")
+	showVersion := flag.Bool("version", false, "Print version information")
 	flag.Parse()
 
 	// Print version if requested
-	if *version {
-		fmt.Println("Sauron v1.0.0")
+	if *showVersion {
+		fmt.Println("Sauron v" + version.Version)
 		fmt.Println("The All-Seeing Oracle for Pocket Network")
 		os.Exit(0)
 	}
@@ -51,3 +74,167 @@ func main() {
 	// Wait for shutdown signal
 	srv.WaitForShutdown()
 }
+
+// validate checks a configuration file without starting the server and returns the exit code
+// sauron validate [-config path] [--strict]
+func validate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file (.yaml, .toml or .json)")
+	strict := fs.Bool("strict", false, "Fail on lint warnings too")
+	fs.Parse(args)
+
+	_, warnings, err := config.LoadFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if *strict && len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d warning(s) (--strict)\n", *configPath, len(warnings))
+		return 1
+	}
+
+	fmt.Printf("%s: configuration is valid\n", *configPath)
+	return 0
+}
+
+// genDashboards writes a Grafana dashboard and Prometheus alerting rules for a configuration and returns the exit code
+// sauron gen-dashboards [-config path] [-out dir]
+func genDashboards(args []string) int {
+	fs := flag.NewFlagSet("gen-dashboards", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file (.yaml, .toml or .json)")
+	outDir := fs.String("out", ".", "Directory to write sauron-dashboard.json and sauron-alerts.yaml to")
+	fs.Parse(args)
+
+	cfg, _, err := config.LoadFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+
+	dashboard, err := dashboards.Dashboard(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate dashboard: %v\n", err)
+		return 1
+	}
+	rules, err := dashboards.AlertRules(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate alert rules: %v\n", err)
+		return 1
+	}
+
+	for name, content := range map[string][]byte{"sauron-dashboard.json": dashboard, "sauron-alerts.yaml": rules} {
+		path := filepath.Join(*outDir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return 0
+}
+
+// simulate replays captured height/latency samples through the selector and prints the node picked over time
+// sauron simulate -samples file.json [-config path] [-policy height|prefer_internals] [-threshold n] [-picks n]
+func simulate(args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file (.yaml, .toml or .json)")
+	samplesPath := fs.String("samples", "", "JSON array of samples: {time, network, type, node, height, latency_ms}")
+	policy := fs.String("policy", "", "Selection policy: height or prefer_internals (default: as configured)")
+	threshold := fs.Int64("threshold", -1, "external_failover_threshold override (default: as configured)")
+	picks := fs.Int("picks", 1, "Selections per network type at each sample time")
+	fs.Parse(args)
+
+	if *samplesPath == "" {
+		fmt.Fprintln(os.Stderr, "-samples is required")
+		return 2
+	}
+
+	cfg, _, err := config.LoadFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+	switch *policy {
+	case "":
+	case "height":
+		cfg.Egress.PreferInternals = false
+	case "prefer_internals":
+		cfg.Egress.PreferInternals = true
+	default:
+		fmt.Fprintf(os.Stderr, "unknown policy %q (expected height or prefer_internals)\n", *policy)
+		return 2
+	}
+	if *threshold >= 0 {
+		cfg.ExternalFailoverThreshold = *threshold
+	}
+
+	data, err := os.ReadFile(*samplesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read samples: %v\n", err)
+		return 1
+	}
+	var samples []selector.SimulationSample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *samplesPath, err)
+		return 1
+	}
+
+	logger := zap.NewNop()
+	loader, err := config.NewStaticLoader(cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 1
+	}
+	results, err := selector.Simulate(loader, samples, *picks, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *samplesPath, err)
+		return 1
+	}
+
+	// Picks over time, then per network type: share per node and how often the pick moved
+	type summary struct {
+		picks    map[string]int
+		switches int
+		last     string
+	}
+	summaries := make(map[string]*summary)
+	var order []string
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tNETWORK\tTYPE\tNODE\tREASON\tHEIGHT\tFAILOVER")
+	for _, pick := range results {
+		node := pick.Node
+		if node == "" {
+			node = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%t\n",
+			pick.Time.Format(time.RFC3339), pick.Network, pick.Type, node, pick.Reason, pick.Height, pick.Failover)
+
+		key := pick.Network + " " + pick.Type
+		sum, ok := summaries[key]
+		if !ok {
+			sum = &summary{picks: make(map[string]int), last: node}
+			summaries[key] = sum
+			order = append(order, key)
+		}
+		sum.picks[node]++
+		if node != sum.last {
+			sum.switches++
+			sum.last = node
+		}
+	}
+	w.Flush()
+
+	for _, key := range order {
+		sum := summaries[key]
+		fmt.Printf("\n%s: %d switch(es)\n", key, sum.switches)
+		for _, node := range slices.Sorted(maps.Keys(sum.picks)) {
+			fmt.Printf("  %s\t%d\n", node, sum.picks[node])
+		}
+	}
+	return 0
+}
@@ -65,6 +65,24 @@ var (
 		[]string{"network", "node", "type"},
 	)
 
+	// NetworkHeadHeight tracks the estimated head of each network (median of internal nodes and external rings)
+	NetworkHeadHeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_network_head_height",
+			Help: "Estimated network head height (median of internal nodes and external rings)",
+		},
+		[]string{"network"},
+	)
+
+	// ClockJumps counts detected wall-clock jumps (NTP steps, manual changes, VM pauses)
+	ClockJumps = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_clock_jumps_total",
+			Help: "Total number of wall-clock jumps detected against the monotonic clock",
+		},
+		[]string{"direction"}, // forward|backward
+	)
+
 	// HeightCheckDuration tracks how long height checks take
 	HeightCheckDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -92,7 +110,7 @@ var (
 			Name: "sauron_routing_selections_total",
 			Help: "Total number of routing selections by node and reason",
 		},
-		[]string{"network", "type", "selected_node", "reason"}, // reason: height_winner|round_robin|only_available
+		[]string{"network", "type", "selected_node", "reason"}, // reason: height_winner|round_robin|weighted|only_available|session_pinned|manual_pin|...
 	)
 
 	// RoutingFailures tracks when routing fails
@@ -104,15 +122,159 @@ var (
 		[]string{"network", "type", "reason"}, // reason: no_nodes|all_unhealthy|timeout
 	)
 
+	// DegradedSelections counts requests routed to a node lagging the network head (served flagged as stale)
+	DegradedSelections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_degraded_selections_total",
+			Help: "Total number of requests routed while every candidate lagged the network head",
+		},
+		[]string{"network", "type"},
+	)
+
+	// RuleMatches counts API/RPC requests matched by a network's request rules
+	RuleMatches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_proxy_rule_matches_total",
+			Help: "Total number of proxy requests matched by request rules, by action",
+		},
+		[]string{"network", "type", "action"}, // action: route|deny|cache|rewrite, plus cache_hit
+	)
+
+	// SchedulerPaused tracks health checker types paused through the admin API
+	SchedulerPaused = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_scheduler_paused",
+			Help: "Whether a health checker type is paused (1) or running (0)",
+		},
+		[]string{"checker"}, // checker: api|rpc|grpc|external
+	)
+
+	// ProbesSkipped counts probes a node's probe_budget left out
+	ProbesSkipped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_probes_skipped_total",
+			Help: "Total number of health probes skipped by a node's probe budget",
+		},
+		[]string{"network", "node", "probe"}, // probe: height|websocket|grpc_warmup
+	)
+
+	// RPCValidationRejections counts RPC requests refused by the rpc_validation middleware
+	RPCValidationRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_rpc_validation_rejections_total",
+			Help: "Total number of RPC requests refused by JSON-RPC validation, by reason",
+		},
+		[]string{"network", "reason"}, // reason: parse_error|invalid_request|method_not_found|batch_too_large
+	)
+
 	// NodeRequests tracks request distribution per node
 	NodeRequests = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "sauron_node_requests_total",
 			Help: "Total number of requests routed to each node",
 		},
+		[]string{"network", "node", "type", "method"}, // method: HTTP verb, or the JSON-RPC method for rpc
+	)
+
+	// NodeRequestErrors tracks failed requests (4xx/5xx) per node and method
+	NodeRequestErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_node_request_errors_total",
+			Help: "Total number of requests routed to each node that returned an error status",
+		},
 		[]string{"network", "node", "type", "method"},
 	)
 
+	// NodeProxyErrorRate tracks the rolling proxy error rate of internal nodes (error_budget)
+	NodeProxyErrorRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_node_proxy_error_rate",
+			Help: "Rolling share of proxied requests to an internal node that failed",
+		},
+		[]string{"network", "node", "type"},
+	)
+
+	// NodeSelectionWeight tracks the selection weight derived from the node's error rate (1=full share)
+	NodeSelectionWeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_node_selection_weight",
+			Help: "Selection weight of an internal node among nodes at max height (1=full share)",
+		},
+		[]string{"network", "node", "type"},
+	)
+
+	// NodeLatencyP99 tracks the p99 proxy latency of internal nodes over the slow_ejection window
+	NodeLatencyP99 = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_node_latency_p99_seconds",
+			Help: "p99 proxy latency of an internal node over the slow_ejection window",
+		},
+		[]string{"network", "node", "type"},
+	)
+
+	// NodeEjected indicates whether an internal node is ejected for being slow (1=ejected)
+	NodeEjected = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_node_ejected",
+			Help: "Whether an internal node is out of rotation for being slow (1=ejected, 0=in rotation)",
+		},
+		[]string{"network", "node", "type"},
+	)
+
+	// NodeDraining indicates whether an internal node is drained through the admin API (1=draining)
+	NodeDraining = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_node_draining",
+			Help: "Whether an internal node is drained for maintenance and takes no new requests (1=draining, 0=in rotation)",
+		},
+		[]string{"network", "node"},
+	)
+
+	// NodeEjections counts slow-node ejections
+	NodeEjections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_node_ejections_total",
+			Help: "Total number of times an internal node was ejected for being slow",
+		},
+		[]string{"network", "node", "type"},
+	)
+
+	// NodeInFlightRequests tracks requests currently proxied to each node
+	NodeInFlightRequests = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_node_inflight_requests",
+			Help: "Number of API/RPC requests and gRPC calls currently proxied to a node",
+		},
+		[]string{"network", "node", "type"},
+	)
+
+	// ExternalFailoverActive indicates if external endpoints are in the candidate pool (1=failover, 0=internals only)
+	ExternalFailoverActive = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_external_failover_active",
+			Help: "Whether external endpoints are currently in the candidate pool (1=failover, 0=internals only)",
+		},
+		[]string{"network", "type"},
+	)
+
+	// ExternalHeightGap tracks max external height minus max internal height at the last selection
+	ExternalHeightGap = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_external_height_gap",
+			Help: "Max validated external height minus max internal height at the last selection",
+		},
+		[]string{"network", "type"},
+	)
+
+	// ExternalFailoverTransitions counts when traffic starts or stops leaving the internal fleet
+	ExternalFailoverTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_external_failover_transitions_total",
+			Help: "Total number of external failover activations and deactivations",
+		},
+		[]string{"network", "type", "direction"}, // direction: activated|deactivated
+	)
+
 	// RoutingAlternativesConsidered tracks how many nodes were considered
 	RoutingAlternativesConsidered = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -135,6 +297,17 @@ var (
 		[]string{"network", "node", "type", "status"},
 	)
 
+	// UpstreamLatency tracks proxied request latency per backend, internal nodes and external endpoints alike
+	// Comparing source="internal" with source="external" quantifies the cost of failing over
+	UpstreamLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sauron_proxy_upstream_latency_seconds",
+			Help:    "Latency of proxied API/RPC/gRPC requests per backend (WebSocket sessions excluded)",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"network", "type", "source", "node"}, // source: internal|external, node: name or ext:{url}
+	)
+
 	// ProxyResponseSize tracks response sizes
 	ProxyResponseSize = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -160,18 +333,177 @@ var (
 			Name: "sauron_proxy_errors_total",
 			Help: "Total number of proxy errors",
 		},
-		[]string{"network", "node", "type", "status_code", "error_type"},
+		[]string{"network", "node", "type", "status_code", "error_type"}, // error_type: see the proxy.Failure* classes
+	)
+
+	// ProxyRetries tracks requests retried on another node after a failed attempt
+	ProxyRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_proxy_retries_total",
+			Help: "Total number of proxy requests retried on another node",
+		},
+		[]string{"network", "type", "error_type"}, // error_type: failure of the attempt that was retried
+	)
+
+	// BackendConnections counts the backend connections proxied API/RPC requests were sent on, reused from the pool or newly dialed
+	BackendConnections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_proxy_backend_connections_total",
+			Help: "Total number of backend connections used by proxied API/RPC requests, by whether they were reused",
+		},
+		[]string{"network", "node", "type", "reused"}, // reused: true|false
 	)
 
-	// ProxyActiveConnections tracks active proxy connections
+	// BackendConnectionsRecycled counts backend connections closed by transport.rebalance, so replacements are dialed afresh
+	BackendConnectionsRecycled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_proxy_backend_connections_recycled_total",
+			Help: "Total number of long-lived backend connections recycled by transport.rebalance",
+		},
+		[]string{"network", "type"},
+	)
+
+	// BackendDNSDuration tracks how long resolving a backend host took for newly dialed connections
+	BackendDNSDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sauron_proxy_backend_dns_duration_seconds",
+			Help:    "Duration of DNS lookups for new backend connections",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+		},
+		[]string{"network", "node"},
+	)
+
+	// BackendConnectDuration tracks how long the TCP connect to a backend took for newly dialed connections
+	BackendConnectDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sauron_proxy_backend_connect_duration_seconds",
+			Help:    "Duration of TCP connects for new backend connections",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+		},
+		[]string{"network", "node"},
+	)
+
+	// BackendTLSHandshakeDuration tracks how long the TLS handshake with a backend took for newly dialed connections
+	BackendTLSHandshakeDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sauron_proxy_backend_tls_handshake_duration_seconds",
+			Help:    "Duration of TLS handshakes for new backend connections",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 10},
+		},
+		[]string{"network", "node"},
+	)
+
+	// ProxyActiveConnections tracks requests in flight plus WebSockets open to each node
 	ProxyActiveConnections = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "sauron_proxy_active_connections",
-			Help: "Number of active proxy connections",
+			Help: "Number of requests in flight plus WebSocket connections open to a node",
 		},
 		[]string{"network", "node", "type"},
 	)
 
+	// ProxyOpenStreams tracks currently open long-lived WebSocket connections and gRPC streams
+	ProxyOpenStreams = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_proxy_open_streams",
+			Help: "Number of currently open WebSocket connections and gRPC streams",
+		},
+		[]string{"network", "node", "kind"}, // kind: websocket|grpc_stream
+	)
+
+	// ProxyStreamDuration tracks how long WebSocket connections and gRPC streams stay open
+	ProxyStreamDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sauron_proxy_stream_duration_seconds",
+			Help:    "Lifetime of WebSocket connections and gRPC streams",
+			Buckets: []float64{.1, 1, 10, 60, 300, 900, 3600, 14400},
+		},
+		[]string{"network", "kind", "reason"},
+	)
+
+	// ProxyStreamCloses counts closed WebSocket connections and gRPC streams by reason
+	ProxyStreamCloses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_proxy_stream_closes_total",
+			Help: "Total number of closed WebSocket connections and gRPC streams",
+		},
+		[]string{"network", "node", "kind", "reason"}, // reason: completed|client_closed|backend_closed|client_error|backend_error|client_canceled|idle_closed
+	)
+
+	// GRPCOldestStreamAge tracks how long the oldest open gRPC stream of a network has been open
+	GRPCOldestStreamAge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_grpc_oldest_stream_age_seconds",
+			Help: "Age of the oldest open proxied gRPC stream (0 when none is open)",
+		},
+		[]string{"network"},
+	)
+
+	// GRPCIdleStreams tracks open gRPC streams without a message for longer than stream_leaks.max_idle
+	GRPCIdleStreams = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_grpc_idle_streams",
+			Help: "Open proxied gRPC streams idle for longer than stream_leaks.max_idle (suspected leaks)",
+		},
+		[]string{"network"},
+	)
+
+	// ConnectionLimitRejections counts requests, WebSockets and gRPC streams refused by per-client limits
+	ConnectionLimitRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_proxy_connection_limit_rejections_total",
+			Help: "Total number of proxy connections rejected because the client reached its concurrency limit",
+		},
+		[]string{"network", "kind"}, // kind: http|websocket|grpc_stream
+	)
+
+	// GRPCDeniedCalls counts gRPC calls refused by a network's service allow/deny list
+	GRPCDeniedCalls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_grpc_denied_calls_total",
+			Help: "Total number of gRPC calls refused by the network's service allow/deny list",
+		},
+		[]string{"network", "rule"}, // rule: matched deny prefix, or "not_allowed"
+	)
+
+	// GRPCInspections counts gRPC calls acted on by inspectors (proxy.GRPCInspector)
+	GRPCInspections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_grpc_inspections_total",
+			Help: "Total number of gRPC calls denied, tagged or routed by inspectors",
+		},
+		[]string{"network", "action"}, // action: deny|tag|route
+	)
+
+	// ProtocolMismatches counts connections rejected for speaking the wrong protocol for the port
+	ProtocolMismatches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_protocol_mismatches_total",
+			Help: "Total number of proxy connections rejected due to protocol mismatch",
+		},
+		[]string{"network", "listener", "detected"}, // listener: api|rpc|grpc, detected: http1|grpc
+	)
+
+	// SLO Tracking
+
+	// SLOBurnRate tracks how fast each configured SLO spends its error budget over its window
+	SLOBurnRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_slo_burn_rate",
+			Help: "Error budget burn rate of configured SLOs over their window (1 = budget spent exactly at window end)",
+		},
+		[]string{"network", "type", "method", "objective"}, // method: "all" unless the SLO targets one, objective: latency|errors
+	)
+
+	// SLOViolated indicates if an SLO is burning its budget faster than allowed (1=violated, 0=ok)
+	SLOViolated = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_slo_violated",
+			Help: "SLO violation status over its window (1=burn rate above 1, 0=within budget)",
+		},
+		[]string{"network", "type", "method", "objective"},
+	)
+
 	// User Analytics
 
 	// UserRequests tracks requests per user
@@ -189,7 +521,33 @@ var (
 			Name: "sauron_auth_failures_total",
 			Help: "Total number of authentication failures",
 		},
-		[]string{"reason"}, // reason: invalid_token|missing_token|forbidden_type
+		[]string{"reason"}, // reason: invalid_token|missing_token|expired_token|forbidden_type|forbidden_method|forbidden_path|not_admin|metrics_unauthorized|*_signature|untrusted_ring
+	)
+
+	// AbuseBans counts client IPs banned by abuse detection or by an admin
+	AbuseBans = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_abuse_bans_total",
+			Help: "Total number of client IPs banned",
+		},
+		[]string{"reason"}, // reason: rate_limited|auth_failure|malformed|manual
+	)
+
+	// AbuseBannedIPs tracks client IPs currently banned
+	AbuseBannedIPs = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sauron_abuse_banned_ips",
+			Help: "Number of client IPs currently banned",
+		},
+	)
+
+	// AbuseRejections counts requests refused because the client IP is banned
+	AbuseRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_abuse_rejected_requests_total",
+			Help: "Total number of requests refused from banned client IPs",
+		},
+		[]string{"listener"}, // listener: status|api|rpc|grpc
 	)
 
 	// External Ring Performance
@@ -231,6 +589,33 @@ var (
 		[]string{"ring_name", "ring_url", "error_type"},
 	)
 
+	// ExternalRingProtocolVersion tracks the federation protocol version each ring reports
+	ExternalRingProtocolVersion = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_external_ring_protocol_version",
+			Help: "Federation protocol version reported by an external ring (0 = before versioning)",
+		},
+		[]string{"ring_name", "ring_url"},
+	)
+
+	// ExternalNetworkMismatches counts ring checks rejected because the height belongs to another network
+	ExternalNetworkMismatches = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_external_network_mismatches_total",
+			Help: "Total number of external ring checks rejected because the reported height is implausible for the network",
+		},
+		[]string{"ring_name", "network"},
+	)
+
+	// ExternalEndpointsRefused counts advertised endpoints not consumed because of allowed_types or external_policy
+	ExternalEndpointsRefused = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_external_endpoints_refused_total",
+			Help: "Total number of advertised external endpoints refused by policy (reason: type_not_allowed|url_denied)",
+		},
+		[]string{"ring_name", "network", "type", "reason"},
+	)
+
 	// External Endpoint Tracking (advertised endpoints from rings)
 
 	// ExternalEndpointsTracked tracks total number of external endpoints discovered
@@ -306,6 +691,23 @@ var (
 		[]string{"network", "type", "ring_name"},
 	)
 
+	// RateLimiterEntries tracks the number of client IPs with an active rate limiter
+	RateLimiterEntries = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sauron_rate_limiter_entries",
+			Help: "Number of client IPs currently tracked by the status API rate limiter",
+		},
+	)
+
+	// RateLimiterEvictions counts tracked client IPs dropped by the rate limiter
+	RateLimiterEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sauron_rate_limiter_evictions_total",
+			Help: "Total number of client IPs evicted from the rate limiter",
+		},
+		[]string{"reason"}, // reason: lru|idle
+	)
+
 	// Cache Performance
 
 	// CacheOperations tracks cache hits/misses
@@ -314,7 +716,7 @@ var (
 			Name: "sauron_cache_operations_total",
 			Help: "Total number of cache operations",
 		},
-		[]string{"operation", "result"}, // operation: get|set|delete, result: hit|miss|error
+		[]string{"operation", "result"}, // operation: get|set|delete, result: hit|miss|error (get), success|error (set)
 	)
 
 	// CacheOperationDuration tracks cache operation latency
@@ -364,6 +766,26 @@ var (
 		[]string{"result"}, // result: success|failure
 	)
 
+	// Runtime Self-Monitoring (go_* and process_* come from the Go and process collectors)
+
+	// RuntimeSoftLimit tracks the configured runtime_limits (0 = no limit)
+	RuntimeSoftLimit = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_runtime_soft_limit",
+			Help: "Configured soft limit of a runtime resource (0 = no limit)",
+		},
+		[]string{"resource"}, // goroutines|open_fds
+	)
+
+	// RuntimeSoftLimitExceeded tracks whether a runtime resource is above its soft limit (1=exceeded)
+	RuntimeSoftLimitExceeded = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sauron_runtime_soft_limit_exceeded",
+			Help: "Whether a runtime resource is above its soft limit (1=exceeded, 0=within)",
+		},
+		[]string{"resource"},
+	)
+
 	// KEDA Autoscaling Metrics
 
 	// KEDARequestRate tracks request rate per second for autoscaling
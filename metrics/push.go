@@ -0,0 +1,240 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sauron/config"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Metrics push defaults (used when metrics.push values are unset)
+const (
+	// DefaultPushInterval is how often metrics are pushed
+	DefaultPushInterval = 15 * time.Second
+	// DefaultPushTimeout bounds one push
+	DefaultPushTimeout = 10 * time.Second
+	// DefaultPushJob is the job label pushed metrics carry
+	DefaultPushJob = "sauron"
+)
+
+// pushedPrefix selects the metrics that are pushed: Sauron's own, not Go runtime or process metrics
+const pushedPrefix = "sauron_"
+
+// Pusher sends Sauron's metrics to a Pushgateway or a remote-write endpoint
+// For deployments Prometheus can't reach, such as edge nodes behind NAT
+type Pusher struct {
+	cfg      config.MetricsPush
+	gatherer prometheus.Gatherer
+	client   *http.Client
+}
+
+// NewPusher creates a pusher for the metrics.push settings, reading the default registry
+func NewPusher(cfg config.MetricsPush) *Pusher {
+	if cfg.Job == "" {
+		cfg.Job = DefaultPushJob
+	}
+	return &Pusher{
+		cfg:      cfg,
+		gatherer: sauronGatherer(prometheus.DefaultGatherer),
+		client:   &http.Client{},
+	}
+}
+
+// Interval returns how often metrics should be pushed
+func (p *Pusher) Interval() time.Duration {
+	if p.cfg.Interval == 0 {
+		return DefaultPushInterval
+	}
+	return p.cfg.Interval
+}
+
+// Push sends the current metrics once
+func (p *Pusher) Push(ctx context.Context) error {
+	timeout := p.cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultPushTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if p.cfg.Mode == config.MetricsPushRemoteWrite {
+		return p.remoteWrite(ctx)
+	}
+	return p.pushGateway(ctx)
+}
+
+// pushGateway replaces this job's metrics on the Pushgateway, grouped by the configured labels
+func (p *Pusher) pushGateway(ctx context.Context) error {
+	pusher := push.New(p.cfg.URL, p.cfg.Job).Gatherer(p.gatherer).Client(p.client)
+	for _, name := range sortedKeys(p.cfg.Labels) {
+		pusher = pusher.Grouping(name, p.cfg.Labels[name])
+	}
+	if len(p.cfg.Headers) > 0 {
+		header := make(http.Header, len(p.cfg.Headers))
+		for key, value := range p.cfg.Headers {
+			header.Set(key, value)
+		}
+		pusher = pusher.Header(header)
+	}
+	return pusher.PushContext(ctx)
+}
+
+// remoteWrite POSTs one sample of every series as a snappy-compressed remote-write request
+func (p *Pusher) remoteWrite(ctx context.Context) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+
+	extra := map[string]string{"job": p.cfg.Job}
+	for name, value := range p.cfg.Labels {
+		extra[name] = value
+	}
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(families, extra, time.Now().UnixMilli()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "sauron-metrics-push")
+	for key, value := range p.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sauronGatherer keeps only Sauron's own metric families
+func sauronGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		return slices.DeleteFunc(families, func(mf *dto.MetricFamily) bool {
+			return !strings.HasPrefix(mf.GetName(), pushedPrefix)
+		}), err
+	})
+}
+
+// label is one name/value pair of a remote-write series
+type label struct {
+	name, value string
+}
+
+// encodeWriteRequest encodes metric families as a remote-write WriteRequest protobuf
+// Histograms and summaries are flattened into their _bucket/_sum/_count and quantile series, as Prometheus stores them
+func encodeWriteRequest(families []*dto.MetricFamily, extra map[string]string, timestampMs int64) []byte {
+	var out []byte
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			labels := make([]label, 0, len(m.GetLabel())+len(extra)+2)
+			for _, lp := range m.GetLabel() {
+				labels = append(labels, label{lp.GetName(), lp.GetValue()})
+			}
+			for extraName, value := range extra {
+				if !slices.ContainsFunc(labels, func(l label) bool { return l.name == extraName }) {
+					labels = append(labels, label{extraName, value})
+				}
+			}
+
+			series := func(suffix string, value float64, more ...label) {
+				all := append(append([]label{{"__name__", name + suffix}}, labels...), more...)
+				out = protowire.AppendTag(out, 1, protowire.BytesType)
+				out = protowire.AppendBytes(out, encodeTimeSeries(all, value, timestampMs))
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				series("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				series("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				series("", m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					series("_bucket", float64(b.GetCumulativeCount()), label{"le", formatFloat(b.GetUpperBound())})
+				}
+				series("_bucket", float64(h.GetSampleCount()), label{"le", "+Inf"})
+				series("_sum", h.GetSampleSum())
+				series("_count", float64(h.GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				sm := m.GetSummary()
+				for _, q := range sm.GetQuantile() {
+					series("", q.GetValue(), label{"quantile", formatFloat(q.GetQuantile())})
+				}
+				series("_sum", sm.GetSampleSum())
+				series("_count", float64(sm.GetSampleCount()))
+			}
+		}
+	}
+	return out
+}
+
+// encodeTimeSeries encodes one TimeSeries with a single sample; labels are sorted by name as remote write requires
+func encodeTimeSeries(labels []label, value float64, timestampMs int64) []byte {
+	slices.SortFunc(labels, func(a, b label) int { return strings.Compare(a.name, b.name) })
+
+	var ts []byte
+	for _, l := range labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+		ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, lb)
+	}
+
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestampMs))
+	ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+	return protowire.AppendBytes(ts, sample)
+}
+
+// formatFloat formats a bucket bound or quantile the way the Prometheus text format does
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// sortedKeys returns a map's keys in order, so grouping labels form a stable Pushgateway URL
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodedSeries is one TimeSeries decoded from a WriteRequest
+type decodedSeries struct {
+	labels      []label
+	value       float64
+	timestampMs int64
+}
+
+// key identifies a series by its labels in order, e.g. `__name__=x,le=+Inf`
+func (s decodedSeries) key() string {
+	parts := make([]string, len(s.labels))
+	for i, l := range s.labels {
+		parts[i] = l.name + "=" + l.value
+	}
+	return strings.Join(parts, ",")
+}
+
+// fields decodes a protobuf message into its length-delimited and fixed/varint fields, failing on anything else
+func fields(t *testing.T, b []byte, each func(num protowire.Number, typ protowire.Type, raw []byte, v uint64)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("Invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			raw, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("Invalid bytes field %d: %v", num, protowire.ParseError(n))
+			}
+			each(num, typ, raw, 0)
+			b = b[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				t.Fatalf("Invalid fixed64 field %d: %v", num, protowire.ParseError(n))
+			}
+			each(num, typ, nil, v)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatalf("Invalid varint field %d: %v", num, protowire.ParseError(n))
+			}
+			each(num, typ, nil, v)
+			b = b[n:]
+		default:
+			t.Fatalf("Unexpected wire type %d for field %d", typ, num)
+		}
+	}
+}
+
+// decodeWriteRequest decodes a remote-write WriteRequest (timeseries = 1; labels = 1, samples = 2; name = 1, value = 2; value = 1, timestamp = 2)
+func decodeWriteRequest(t *testing.T, b []byte) []decodedSeries {
+	t.Helper()
+	var out []decodedSeries
+	fields(t, b, func(num protowire.Number, typ protowire.Type, raw []byte, _ uint64) {
+		if num != 1 || typ != protowire.BytesType {
+			t.Fatalf("Unexpected WriteRequest field %d", num)
+		}
+		var s decodedSeries
+		samples := 0
+		fields(t, raw, func(num protowire.Number, _ protowire.Type, raw []byte, _ uint64) {
+			switch num {
+			case 1:
+				var l label
+				fields(t, raw, func(num protowire.Number, _ protowire.Type, raw []byte, _ uint64) {
+					if num == 1 {
+						l.name = string(raw)
+					} else {
+						l.value = string(raw)
+					}
+				})
+				s.labels = append(s.labels, l)
+			case 2:
+				samples++
+				fields(t, raw, func(num protowire.Number, _ protowire.Type, _ []byte, v uint64) {
+					if num == 1 {
+						s.value = math.Float64frombits(v)
+					} else {
+						s.timestampMs = int64(v)
+					}
+				})
+			default:
+				t.Fatalf("Unexpected TimeSeries field %d", num)
+			}
+		})
+		if samples != 1 {
+			t.Fatalf("Expected one sample per series, got %d", samples)
+		}
+		out = append(out, s)
+	})
+	return out
+}
+
+// TestEncodeWriteRequest tests that counters and histograms encode as sorted-label remote-write series with their samples
+func TestEncodeWriteRequest(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "sauron_test_requests_total"}, []string{"network", "code"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "sauron_test_latency_seconds", Buckets: []float64{0.1, 1}})
+	reg.MustRegister(requests, latency)
+
+	requests.WithLabelValues("pocket", "200").Add(3)
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	const ts = 1700000000000
+	series := decodeWriteRequest(t, encodeWriteRequest(families, map[string]string{"job": "sauron", "instance": "edge-1"}, ts))
+
+	got := make(map[string]float64, len(series))
+	for _, s := range series {
+		for i := 1; i < len(s.labels); i++ {
+			if s.labels[i-1].name >= s.labels[i].name {
+				t.Errorf("Expected labels sorted by name, got %v", s.labels)
+			}
+		}
+		if s.timestampMs != ts {
+			t.Errorf("Expected timestamp %d, got %d", int64(ts), s.timestampMs)
+		}
+		got[s.key()] = s.value
+	}
+
+	const extra = ",instance=edge-1,job=sauron"
+	want := map[string]float64{
+		"__name__=sauron_test_requests_total,code=200" + extra + ",network=pocket": 3,
+		"__name__=sauron_test_latency_seconds_bucket" + extra + ",le=0.1":          1,
+		"__name__=sauron_test_latency_seconds_bucket" + extra + ",le=1":            2,
+		"__name__=sauron_test_latency_seconds_bucket" + extra + ",le=+Inf":         3,
+		"__name__=sauron_test_latency_seconds_sum" + extra:                         5.55,
+		"__name__=sauron_test_latency_seconds_count" + extra:                       3,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d series, got %d: %v", len(want), len(got), got)
+	}
+	for key, value := range want {
+		v, ok := got[key]
+		if !ok {
+			t.Errorf("Missing series %s", key)
+		} else if math.Abs(v-value) > 1e-9 {
+			t.Errorf("Expected %s = %v, got %v", key, value, v)
+		}
+	}
+}
+
+// TestEncodeWriteRequestKeepsMetricLabels tests that an extra label doesn't override the metric's own label of the same name
+func TestEncodeWriteRequestKeepsMetricLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	up := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "sauron_test_up"}, []string{"instance"})
+	reg.MustRegister(up)
+	up.WithLabelValues("node-1").Set(1)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	series := decodeWriteRequest(t, encodeWriteRequest(families, map[string]string{"instance": "edge-1"}, 0))
+	if len(series) != 1 || series[0].key() != "__name__=sauron_test_up,instance=node-1" || series[0].value != 1 {
+		t.Errorf("Expected the metric's instance label to be kept, got %+v", series)
+	}
+}
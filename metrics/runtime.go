@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sauron/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// DefaultRuntimeCheckInterval is how often runtime_limits are checked when check_interval is unset
+const DefaultRuntimeCheckInterval = 15 * time.Second
+
+// Runtime resources with soft limits
+const (
+	RuntimeGoroutines = "goroutines"
+	RuntimeOpenFDs    = "open_fds"
+)
+
+// The default registry comes with the Go and process collectors; they are registered again explicitly,
+// the Go collector with GC and scheduler runtime metrics on top (e.g. go_sched_latencies_seconds)
+func init() {
+	prometheus.Unregister(collectors.NewGoCollector())
+	prometheus.Unregister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	prometheus.MustRegister(
+		collectors.NewGoCollector(collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsGC, collectors.MetricsScheduler)),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RuntimeStats is a snapshot of Sauron's own runtime resources
+type RuntimeStats struct {
+	Goroutines  int
+	OpenFDs     int // -1 where unknown (not Linux)
+	HeapAlloc   uint64
+	NumGC       uint32
+	LastGCPause time.Duration
+}
+
+// ReadRuntimeStats samples goroutines, open file descriptors and GC state
+// Reading memory stats briefly stops the world, so callers sample every few seconds, not per request
+func ReadRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		OpenFDs:    -1,
+		HeapAlloc:  mem.HeapAlloc,
+		NumGC:      mem.NumGC,
+	}
+	if mem.NumGC > 0 {
+		stats.LastGCPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256])
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		stats.OpenFDs = len(fds)
+	}
+	return stats
+}
+
+// runtimeSample is the last RuntimeStats taken by SampleRuntimeStats
+type runtimeSample struct {
+	stats RuntimeStats
+	at    time.Time
+}
+
+var (
+	lastRuntimeSample atomic.Pointer[runtimeSample]
+	runtimeSampleMu   sync.Mutex // one reader takes a sample when the last one is too old
+)
+
+// SampleRuntimeStats reads runtime stats and keeps them for LastRuntimeStats
+func SampleRuntimeStats() RuntimeStats {
+	stats := ReadRuntimeStats()
+	lastRuntimeSample.Store(&runtimeSample{stats: stats, at: time.Now()})
+	return stats
+}
+
+// LastRuntimeStats returns the last sample, taking a new one only when none is younger than maxAge
+// Lets per-request readers share the runtime monitor's samples instead of stopping the world
+func LastRuntimeStats(maxAge time.Duration) RuntimeStats {
+	if sample := lastRuntimeSample.Load(); sample != nil && time.Since(sample.at) < maxAge {
+		return sample.stats
+	}
+
+	runtimeSampleMu.Lock()
+	defer runtimeSampleMu.Unlock()
+	if sample := lastRuntimeSample.Load(); sample != nil && time.Since(sample.at) < maxAge {
+		return sample.stats
+	}
+	return SampleRuntimeStats()
+}
+
+// Exceeded returns the resources above their soft limit
+func (s RuntimeStats) Exceeded(limits config.RuntimeLimits) []string {
+	var exceeded []string
+	if limits.MaxGoroutines > 0 && s.Goroutines > limits.MaxGoroutines {
+		exceeded = append(exceeded, RuntimeGoroutines)
+	}
+	if limits.MaxOpenFDs > 0 && s.OpenFDs > limits.MaxOpenFDs {
+		exceeded = append(exceeded, RuntimeOpenFDs)
+	}
+	return exceeded
+}
+
+// ObserveRuntimeLimits exports the soft limits and whether each is exceeded
+func ObserveRuntimeLimits(limits config.RuntimeLimits, exceeded []string) {
+	RuntimeSoftLimit.WithLabelValues(RuntimeGoroutines).Set(float64(limits.MaxGoroutines))
+	RuntimeSoftLimit.WithLabelValues(RuntimeOpenFDs).Set(float64(limits.MaxOpenFDs))
+	for _, resource := range []string{RuntimeGoroutines, RuntimeOpenFDs} {
+		value := 0.0
+		if slices.Contains(exceeded, resource) {
+			value = 1
+		}
+		RuntimeSoftLimitExceeded.WithLabelValues(resource).Set(value)
+	}
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"sauron/clock"
+	"sauron/config"
+)
+
+// SLO defaults (used when slos values are unset)
+const (
+	// DefaultSLOWindow is the sliding window burn rates are computed over
+	DefaultSLOWindow = time.Hour
+	// DefaultSLOLatencyQuantile is the share of requests that must finish within the latency target
+	DefaultSLOLatencyQuantile = 0.99
+)
+
+// sloSlots is how many buckets a window is split into; older buckets roll off one at a time
+const sloSlots = 60
+
+// sloSlot counts requests in one slice of the window
+type sloSlot struct {
+	index  int64 // slot number since process start (monotonic), to detect stale slots
+	total  uint64
+	errors uint64
+	slow   uint64
+}
+
+// sloWindow keeps sliding-window counts for one SLO
+type sloWindow struct {
+	width time.Duration // duration of one slot
+	slots [sloSlots]sloSlot
+}
+
+// record adds one request to the current slot
+func (w *sloWindow) record(now time.Duration, failed, slow bool) {
+	index := int64(now / w.width)
+	slot := &w.slots[index%sloSlots]
+	if slot.index != index {
+		*slot = sloSlot{index: index}
+	}
+	slot.total++
+	if failed {
+		slot.errors++
+	}
+	if slow {
+		slot.slow++
+	}
+}
+
+// totals sums the slots still inside the window
+func (w *sloWindow) totals(now time.Duration) (total, errors, slow uint64) {
+	current := int64(now / w.width)
+	for _, slot := range w.slots {
+		if slot.index > current-sloSlots {
+			total += slot.total
+			errors += slot.errors
+			slow += slot.slow
+		}
+	}
+	return total, errors, slow
+}
+
+// sloTracker holds the sliding windows of all configured SLOs
+// The Eye keeps count of every promise the tower makes
+var sloTracker = struct {
+	sync.Mutex
+	windows map[string]*sloWindow
+}{windows: make(map[string]*sloWindow)}
+
+// ObserveSLO records a proxied request against every matching SLO and updates its burn-rate gauges
+// A burn rate of 1 spends the error budget exactly over the window; above 1 the SLO is being violated
+func ObserveSLO(slos []config.SLO, network, endpointType, method string, duration time.Duration, failed bool) {
+	now := clock.Elapsed() // monotonic, so clock steps don't scramble the window
+
+	for _, slo := range slos {
+		if slo.Network != network || slo.Type != endpointType || (slo.Method != "" && slo.Method != method) {
+			continue
+		}
+
+		window := slo.Window
+		if window == 0 {
+			window = DefaultSLOWindow
+		}
+		quantile := slo.LatencyQuantile
+		if quantile == 0 {
+			quantile = DefaultSLOLatencyQuantile
+		}
+		methodLabel := slo.Method
+		if methodLabel == "" {
+			methodLabel = "all"
+		}
+
+		key := network + ":" + endpointType + ":" + slo.Method
+		slow := slo.Latency > 0 && duration > slo.Latency
+
+		sloTracker.Lock()
+		w, exists := sloTracker.windows[key]
+		if !exists || w.width != window/sloSlots {
+			// New SLO or its window changed on reload: start counting afresh
+			w = &sloWindow{width: window / sloSlots}
+			sloTracker.windows[key] = w
+		}
+		w.record(now, failed, slow)
+		total, errors, slowCount := w.totals(now)
+		sloTracker.Unlock()
+
+		if slo.Latency > 0 {
+			burn := float64(slowCount) / float64(total) / (1 - quantile)
+			setSLOBurnRate(network, endpointType, methodLabel, "latency", burn)
+		}
+		if slo.ErrorRate > 0 {
+			burn := float64(errors) / float64(total) / slo.ErrorRate
+			setSLOBurnRate(network, endpointType, methodLabel, "errors", burn)
+		}
+	}
+}
+
+// setSLOBurnRate publishes a burn rate and whether it exceeds the budget
+func setSLOBurnRate(network, endpointType, method, objective string, burn float64) {
+	SLOBurnRate.WithLabelValues(network, endpointType, method, objective).Set(burn)
+	violated := 0.0
+	if burn > 1 {
+		violated = 1
+	}
+	SLOViolated.WithLabelValues(network, endpointType, method, objective).Set(violated)
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"sauron/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// StatsD defaults (used when metrics.statsd values are unset)
+const (
+	// DefaultStatsDPrefix is prepended to emitted metric names, replacing "sauron_"
+	DefaultStatsDPrefix = "sauron."
+	// DefaultStatsDInterval is how often metrics are emitted
+	DefaultStatsDInterval = 10 * time.Second
+)
+
+// maxStatsDPacket keeps datagrams under a typical MTU, so they aren't fragmented
+const maxStatsDPacket = 1432
+
+// StatsDEmitter sends Sauron's metrics to a DogStatsD agent
+// Gauges are sent as gauges; counters, and histogram/summary count and sum, as counts of the increase since the last emit
+type StatsDEmitter struct {
+	cfg      config.StatsD
+	gatherer prometheus.Gatherer
+	conn     net.Conn
+	last     map[string]float64 // series -> cumulative value at the last emit
+}
+
+// NewStatsDEmitter creates an emitter for the metrics.statsd settings, reading the default registry
+func NewStatsDEmitter(cfg config.StatsD) (*StatsDEmitter, error) {
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultStatsDPrefix
+	}
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("dial statsd agent: %w", err)
+	}
+	return &StatsDEmitter{
+		cfg:      cfg,
+		gatherer: sauronGatherer(prometheus.DefaultGatherer),
+		conn:     conn,
+		last:     make(map[string]float64),
+	}, nil
+}
+
+// Interval returns how often metrics should be emitted
+func (e *StatsDEmitter) Interval() time.Duration {
+	if e.cfg.Interval == 0 {
+		return DefaultStatsDInterval
+	}
+	return e.cfg.Interval
+}
+
+// Emit sends the current metrics once; not safe for concurrent use
+func (e *StatsDEmitter) Emit() error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+
+	var packet []byte
+	var sendErr error
+	line := func(name string, value float64, kind string, tags []string) {
+		l := e.cfg.Prefix + name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind
+		if len(tags) > 0 {
+			l += "|#" + strings.Join(tags, ",")
+		}
+		if len(packet) > 0 && len(packet)+1+len(l) > maxStatsDPacket {
+			if _, err := e.conn.Write(packet); err != nil && sendErr == nil {
+				sendErr = err
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, l...)
+	}
+
+	// count sends how much a cumulative value grew since the last emit (counters start at 0 with the process)
+	count := func(name string, cumulative float64, tags []string) {
+		key := name + "|" + strings.Join(tags, ",")
+		if delta := cumulative - e.last[key]; delta > 0 {
+			line(name, delta, "c", tags)
+		}
+		e.last[key] = cumulative
+	}
+
+	for _, mf := range families {
+		name := strings.TrimPrefix(mf.GetName(), pushedPrefix)
+		for _, m := range mf.GetMetric() {
+			tags := append([]string(nil), e.cfg.Tags...)
+			for _, lp := range m.GetLabel() {
+				tags = append(tags, lp.GetName()+":"+statsDTagValue(lp.GetValue()))
+			}
+
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				line(name, m.GetGauge().GetValue(), "g", tags)
+			case dto.MetricType_UNTYPED:
+				line(name, m.GetUntyped().GetValue(), "g", tags)
+			case dto.MetricType_COUNTER:
+				count(name, m.GetCounter().GetValue(), tags)
+			case dto.MetricType_HISTOGRAM:
+				count(name+".count", float64(m.GetHistogram().GetSampleCount()), tags)
+				count(name+".sum", m.GetHistogram().GetSampleSum(), tags)
+			case dto.MetricType_SUMMARY:
+				count(name+".count", float64(m.GetSummary().GetSampleCount()), tags)
+				count(name+".sum", m.GetSummary().GetSampleSum(), tags)
+			}
+		}
+	}
+
+	if len(packet) > 0 {
+		if _, err := e.conn.Write(packet); err != nil && sendErr == nil {
+			sendErr = err
+		}
+	}
+	return sendErr
+}
+
+// Close closes the connection to the agent
+func (e *StatsDEmitter) Close() error {
+	return e.conn.Close()
+}
+
+// statsDTagValue replaces the characters DogStatsD uses as separators in a tag value
+func statsDTagValue(v string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "\n", "_").Replace(v)
+}
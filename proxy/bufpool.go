@@ -0,0 +1,40 @@
+package proxy
+
+import "sync"
+
+// DefaultProxyBufferSize is the buffer proxied response bodies are copied through when transport.buffer_size is unset
+const DefaultProxyBufferSize = 32 << 10
+
+// bufferPool reuses the buffers ReverseProxy copies response bodies through
+// Without it every proxied response allocates its own buffer, which adds up on large block and state responses
+type bufferPool struct {
+	size int
+	pool sync.Pool // *[]byte of len size
+}
+
+// newBufferPool creates a pool of size byte buffers (DefaultProxyBufferSize when size is 0)
+func newBufferPool(size int) *bufferPool {
+	if size == 0 {
+		size = DefaultProxyBufferSize
+	}
+	b := &bufferPool{size: size}
+	b.pool.New = func() any {
+		buf := make([]byte, size)
+		return &buf
+	}
+	return b
+}
+
+// Get returns a buffer for one response copy
+func (b *bufferPool) Get() []byte {
+	return *b.pool.Get().(*[]byte)
+}
+
+// Put returns a buffer once its response is copied; buffers of another size are dropped
+func (b *bufferPool) Put(buf []byte) {
+	if cap(buf) != b.size {
+		return
+	}
+	buf = buf[:b.size]
+	b.pool.Put(&buf)
+}
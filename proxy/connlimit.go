@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"sauron/abuse"
+	"sauron/clientip"
+	"sauron/config"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Connection kinds capped by connection_limits
+const (
+	connKindHTTP      = "http"
+	connKindWebSocket = streamKindWebSocket
+	connKindGRPC      = streamKindGRPC
+)
+
+// clientConnections counts in-flight requests, WebSockets and gRPC streams per client, across all networks
+var clientConnections = struct {
+	sync.Mutex
+	counts map[string]int // kind:client -> open
+}{counts: make(map[string]int)}
+
+// acquireConnection reserves a slot for a client; returns false when the client is at its limit
+// A limit of 0 means unlimited; every successful acquire must be paired with releaseConnection
+func acquireConnection(kind, client string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	key := kind + ":" + client
+
+	clientConnections.Lock()
+	defer clientConnections.Unlock()
+
+	if clientConnections.counts[key] >= limit {
+		return false
+	}
+	clientConnections.counts[key]++
+	return true
+}
+
+// releaseConnection frees a slot reserved by acquireConnection
+func releaseConnection(kind, client string) {
+	key := kind + ":" + client
+
+	clientConnections.Lock()
+	defer clientConnections.Unlock()
+
+	if clientConnections.counts[key] <= 1 {
+		delete(clientConnections.counts, key)
+		return
+	}
+	clientConnections.counts[key]--
+}
+
+// connectionLimit returns the configured cap for a kind of connection
+func connectionLimit(limits config.ConnectionLimits, kind string) int {
+	switch kind {
+	case connKindWebSocket:
+		return limits.MaxWebSockets
+	case connKindGRPC:
+		return limits.MaxGRPCStreams
+	}
+	return limits.MaxRequests
+}
+
+// clientKey identifies a client for connection limits: the user for a known token, otherwise the client IP
+// Tokens themselves are never used as keys, so they can't leak into logs
+func clientKey(cfg *config.Config, authorization, ip string) string {
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		if user := cfg.FindUser(token); user != nil {
+			return "user:" + user.Name
+		}
+	}
+	return "ip:" + ip
+}
+
+// httpClientKey identifies the client of an HTTP request, honoring forwarding headers from trusted_proxies
+func httpClientKey(cfg *config.Config, trusted *clientip.Resolver, r *http.Request) string {
+	return clientKey(cfg, r.Header.Get("Authorization"), httpClientIP(trusted, r))
+}
+
+// httpClientIP returns the IP of the client of an HTTP request, honoring forwarding headers from trusted
+// A nil resolver (no trusted_proxies) returns the peer
+func httpClientIP(trusted *clientip.Resolver, r *http.Request) string {
+	return trusted.ClientIP(r)
+}
+
+// connLimitInterceptor caps simultaneous gRPC streams per client
+// It runs ahead of the configured interceptors, before auth strips the token,
+// and also records the client identity for read-your-writes and sticky sessions
+func (p *GRPCProxy) connLimitInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	cfg := p.configLoader.Get()
+	limit := cfg.ConnectionLimits.MaxGRPCStreams
+	if sticky := p.networkConfig().StickySessions; sticky.Enabled {
+		ss = &contextStream{ServerStream: ss, ctx: withStickyClient(ss.Context(), grpcStickyClient(ss.Context(), sticky))}
+	}
+	if limit <= 0 && !cfg.ReadYourWrites.Enabled {
+		return handler(srv, ss)
+	}
+
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ss.Context()); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+	client := clientKey(cfg, authorization, grpcPeerIP(ss.Context()))
+
+	if cfg.ReadYourWrites.Enabled {
+		ss = &contextStream{ServerStream: ss, ctx: withSession(ss.Context(), client)}
+	}
+	if limit <= 0 {
+		return handler(srv, ss)
+	}
+
+	if !acquireConnection(connKindGRPC, client, limit) {
+		metrics.ConnectionLimitRejections.WithLabelValues(p.network, connKindGRPC).Inc()
+		p.logger.Warn("gRPC stream limit reached for client",
+			zap.String("network", p.network),
+			zap.String("client", client),
+			zap.String("method", info.FullMethod),
+			zap.Int("limit", limit),
+		)
+		p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventRateLimited)
+		return status.Error(codes.ResourceExhausted, "too many concurrent streams")
+	}
+	defer releaseConnection(connKindGRPC, client)
+
+	return handler(srv, ss)
+}
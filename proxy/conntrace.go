@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"sauron/metrics"
+)
+
+// connTrace times the phases of dialing one backend connection
+// Hooks run on the transport's dial goroutines (Happy Eyeballs races two), so the start times are guarded
+type connTrace struct {
+	mu                               sync.Mutex
+	dnsStart, connectStart, tlsStart time.Time
+	conn                             *backendConn // connection the request got, if tracked
+}
+
+// backendTrace records how a proxied request got its backend connection: reused from the pool or dialed,
+// and for dialed ones the DNS, TCP connect and TLS handshake times
+// A low reuse ratio means transport.proxy keeps too few idle connections per host
+// It also tells tracked connections apart while in use or idle, for transport.rebalance
+func backendTrace(network, nodeName, endpointType string) *httptrace.ClientTrace {
+	t := &connTrace{}
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.BackendConnections.WithLabelValues(network, nodeName, endpointType, strconv.FormatBool(info.Reused)).Inc()
+			if c := asBackendConn(info.Conn); c != nil {
+				c.owner.Store(t)
+				t.mu.Lock()
+				t.conn = c
+				t.mu.Unlock()
+			}
+		},
+		PutIdleConn: func(err error) {
+			// Only the current owner marks the connection idle: the transport may already have handed it to a waiting request
+			t.mu.Lock()
+			c := t.conn
+			t.mu.Unlock()
+			if err == nil && c != nil {
+				c.owner.CompareAndSwap(t, nil)
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.start(&t.dnsStart)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if d, ok := t.done(&t.dnsStart, info.Err); ok {
+				metrics.BackendDNSDuration.WithLabelValues(network, nodeName).Observe(d.Seconds())
+			}
+		},
+		ConnectStart: func(_, _ string) {
+			t.start(&t.connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if d, ok := t.done(&t.connectStart, err); ok {
+				metrics.BackendConnectDuration.WithLabelValues(network, nodeName).Observe(d.Seconds())
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.start(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if d, ok := t.done(&t.tlsStart, err); ok {
+				metrics.BackendTLSHandshakeDuration.WithLabelValues(network, nodeName).Observe(d.Seconds())
+			}
+		},
+	}
+}
+
+// start marks the beginning of a phase; a phase already running (a raced connect) keeps its first start
+func (t *connTrace) start(at *time.Time) {
+	t.mu.Lock()
+	if at.IsZero() {
+		*at = time.Now()
+	}
+	t.mu.Unlock()
+}
+
+// done returns how long a phase took when it succeeded, at most once per phase
+func (t *connTrace) done(at *time.Time, err error) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if at.IsZero() || err != nil {
+		return 0, false
+	}
+	d := time.Since(*at)
+	*at = time.Time{}
+	return d, true
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"sauron/config"
+	"sauron/selector"
+	"sauron/storage"
+
+	"go.uber.org/zap"
+)
+
+// DefaultReadYourWritesWindow is how long a client stays pinned after a broadcast when read_your_writes.window is unset
+const DefaultReadYourWritesWindow = 30 * time.Second
+
+// maxSessionPins bounds the pin table; new pins are skipped while it is full of live pins
+const maxSessionPins = 10000
+
+// sessionPin remembers where a client last broadcast a transaction
+type sessionPin struct {
+	node    string
+	height  int64 // height of the node when the broadcast was proxied
+	expires time.Time
+}
+
+// sessionPins maps network:client to the node its latest broadcast went to, across all proxies
+var sessionPins = struct {
+	sync.Mutex
+	pins map[string]sessionPin
+}{pins: make(map[string]sessionPin)}
+
+// pinSession pins a client's subsequent queries on a network to the node that took its broadcast
+func pinSession(network, client, node string, height int64, window time.Duration) {
+	if client == "" {
+		return
+	}
+	if window == 0 {
+		window = DefaultReadYourWritesWindow
+	}
+	key := network + ":" + client
+	now := time.Now()
+
+	sessionPins.Lock()
+	defer sessionPins.Unlock()
+
+	if _, exists := sessionPins.pins[key]; !exists && len(sessionPins.pins) >= maxSessionPins {
+		for k, pin := range sessionPins.pins {
+			if now.After(pin.expires) {
+				delete(sessionPins.pins, k)
+			}
+		}
+		if len(sessionPins.pins) >= maxSessionPins {
+			return
+		}
+	}
+	sessionPins.pins[key] = sessionPin{node: node, height: height, expires: now.Add(window)}
+}
+
+// lookupSession returns the live pin of a client on a network, if any
+func lookupSession(network, client string) (sessionPin, bool) {
+	if client == "" {
+		return sessionPin{}, false
+	}
+	key := network + ":" + client
+
+	sessionPins.Lock()
+	defer sessionPins.Unlock()
+
+	pin, ok := sessionPins.pins[key]
+	if !ok {
+		return sessionPin{}, false
+	}
+	if time.Now().After(pin.expires) {
+		delete(sessionPins.pins, key)
+		return sessionPin{}, false
+	}
+	return pin, true
+}
+
+// sessionKey carries the client identity for read-your-writes through the middleware chain
+type sessionKey struct{}
+
+// withSession stores the client identity before middleware (e.g. auth) strips credentials
+func withSession(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, client)
+}
+
+// sessionFrom returns the client identity stored by withSession ("" when read_your_writes is off)
+func sessionFrom(ctx context.Context) string {
+	client, _ := ctx.Value(sessionKey{}).(string)
+	return client
+}
+
+// isBroadcast reports whether a proxied request submits a transaction
+// method is the JSON-RPC method for rpc requests and the HTTP method otherwise
+func isBroadcast(endpointType, method string, r *http.Request) bool {
+	switch endpointType {
+	case "rpc":
+		return strings.HasPrefix(method, "broadcast_tx_") || method == "eth_sendRawTransaction"
+	case "api":
+		return r.Method == http.MethodPost && strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/cosmos/tx/v1beta1/txs")
+	}
+	return false
+}
+
+// isGRPCBroadcast reports whether a gRPC method submits a transaction
+func isGRPCBroadcast(fullMethod string) bool {
+	return fullMethod == "/cosmos.tx.v1beta1.Service/BroadcastTx"
+}
+
+// pinnedNode returns the node a client's session is pinned to, if it can still serve this endpoint type
+// Falling back to regular selection is always safe: it prefers the highest nodes anyway
+func pinnedNode(sel selector.NodeSelector, cfg *config.Config, logger *zap.Logger, network, endpointType, client string) (*storage.NodeMetrics, string, *selector.SelectionDecision) {
+	if !cfg.ReadYourWrites.Enabled {
+		return nil, "", nil
+	}
+	pin, ok := lookupSession(network, client)
+	if !ok {
+		return nil, "", nil
+	}
+
+	nodeMetrics, decision := sel.GetPinnedNode(network, endpointType, pin.node, pin.height)
+	if nodeMetrics == nil {
+		logger.Debug("Pinned node unavailable, using regular selection",
+			zap.String("network", network),
+			zap.String("type", endpointType),
+			zap.String("node", pin.node),
+		)
+		return nil, "", nil
+	}
+	return nodeMetrics, pin.node, decision
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"sauron/config"
+)
+
+// DefaultDialTimeout bounds connecting to a backend when timeouts.dial is unset
+const DefaultDialTimeout = 10 * time.Second
+
+// dialKeepAlive is the TCP keep-alive period of backend connections
+const dialKeepAlive = 30 * time.Second
+
+// dialTimeout returns timeouts.dial, or its default
+func dialTimeout(timeouts config.Timeouts) time.Duration {
+	if timeouts.Dial == 0 {
+		return DefaultDialTimeout
+	}
+	return timeouts.Dial
+}
+
+// dialBackend connects to a backend address, completing the TLS handshake when useTLS
+// Gives up after timeout or as soon as ctx is done, so a client going away aborts the attempt
+func dialBackend(ctx context.Context, addr, serverName string, useTLS bool, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{KeepAlive: dialKeepAlive}
+	if !useTLS {
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	tlsDialer := &tls.Dialer{
+		NetDialer: dialer,
+		Config:    &tls.Config{ServerName: serverName},
+	}
+	return tlsDialer.DialContext(ctx, "tcp", addr)
+}
+
+// dialContext is the HTTP transport's dialer: the request context and timeouts.dial bound each connection attempt
+// Connections are tracked so transport.rebalance can recycle them
+func (p *HTTPProxy) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout(p.configLoader.Get().Timeouts),
+		KeepAlive: dialKeepAlive,
+	}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return p.conns.track(conn), nil
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Failure classes shared by the HTTP and gRPC proxies
+// Used as the error_type label of sauron_proxy_errors_total and for retry decisions
+const (
+	FailureTimeout         = "timeout"            // deadline exceeded talking to the backend
+	FailureConnRefused     = "connection_refused" // nothing listening at the backend address
+	FailureConnReset       = "connection_reset"   // backend dropped the connection mid-request
+	FailureTLS             = "tls"                // handshake or certificate failure
+	FailureDNS             = "dns"                // backend host could not be resolved
+	FailureBackend         = "backend_error"      // backend answered 5xx / a server-side gRPC code
+	FailureRejected        = "backend_rejected"   // backend answered 4xx / a client-side gRPC code
+	FailureClientAbort     = "client_abort"       // the client went away before the response
+	FailurePayloadTooLarge = "payload_too_large"  // request or response exceeded a size limit
+	FailureOther           = "other"
+)
+
+// StatusClientClosedRequest is logged and counted when the client hangs up before the backend answers
+// (nginx convention; the client never sees it)
+const StatusClientClosedRequest = 499
+
+// classifyError maps a transport error to a failure class ("" for nil)
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// gRPC errors carry their own code
+	if _, ok := status.FromError(err); ok {
+		return classifyGRPC(err)
+	}
+
+	var dnsErr *net.DNSError
+	var maxBytesErr *http.MaxBytesError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return FailureClientAbort
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.As(err, &dnsErr):
+		return FailureDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureConnRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.ErrUnexpectedEOF):
+		return FailureConnReset
+	case isTLSError(err):
+		return FailureTLS
+	case errors.As(err, &maxBytesErr):
+		return FailurePayloadTooLarge
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	}
+	return FailureOther
+}
+
+// isTLSError reports handshake, record and certificate errors
+func isTLSError(err error) bool {
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// classifyHTTPStatus maps a backend response status to a failure class ("" below 400)
+func classifyHTTPStatus(code int) string {
+	switch {
+	case code < http.StatusBadRequest:
+		return ""
+	case code == http.StatusRequestEntityTooLarge:
+		return FailurePayloadTooLarge
+	case code == http.StatusGatewayTimeout:
+		return FailureTimeout
+	case code >= http.StatusInternalServerError:
+		return FailureBackend
+	default:
+		return FailureRejected
+	}
+}
+
+// classifyGRPC maps a gRPC call error to a failure class ("" for nil or OK)
+// Transport failures arrive as Unavailable with the cause only in the message
+func classifyGRPC(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return classifyError(err)
+	}
+
+	msg := st.Message()
+	switch st.Code() {
+	case codes.OK:
+		return ""
+	case codes.Canceled:
+		return FailureClientAbort
+	case codes.DeadlineExceeded:
+		return FailureTimeout
+	case codes.ResourceExhausted:
+		if strings.Contains(msg, "larger than max") {
+			return FailurePayloadTooLarge
+		}
+		return FailureRejected
+	case codes.Unavailable:
+		switch {
+		case strings.Contains(msg, "connection refused"):
+			return FailureConnRefused
+		case strings.Contains(msg, "no such host"):
+			return FailureDNS
+		case strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:"):
+			return FailureTLS
+		case strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe"):
+			return FailureConnReset
+		}
+		return FailureBackend
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		return FailureBackend
+	}
+	return FailureRejected
+}
+
+// clientAborted reports whether a failure was caused by the client going away, not the backend
+// Such failures must not count against the node (error budget, external endpoint errors)
+func clientAborted(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return true
+	}
+	var se *streamError
+	if errors.As(err, &se) && se.side == "client" {
+		return true
+	}
+	return classifyError(err) == FailureClientAbort
+}
+
+// retryableFailure reports whether the request never reached the backend,
+// so another node can safely take it even when it isn't idempotent
+func retryableFailure(class string) bool {
+	switch class {
+	case FailureConnRefused, FailureDNS, FailureTLS:
+		return true
+	}
+	return false
+}
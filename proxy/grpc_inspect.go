@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"context"
+	"io"
+
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCCall is a gRPC call as shown to inspectors, with its first request message, before a backend is chosen
+type GRPCCall struct {
+	Network    string
+	FullMethod string      // e.g. "/cosmos.bank.v1beta1.Query/Balance"
+	Metadata   metadata.MD // incoming metadata (read-only)
+	Message    []byte      // first request message, serialized and decompressed (read-only); nil if the client sent none
+	Size       int         // length of Message
+}
+
+// GRPCVerdict is an inspector's decision; the zero value lets the call through unchanged
+type GRPCVerdict struct {
+	Deny     bool
+	Code     codes.Code        // refusal code (default PermissionDenied)
+	Message  string            // refusal message
+	Tags     map[string]string // metadata set on the forwarded call
+	RouteTag string            // send the call to internal nodes carrying this tag
+}
+
+// GRPCInspector decides on a gRPC call from its first request message (e.g. deny large queries, route by content)
+// Inspectors run in the order added: the first denial wins, tags are merged and the first route tag wins
+type GRPCInspector interface {
+	InspectGRPC(ctx context.Context, call *GRPCCall) GRPCVerdict
+}
+
+// GRPCInspectorFunc adapts a function to a GRPCInspector
+type GRPCInspectorFunc func(ctx context.Context, call *GRPCCall) GRPCVerdict
+
+// InspectGRPC calls f
+func (f GRPCInspectorFunc) InspectGRPC(ctx context.Context, call *GRPCCall) GRPCVerdict {
+	return f(ctx, call)
+}
+
+// Inspect adds inspectors shown the first request message of every call
+// Without inspectors, calls are proxied frame by frame without waiting for it
+// Must be called before GetServer
+func (p *GRPCProxy) Inspect(inspectors ...GRPCInspector) {
+	p.inspectors = append(p.inspectors, inspectors...)
+}
+
+// inspectFirstMessage reads the client's first request message and runs the inspectors on it
+// The returned frame must still be forwarded (nil when the client closed its side without sending)
+func (p *GRPCProxy) inspectFirstMessage(stream grpc.ServerStream, method string) (*rawFrame, GRPCVerdict, error) {
+	first := &rawFrame{}
+	if err := stream.RecvMsg(first); err != nil {
+		if err != io.EOF {
+			return nil, GRPCVerdict{}, status.Errorf(status.Code(err), "recv from client: %v", err)
+		}
+		first = nil
+	}
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	call := &GRPCCall{Network: p.network, FullMethod: method, Metadata: md}
+	if first != nil {
+		call.Message = first.payload.Materialize()
+		call.Size = len(call.Message)
+	}
+
+	var verdict GRPCVerdict
+	for _, inspector := range p.inspectors {
+		v := inspector.InspectGRPC(stream.Context(), call)
+		if v.Deny {
+			if first != nil {
+				first.free()
+			}
+			metrics.GRPCInspections.WithLabelValues(p.network, "deny").Inc()
+			if v.Code == codes.OK {
+				v.Code = codes.PermissionDenied
+			}
+			if v.Message == "" {
+				v.Message = "call refused by policy"
+			}
+			p.logger.Warn("gRPC call denied by inspector",
+				zap.String("network", p.network),
+				zap.String("method", method),
+				zap.Int("size", call.Size),
+			)
+			return nil, v, nil
+		}
+		for key, value := range v.Tags {
+			if verdict.Tags == nil {
+				verdict.Tags = make(map[string]string)
+			}
+			verdict.Tags[key] = value
+		}
+		if verdict.RouteTag == "" {
+			verdict.RouteTag = v.RouteTag
+		}
+	}
+
+	if len(verdict.Tags) > 0 {
+		metrics.GRPCInspections.WithLabelValues(p.network, "tag").Inc()
+	}
+	if verdict.RouteTag != "" {
+		metrics.GRPCInspections.WithLabelValues(p.network, "route").Inc()
+	}
+	return first, verdict, nil
+}
@@ -0,0 +1,292 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"sauron/abuse"
+	"sauron/config"
+	"sauron/metrics"
+	"sauron/ratelimit"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Built-in gRPC interceptors, selectable per network with grpc_interceptors
+const (
+	GRPCInterceptorAuth          = "auth"           // require a user token with grpc permission
+	GRPCInterceptorRateLimit     = "rate_limit"     // per-client request rate (rate_limit settings)
+	GRPCInterceptorLogging       = "logging"        // one log line per call
+	GRPCInterceptorMetadata      = "metadata"       // rewrite forwarded metadata (grpc_metadata)
+	GRPCInterceptorRingSignature = "ring_signature" // require a call signed by a ring_signing.trusted peer
+)
+
+// Use appends interceptors that run after the configured ones, closest to the proxy handler
+// Must be called before GetServer
+func (p *GRPCProxy) Use(interceptors ...grpc.StreamServerInterceptor) {
+	p.interceptors = append(p.interceptors, interceptors...)
+}
+
+// buildInterceptors returns the configured interceptor chain followed by those added with Use
+// The chain order is fixed when the server is created; interceptors read config on every call
+func (p *GRPCProxy) buildInterceptors(network config.Network) []grpc.StreamServerInterceptor {
+	// Connection limits always come first so they see the client's token, after refusing banned clients
+	chain := []grpc.StreamServerInterceptor{p.connLimitInterceptor}
+	if p.abuse != nil {
+		chain = append([]grpc.StreamServerInterceptor{p.banInterceptor}, chain...)
+	}
+
+	for _, name := range network.GRPCInterceptors {
+		switch name {
+		case GRPCInterceptorAuth:
+			chain = append(chain, p.authInterceptor)
+		case GRPCInterceptorRateLimit:
+			chain = append(chain, p.rateLimitInterceptor())
+		case GRPCInterceptorLogging:
+			chain = append(chain, p.loggingInterceptor)
+		case GRPCInterceptorMetadata:
+			chain = append(chain, p.metadataInterceptor)
+		case GRPCInterceptorRingSignature:
+			chain = append(chain, p.ringSignatureInterceptor)
+		default:
+			// Validation rejects unknown names, so only reachable for hand-built configs
+			p.logger.Warn("Unknown gRPC interceptor, skipping",
+				zap.String("network", p.network),
+				zap.String("interceptor", name),
+			)
+		}
+	}
+
+	return append(chain, p.interceptors...)
+}
+
+// SetAbuseDetector counts auth failures and rate limit refusals towards bans, and refuses banned clients
+// Must be called before GetServer
+func (p *GRPCProxy) SetAbuseDetector(detector *abuse.Detector) {
+	p.abuse = detector
+}
+
+// banInterceptor refuses calls from client IPs banned by the abuse detector
+func (p *GRPCProxy) banInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if p.abuse.Banned(grpcPeerIP(ss.Context())) {
+		metrics.AbuseRejections.WithLabelValues("grpc").Inc()
+		return status.Error(codes.PermissionDenied, "client banned")
+	}
+	return handler(srv, ss)
+}
+
+// contextStream overrides the context of a server stream (e.g. to rewrite incoming metadata)
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// authInterceptor requires "authorization: Bearer <token>" from a user allowed to use gRPC
+// The token is stripped before the call is forwarded to the backend
+func (p *GRPCProxy) authInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		metrics.AuthFailures.WithLabelValues("missing_token").Inc()
+		p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventAuthFailure)
+		return status.Error(codes.Unauthenticated, "authorization required")
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		metrics.AuthFailures.WithLabelValues("invalid_format").Inc()
+		p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventAuthFailure)
+		return status.Error(codes.Unauthenticated, "invalid authorization format, expected: Bearer <token>")
+	}
+
+	user := p.configLoader.Get().FindUser(token)
+	if user == nil {
+		metrics.AuthFailures.WithLabelValues("invalid_token").Inc()
+		p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventAuthFailure)
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if !user.Active(time.Now()) {
+		metrics.AuthFailures.WithLabelValues("expired_token").Inc()
+		p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventAuthFailure)
+		return status.Error(codes.Unauthenticated, "token expired or not yet valid")
+	}
+	if !user.GRPC {
+		metrics.AuthFailures.WithLabelValues("forbidden_type").Inc()
+		return status.Error(codes.PermissionDenied, "user not allowed to use gRPC")
+	}
+	if len(user.GRPCServices) > 0 && !slices.ContainsFunc(user.GRPCServices, func(prefix string) bool {
+		return matchServicePrefix(strings.TrimPrefix(info.FullMethod, "/"), prefix)
+	}) {
+		metrics.AuthFailures.WithLabelValues("forbidden_method").Inc()
+		return status.Errorf(codes.PermissionDenied, "user not allowed to call %s", info.FullMethod)
+	}
+
+	md = md.Copy()
+	md.Delete("authorization")
+	ctx := metadata.NewIncomingContext(ss.Context(), md)
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// ringSignatureInterceptor only lets through calls signed by a ring_signing.trusted peer
+// The signature metadata is stripped before the call is forwarded to the backend
+func (p *GRPCProxy) ringSignatureInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	reason, ok := verifyRingSignature(p.configLoader.Get().RingSigning,
+		first(RingNameHeader), first(RingTimestampHeader), first(RingSignatureHeader),
+		grpcSignedMethod, info.FullMethod, emptyBodyHash, time.Now())
+	if !ok {
+		metrics.AuthFailures.WithLabelValues(reason).Inc()
+		p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventAuthFailure)
+		return status.Error(codes.Unauthenticated, "valid ring signature required")
+	}
+
+	md = md.Copy()
+	stripRingSignatureMetadata(md)
+	ctx := metadata.NewIncomingContext(ss.Context(), md)
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// rateLimitInterceptor limits calls per client address using the rate_limit settings
+func (p *GRPCProxy) rateLimitInterceptor() grpc.StreamServerInterceptor {
+	cfg := p.configLoader.Get()
+	reqPerSec := cfg.RateLimit.RequestsPerSecond
+	if reqPerSec == 0 {
+		reqPerSec = 10 // default: 10 requests per second
+	}
+	burst := cfg.RateLimit.Burst
+	if burst == 0 {
+		burst = reqPerSec * 2 // default: 2x burst
+	}
+
+	limiter := ratelimit.New(reqPerSec, burst, cfg.RateLimit.MaxEntries, nil)
+	p.limiters = append(p.limiters, limiter)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !limiter.AllowKey(grpcPeerIP(ss.Context())) {
+			p.logger.Warn("gRPC rate limit exceeded",
+				zap.String("network", p.network),
+				zap.String("method", info.FullMethod),
+				zap.String("peer", grpcPeerIP(ss.Context())),
+			)
+			p.abuse.RecordIP(grpcPeerIP(ss.Context()), abuse.EventRateLimited)
+			return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+		return handler(srv, ss)
+	}
+}
+
+// loggingInterceptor logs each call with its outcome and duration
+func (p *GRPCProxy) loggingInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+
+	p.logger.Info("gRPC call",
+		zap.String("network", p.network),
+		zap.String("method", info.FullMethod),
+		zap.String("peer", grpcPeerIP(ss.Context())),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	return err
+}
+
+// metadataInterceptor applies the network's grpc_metadata to the forwarded call
+// Keys with a value are set (replacing what the client sent), keys with an empty value are removed
+func (p *GRPCProxy) metadataInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	rewrites := p.networkConfig().GRPCMetadata
+	if len(rewrites) == 0 {
+		return handler(srv, ss)
+	}
+
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	md = md.Copy()
+	for key, value := range rewrites {
+		if value == "" {
+			md.Delete(key)
+			continue
+		}
+		md.Set(key, value)
+	}
+	ctx := metadata.NewIncomingContext(ss.Context(), md)
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// networkConfig returns the current configuration of the network this proxy serves
+func (p *GRPCProxy) networkConfig() config.Network {
+	for _, network := range p.configLoader.Get().Networks {
+		if network.Name == p.network {
+			return network
+		}
+	}
+	return config.Network{}
+}
+
+// serviceAllowed applies grpc_deny_services and grpc_allow_services to a full method name
+// ("/cosmos.tx.v1beta1.Service/BroadcastTx"); returns the rule that refused it, if any
+func serviceAllowed(network config.Network, fullMethod string) (string, bool) {
+	name := strings.TrimPrefix(fullMethod, "/")
+
+	for _, prefix := range network.GRPCDenyServices {
+		if matchServicePrefix(name, prefix) {
+			return strings.Trim(prefix, "/."), false
+		}
+	}
+	if len(network.GRPCAllowServices) == 0 {
+		return "", true
+	}
+	for _, prefix := range network.GRPCAllowServices {
+		if matchServicePrefix(name, prefix) {
+			return "", true
+		}
+	}
+	return "not_allowed", false
+}
+
+// matchServicePrefix reports whether a method name falls under a dotted prefix
+// "cosmos.tx" matches "cosmos.tx.v1beta1.Service/X" but not "cosmos.txs.Service/X"
+func matchServicePrefix(name, prefix string) bool {
+	prefix = strings.Trim(prefix, "/.")
+	if prefix == "" || !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	next := name[len(prefix)]
+	return next == '.' || next == '/'
+}
+
+// grpcPeerIP returns the address of the directly connected gRPC client
+func grpcPeerIP(ctx context.Context) string {
+	pr, ok := peer.FromContext(ctx)
+	if !ok || pr.Addr == nil {
+		return ""
+	}
+	ip, _, err := net.SplitHostPort(pr.Addr.String())
+	if err != nil {
+		return pr.Addr.String()
+	}
+	return ip
+}
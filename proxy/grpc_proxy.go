@@ -1,7 +1,9 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -9,8 +11,11 @@ import (
 	"sync"
 	"time"
 
+	"sauron/abuse"
+	"sauron/clock"
 	"sauron/config"
 	"sauron/metrics"
+	"sauron/ratelimit"
 	"sauron/selector"
 	"sauron/storage"
 
@@ -20,29 +25,44 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/experimental"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/mem"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // rawFrame represents a raw gRPC frame for transparent proxying
+// The payload references pooled buffers: it is released when the frame is sent, or with free when it is not
 type rawFrame struct {
-	payload []byte
+	payload mem.BufferSlice
+}
+
+// free releases the payload's buffers back to the pool
+func (f *rawFrame) free() {
+	f.payload.Free()
+	f.payload = nil
 }
 
 // rawCodec implements a codec that simply passes through raw bytes
 // This enables transparent proxying without needing to know the proto types
+// Frames stay in gRPC's pooled buffers end to end instead of being copied into a fresh slice per message
 type rawCodec struct{}
 
-func (c *rawCodec) Marshal(v interface{}) ([]byte, error) {
+// Marshal hands the frame's buffers to gRPC, which frees them once written
+func (c *rawCodec) Marshal(v any) (mem.BufferSlice, error) {
 	if frame, ok := v.(*rawFrame); ok {
-		return frame.payload, nil
+		payload := frame.payload
+		frame.payload = nil
+		return payload, nil
 	}
 	return nil, fmt.Errorf("invalid type for raw codec: %T", v)
 }
 
-func (c *rawCodec) Unmarshal(data []byte, v interface{}) error {
+// Unmarshal keeps a reference to the received buffers, which gRPC frees after Unmarshal returns
+func (c *rawCodec) Unmarshal(data mem.BufferSlice, v any) error {
 	if frame, ok := v.(*rawFrame); ok {
+		data.Ref()
 		frame.payload = data
 		return nil
 	}
@@ -54,28 +74,41 @@ func (c *rawCodec) Name() string {
 }
 
 func init() {
-	encoding.RegisterCodec(&rawCodec{})
+	encoding.RegisterCodecV2(&rawCodec{})
 }
 
 // GRPCProxy handles gRPC proxying with transparent request forwarding
 // The Eye's gaze through the gRPC realm
 type GRPCProxy struct {
-	selector      *selector.Selector
+	selector      selector.NodeSelector
 	configLoader  *config.Loader
 	endpointStore *storage.ExternalEndpointStore
+	inflight      *storage.InflightTracker
 	logger        *zap.Logger
-	network       string // The network this proxy serves
+	network       string          // The network this proxy serves
+	abuse         *abuse.Detector // Counts abusive calls, if abuse_detection is enabled
+
+	// Interceptors added with Use, and rate limiters owned by the configured chain
+	interceptors []grpc.StreamServerInterceptor
+	limiters     []*ratelimit.Limiter
+
+	// Inspectors shown the first request message of each call, added with Inspect
+	inspectors []GRPCInspector
 
 	// Connection pool for backend connections (optimization)
-	connPool map[string]*grpc.ClientConn
+	connPool map[string]*pooledConn
 	connMu   sync.RWMutex
+
+	// Open streams, watched by the leak detector (SweepStreams)
+	streams openStreams
 }
 
 // NewGRPCProxy creates a new gRPC proxy for a specific network
 func NewGRPCProxy(
-	selector *selector.Selector,
+	selector selector.NodeSelector,
 	configLoader *config.Loader,
 	endpointStore *storage.ExternalEndpointStore,
+	inflight *storage.InflightTracker,
 	logger *zap.Logger,
 	network string,
 ) *GRPCProxy {
@@ -83,9 +116,10 @@ func NewGRPCProxy(
 		selector:      selector,
 		configLoader:  configLoader,
 		endpointStore: endpointStore,
+		inflight:      inflight,
 		logger:        logger,
 		network:       network,
-		connPool:      make(map[string]*grpc.ClientConn),
+		connPool:      make(map[string]*pooledConn),
 	}
 }
 
@@ -93,14 +127,15 @@ func NewGRPCProxy(
 func (p *GRPCProxy) GetServer() *grpc.Server {
 	// Get network config for message size limits
 	cfg := p.configLoader.Get()
-	var maxRecvSize, maxSendSize int
+	var networkCfg config.Network
 	for _, network := range cfg.Networks {
 		if network.Name == p.network {
-			maxRecvSize = network.GRPCMaxRecvMsgSize
-			maxSendSize = network.GRPCMaxSendMsgSize
+			networkCfg = network
 			break
 		}
 	}
+	maxRecvSize := networkCfg.GRPCMaxRecvMsgSize
+	maxSendSize := networkCfg.GRPCMaxSendMsgSize
 
 	// Default to 100MB if not configured or set to 0
 	if maxRecvSize == 0 {
@@ -115,20 +150,46 @@ func (p *GRPCProxy) GetServer() *grpc.Server {
 		grpc.UnknownServiceHandler(p.proxyHandler),
 		grpc.MaxRecvMsgSize(maxRecvSize),
 		grpc.MaxSendMsgSize(maxSendSize),
-		grpc.ForceServerCodec(&rawCodec{}), // Use raw codec for transparent proxying
+		grpc.ForceServerCodecV2(&rawCodec{}), // Use raw codec for transparent proxying
+		experimental.BufferPool(grpcFramePool),
 	}
+	opts = append(opts, serverLimits(networkCfg.GRPCServer)...)
+
+	// Cross-cutting concerns run as stream interceptors in front of proxyHandler
+	opts = append(opts, grpc.ChainStreamInterceptor(p.buildInterceptors(networkCfg)...))
 
 	server := grpc.NewServer(opts...)
 	return server
 }
 
+// serverLimits turns grpc_server settings into server options; unset values keep gRPC's defaults
+func serverLimits(limits config.GRPCServer) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             limits.KeepaliveMinTime,
+			PermitWithoutStream: limits.KeepaliveWithoutCalls,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     limits.MaxConnectionIdle,
+			MaxConnectionAge:      limits.MaxConnectionAge,
+			MaxConnectionAgeGrace: limits.MaxConnectionAgeGrace,
+		}),
+	}
+	if limits.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(limits.MaxConcurrentStreams))
+	}
+	return opts
+}
+
 // getOrCreateConnection gets a pooled connection or creates a new one (optimization)
-func (p *GRPCProxy) getOrCreateConnection(targetAddr string, useInsecure bool) (*grpc.ClientConn, error) {
+// The connection counts the caller's stream until release, so rebalancing never closes it mid-stream
+func (p *GRPCProxy) getOrCreateConnection(targetAddr string, useInsecure bool) (*pooledConn, error) {
 	// Check if we have a cached connection
 	p.connMu.RLock()
 	if conn, exists := p.connPool[targetAddr]; exists {
 		// Verify connection is still valid
 		if conn.GetState().String() != "SHUTDOWN" {
+			conn.streams.Add(1)
 			p.connMu.RUnlock()
 			return conn, nil
 		}
@@ -141,6 +202,7 @@ func (p *GRPCProxy) getOrCreateConnection(targetAddr string, useInsecure bool) (
 
 	// Double-check after acquiring write lock
 	if conn, exists := p.connPool[targetAddr]; exists && conn.GetState().String() != "SHUTDOWN" {
+		conn.streams.Add(1)
 		return conn, nil
 	}
 
@@ -181,8 +243,9 @@ func (p *GRPCProxy) getOrCreateConnection(targetAddr string, useInsecure bool) (
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(maxRecvSize), // Use configured limit for backend connections
 			grpc.MaxCallSendMsgSize(maxSendSize), // Use configured limit for backend connections
-			grpc.ForceCodec(&rawCodec{}),         // Use raw codec for transparent proxying
+			grpc.ForceCodecV2(&rawCodec{}),       // Use raw codec for transparent proxying
 		),
+		experimental.WithBufferPool(grpcFramePool),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                10 * time.Second, // Send keepalive pings every 10 seconds
 			Timeout:             3 * time.Second,  // Wait 3 seconds for ping ack
@@ -197,11 +260,13 @@ func (p *GRPCProxy) getOrCreateConnection(targetAddr string, useInsecure bool) (
 	}
 
 	// Create connection using grpc.NewClient (replaces deprecated DialContext)
-	conn, err := grpc.NewClient(target, opts...)
+	client, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	conn := &pooledConn{ClientConn: client, addr: targetAddr, opened: time.Now()}
+	conn.streams.Add(1)
 	p.connPool[targetAddr] = conn
 	return conn, nil
 }
@@ -222,8 +287,52 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 		zap.String("network", p.network),
 	)
 
-	// Select best node
-	nodeMetrics, nodeName, decision := p.selector.GetBestNode(p.network, "grpc")
+	// Enforce the network's service allow/deny list before touching any backend
+	if rule, allowed := serviceAllowed(p.networkConfig(), method); !allowed {
+		metrics.GRPCDeniedCalls.WithLabelValues(p.network, rule).Inc()
+		p.logger.Warn("gRPC call denied by service policy",
+			zap.String("network", p.network),
+			zap.String("method", method),
+			zap.String("rule", rule),
+		)
+		return status.Errorf(codes.PermissionDenied, "method %s is not allowed on this gateway", method)
+	}
+
+	// Inspectors see the first request message before a backend is chosen; it is forwarded first
+	var first *rawFrame
+	var verdict GRPCVerdict
+	if len(p.inspectors) > 0 {
+		var err error
+		first, verdict, err = p.inspectFirstMessage(stream, method)
+		if err != nil {
+			return err
+		}
+		if verdict.Deny {
+			return status.Error(verdict.Code, verdict.Message)
+		}
+	}
+	defer func() {
+		// Not forwarded (the call failed before reaching a backend)
+		if first != nil {
+			first.free()
+		}
+	}()
+
+	// Select best node: an inspector's tagged pool, the node the client's last broadcast
+	// pinned it to (read-your-writes), or the best available (the client's own with sticky sessions)
+	cfg := p.configLoader.Get()
+	session := sessionFrom(stream.Context())
+	var nodeMetrics *storage.NodeMetrics
+	var nodeName string
+	var decision *selector.SelectionDecision
+	if verdict.RouteTag != "" {
+		nodeMetrics, nodeName, decision = p.selector.GetBestTaggedNode(p.network, "grpc", verdict.RouteTag)
+	} else {
+		nodeMetrics, nodeName, decision = pinnedNode(p.selector, cfg, p.logger, p.network, "grpc", session)
+		if nodeMetrics == nil {
+			nodeMetrics, nodeName, decision = bestNode(p.selector, p.network, "grpc", stickyClientFrom(stream.Context()))
+		}
+	}
 	if nodeMetrics == nil || nodeName == "" {
 		p.logger.Warn("No available nodes for gRPC routing",
 			zap.String("network", p.network),
@@ -232,7 +341,7 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 	}
 
 	// Get endpoint URL
-	targetAddr := p.selector.GetEndpointURL(nodeName, "grpc")
+	targetAddr := p.selector.GetEndpointURL(p.network, nodeName, "grpc")
 	if targetAddr == "" {
 		p.logger.Error("Failed to get gRPC endpoint",
 			zap.String("node", nodeName),
@@ -253,19 +362,44 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 	// Get or create pooled connection (optimization)
 	conn, err := p.getOrCreateConnection(targetAddr, useInsecure)
 	if err != nil {
+		failure := classifyError(err)
 		p.logger.Error("Failed to dial backend",
 			zap.String("target", targetAddr),
 			zap.Error(err),
+			zap.String("failure", failure),
+			zap.Bool("retryable", retryableFailure(failure)),
 		)
-		metrics.ProxyErrors.WithLabelValues(p.network, nodeName, "grpc", "unavailable", "dial_error").Inc()
+		metrics.ProxyErrors.WithLabelValues(p.network, nodeName, "grpc", "unavailable", failure).Inc()
+		p.selector.RecordOutcome(p.network, "grpc", nodeName, true)
 		return status.Errorf(codes.Unavailable, "failed to connect to backend: %v", err)
 	}
+	defer conn.release()
+
+	// Every candidate lags the network head: still serve, but tell the client in the response header
+	if decision != nil && decision.Stale {
+		_ = grpc.SetHeader(stream.Context(), metadata.Pairs(StaleMetadataKey, "true"))
+	}
+
+	// Count the call against the node while it is in flight (read by load-aware selection, e.g. egress overflow)
+	p.inflight.Increment(p.network, nodeName, "grpc")
+	defer p.inflight.Decrement(p.network, nodeName, "grpc")
 
-	// Forward metadata
+	// Forward metadata, signed when the node belongs to a peer ring that verifies signatures
 	ctx := stream.Context()
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		ctx = metadata.NewOutgoingContext(ctx, md)
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	stripRingSignatureMetadata(md)
+	for key, value := range verdict.Tags {
+		md.Set(key, value)
 	}
+	if secret, ok := signingSecret(cfg, p.endpointStore, p.network, "grpc", nodeName); ok {
+		signRequest(func(key, value string) { md.Set(key, value) }, cfg.RingSigning.Name, secret, grpcSignedMethod, method, emptyBodyHash, time.Now())
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	// The leak detector cancels the backend stream with errStreamIdle when force-closing it
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 
 	// Create client stream
 	clientStream, err := conn.NewStream(ctx, &grpc.StreamDesc{
@@ -278,7 +412,7 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 			zap.String("method", method),
 			zap.Error(err),
 		)
-		metrics.ProxyErrors.WithLabelValues(p.network, nodeName, "grpc", "unavailable", "stream_error").Inc()
+		metrics.ProxyErrors.WithLabelValues(p.network, nodeName, "grpc", "unavailable", classifyGRPC(err)).Inc()
 		return status.Errorf(codes.Internal, "failed to create stream: %v", err)
 	}
 
@@ -287,15 +421,32 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 		zap.String("method", method),
 	)
 
+	// Track the open stream until both directions finish or one fails
+	closeStream := trackStreamOpen(p.network, nodeName, streamKindGRPC)
+	watched := &openStream{node: nodeName, method: method, opened: clock.Elapsed(), cancel: cancel}
+	defer p.streams.add(watched)()
+
 	// Create bidirectional forwarding using raw frames
 	// When one goroutine fails, we exit immediately without waiting for both
 	errChan := make(chan error, 2)
 
-	// Forward client -> server
+	// Forward client -> server, starting with the message read for inspectors
+	pending := first
+	first = nil
 	go func() {
 		p.logger.Debug("Started client->server forwarding goroutine")
 		defer p.logger.Debug("Exiting client->server forwarding goroutine")
 
+		if pending != nil {
+			watched.touch()
+			if err := clientStream.SendMsg(pending); err != nil {
+				pending.free()
+				p.logger.Error("Error sending to backend", zap.Error(err))
+				errChan <- &streamError{side: "backend", err: fmt.Errorf("send to backend: %w", err)}
+				return
+			}
+		}
+
 		for {
 			frame := &rawFrame{}
 			if err := stream.RecvMsg(frame); err != nil {
@@ -306,14 +457,16 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 					return
 				}
 				p.logger.Error("Error receiving from client", zap.Error(err))
-				errChan <- fmt.Errorf("recv from client: %w", err)
+				errChan <- &streamError{side: "client", err: fmt.Errorf("recv from client: %w", err)}
 				return
 			}
-			p.logger.Debug("Received frame from client", zap.Int("payload_size", len(frame.payload)))
+			p.logger.Debug("Received frame from client", zap.Int("payload_size", frame.payload.Len()))
+			watched.touch()
 
 			if err := clientStream.SendMsg(frame); err != nil {
+				frame.free()
 				p.logger.Error("Error sending to backend", zap.Error(err))
-				errChan <- fmt.Errorf("send to backend: %w", err)
+				errChan <- &streamError{side: "backend", err: fmt.Errorf("send to backend: %w", err)}
 				return
 			}
 		}
@@ -333,14 +486,16 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 					return
 				}
 				p.logger.Error("Error receiving from backend", zap.Error(err))
-				errChan <- fmt.Errorf("recv from backend: %w", err)
+				errChan <- &streamError{side: "backend", err: fmt.Errorf("recv from backend: %w", err)}
 				return
 			}
-			p.logger.Debug("Received frame from backend", zap.Int("payload_size", len(frame.payload)))
+			p.logger.Debug("Received frame from backend", zap.Int("payload_size", frame.payload.Len()))
+			watched.touch()
 
 			if err := stream.SendMsg(frame); err != nil {
+				frame.free()
 				p.logger.Error("Error sending to client", zap.Error(err))
-				errChan <- fmt.Errorf("send to client: %w", err)
+				errChan <- &streamError{side: "client", err: fmt.Errorf("send to client: %w", err)}
 				return
 			}
 		}
@@ -367,7 +522,16 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 		}
 	}
 
+	// A stream the leak detector closed ends with its own status and close reason
+	closeReason := grpcCloseReason(proxyErr)
+	idleClosed := errors.Is(context.Cause(ctx), errStreamIdle)
+	if idleClosed {
+		proxyErr = status.Error(codes.Aborted, errStreamIdle.Error())
+		closeReason = "idle_closed"
+	}
+
 	// Record metrics
+	closeStream(closeReason)
 	duration := time.Since(start)
 	grpcStatus := status.Code(proxyErr)
 	statusStr := strconv.Itoa(int(grpcStatus))
@@ -379,19 +543,45 @@ func (p *GRPCProxy) proxyHandler(srv interface{}, stream grpc.ServerStream) erro
 		statusStr,
 	).Observe(duration.Seconds())
 
+	observeUpstreamLatency(p.network, "grpc", nodeName, duration)
 	metrics.NodeRequests.WithLabelValues(p.network, nodeName, "grpc", method).Inc()
 
+	// gRPC codes that map to 5xx: Internal(13), Unavailable(14), DataLoss(15), Unknown(2)
+	serverError := grpcStatus == codes.Internal || grpcStatus == codes.Unavailable ||
+		grpcStatus == codes.DataLoss || grpcStatus == codes.Unknown
+
+	// A client that hung up is not the backend's fault
+	aborted := proxyErr != nil && clientAborted(stream.Context(), proxyErr)
+	if aborted {
+		serverError = false
+	}
+	if !aborted && !idleClosed {
+		p.selector.RecordLatency(p.network, "grpc", nodeName, duration)
+	}
+	metrics.ObserveSLO(cfg.SLOs, p.network, "grpc", method, duration, serverError)
+	p.selector.RecordOutcome(p.network, "grpc", nodeName, serverError)
+
+	// Pin the client to this node after a successful broadcast so it can read its own write
+	if cfg.ReadYourWrites.Enabled && proxyErr == nil && isGRPCBroadcast(method) {
+		pinSession(p.network, session, nodeName, nodeMetrics.Height, cfg.ReadYourWrites.Window)
+	}
+
 	if proxyErr != nil {
-		metrics.ProxyErrors.WithLabelValues(p.network, nodeName, "grpc", statusStr, "proxy_error").Inc()
-		p.logger.Error("gRPC proxy error",
+		failure := classifyGRPC(proxyErr)
+		logf := p.logger.Error
+		if aborted {
+			failure = FailureClientAbort
+			logf = p.logger.Debug
+		}
+		metrics.ProxyErrors.WithLabelValues(p.network, nodeName, "grpc", statusStr, failure).Inc()
+		logf("gRPC proxy error",
 			zap.String("method", method),
 			zap.Error(proxyErr),
+			zap.String("failure", failure),
 		)
 
 		// Track 5xx-equivalent gRPC errors for external endpoints
-		// gRPC codes that map to 5xx: Internal(13), Unavailable(14), DataLoss(15), Unknown(2)
-		if grpcStatus == codes.Internal || grpcStatus == codes.Unavailable ||
-			grpcStatus == codes.DataLoss || grpcStatus == codes.Unknown {
+		if serverError {
 			if p.endpointStore != nil {
 				if p.endpointStore.TrackProxyError(p.network, "grpc", targetAddr) {
 					p.logger.Info("Tracked gRPC 5xx-equivalent error for external endpoint",
@@ -436,7 +626,7 @@ func (p *GRPCProxy) shouldUseInsecureForNode(nodeName string) bool {
 	cfg := p.configLoader.Get()
 	// Check internal nodes
 	for _, node := range cfg.Internals {
-		if node.Name == nodeName {
+		if node.Name == nodeName && node.Network == p.network {
 			return node.GRPCInsecure
 		}
 	}
@@ -444,19 +634,23 @@ func (p *GRPCProxy) shouldUseInsecureForNode(nodeName string) bool {
 	return p.shouldUseInsecure()
 }
 
-// Close closes all pooled connections
+// Close closes all pooled connections and stops interceptor rate limiters
 func (p *GRPCProxy) Close() error {
+	for _, limiter := range p.limiters {
+		limiter.Stop()
+	}
+
 	p.connMu.Lock()
 	defer p.connMu.Unlock()
 
 	for addr, conn := range p.connPool {
-		if err := conn.Close(); err != nil {
+		if err := conn.ClientConn.Close(); err != nil {
 			p.logger.Warn("Failed to close gRPC connection",
 				zap.String("addr", addr),
 				zap.Error(err),
 			)
 		}
 	}
-	p.connPool = make(map[string]*grpc.ClientConn)
+	p.connPool = make(map[string]*pooledConn)
 	return nil
 }
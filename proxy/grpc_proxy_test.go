@@ -0,0 +1,221 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"sauron/clock"
+	"sauron/config"
+	"sauron/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/mem"
+)
+
+func TestRawCodecKeepsPooledPayload(t *testing.T) {
+	codec := &rawCodec{}
+	want := bytes.Repeat([]byte{0x2a}, 10<<10)
+
+	// gRPC frees the received buffers once Unmarshal returns; the frame must still hold them
+	data := mem.BufferSlice{mem.Copy(want, grpcFramePool)}
+	frame := &rawFrame{}
+	if err := codec.Unmarshal(data, frame); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	data.Free()
+
+	out, err := codec.Marshal(frame)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if frame.payload != nil {
+		t.Error("Marshal should hand the payload over to gRPC")
+	}
+	if got := out.Materialize(); !bytes.Equal(got, want) {
+		t.Errorf("payload changed: got %d bytes, want %d", len(got), len(want))
+	}
+	out.Free()
+
+	if err := codec.Unmarshal(nil, struct{}{}); err == nil {
+		t.Error("Unmarshal should reject non-frame values")
+	}
+}
+
+func TestCappedBufferPool(t *testing.T) {
+	pool := &cappedBufferPool{pool: mem.NewTieredBufferPool(4 << 10), max: 4 << 10}
+
+	small := pool.Get(100)
+	if len(*small) != 100 || cap(*small) != 4<<10 {
+		t.Errorf("small buffer: len %d cap %d, want len 100 from the 4KB class", len(*small), cap(*small))
+	}
+	pool.Put(small)
+
+	large := pool.Get(8 << 10)
+	if len(*large) != 8<<10 {
+		t.Errorf("large buffer: len %d, want %d", len(*large), 8<<10)
+	}
+	// Dropped rather than pooled; must not panic or end up in the 4KB class
+	pool.Put(large)
+	if again := pool.Get(4 << 10); cap(*again) != 4<<10 {
+		t.Errorf("pooled buffer cap %d, want %d", cap(*again), 4<<10)
+	}
+}
+
+// fakeServerStream delivers one message (or EOF when nil) to the proxy
+type fakeServerStream struct {
+	grpc.ServerStream
+	msg []byte
+}
+
+func (s *fakeServerStream) Context() context.Context { return context.Background() }
+
+func (s *fakeServerStream) RecvMsg(m any) error {
+	if s.msg == nil {
+		return io.EOF
+	}
+	return (&rawCodec{}).Unmarshal(mem.BufferSlice{mem.SliceBuffer(s.msg)}, m)
+}
+
+func TestInspectFirstMessage(t *testing.T) {
+	p := &GRPCProxy{network: "pocket", logger: zap.NewNop()}
+	var seen *GRPCCall
+	p.Inspect(
+		GRPCInspectorFunc(func(ctx context.Context, call *GRPCCall) GRPCVerdict {
+			seen = call
+			return GRPCVerdict{Tags: map[string]string{"x-size": "small"}, RouteTag: "archive"}
+		}),
+		GRPCInspectorFunc(func(ctx context.Context, call *GRPCCall) GRPCVerdict {
+			if call.Size > 4 {
+				return GRPCVerdict{Deny: true}
+			}
+			return GRPCVerdict{Tags: map[string]string{"x-checked": "true"}, RouteTag: "fast"}
+		}),
+	)
+
+	first, verdict, err := p.inspectFirstMessage(&fakeServerStream{msg: []byte("abc")}, "/cosmos.bank.v1beta1.Query/Balance")
+	if err != nil || first == nil {
+		t.Fatalf("Expected the first message, got %v", err)
+	}
+	if !bytes.Equal(first.payload.Materialize(), []byte("abc")) || seen.Size != 3 || seen.FullMethod != "/cosmos.bank.v1beta1.Query/Balance" {
+		t.Errorf("Unexpected call: %+v", seen)
+	}
+	first.free()
+	if verdict.Deny || verdict.RouteTag != "archive" || len(verdict.Tags) != 2 {
+		t.Errorf("Expected merged tags and the first route tag, got %+v", verdict)
+	}
+
+	first, verdict, _ = p.inspectFirstMessage(&fakeServerStream{msg: []byte("too large")}, "/cosmos.tx.v1beta1.Service/Simulate")
+	if first != nil || !verdict.Deny || verdict.Code != codes.PermissionDenied {
+		t.Errorf("Expected a PermissionDenied denial, got %+v", verdict)
+	}
+
+	first, _, err = p.inspectFirstMessage(&fakeServerStream{}, "/cosmos.bank.v1beta1.Query/Balance")
+	if err != nil || first != nil || seen.Message != nil {
+		t.Errorf("Expected no message when the client sent none, got %v", err)
+	}
+}
+
+// TestSweepStreams tests that only streams idle beyond max_idle are reported, and closed with force_close
+func TestSweepStreams(t *testing.T) {
+	settings := config.StreamLeaks{Enabled: true, MaxIdle: time.Minute}
+	loader, err := config.NewStaticLoader(&config.Config{
+		GRPC:        true,
+		Listen:      ":3000",
+		Timeouts:    config.Timeouts{HealthCheck: 5 * time.Second, Proxy: time.Second},
+		Networks:    []config.Network{{Name: "pocket", GRPCListen: ":9090"}},
+		Internals:   []config.Node{{Name: "node-1", GRPC: "localhost:9091", Network: "pocket"}},
+		StreamLeaks: settings,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config loader: %v", err)
+	}
+	p := NewGRPCProxy(nil, loader, nil, nil, zap.NewNop(), "pocket")
+
+	watch := func(idle time.Duration) context.Context {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		stream := &openStream{node: "node-1", method: "/cosmos.base.tendermint.v1beta1.Service/GetLatestBlock", opened: clock.Elapsed() - 2*idle, cancel: cancel}
+		t.Cleanup(p.streams.add(stream))
+		stream.active.Store(int64(clock.Elapsed() - idle))
+		return ctx
+	}
+	busy := watch(time.Second)
+	idle := watch(2 * time.Minute)
+
+	p.SweepStreams()
+	if got := testutil.ToFloat64(metrics.GRPCIdleStreams.WithLabelValues("pocket")); got != 1 {
+		t.Errorf("Expected 1 idle stream, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.GRPCOldestStreamAge.WithLabelValues("pocket")); got < 240 {
+		t.Errorf("Expected the oldest stream age to be at least 4m, got %vs", got)
+	}
+	if idle.Err() != nil {
+		t.Fatal("Expected idle stream to stay open without force_close")
+	}
+
+	settings.ForceClose = true
+	cfg := loader.Get()
+	cfg.StreamLeaks = settings
+	if err := loader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable force_close: %v", err)
+	}
+	p.SweepStreams()
+	if !errors.Is(context.Cause(idle), errStreamIdle) {
+		t.Errorf("Expected idle stream to be closed by the leak detector, got %v", context.Cause(idle))
+	}
+	if busy.Err() != nil {
+		t.Errorf("Expected busy stream to stay open, got %v", busy.Err())
+	}
+}
+
+// TestGRPCProxyRebalanceConnections tests that a retired pooled connection leaves the pool at once
+// but only closes after its last stream
+func TestGRPCProxyRebalanceConnections(t *testing.T) {
+	loader, err := config.NewStaticLoader(&config.Config{
+		GRPC:      true,
+		Listen:    ":3000",
+		Timeouts:  config.Timeouts{HealthCheck: 5 * time.Second, Proxy: time.Second},
+		Networks:  []config.Network{{Name: "pocket", GRPCListen: ":9090"}},
+		Internals: []config.Node{{Name: "node-1", GRPC: "localhost:9091", Network: "pocket"}},
+		Transport: config.Transport{Rebalance: config.Rebalance{Interval: time.Second, Fraction: 1, MinAge: time.Nanosecond}},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config loader: %v", err)
+	}
+	p := NewGRPCProxy(nil, loader, nil, nil, zap.NewNop(), "pocket")
+	defer func() { _ = p.Close() }()
+
+	conn, err := p.getOrCreateConnection("localhost:9091", true)
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+
+	p.RebalanceConnections()
+	if len(p.connPool) != 0 {
+		t.Fatalf("Expected the connection to leave the pool, got %d pooled", len(p.connPool))
+	}
+	if conn.GetState() == connectivity.Shutdown {
+		t.Fatal("Expected the connection to stay open while a stream uses it")
+	}
+
+	conn.release()
+	if conn.GetState() != connectivity.Shutdown {
+		t.Errorf("Expected the connection to close after its last stream, got %s", conn.GetState())
+	}
+
+	next, err := p.getOrCreateConnection("localhost:9091", true)
+	if err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	defer next.release()
+	if next == conn {
+		t.Error("Expected a new connection after rebalancing")
+	}
+}
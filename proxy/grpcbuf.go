@@ -0,0 +1,47 @@
+package proxy
+
+import "google.golang.org/grpc/mem"
+
+// grpcFrameSizes are the size classes gRPC frames are pooled in, from small unary replies up to block-sized messages
+var grpcFrameSizes = []int{
+	256,
+	4 << 10,
+	16 << 10,
+	32 << 10,
+	256 << 10,
+	1 << 20,
+	4 << 20,
+}
+
+// maxPooledGRPCFrame caps pooled frame buffers: larger frames are allocated per message and left to the GC
+// Keeps a burst of huge messages (genesis, state exports) from pinning their buffers in the pool
+const maxPooledGRPCFrame = 4 << 20
+
+// grpcFramePool is shared by the gRPC proxy servers and backend connections
+var grpcFramePool mem.BufferPool = &cappedBufferPool{
+	pool: mem.NewTieredBufferPool(grpcFrameSizes...),
+	max:  maxPooledGRPCFrame,
+}
+
+// cappedBufferPool pools buffers up to max bytes and allocates the rest
+type cappedBufferPool struct {
+	pool mem.BufferPool
+	max  int
+}
+
+// Get returns a buffer of length size, from the pool when it is within the cap
+func (p *cappedBufferPool) Get(size int) *[]byte {
+	if size > p.max {
+		buf := make([]byte, size)
+		return &buf
+	}
+	return p.pool.Get(size)
+}
+
+// Put returns a buffer to the pool; buffers over the cap are dropped
+func (p *cappedBufferPool) Put(buf *[]byte) {
+	if cap(*buf) > p.max {
+		return
+	}
+	p.pool.Put(buf)
+}
@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"sauron/clientip"
+	"sauron/config"
+)
+
+// viaPseudonym identifies Sauron in the Via header
+const viaPseudonym = "sauron"
+
+// Set on responses served while every candidate lagged the network head (stale_threshold)
+const (
+	StaleHeader      = "X-Sauron-Stale" // HTTP response header
+	StaleMetadataKey = "x-sauron-stale" // gRPC response header metadata
+)
+
+// hopByHopHeaders are connection-specific headers that must not be forwarded (RFC 9110 §7.6.1)
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection", // non-standard but still sent by some clients
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopByHopHeaders strips hop-by-hop headers, including any listed in Connection
+// keepUpgrade retains Connection and Upgrade so a WebSocket handshake can be forwarded
+func removeHopByHopHeaders(h http.Header, keepUpgrade bool) {
+	for _, value := range h["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			name = textproto.TrimString(name)
+			if keepUpgrade && strings.EqualFold(name, "Upgrade") {
+				continue
+			}
+			if name != "" {
+				h.Del(name)
+			}
+		}
+	}
+
+	for _, name := range hopByHopHeaders {
+		if keepUpgrade && (name == "Connection" || name == "Upgrade") {
+			continue
+		}
+		h.Del(name)
+	}
+
+	if keepUpgrade {
+		h.Set("Connection", "Upgrade")
+	}
+}
+
+// setForwardedHeaders writes Forwarded, X-Forwarded-* and Via headers onto an outbound request
+// in is the request as received from the client; out holds the headers sent to the backend
+// A prior forwarding chain is only kept when trusted holds the peer (or trusted_proxies is empty and trusted nil)
+func setForwardedHeaders(out http.Header, in *http.Request, fwd config.Forwarding, trusted *clientip.Resolver) {
+	proto := "http"
+	if in.TLS != nil {
+		proto = "https"
+	}
+
+	clientIP := clientip.PeerIP(in)
+
+	// Forwarded (RFC 7239) element for this hop
+	element := "proto=" + proto
+	if in.Host != "" {
+		element += ";host=" + quoteForwardedValue(in.Host)
+	}
+
+	var prior, priorXFF []string
+	if forwardedChainTrusted(clientIP, trusted) {
+		prior = in.Header.Values("Forwarded")
+		priorXFF = in.Header.Values("X-Forwarded-For")
+	}
+	out.Del("Forwarded")
+	out.Del("X-Forwarded-For")
+
+	switch fwd.ClientIP {
+	case "omit":
+		// Don't reveal the client address, nor any address forwarded by earlier hops
+		out.Set("Forwarded", "for=unknown;"+element)
+	case "replace":
+		out.Set("Forwarded", "for="+formatForwardedNode(clientIP)+";"+element)
+		out.Set("X-Forwarded-For", clientIP)
+	default: // "append"
+		prior = append(prior, "for="+formatForwardedNode(clientIP)+";"+element)
+		out.Set("Forwarded", strings.Join(prior, ", "))
+		priorXFF = append(priorXFF, clientIP)
+		out.Set("X-Forwarded-For", strings.Join(priorXFF, ", "))
+	}
+
+	out.Set("X-Forwarded-Proto", proto)
+	if in.Host != "" {
+		out.Set("X-Forwarded-Host", in.Host)
+	}
+
+	if !fwd.DisableVia {
+		addViaHeader(out, in.ProtoMajor, in.ProtoMinor)
+	}
+}
+
+// forwardedChainTrusted reports whether the peer may hand us an existing forwarding chain
+// Without trusted_proxies (a nil resolver) the chain is always kept, as before
+func forwardedChainTrusted(peer string, trusted *clientip.Resolver) bool {
+	return trusted == nil || trusted.Trusted(peer)
+}
+
+// newTrustedResolver parses trusted_proxies once, returning nil when none are configured
+func newTrustedResolver(trustedProxies []string) *clientip.Resolver {
+	if len(trustedProxies) == 0 {
+		return nil
+	}
+
+	resolver, err := clientip.NewResolver(false, trustedProxies)
+	if err != nil {
+		// Validation rejects bad entries, so only reachable for hand-built configs; trust no peer
+		resolver, _ = clientip.NewResolver(false, nil)
+	}
+	return resolver
+}
+
+// addViaHeader appends this hop to the Via header, e.g. "1.1 sauron"
+func addViaHeader(h http.Header, protoMajor, protoMinor int) {
+	version := strconv.Itoa(protoMajor)
+	if protoMajor < 2 {
+		version += "." + strconv.Itoa(protoMinor)
+	}
+	h.Add("Via", version+" "+viaPseudonym)
+}
+
+// formatForwardedNode formats an IP for the Forwarded "for" parameter
+// IPv6 addresses must be bracketed and quoted per RFC 7239
+func formatForwardedNode(ip string) string {
+	if ip == "" {
+		return "unknown"
+	}
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+	return ip
+}
+
+// quoteForwardedValue quotes a Forwarded parameter value when it is not a valid token
+func quoteForwardedValue(v string) string {
+	if strings.ContainsAny(v, ":[]\" ;,") {
+		return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+	}
+	return v
+}
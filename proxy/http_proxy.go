@@ -2,18 +2,23 @@ package proxy
 
 import (
 	"bufio"
-	"crypto/tls"
+	"bytes"
 	"io"
-	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/http/httputil"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"sauron/abuse"
+	"sauron/clientip"
 	"sauron/config"
 	"sauron/metrics"
+	"sauron/ratelimit"
 	"sauron/selector"
 	"sauron/storage"
 
@@ -23,43 +28,81 @@ import (
 // HTTPProxy handles HTTP/API and RPC proxying
 // The gates through which the Ringwraiths pass
 type HTTPProxy struct {
-	selector      *selector.Selector
+	selector      selector.NodeSelector
 	configLoader  *config.Loader
 	endpointStore *storage.ExternalEndpointStore
+	inflight      *storage.InflightTracker
 	transport     *http.Transport
 	logger        *zap.Logger
 	endpointType  string // "api" or "rpc"
 	network       string // The network this proxy serves
+
+	handler    http.Handler       // serveProxy wrapped in the middleware chain
+	middleware []Middleware       // Middleware added with Use
+	limiter    *ratelimit.Limiter // Created once for the rate_limit middleware, if configured; stopped by Close
+	cache      *responseCache     // Responses kept by cache rules
+	buffers    *bufferPool        // Copy buffers of proxied response bodies
+	abuse      *abuse.Detector    // Counts abusive requests, if abuse_detection is enabled
+	conns      backendConns       // Backend connections the transport holds open, for rebalancing
+
+	trusted atomic.Pointer[clientip.Resolver] // trusted_proxies, parsed once and rebuilt on reload (nil when empty)
 }
 
 // NewHTTPProxy creates a new HTTP proxy for a specific network
 func NewHTTPProxy(
-	selector *selector.Selector,
+	selector selector.NodeSelector,
 	configLoader *config.Loader,
 	endpointStore *storage.ExternalEndpointStore,
+	inflight *storage.InflightTracker,
 	logger *zap.Logger,
 	endpointType string,
 	network string,
 ) *HTTPProxy {
-	// Optimized transport for maximum throughput
+	// Optimized transport for maximum throughput; pool sizes can be tuned with transport.proxy
+	settings := configLoader.Get().Transport.Proxy.WithDefaults(config.HTTPTransport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		MaxConnsPerHost:     0, // Unlimited
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	})
 	transport := &http.Transport{
-		MaxIdleConns:          100,
-		MaxIdleConnsPerHost:   100,
-		MaxConnsPerHost:       0, // Unlimited
-		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       settings.MaxConnsPerHost,
+		IdleConnTimeout:       settings.IdleConnTimeout,
 		ResponseHeaderTimeout: 60 * time.Second, // Will be updated from config
-		TLSHandshakeTimeout:   10 * time.Second,
+		TLSHandshakeTimeout:   settings.TLSHandshakeTimeout,
+		DisableKeepAlives:     settings.DisableKeepAlives,
 	}
 
-	return &HTTPProxy{
+	p := &HTTPProxy{
 		selector:      selector,
 		configLoader:  configLoader,
 		endpointStore: endpointStore,
+		inflight:      inflight,
 		transport:     transport,
 		logger:        logger,
 		endpointType:  endpointType,
 		network:       network,
+		cache:         newResponseCache(),
+		buffers:       newBufferPool(configLoader.Get().Transport.BufferSize),
+	}
+	transport.DialContext = p.dialContext
+	// The limiter outlives handler rebuilds by Use, so its state and cleanup goroutine exist once
+	if slices.Contains(p.networkConfig().HTTPMiddleware, MiddlewareRateLimit) {
+		p.limiter = p.newRateLimiter()
 	}
+	p.handler = p.buildHandler()
+
+	p.trusted.Store(newTrustedResolver(configLoader.Get().TrustedProxies))
+	configLoader.OnReload(func(err error) {
+		if err == nil {
+			p.trusted.Store(newTrustedResolver(configLoader.Get().TrustedProxies))
+		}
+	})
+
+	return p
 }
 
 // isWebSocketRequest checks if this is a WebSocket upgrade request
@@ -69,8 +112,64 @@ func isWebSocketRequest(r *http.Request) bool {
 	return strings.Contains(connection, "upgrade") && upgrade == "websocket"
 }
 
-// ServeHTTP handles the proxy request
+// ServeHTTP runs the request through the middleware chain and then the proxy
+// Per-client connection limits apply first, before any middleware strips credentials
 func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cfg := p.configLoader.Get()
+	kind := connKindHTTP
+	if isWebSocketRequest(r) {
+		kind = connKindWebSocket
+	}
+
+	limit := connectionLimit(cfg.ConnectionLimits, kind)
+	var client string
+	if limit > 0 || cfg.ReadYourWrites.Enabled {
+		client = httpClientKey(cfg, p.trusted.Load(), r)
+	}
+
+	// Remember who the client is for read-your-writes, before middleware strips credentials
+	if cfg.ReadYourWrites.Enabled {
+		r = r.WithContext(withSession(r.Context(), client))
+	}
+	// Sticky sessions hash the client before middleware can rewrite its headers
+	if sticky := p.networkConfig().StickySessions; sticky.Enabled {
+		r = r.WithContext(withStickyClient(r.Context(), httpStickyClient(p.trusted.Load(), sticky, r)))
+	}
+
+	if limit > 0 {
+		if !acquireConnection(kind, client, limit) {
+			metrics.ConnectionLimitRejections.WithLabelValues(p.network, kind).Inc()
+			p.logger.Warn("Connection limit reached for client",
+				zap.String("network", p.network),
+				zap.String("type", p.endpointType),
+				zap.String("kind", kind),
+				zap.String("client", client),
+				zap.Int("limit", limit),
+			)
+			p.abuse.Record(r, abuse.EventRateLimited)
+			http.Error(w, "Too many concurrent connections", http.StatusTooManyRequests)
+			return
+		}
+		defer releaseConnection(kind, client)
+	}
+
+	p.handler.ServeHTTP(w, r)
+}
+
+// SetAbuseDetector counts auth failures, rate limit refusals and malformed requests towards bans
+func (p *HTTPProxy) SetAbuseDetector(detector *abuse.Detector) {
+	p.abuse = detector
+}
+
+// Close stops the rate limiter of the rate_limit middleware
+func (p *HTTPProxy) Close() {
+	if p.limiter != nil {
+		p.limiter.Stop()
+	}
+}
+
+// serveProxy handles the proxy request
+func (p *HTTPProxy) serveProxy(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
 	// Log every request for debugging
@@ -88,8 +187,60 @@ func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Use the network this proxy is configured for (no detection needed!)
 	network := p.network
 
-	// Select best node
-	nodeMetrics, nodeName, decision := p.selector.GetBestNode(network, p.endpointType)
+	// Per-network request rules, first match wins
+	var routeTag, cacheKeyValue string
+	var cacheTTL time.Duration
+	if rule := matchRule(p.networkConfig().Rules, p.endpointType, r); rule != nil {
+		metrics.RuleMatches.WithLabelValues(network, p.endpointType, rule.Action).Inc()
+		switch rule.Action {
+		case config.RuleActionDeny:
+			status := rule.Status
+			if status == 0 {
+				status = http.StatusForbidden
+			}
+			http.Error(w, "Request not allowed on this gateway", status)
+			return
+		case config.RuleActionRewrite:
+			rewritePath(r, rule)
+		case config.RuleActionRoute:
+			routeTag = rule.Tag
+		case config.RuleActionCache:
+			if r.Method == http.MethodGet && !isWebSocketRequest(r) {
+				cacheKeyValue, cacheTTL = cacheKey(r), rule.CacheTTL
+				if p.cache.serve(w, cacheKeyValue) {
+					metrics.RuleMatches.WithLabelValues(network, p.endpointType, "cache_hit").Inc()
+					return
+				}
+			}
+		}
+	}
+
+	// Handle WebSocket upgrade requests separately (only WebSocket-capable nodes qualify)
+	if isWebSocketRequest(r) {
+		p.handleWebSocket(w, r, network, start)
+		return
+	}
+
+	// Label RPC traffic by chain method rather than HTTP verb (reads a bounded prefix of the body)
+	method := r.Method
+	if p.endpointType == "rpc" {
+		method = rpcMethod(r)
+	}
+
+	// Select best node: a route rule's tagged pool, the node the client's last broadcast
+	// pinned it to (read-your-writes), or the best available (the client's own with sticky sessions)
+	session := sessionFrom(r.Context())
+	var nodeMetrics *storage.NodeMetrics
+	var nodeName string
+	var decision *selector.SelectionDecision
+	if routeTag != "" {
+		nodeMetrics, nodeName, decision = p.selector.GetBestTaggedNode(network, p.endpointType, routeTag)
+	} else {
+		nodeMetrics, nodeName, decision = pinnedNode(p.selector, cfg, p.logger, network, p.endpointType, session)
+		if nodeMetrics == nil {
+			nodeMetrics, nodeName, decision = bestNode(p.selector, network, p.endpointType, stickyClientFrom(r.Context()))
+		}
+	}
 	if nodeMetrics == nil || nodeName == "" {
 		p.logger.Warn("No available nodes for routing",
 			zap.String("network", network),
@@ -99,15 +250,118 @@ func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Retries need the body again; reads are retried on any transport failure, other requests
+	// only when the backend was never reached
+	maxAttempts := 1
+	var body []byte
+	safe := false
+	if cfg.Retry.Enabled {
+		var replayable bool
+		if body, replayable = replayableBody(r); replayable {
+			maxAttempts = cfg.Retry.MaxAttempts
+			if maxAttempts == 0 {
+				maxAttempts = DefaultRetryMaxAttempts
+			}
+		}
+		safe = retrySafe(cfg.Retry.Rules, p.endpointType, method, r)
+	}
+
+	// Keep a copy of the response for a cache rule
+	var capture *cacheWriter
+	if cacheKeyValue != "" {
+		capture = &cacheWriter{ResponseWriter: w}
+		w = capture
+	}
+
+	var statusCode int
+	tried := make([]string, 0, maxAttempts)
+	for attempt := 1; ; attempt++ {
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		tried = append(tried, nodeName)
+
+		canRetry := func(failure string) bool {
+			return attempt < maxAttempts && (safe || retryableFailure(failure))
+		}
+		var failure string
+		statusCode, failure = p.forward(w, r, cfg, nodeName, decision, method, start, canRetry)
+		if failure == "" {
+			break
+		}
+
+		// Another node takes the request; giving up when every candidate was tried
+		retryMetrics, retryName, retryDecision := p.selector.GetRetryNode(network, p.endpointType, routeTag, tried)
+		if retryMetrics == nil {
+			p.writeFailure(w, failure)
+			statusCode = failureStatus(failure)
+			break
+		}
+		metrics.ProxyRetries.WithLabelValues(network, p.endpointType, failure).Inc()
+		p.logger.Warn("Retrying request on another node",
+			zap.String("network", network),
+			zap.String("type", p.endpointType),
+			zap.String("failed_node", nodeName),
+			zap.String("retry_node", retryName),
+			zap.String("failure", failure),
+			zap.Bool("retry_safe", safe),
+			zap.Int("attempt", attempt+1),
+		)
+		nodeMetrics, nodeName, decision = retryMetrics, retryName, retryDecision
+	}
+
+	// Requests the backend couldn't parse count towards a malformed request flood
+	if statusCode == http.StatusBadRequest {
+		p.abuse.Record(r, abuse.EventMalformed)
+	}
+
+	if capture != nil && capture.cacheable() {
+		p.cache.store(cacheKeyValue, capture.status, capture.Header().Clone(), capture.body.Bytes(), cacheTTL)
+	}
+
+	// Pin the client to this node after a successful broadcast so it can read its own write
+	if cfg.ReadYourWrites.Enabled && statusCode < 400 && isBroadcast(p.endpointType, method, r) {
+		pinSession(network, session, nodeName, nodeMetrics.Height, cfg.ReadYourWrites.Window)
+	}
+
+	p.logger.Debug("Request proxied",
+		zap.String("network", network),
+		zap.String("node", nodeName),
+		zap.String("type", p.endpointType),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.Int("status", statusCode),
+		zap.Int("attempts", len(tried)),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("selection_reason", decision.Reason),
+	)
+}
+
+// forward proxies one attempt of a request to a node and records its metrics
+// When canRetry accepts a transport failure nothing is written to the client, so the caller can try another node
+// Returns the status of the attempt, and its failure class when it is to be retried ("" otherwise)
+func (p *HTTPProxy) forward(
+	w http.ResponseWriter,
+	r *http.Request,
+	cfg *config.Config,
+	nodeName string,
+	decision *selector.SelectionDecision,
+	method string,
+	start time.Time,
+	canRetry func(failure string) bool,
+) (int, string) {
+	network := p.network
+	attemptStart := time.Now()
+
 	// Get endpoint URL
-	targetURL := p.selector.GetEndpointURL(nodeName, p.endpointType)
+	targetURL := p.selector.GetEndpointURL(network, nodeName, p.endpointType)
 	if targetURL == "" {
 		p.logger.Error("Failed to get endpoint URL",
 			zap.String("node", nodeName),
 			zap.String("type", p.endpointType),
 		)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return http.StatusInternalServerError, ""
 	}
 
 	p.logger.Info("Routing decision made",
@@ -125,45 +379,94 @@ func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			zap.Error(err),
 		)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return http.StatusInternalServerError, ""
 	}
 
-	// Handle WebSocket upgrade requests separately
-	if isWebSocketRequest(r) {
-		p.handleWebSocket(w, r, target, nodeName, network, start, decision)
-		return
+	// Requests to a peer ring that verifies signatures are signed with the secret shared with it
+	secret, sign := signingSecret(cfg, p.endpointStore, network, p.endpointType, nodeName)
+	var signedBody string
+	if sign {
+		if signedBody, sign = requestBodyHash(r); !sign {
+			p.logger.Warn("Request body too large to sign, forwarding unsigned",
+				zap.String("network", network),
+				zap.String("node", nodeName),
+			)
+		}
 	}
 
 	// Create reverse proxy
-	proxy := httputil.NewSingleHostReverseProxy(target)
-	proxy.Transport = p.transport
-
-	// Customize the Director to properly forward path, headers, and query params
-	originalDirector := proxy.Director
-	proxy.Director = func(req *http.Request) {
-		originalDirector(req)
-		// CRITICAL: Set the Host header to the backend host, not the proxy host
-		req.Host = target.Host
-		// Log what we're sending to backend
-		p.logger.Info("Outgoing request to backend",
-			zap.String("method", req.Method),
-			zap.String("url", req.URL.String()),
-			zap.String("host", req.Host),
-			zap.String("path", req.URL.Path),
-			zap.String("raw_query", req.URL.RawQuery),
-		)
+	// Rewrite (unlike Director) strips hop-by-hop and inbound X-Forwarded-* headers
+	// before we get to set the forwarding headers ourselves
+	proxy := &httputil.ReverseProxy{
+		Transport:  p.transport,
+		BufferPool: p.buffers,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// SetURL forwards path and query params and sets Host to the backend host
+			pr.SetURL(target)
+			setForwardedHeaders(pr.Out.Header, pr.In, cfg.Forwarding, p.trusted.Load())
+			stripRingSignature(pr.Out.Header)
+			if sign {
+				signRequest(pr.Out.Header.Set, cfg.RingSigning.Name, secret, pr.Out.Method, pr.Out.URL.RequestURI(), signedBody, time.Now())
+			}
+
+			// Log what we're sending to backend
+			p.logger.Info("Outgoing request to backend",
+				zap.String("method", pr.Out.Method),
+				zap.String("url", pr.Out.URL.String()),
+				zap.String("host", pr.Out.URL.Host),
+				zap.String("path", pr.Out.URL.Path),
+				zap.String("raw_query", pr.Out.URL.RawQuery),
+			)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if !cfg.Forwarding.DisableVia {
+				addViaHeader(resp.Header, resp.ProtoMajor, resp.ProtoMinor)
+			}
+			return nil
+		},
 	}
 
-	// Add error handler to log proxy errors
+	// Add error handler to log and classify proxy errors
+	var failure string
+	retried := false
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		// The client hung up: nothing to answer and nothing the backend did wrong
+		if clientAborted(r.Context(), err) {
+			failure = FailureClientAbort
+			p.logger.Debug("Client closed request before the backend answered",
+				zap.String("path", r.URL.Path),
+				zap.String("backend", target.Host),
+			)
+			w.WriteHeader(StatusClientClosedRequest)
+			return
+		}
+
+		failure = classifyError(err)
+		retried = canRetry(failure)
 		p.logger.Error("Reverse proxy error",
 			zap.Error(err),
 			zap.String("path", r.URL.Path),
 			zap.String("backend", target.Host),
+			zap.String("failure", failure),
+			zap.Bool("retryable", retryableFailure(failure)),
+			zap.Bool("retried", retried),
 		)
-		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		if !retried {
+			p.writeFailure(w, failure)
+		}
 	}
 
+	// Every candidate lags the network head: still serve, but tell the client
+	if decision != nil && decision.Stale {
+		w.Header().Set(StaleHeader, "true")
+	} else {
+		w.Header().Del(StaleHeader)
+	}
+
+	// Count the request against the node while it is in flight (read by load-aware selection, e.g. egress overflow)
+	p.inflight.Increment(network, nodeName, p.endpointType)
+	defer p.inflight.Decrement(network, nodeName, p.endpointType)
+
 	// Wrap response writer to track status and size
 	tracker := &responseTracker{ResponseWriter: w, statusCode: 200}
 
@@ -174,54 +477,79 @@ func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		zap.String("request_path", r.URL.Path),
 		zap.String("request_query", r.URL.RawQuery),
 	)
-	proxy.ServeHTTP(tracker, r)
+	// Trace connection reuse and dial timings per node
+	traced := r.WithContext(httptrace.WithClientTrace(r.Context(), backendTrace(network, nodeName, p.endpointType)))
+	proxy.ServeHTTP(tracker, traced)
+
+	// A retried attempt wrote nothing; record what the client would have seen
+	statusCode := tracker.statusCode
+	if retried {
+		statusCode = failureStatus(failure)
+	}
 
 	p.logger.Info("Backend response received",
-		zap.Int("status_code", tracker.statusCode),
+		zap.Int("status_code", statusCode),
 		zap.Int64("response_bytes", tracker.bytesWritten),
 	)
 
 	// Record metrics
-	duration := time.Since(start)
-	statusStr := strconv.Itoa(tracker.statusCode)
+	duration := time.Since(attemptStart)
+	statusStr := strconv.Itoa(statusCode)
 
 	metrics.ProxyRequestDuration.WithLabelValues(
 		network,
 		nodeName,
 		p.endpointType,
 		statusStr,
-	).Observe(duration.Seconds())
+	).Observe(time.Since(start).Seconds())
 
+	observeUpstreamLatency(network, p.endpointType, nodeName, duration)
 	metrics.ProxyResponseSize.WithLabelValues(network, p.endpointType).Observe(float64(tracker.bytesWritten))
-	metrics.NodeRequests.WithLabelValues(network, nodeName, p.endpointType, r.Method).Inc()
+	metrics.NodeRequests.WithLabelValues(network, nodeName, p.endpointType, method).Inc()
 
-	if tracker.statusCode >= 400 {
-		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, statusStr, "http_error").Inc()
+	if failure != FailureClientAbort {
+		p.selector.RecordLatency(network, p.endpointType, nodeName, duration)
+	}
+	errorType := failure
+	if errorType == "" {
+		errorType = classifyHTTPStatus(statusCode)
+	}
+	if statusCode >= 400 {
+		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, statusStr, errorType).Inc()
+		metrics.NodeRequestErrors.WithLabelValues(network, nodeName, p.endpointType, method).Inc()
 	}
+	metrics.ObserveSLO(cfg.SLOs, network, p.endpointType, method, time.Since(start), statusCode >= 500)
+	p.selector.RecordOutcome(network, p.endpointType, nodeName, statusCode >= 500)
 
 	// Track 5xx errors for external endpoints
-	if tracker.statusCode >= 500 && p.endpointStore != nil {
+	if statusCode >= 500 && p.endpointStore != nil {
 		if p.endpointStore.TrackProxyError(network, p.endpointType, targetURL) {
 			p.logger.Info("Tracked 5xx error for external endpoint",
 				zap.String("url", targetURL),
 				zap.String("network", network),
 				zap.String("type", p.endpointType),
-				zap.Int("status", tracker.statusCode),
+				zap.Int("status", statusCode),
 			)
 		}
 	}
 
-	p.logger.Debug("Request proxied",
-		zap.String("network", network),
-		zap.String("node", nodeName),
-		zap.String("type", p.endpointType),
-		zap.String("method", r.Method),
-		zap.String("path", r.URL.Path),
-		zap.Int("status", tracker.statusCode),
-		zap.Int64("bytes", tracker.bytesWritten),
-		zap.Duration("duration", duration),
-		zap.String("selection_reason", decision.Reason),
-	)
+	if !retried {
+		return statusCode, ""
+	}
+	return statusCode, failure
+}
+
+// failureStatus is the status a client gets for a transport failure
+func failureStatus(failure string) int {
+	if failure == FailureTimeout {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// writeFailure answers a request whose backend failed at the transport level
+func (p *HTTPProxy) writeFailure(w http.ResponseWriter, failure string) {
+	http.Error(w, http.StatusText(failureStatus(failure)), failureStatus(failure))
 }
 
 // responseTracker tracks response status and size
@@ -242,25 +570,81 @@ func (rt *responseTracker) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap lets http.ResponseController (used by ReverseProxy to flush streamed responses) reach the writer
+func (rt *responseTracker) Unwrap() http.ResponseWriter {
+	return rt.ResponseWriter
+}
+
 // handleWebSocket handles WebSocket proxy requests
-func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, target *url.URL, nodeName, network string, start time.Time, decision *selector.SelectionDecision) {
+// Selection is restricted to nodes whose WebSocket endpoint passed its health check
+func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, network string, start time.Time) {
+	// Select the best WebSocket-capable node
+	nodeMetrics, nodeName, decision := p.selector.GetBestWebSocketNode(network, p.endpointType)
+	if nodeMetrics == nil || nodeName == "" {
+		p.logger.Warn("No WebSocket-capable nodes available for routing",
+			zap.String("network", network),
+			zap.String("type", p.endpointType),
+		)
+		http.Error(w, "WebSocket not supported by any available backend", http.StatusServiceUnavailable)
+		metrics.ProxyErrors.WithLabelValues(network, "", p.endpointType, "503", "websocket_not_supported").Inc()
+		return
+	}
+
+	// Get WebSocket URL (ws override or derived from the HTTP URL)
+	targetURL := p.selector.GetWebSocketURL(network, nodeName, p.endpointType)
+	target, err := url.Parse(targetURL)
+	if targetURL == "" || err != nil {
+		p.logger.Error("Failed to get WebSocket URL",
+			zap.String("node", nodeName),
+			zap.String("type", p.endpointType),
+			zap.String("url", targetURL),
+			zap.Error(err),
+		)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	useTLS := target.Scheme == "wss" || target.Scheme == "https"
+
 	p.logger.Info("Handling WebSocket upgrade",
+		zap.String("network", network),
+		zap.String("selected_node", nodeName),
 		zap.String("target_host", target.Host),
 		zap.String("target_scheme", target.Scheme),
 		zap.String("path", r.URL.Path),
 	)
 
-	// Check if the selected node supports WebSocket
-	nodeMetrics, selectedNode, _ := p.selector.GetBestNode(network, p.endpointType)
-	if nodeMetrics != nil && !nodeMetrics.WebSocketAvailable {
-		p.logger.Warn("Selected node does not support WebSocket",
-			zap.String("node", selectedNode),
-			zap.String("network", network),
-		)
-		http.Error(w, "WebSocket not supported by selected backend", http.StatusServiceUnavailable)
-		metrics.ProxyErrors.WithLabelValues(network, selectedNode, p.endpointType, "503", "websocket_not_supported").Inc()
+	cfg := p.configLoader.Get()
+
+	// Determine the backend address with port
+	backendAddr := target.Host
+	if target.Port() == "" {
+		// Add default port if not specified
+		if useTLS {
+			backendAddr = target.Hostname() + ":443"
+		} else {
+			backendAddr = target.Hostname() + ":80"
+		}
+	}
+
+	// Connect to the backend before taking over the client connection: until then the request
+	// context is canceled when the client goes away, which aborts the dial
+	backendConn, err := dialBackend(r.Context(), backendAddr, target.Hostname(), useTLS, dialTimeout(cfg.Timeouts))
+	if err != nil {
+		if clientAborted(r.Context(), err) {
+			p.logger.Debug("Client closed request before the backend WebSocket connected",
+				zap.String("backend", backendAddr),
+			)
+			w.WriteHeader(StatusClientClosedRequest)
+			return
+		}
+		failure := classifyError(err)
+		p.logger.Error("Failed to connect to backend", zap.Error(err), zap.String("failure", failure))
+		http.Error(w, http.StatusText(failureStatus(failure)), failureStatus(failure))
+		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, strconv.Itoa(failureStatus(failure)), failure).Inc()
+		p.selector.RecordOutcome(network, p.endpointType, nodeName, true)
 		return
 	}
+	defer func() { _ = backendConn.Close() }()
 
 	// Hijack the client connection
 	hijacker, ok := w.(http.Hijacker)
@@ -278,9 +662,13 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 	}
 	defer func() { _ = clientConn.Close() }()
 
-	// Build backend WebSocket URL
+	// Listener read/write timeouts must not cut off a long-lived WebSocket
+	_ = clientConn.SetDeadline(time.Time{})
+
+	// Build backend WebSocket URL, prefixing any path from the target URL
+	r.URL.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
 	backendScheme := "ws"
-	if target.Scheme == "https" {
+	if useTLS {
 		backendScheme = "wss"
 	}
 	backendURL := backendScheme + "://" + target.Host + r.URL.Path
@@ -292,42 +680,25 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 		zap.String("backend_url", backendURL),
 	)
 
-	// Determine the backend address with port
-	backendAddr := target.Host
-	if target.Port() == "" {
-		// Add default port if not specified
-		if target.Scheme == "https" {
-			backendAddr = target.Hostname() + ":443"
-		} else {
-			backendAddr = target.Hostname() + ":80"
-		}
-	}
+	// The upgrade handshake gets the proxy timeout, like a response header; the session itself has none
+	_ = backendConn.SetDeadline(time.Now().Add(cfg.Timeouts.Proxy))
 
-	// Connect to backend WebSocket
-	var backendConn net.Conn
-	if target.Scheme == "https" {
-		// Use TLS for wss://
-		tlsConfig := &tls.Config{
-			ServerName: target.Hostname(),
-		}
-		backendConn, err = tls.Dial("tcp", backendAddr, tlsConfig)
-	} else {
-		// Plain TCP for ws://
-		backendConn, err = net.Dial("tcp", backendAddr)
-	}
-
-	if err != nil {
-		p.logger.Error("Failed to connect to backend", zap.Error(err))
-		_, _ = clientConn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
-		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, "502", "backend_connect_error").Inc()
-		return
-	}
-	defer func() { _ = backendConn.Close() }()
+	// Strip hop-by-hop headers (keeping the upgrade handshake) and add forwarding headers
+	// before the Host is rewritten, so X-Forwarded-Host reflects what the client asked for
+	removeHopByHopHeaders(r.Header, true)
+	setForwardedHeaders(r.Header, r, cfg.Forwarding, p.trusted.Load())
 
 	// Update the Host header to match the backend
 	r.Host = target.Host
 	r.Header.Set("Host", target.Host)
 
+	stripRingSignature(r.Header)
+	if secret, ok := signingSecret(cfg, p.endpointStore, network, p.endpointType, nodeName); ok {
+		if hash, ok := requestBodyHash(r); ok {
+			signRequest(r.Header.Set, cfg.RingSigning.Name, secret, r.Method, r.URL.RequestURI(), hash, time.Now())
+		}
+	}
+
 	// Forward the upgrade request to backend
 	err = r.Write(backendConn)
 	if err != nil {
@@ -346,6 +717,7 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, "502", "upgrade_response_error").Inc()
 		return
 	}
+	_ = backendConn.SetDeadline(time.Time{})
 
 	// Forward the response to client
 	err = resp.Write(clientConn)
@@ -359,8 +731,12 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 		zap.Int("response_status", resp.StatusCode),
 	)
 
+	// Track the open connection until one side goes away (read by least-connections selection)
+	closeStream := trackStreamOpen(network, nodeName, streamKindWebSocket)
+	p.inflight.OpenWebSocket(network, nodeName, p.endpointType)
+
 	// Bidirectional copy
-	errChan := make(chan error, 2)
+	errChan := make(chan streamEnd, 2)
 
 	// Client -> Backend
 	go func() {
@@ -377,7 +753,7 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 			zap.Int64("bytes", written),
 			zap.Error(err),
 		)
-		errChan <- err
+		errChan <- streamEnd{side: "client", err: err}
 	}()
 
 	// Backend -> Client
@@ -395,12 +771,15 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 			zap.Int64("bytes", written),
 			zap.Error(err),
 		)
-		errChan <- err
+		errChan <- streamEnd{side: "backend", err: err}
 	}()
 
 	// Wait for one direction to finish (when one closes, the other will follow)
-	err = <-errChan
+	end := <-errChan
+	err = end.err
 	duration := time.Since(start)
+	closeStream(webSocketCloseReason(end))
+	p.inflight.CloseWebSocket(network, nodeName, p.endpointType)
 
 	statusStr := strconv.Itoa(resp.StatusCode)
 	metrics.ProxyRequestDuration.WithLabelValues(
@@ -417,7 +796,7 @@ func (p *HTTPProxy) handleWebSocket(w http.ResponseWriter, r *http.Request, targ
 			zap.Error(err),
 			zap.Duration("duration", duration),
 		)
-		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, statusStr, "websocket_error").Inc()
+		metrics.ProxyErrors.WithLabelValues(network, nodeName, p.endpointType, statusStr, classifyError(err)).Inc()
 	} else {
 		p.logger.Info("WebSocket connection closed normally",
 			zap.Duration("duration", duration),
@@ -0,0 +1,614 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"sauron/config"
+	"sauron/metrics"
+	"sauron/selector"
+	"sauron/storage"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+// silentListener accepts TCP connections and never answers, so TLS handshakes hang
+func silentListener(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// newTestProxy creates an RPC proxy for one internal node at backendURL
+func newTestProxy(t *testing.T, backendURL string) *HTTPProxy {
+	t.Helper()
+
+	loader, err := config.NewStaticLoader(&config.Config{
+		RPC:       true,
+		Listen:    ":3000",
+		Timeouts:  config.Timeouts{HealthCheck: 5 * time.Second, Proxy: time.Second},
+		Networks:  []config.Network{{Name: "pocket", RPCListen: ":8081"}},
+		Internals: []config.Node{{Name: "node-1", RPC: backendURL, Network: "pocket"}},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config loader: %v", err)
+	}
+
+	heightStore := storage.NewHeightStore()
+	heightStore.Update("pocket", "node-1", "rpc", 100, time.Millisecond, "internal")
+	inflight := storage.NewInflightTracker()
+	sel := selector.NewSelector(heightStore, nil, inflight, loader, zap.NewNop())
+	return NewHTTPProxy(sel, loader, nil, inflight, zap.NewNop(), "rpc", "pocket")
+}
+
+// TestHTTPProxyGroupNodePerNetworkURL tests that a group node shared by two networks is reached
+// at each network's own default port, not at the first network's
+func TestHTTPProxyGroupNodePerNetworkURL(t *testing.T) {
+	hits := make(map[string]int)
+	var mu sync.Mutex
+	backendPort := func(name string) int {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+		}))
+		t.Cleanup(backend.Close)
+		return backend.Listener.Addr().(*net.TCPAddr).Port
+	}
+
+	loader, err := config.NewStaticLoader(&config.Config{
+		RPC:      true,
+		Listen:   ":3000",
+		Timeouts: config.Timeouts{HealthCheck: 5 * time.Second, Proxy: time.Second},
+		Networks: []config.Network{
+			{Name: "pocket", RPCListen: ":8081", NodeGroups: []string{"shared"}, DefaultScheme: "http", DefaultRPCPort: backendPort("pocket")},
+			{Name: "cosmos", RPCListen: ":8082", NodeGroups: []string{"shared"}, DefaultScheme: "http", DefaultRPCPort: backendPort("cosmos")},
+		},
+		NodeGroups: []config.NodeGroup{{Name: "shared", Nodes: []config.Node{{Name: "node-1", RPC: "127.0.0.1"}}}},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config loader: %v", err)
+	}
+
+	heightStore := storage.NewHeightStore()
+	inflight := storage.NewInflightTracker()
+	sel := selector.NewSelector(heightStore, nil, inflight, loader, zap.NewNop())
+	for _, network := range []string{"pocket", "cosmos"} {
+		heightStore.Update(network, "node-1", "rpc", 100, time.Millisecond, "internal")
+	}
+
+	for _, network := range []string{"cosmos", "pocket", "cosmos"} {
+		p := NewHTTPProxy(sel, loader, nil, inflight, zap.NewNop(), "rpc", network)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", network, w.Code)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hits["pocket"] != 1 || hits["cosmos"] != 2 {
+		t.Errorf("Expected 1 pocket and 2 cosmos backend hits, got %v", hits)
+	}
+}
+
+// TestHTTPProxyUseKeepsRateLimiter tests that adding middleware keeps the rate limiter and the budget it has used
+func TestHTTPProxyUseKeepsRateLimiter(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	p := newTestProxy(t, backend.URL)
+	cfg := p.configLoader.Get()
+	cfg.RateLimit = config.RateLimit{RequestsPerSecond: 1, Burst: 1}
+	cfg.Networks[0].HTTPMiddleware = []string{MiddlewareRateLimit}
+	if err := p.configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable rate_limit: %v", err)
+	}
+	p = NewHTTPProxy(p.selector, p.configLoader, nil, p.inflight, zap.NewNop(), "rpc", "pocket")
+	defer p.Close()
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+		return w.Code
+	}
+
+	limiter := p.limiter
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("Expected the first request through, got %d", code)
+	}
+
+	p.Use(func(next http.Handler) http.Handler { return next })
+	if p.limiter != limiter {
+		t.Fatal("Expected Use to keep the rate limiter")
+	}
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the used burst to carry over, got %d", code)
+	}
+}
+
+// TestDialBackendCanceled tests that canceling the request context aborts a hanging TLS dial
+func TestDialBackendCanceled(t *testing.T) {
+	addr := silentListener(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	begin := time.Now()
+	_, err := dialBackend(ctx, addr, "localhost", true, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context canceled, got: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Errorf("Expected the dial to stop on cancel, took %s", elapsed)
+	}
+}
+
+// TestDialBackendTimeout tests that a backend never completing the handshake fails after the dial timeout
+func TestDialBackendTimeout(t *testing.T) {
+	addr := silentListener(t)
+
+	begin := time.Now()
+	_, err := dialBackend(context.Background(), addr, "localhost", true, 100*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Errorf("Expected the dial to stop at the timeout, took %s", elapsed)
+	}
+	if classifyError(err) != FailureTimeout {
+		t.Errorf("Expected a dial timeout to be classified as %s, got %s", FailureTimeout, classifyError(err))
+	}
+}
+
+// TestHTTPProxyPropagatesClientCancel tests that a client going away cancels the backend request
+// and is answered with 499 rather than counted as a backend failure
+func TestHTTPProxyPropagatesClientCancel(t *testing.T) {
+	backendCanceled := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(backendCanceled)
+	}))
+	defer backend.Close()
+
+	p := newTestProxy(t, backend.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil).WithContext(ctx))
+
+	if rec.Code != StatusClientClosedRequest {
+		t.Errorf("Expected status %d, got %d", StatusClientClosedRequest, rec.Code)
+	}
+	select {
+	case <-backendCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the backend request to be canceled")
+	}
+}
+
+// TestHTTPProxyTimeout tests that a backend slower than the proxy timeout gets a 504
+func TestHTTPProxyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	p := newTestProxy(t, backend.URL)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, rec.Code)
+	}
+}
+
+// TestHTTPProxyLargeResponse tests that a response much larger than the pooled copy buffer arrives intact
+func TestHTTPProxyLargeResponse(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789abcdef"), 512<<10) // 8MB
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer backend.Close()
+
+	p := newTestProxy(t, backend.URL)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/block", nil))
+		if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), body) {
+			t.Fatalf("Expected the %d byte body intact, got status %d and %d bytes", len(body), rec.Code, rec.Body.Len())
+		}
+	}
+}
+
+// TestHTTPProxyConnectionReuse tests that the first request dials the backend and the next reuses the connection
+func TestHTTPProxyConnectionReuse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":{}}`))
+	}))
+	defer backend.Close()
+
+	p := newTestProxy(t, backend.URL)
+	dialed := metrics.BackendConnections.WithLabelValues("pocket", "node-1", "rpc", "false")
+	reused := metrics.BackendConnections.WithLabelValues("pocket", "node-1", "rpc", "true")
+	dialedBefore, reusedBefore := testutil.ToFloat64(dialed), testutil.ToFloat64(reused)
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+	}
+
+	if got := testutil.ToFloat64(dialed) - dialedBefore; got != 1 {
+		t.Errorf("Expected 1 dialed connection, got %v", got)
+	}
+	if got := testutil.ToFloat64(reused) - reusedBefore; got != 2 {
+		t.Errorf("Expected 2 reused connections, got %v", got)
+	}
+}
+
+// TestHTTPProxyRebalanceConnections tests that rebalancing closes idle backend connections, so the next request dials
+func TestHTTPProxyRebalanceConnections(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result":{}}`))
+	}))
+	defer backend.Close()
+
+	p := newTestProxy(t, backend.URL)
+	cfg := p.configLoader.Get()
+	cfg.Transport.Rebalance = config.Rebalance{Interval: time.Second, Fraction: 1, MinAge: time.Nanosecond}
+	if err := p.configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable rebalancing: %v", err)
+	}
+	dialed := metrics.BackendConnections.WithLabelValues("pocket", "node-1", "rpc", "false")
+	recycled := metrics.BackendConnectionsRecycled.WithLabelValues("pocket", "rpc")
+	dialedBefore, recycledBefore := testutil.ToFloat64(dialed), testutil.ToFloat64(recycled)
+
+	serve := func() {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+	}
+
+	serve()
+	// The transport returns the connection to its pool right after the body is read
+	deadline := time.Now().Add(time.Second)
+	for len(p.conns.idleBefore(time.Now())) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	p.RebalanceConnections()
+	if got := testutil.ToFloat64(recycled) - recycledBefore; got != 1 {
+		t.Fatalf("Expected 1 recycled connection, got %v", got)
+	}
+
+	serve()
+	if got := testutil.ToFloat64(dialed) - dialedBefore; got != 2 {
+		t.Errorf("Expected a fresh dial after rebalancing, got %v dials", got)
+	}
+}
+
+// TestUserAllows tests method and path allowlists, including batches, and that the body is kept for proxying
+func TestUserAllows(t *testing.T) {
+	user := &config.User{
+		RPC:     true,
+		Methods: []string{"broadcast_tx_sync", "status"},
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   bool
+	}{
+		{"allowed method", http.MethodPost, "/", `{"jsonrpc":"2.0","id":1,"method":"broadcast_tx_sync"}`, true},
+		{"other method", http.MethodPost, "/", `{"jsonrpc":"2.0","id":1,"method":"block"}`, false},
+		{"allowed batch", http.MethodPost, "/", `[{"method":"status"},{"method":"broadcast_tx_sync"}]`, true},
+		{"batch with other method", http.MethodPost, "/", `[{"method":"status"},{"method":"block"}]`, false},
+		{"not json-rpc", http.MethodPost, "/", `hello`, false},
+		{"allowed uri form", http.MethodGet, "/status", "", true},
+		{"other uri form", http.MethodGet, "/block", "", false},
+		{"other uri form behind a dot segment", http.MethodGet, "/status/../block", "", false},
+		{"post to an allowed method path", http.MethodPost, "/status", "", true},
+		{"post to another method path", http.MethodPost, "/abci_query", `{"jsonrpc":"2.0","id":1,"method":"broadcast_tx_sync"}`, false},
+		{"post to another method path without json", http.MethodPost, "/abci_query", `path="/store"`, false},
+		{"post to an allowed path and method", http.MethodPost, "/status", `{"jsonrpc":"2.0","id":1,"method":"broadcast_tx_sync"}`, true},
+		{"post to an allowed path with another method", http.MethodPost, "/status", `{"jsonrpc":"2.0","id":1,"method":"block"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if _, ok := userAllows(user, "rpc", r); ok != tt.want {
+				t.Fatalf("Expected allowed=%v, got %v", tt.want, ok)
+			}
+			var body bytes.Buffer
+			_, _ = body.ReadFrom(r.Body)
+			if body.String() != tt.body {
+				t.Errorf("Expected the body to be kept, got %q", body.String())
+			}
+		})
+	}
+
+	api := &config.User{API: true, Paths: []string{"/cosmos/bank/*", "/status"}}
+	for path, want := range map[string]bool{
+		"/cosmos/bank/v1beta1/balances/x":                true,
+		"/status":                                        true,
+		"/cosmos/tx/v1beta1/txs":                         false,
+		"/cosmos/bank/../staking/v1beta1/validators":     false,
+		"/cosmos/bank/%2E%2E/staking/v1beta1/validators": false,
+		"/cosmos/bank%2F..%2Fstaking/v1beta1/validators": false,
+		"/cosmos/bank/./v1beta1/supply":                  true,
+	} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if reason, ok := userAllows(api, "api", r); ok != want || (!ok && reason != "forbidden_path") {
+			t.Errorf("%s: expected allowed=%v, got %v (%s)", path, want, ok, reason)
+		}
+	}
+}
+
+// TestMatchRuleCleansPath tests that dot segments, plain or encoded, cannot sidestep a rule
+func TestMatchRuleCleansPath(t *testing.T) {
+	rules := []config.RouteRule{
+		{Path: "/cosmos/gov/*", Action: config.RuleActionDeny},
+		{Path: "/legacy/*", Action: config.RuleActionRewrite, Rewrite: "/cosmos/"},
+	}
+
+	for path, want := range map[string]bool{
+		"/cosmos/gov/v1/proposals":               true,
+		"/cosmos/bank/../gov/v1/proposals":       true,
+		"/cosmos/bank/%2E%2E/gov/v1/proposals":   true,
+		"/cosmos/bank%2F..%2Fgov/v1/proposals":   true,
+		"/cosmos/gov/../bank/v1beta1/balances/x": false,
+		"/cosmos/bank/v1beta1/balances/x":        false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if rule := matchRule(rules[:1], "api", r); (rule != nil) != want {
+			t.Errorf("%s: expected deny=%v", path, want)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/legacy/./bank/v1beta1/supply", nil)
+	rule := matchRule(rules, "api", r)
+	if rule == nil || rule.Action != config.RuleActionRewrite {
+		t.Fatal("Expected the rewrite rule to match")
+	}
+	rewritePath(r, rule)
+	if r.URL.Path != "/cosmos/bank/v1beta1/supply" {
+		t.Errorf("Expected rewrite to /cosmos/bank/v1beta1/supply, got %q", r.URL.Path)
+	}
+}
+
+// TestRingSignatureMiddleware tests that only requests signed by a trusted ring get through, without their signature
+func TestRingSignatureMiddleware(t *testing.T) {
+	loader, err := config.NewStaticLoader(&config.Config{
+		RPC:         true,
+		Listen:      ":3000",
+		Timeouts:    config.Timeouts{HealthCheck: 5 * time.Second, Proxy: time.Second},
+		Networks:    []config.Network{{Name: "pocket", RPCListen: ":8081"}},
+		Internals:   []config.Node{{Name: "node-1", RPC: "http://127.0.0.1:26657", Network: "pocket"}},
+		RingSigning: config.RingSigning{Trusted: []config.TrustedRing{{Name: "peer", Secret: "shared"}}},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create config loader: %v", err)
+	}
+	p := &HTTPProxy{configLoader: loader}
+
+	var forwarded http.Header
+	var forwardedBody bytes.Buffer
+	handler := p.ringSignatureMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Clone()
+		forwardedBody.Reset()
+		_, _ = forwardedBody.ReadFrom(r.Body)
+	}))
+
+	tests := []struct {
+		name         string
+		ring, secret string
+		signedURI    string
+		at           time.Time
+		wantStatus   int
+	}{
+		{"valid", "peer", "shared", "/status?height=1", time.Now(), http.StatusOK},
+		{"unknown ring", "stranger", "shared", "/status?height=1", time.Now(), http.StatusUnauthorized},
+		{"wrong secret", "peer", "guess", "/status?height=1", time.Now(), http.StatusUnauthorized},
+		{"other target", "peer", "shared", "/block", time.Now(), http.StatusUnauthorized},
+		{"stale", "peer", "shared", "/status?height=1", time.Now().Add(-time.Hour), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded = nil
+			r := httptest.NewRequest(http.MethodGet, "/status?height=1", nil)
+			signRequest(r.Header.Set, tt.ring, tt.secret, http.MethodGet, tt.signedURI, emptyBodyHash, tt.at)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if forwarded != nil && forwarded.Get(RingSignatureHeader) != "" {
+				t.Errorf("Expected the signature to be stripped before forwarding")
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned requests to be refused, got %d", rec.Code)
+	}
+
+	// The body is signed: a captured signature can't carry another JSON-RPC call
+	signed := `{"jsonrpc":"2.0","id":1,"method":"status"}`
+	for body, want := range map[string]int{
+		signed: http.StatusOK,
+		`{"jsonrpc":"2.0","id":1,"method":"broadcast_tx_sync","params":{"tx":"AA=="}}`: http.StatusUnauthorized,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		signRequest(r.Header.Set, "peer", "shared", http.MethodPost, "/", bodyHash([]byte(signed)), time.Now())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", body, want, rec.Code)
+		}
+		if want == http.StatusOK && forwardedBody.String() != signed {
+			t.Errorf("Expected the signed body to be forwarded intact, got %q", forwardedBody.String())
+		}
+	}
+}
+
+// TestValidateRPCRequest tests that only JSON-RPC 2.0 calls of known methods pass, keeping the body intact
+func TestValidateRPCRequest(t *testing.T) {
+	settings := config.RPCValidation{ExtraMethods: []string{"custom_query"}, MaxBatch: 2}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   string // rejection reason, "" when valid
+		wantID string
+	}{
+		{"valid call", http.MethodPost, "/", `{"jsonrpc":"2.0","id":1,"method":"status","params":{}}`, "", ""},
+		{"string id", http.MethodPost, "/", `{"jsonrpc":"2.0","id":"a","method":"block"}`, "", ""},
+		{"extra method", http.MethodPost, "/", `{"jsonrpc":"2.0","id":1,"method":"custom_query"}`, "", ""},
+		{"valid batch", http.MethodPost, "/", `[{"jsonrpc":"2.0","id":1,"method":"status"},{"jsonrpc":"2.0","id":2,"method":"health"}]`, "", ""},
+		{"uri form", http.MethodGet, "/block", "", "", ""},
+		{"route list", http.MethodGet, "/", "", "", ""},
+		{"not json", http.MethodPost, "/", `hello`, "parse_error", ""},
+		{"empty body", http.MethodPost, "/", ``, "parse_error", ""},
+		{"wrong version", http.MethodPost, "/", `{"jsonrpc":"1.0","id":7,"method":"status"}`, "invalid_request", "7"},
+		{"missing id", http.MethodPost, "/", `{"jsonrpc":"2.0","method":"status"}`, "invalid_request", ""},
+		{"object id", http.MethodPost, "/", `{"jsonrpc":"2.0","id":{},"method":"status"}`, "invalid_request", ""},
+		{"scalar params", http.MethodPost, "/", `{"jsonrpc":"2.0","id":1,"method":"status","params":5}`, "invalid_request", "1"},
+		{"unknown method", http.MethodPost, "/", `{"jsonrpc":"2.0","id":"x","method":"dial_peers"}`, "method_not_found", `"x"`},
+		{"unknown uri form", http.MethodGet, "/unsafe_flush_mempool", "", "method_not_found", ""},
+		{"batch too large", http.MethodPost, "/", `[{"jsonrpc":"2.0","id":1,"method":"status"},{"jsonrpc":"2.0","id":2,"method":"status"},{"jsonrpc":"2.0","id":3,"method":"status"}]`, "batch_too_large", ""},
+		{"invalid call in batch", http.MethodPost, "/", `[{"jsonrpc":"2.0","id":1,"method":"status"},5]`, "invalid_request", ""},
+		{"other verb", http.MethodPut, "/", "", "invalid_request", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			rejection, id := validateRPCRequest(settings, r)
+			switch {
+			case tt.want == "" && rejection != nil:
+				t.Fatalf("Expected valid, got %s (%s)", rejection.reason, rejection.message)
+			case tt.want != "" && (rejection == nil || rejection.reason != tt.want):
+				t.Fatalf("Expected %s, got %+v", tt.want, rejection)
+			}
+			if string(id) != tt.wantID && tt.want != "" {
+				t.Errorf("Expected id %q, got %q", tt.wantID, id)
+			}
+			var body bytes.Buffer
+			_, _ = body.ReadFrom(r.Body)
+			if body.String() != tt.body {
+				t.Errorf("Expected the body to be kept, got %q", body.String())
+			}
+		})
+	}
+}
+
+// TestForwardedChainTrustedProxiesReload tests that a prior X-Forwarded-For chain follows trusted_proxies,
+// including after a reload changes them
+func TestForwardedChainTrustedProxiesReload(t *testing.T) {
+	forwarded := make(chan string, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded <- r.Header.Get("X-Forwarded-For")
+	}))
+	defer backend.Close()
+
+	p := newTestProxy(t, backend.URL)
+	forward := func() string {
+		r := httptest.NewRequest(http.MethodGet, "/status", nil)
+		r.RemoteAddr = "10.0.0.5:4000"
+		r.Header.Set("X-Forwarded-For", "203.0.113.7")
+		p.ServeHTTP(httptest.NewRecorder(), r)
+		return <-forwarded
+	}
+	trust := func(proxies ...string) {
+		cfg := p.configLoader.Get()
+		cfg.TrustedProxies = proxies
+		if err := p.configLoader.Update(cfg); err != nil {
+			t.Fatalf("Failed to update trusted_proxies: %v", err)
+		}
+	}
+
+	if got := forward(); got != "203.0.113.7, 10.0.0.5" {
+		t.Errorf("Expected the chain kept without trusted_proxies, got %q", got)
+	}
+
+	trust("192.168.0.0/16")
+	if got := forward(); got != "10.0.0.5" {
+		t.Errorf("Expected the chain dropped from an untrusted peer, got %q", got)
+	}
+
+	trust("10.0.0.0/8")
+	if got := forward(); got != "203.0.113.7, 10.0.0.5" {
+		t.Errorf("Expected the chain kept from a trusted peer, got %q", got)
+	}
+}
+
+// TestHTTPStickyClient tests that sticky sessions key on the configured header, falling back to the client IP
+func TestHTTPStickyClient(t *testing.T) {
+	trusted := newTrustedResolver([]string{"10.0.0.0/8"})
+	sticky := config.StickySessions{Enabled: true, Header: "X-Client-ID"}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.5:4000"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if got := httpStickyClient(trusted, sticky, r); got != "ip:203.0.113.7" {
+		t.Errorf("Expected the forwarded client IP, got %s", got)
+	}
+
+	r.Header.Set("X-Client-ID", "wallet-42")
+	if got := httpStickyClient(trusted, sticky, r); got != "header:wallet-42" {
+		t.Errorf("Expected the header value, got %s", got)
+	}
+
+	if got := httpStickyClient(trusted, config.StickySessions{Enabled: true}, r); got != "ip:203.0.113.7" {
+		t.Errorf("Expected the client IP without a header configured, got %s", got)
+	}
+
+	if got := httpStickyClient(nil, config.StickySessions{Enabled: true}, r); got != "ip:10.0.0.5" {
+		t.Errorf("Expected the peer IP without trusted_proxies, got %s", got)
+	}
+}
@@ -0,0 +1,18 @@
+package proxy
+
+import (
+	"strings"
+	"time"
+
+	"sauron/metrics"
+)
+
+// observeUpstreamLatency records a proxied request's latency against the backend that served it
+// External endpoints are named "ext:{url}" by the selector
+func observeUpstreamLatency(network, endpointType, nodeName string, d time.Duration) {
+	source := "internal"
+	if strings.HasPrefix(nodeName, "ext:") {
+		source = "external"
+	}
+	metrics.UpstreamLatency.WithLabelValues(network, endpointType, source, nodeName).Observe(d.Seconds())
+}
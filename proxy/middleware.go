@@ -0,0 +1,350 @@
+package proxy
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sauron/abuse"
+	"sauron/clientip"
+	"sauron/config"
+	"sauron/metrics"
+	"sauron/ratelimit"
+
+	"go.uber.org/zap"
+)
+
+// Middleware wraps the proxy handler with a cross-cutting concern
+type Middleware func(http.Handler) http.Handler
+
+// Built-in HTTP middleware, selectable per network with http_middleware
+const (
+	MiddlewareAuth          = "auth"           // require a user token with permission for the endpoint type
+	MiddlewareRateLimit     = "rate_limit"     // per-client request rate (rate_limit settings)
+	MiddlewareCORS          = "cors"           // answer preflights and set CORS headers for cors_origins
+	MiddlewareHeaders       = "headers"        // rewrite forwarded request headers (http_headers)
+	MiddlewareAccessLog     = "access_log"     // one log line per request
+	MiddlewareRingSignature = "ring_signature" // require a request signed by a ring_signing.trusted peer
+	MiddlewareRPCValidation = "rpc_validation" // refuse RPC requests that aren't JSON-RPC 2.0 calls of known methods
+)
+
+// Use appends middleware that runs after the configured middleware, closest to the proxy
+func (p *HTTPProxy) Use(middleware ...Middleware) {
+	p.middleware = append(p.middleware, middleware...)
+	p.handler = p.buildHandler()
+}
+
+// buildHandler wraps the proxy in the configured middleware followed by those added with Use
+// The first middleware listed is the outermost; the chain order is fixed at construction
+func (p *HTTPProxy) buildHandler() http.Handler {
+	var chain []Middleware
+	for _, name := range p.networkConfig().HTTPMiddleware {
+		switch name {
+		case MiddlewareAuth:
+			chain = append(chain, p.authMiddleware)
+		case MiddlewareRateLimit:
+			chain = append(chain, p.rateLimitMiddleware)
+		case MiddlewareCORS:
+			chain = append(chain, p.corsMiddleware)
+		case MiddlewareHeaders:
+			chain = append(chain, p.headersMiddleware)
+		case MiddlewareAccessLog:
+			chain = append(chain, p.accessLogMiddleware)
+		case MiddlewareRingSignature:
+			chain = append(chain, p.ringSignatureMiddleware)
+		case MiddlewareRPCValidation:
+			chain = append(chain, p.rpcValidationMiddleware)
+		default:
+			// Validation rejects unknown names, so only reachable for hand-built configs
+			p.logger.Warn("Unknown HTTP middleware, skipping",
+				zap.String("network", p.network),
+				zap.String("middleware", name),
+			)
+		}
+	}
+	chain = append(chain, p.middleware...)
+
+	var handler http.Handler = http.HandlerFunc(p.serveProxy)
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+	return handler
+}
+
+// networkConfig returns the current configuration of the network this proxy serves
+func (p *HTTPProxy) networkConfig() config.Network {
+	for _, network := range p.configLoader.Get().Networks {
+		if network.Name == p.network {
+			return network
+		}
+	}
+	return config.Network{}
+}
+
+// authMiddleware requires "Authorization: Bearer <token>" from a user allowed to use this endpoint type
+// The token is stripped before the request is forwarded to the backend
+func (p *HTTPProxy) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			metrics.AuthFailures.WithLabelValues("missing_token").Inc()
+			p.abuse.Record(r, abuse.EventAuthFailure)
+			http.Error(w, "Authorization required", http.StatusUnauthorized)
+			return
+		}
+
+		token, ok := strings.CutPrefix(authHeader, "Bearer ")
+		if !ok {
+			metrics.AuthFailures.WithLabelValues("invalid_format").Inc()
+			p.abuse.Record(r, abuse.EventAuthFailure)
+			http.Error(w, "Invalid Authorization format. Expected: Bearer <token>", http.StatusUnauthorized)
+			return
+		}
+
+		cfg := p.configLoader.Get()
+		user := cfg.FindUser(token)
+		if user == nil {
+			metrics.AuthFailures.WithLabelValues("invalid_token").Inc()
+			p.abuse.Record(r, abuse.EventAuthFailure)
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		if !user.Active(time.Now()) {
+			metrics.AuthFailures.WithLabelValues("expired_token").Inc()
+			p.abuse.Record(r, abuse.EventAuthFailure)
+			http.Error(w, "Token expired or not yet valid", http.StatusUnauthorized)
+			return
+		}
+		if !slices.Contains(cfg.GetUserPermissions(token), p.endpointType) {
+			metrics.AuthFailures.WithLabelValues("forbidden_type").Inc()
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if reason, ok := userAllows(user, p.endpointType, r); !ok {
+			metrics.AuthFailures.WithLabelValues(reason).Inc()
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		r.Header.Del("Authorization")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ringSignatureMiddleware only lets through requests signed by a ring_signing.trusted peer
+// The signature covers the body, so bodies larger than maxSignedBody are refused
+// The signature headers are stripped before the request is forwarded to the backend
+func (p *HTTPProxy) ringSignatureMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reason, ok := "body_too_large", false
+		if hash, readable := requestBodyHash(r); readable {
+			reason, ok = verifyRingSignature(p.configLoader.Get().RingSigning,
+				r.Header.Get(RingNameHeader), r.Header.Get(RingTimestampHeader), r.Header.Get(RingSignatureHeader),
+				r.Method, r.URL.RequestURI(), hash, time.Now())
+		}
+		if !ok {
+			metrics.AuthFailures.WithLabelValues(reason).Inc()
+			p.abuse.Record(r, abuse.EventAuthFailure)
+			http.Error(w, "Valid ring signature required", http.StatusUnauthorized)
+			return
+		}
+
+		stripRingSignature(r.Header)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// userAllows applies a user's paths and methods allowlists; returns the failure reason when refused
+// RPC methods are read from the JSON-RPC body (every call of a batch must be allowed) or the URI form path
+func userAllows(user *config.User, endpointType string, r *http.Request) (string, bool) {
+	requestPath := cleanPath(r.URL.Path)
+	if len(user.Paths) > 0 && !slices.ContainsFunc(user.Paths, func(pattern string) bool {
+		return matchPath(pattern, requestPath)
+	}) {
+		return "forbidden_path", false
+	}
+	if endpointType != "rpc" || len(user.Methods) == 0 {
+		return "", true
+	}
+	methods, ok := rpcMethodNames(r)
+	if !ok {
+		return "forbidden_method", false
+	}
+	for _, method := range methods {
+		if !slices.Contains(user.Methods, method) {
+			return "forbidden_method", false
+		}
+	}
+	return "", true
+}
+
+// newRateLimiter creates the limiter of the rate_limit middleware from the rate_limit settings
+// Forwarding headers are only honored from trusted_proxies
+func (p *HTTPProxy) newRateLimiter() *ratelimit.Limiter {
+	cfg := p.configLoader.Get()
+	reqPerSec := cfg.RateLimit.RequestsPerSecond
+	if reqPerSec == 0 {
+		reqPerSec = 10 // default: 10 requests per second
+	}
+	burst := cfg.RateLimit.Burst
+	if burst == 0 {
+		burst = reqPerSec * 2 // default: 2x burst
+	}
+
+	resolver, err := clientip.NewResolver(false, cfg.TrustedProxies)
+	if err != nil {
+		// Validation rejects bad entries, so only reachable for hand-built configs
+		p.logger.Error("Invalid trusted_proxies, ignoring forwarding headers", zap.Error(err))
+		resolver, _ = clientip.NewResolver(false, nil)
+	}
+	return ratelimit.New(reqPerSec, burst, cfg.RateLimit.MaxEntries, resolver)
+}
+
+// rateLimitMiddleware limits requests per client IP with the limiter created by NewHTTPProxy
+func (p *HTTPProxy) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.limiter.Allow(r) {
+			p.logger.Warn("Proxy rate limit exceeded",
+				zap.String("network", p.network),
+				zap.String("type", p.endpointType),
+				zap.String("path", r.URL.Path),
+				zap.String("remote_addr", r.RemoteAddr),
+			)
+			p.abuse.Record(r, abuse.EventRateLimited)
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsMiddleware answers preflight requests and sets CORS headers for allowed origins
+// Backend CORS headers are replaced so browsers never see conflicting values
+func (p *HTTPProxy) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		origins := p.networkConfig().CORSOrigins
+		if origin == "" || !slices.Contains(origins, origin) && !slices.Contains(origins, "*") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		setCORS := func(h http.Header) {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+		}
+
+		// Preflight: answer directly, the backend never sees it
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			setCORS(w.Header())
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(&hookWriter{ResponseWriter: w, beforeHeader: setCORS}, r)
+	})
+}
+
+// headersMiddleware applies the network's http_headers to the forwarded request
+// Headers with a value are set (replacing what the client sent), headers with an empty value are removed
+func (p *HTTPProxy) headersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range p.networkConfig().HTTPHeaders {
+			if value == "" {
+				r.Header.Del(name)
+				continue
+			}
+			r.Header.Set(name, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accessLogMiddleware logs each request with its status, size and duration
+func (p *HTTPProxy) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		hw := &hookWriter{ResponseWriter: w}
+		next.ServeHTTP(hw, r)
+
+		status := "hijacked"
+		if !hw.hijacked {
+			status = strconv.Itoa(hw.statusCode())
+		}
+		p.logger.Info("Proxy access",
+			zap.String("network", p.network),
+			zap.String("type", p.endpointType),
+			zap.String("remote_addr", clientip.PeerIP(r)),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("status", status),
+			zap.Int64("bytes", hw.bytes),
+			zap.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// hookWriter records the status and size of a response and can adjust headers before they are sent
+// Hijack and Flush pass through so WebSocket upgrades and streaming keep working behind middleware
+type hookWriter struct {
+	http.ResponseWriter
+	beforeHeader func(http.Header)
+	status       int
+	bytes        int64
+	hijacked     bool
+}
+
+func (hw *hookWriter) WriteHeader(code int) {
+	if hw.status == 0 {
+		hw.status = code
+		if hw.beforeHeader != nil {
+			hw.beforeHeader(hw.ResponseWriter.Header())
+		}
+	}
+	hw.ResponseWriter.WriteHeader(code)
+}
+
+func (hw *hookWriter) Write(b []byte) (int, error) {
+	if hw.status == 0 {
+		hw.WriteHeader(http.StatusOK)
+	}
+	n, err := hw.ResponseWriter.Write(b)
+	hw.bytes += int64(n)
+	return n, err
+}
+
+func (hw *hookWriter) Flush() {
+	if f, ok := hw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (hw *hookWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := hw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	hw.hijacked = true
+	return hijacker.Hijack()
+}
+
+func (hw *hookWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+// statusCode returns the status sent to the client (200 if the handler never set one)
+func (hw *hookWriter) statusCode() int {
+	if hw.status == 0 {
+		return http.StatusOK
+	}
+	return hw.status
+}
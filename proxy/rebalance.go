@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sauron/config"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// DefaultRebalanceFraction is the share of eligible connections recycled per interval when transport.rebalance.fraction is unset
+const DefaultRebalanceFraction = 0.25
+
+// rebalanceCutoff returns when a connection must have been opened by to be recycled (min_age, defaulting to the interval)
+func rebalanceCutoff(settings config.Rebalance) time.Time {
+	minAge := settings.MinAge
+	if minAge == 0 {
+		minAge = settings.Interval
+	}
+	return time.Now().Add(-minAge)
+}
+
+// rebalanceSlice returns how many of n eligible connections one round recycles, at least one when any is eligible
+func rebalanceSlice(n int, fraction float64) int {
+	if fraction == 0 {
+		fraction = DefaultRebalanceFraction
+	}
+	return min(n, int(math.Ceil(float64(n)*fraction)))
+}
+
+// backendConn is a connection dialed by an HTTP proxy's transport, tracked for rebalancing
+type backendConn struct {
+	net.Conn
+	pool   *backendConns
+	opened time.Time
+	owner  atomic.Pointer[connTrace] // request the transport handed the connection to; nil while idle in its pool
+}
+
+// Close stops tracking the connection and closes it
+func (c *backendConn) Close() error {
+	c.pool.remove(c)
+	return c.Conn.Close()
+}
+
+// asBackendConn returns the tracked connection under a transport connection (TLS included), if any
+func asBackendConn(conn net.Conn) *backendConn {
+	switch c := conn.(type) {
+	case *backendConn:
+		return c
+	case interface{ NetConn() net.Conn }:
+		return asBackendConn(c.NetConn())
+	}
+	return nil
+}
+
+// backendConns are the connections an HTTP proxy's transport holds open
+type backendConns struct {
+	mu    sync.Mutex
+	conns map[*backendConn]struct{}
+}
+
+// track wraps a newly dialed connection so rebalancing can find it
+func (b *backendConns) track(conn net.Conn) *backendConn {
+	c := &backendConn{Conn: conn, pool: b, opened: time.Now()}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conns == nil {
+		b.conns = make(map[*backendConn]struct{})
+	}
+	b.conns[c] = struct{}{}
+	return c
+}
+
+// remove stops tracking a closed connection
+func (b *backendConns) remove(c *backendConn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.conns, c)
+}
+
+// idleBefore returns the idle connections opened before cutoff, oldest first
+func (b *backendConns) idleBefore(cutoff time.Time) []*backendConn {
+	b.mu.Lock()
+	var idle []*backendConn
+	for c := range b.conns {
+		if c.owner.Load() == nil && c.opened.Before(cutoff) {
+			idle = append(idle, c)
+		}
+	}
+	b.mu.Unlock()
+
+	slices.SortFunc(idle, func(a, b *backendConn) int { return a.opened.Compare(b.opened) })
+	return idle
+}
+
+// RebalanceConnections closes a slice of the oldest idle backend connections, per transport.rebalance
+// The next requests dial (and resolve) their backend afresh instead of sticking to connections from before a scale-up
+// Like a backend's own idle timeout, a request racing for a closing connection is retried by the transport when replayable
+func (p *HTTPProxy) RebalanceConnections() {
+	settings := p.configLoader.Get().Transport.Rebalance
+	idle := p.conns.idleBefore(rebalanceCutoff(settings))
+	recycled := idle[:rebalanceSlice(len(idle), settings.Fraction)]
+	if len(recycled) == 0 {
+		return
+	}
+
+	for _, c := range recycled {
+		_ = c.Close()
+	}
+	metrics.BackendConnectionsRecycled.WithLabelValues(p.network, p.endpointType).Add(float64(len(recycled)))
+	p.logger.Debug("Recycled idle backend connections",
+		zap.String("network", p.network),
+		zap.String("type", p.endpointType),
+		zap.Int("recycled", len(recycled)),
+		zap.Int("idle", len(idle)),
+	)
+}
+
+// pooledConn is a backend connection of the gRPC pool, counted while streams use it
+type pooledConn struct {
+	*grpc.ClientConn
+	addr      string
+	opened    time.Time
+	streams   atomic.Int64
+	retired   atomic.Bool // out of the pool, closed once its last stream ends
+	closeOnce sync.Once
+}
+
+// release ends a stream's use of the connection, closing it when it was retired and this was the last stream
+func (c *pooledConn) release() {
+	if c.streams.Add(-1) == 0 && c.retired.Load() {
+		c.close()
+	}
+}
+
+// close closes the connection once, however many paths race to it
+func (c *pooledConn) close() {
+	c.closeOnce.Do(func() { _ = c.ClientConn.Close() })
+}
+
+// RebalanceConnections retires a slice of the oldest pooled backend connections, per transport.rebalance
+// New streams dial a replacement (resolving the target afresh); streams already open finish on the old connection,
+// which closes after the last one
+func (p *GRPCProxy) RebalanceConnections() {
+	settings := p.configLoader.Get().Transport.Rebalance
+	cutoff := rebalanceCutoff(settings)
+
+	p.connMu.Lock()
+	var eligible []*pooledConn
+	for _, c := range p.connPool {
+		if c.opened.Before(cutoff) {
+			eligible = append(eligible, c)
+		}
+	}
+	slices.SortFunc(eligible, func(a, b *pooledConn) int { return a.opened.Compare(b.opened) })
+	retired := eligible[:rebalanceSlice(len(eligible), settings.Fraction)]
+	for _, c := range retired {
+		delete(p.connPool, c.addr)
+		c.retired.Store(true)
+	}
+	p.connMu.Unlock()
+
+	if len(retired) == 0 {
+		return
+	}
+	for _, c := range retired {
+		if c.streams.Load() == 0 {
+			c.close()
+		}
+	}
+	metrics.BackendConnectionsRecycled.WithLabelValues(p.network, "grpc").Add(float64(len(retired)))
+	p.logger.Debug("Retired pooled gRPC connections",
+		zap.String("network", p.network),
+		zap.Int("retired", len(retired)),
+		zap.Int("eligible", len(eligible)),
+	)
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"sauron/config"
+)
+
+// DefaultRetryMaxAttempts is how many times a request is tried in total when retry.max_attempts is unset
+const DefaultRetryMaxAttempts = 2
+
+// maxRetryBody bounds the request body kept to replay on another node; larger requests are not retried
+const maxRetryBody = 1 << 20
+
+// retrySafe reports whether a request may be repeated on another node after it reached a backend
+// Retry rules win; otherwise reads are safe: GET/HEAD/OPTIONS, Cosmos REST simulate and JSON-RPC query methods
+// Broadcasts, JSON-RPC batches, unreadable bodies and other POSTs are unsafe
+func retrySafe(rules []config.RetryRule, endpointType, method string, r *http.Request) bool {
+	for i := range rules {
+		if matchRetryRule(&rules[i], endpointType, method, r) {
+			return rules[i].Safe
+		}
+	}
+
+	// The Tendermint URI form broadcasts with GET too (/broadcast_tx_sync?tx=...)
+	if isBroadcast(endpointType, method, r) {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+	default:
+		return false
+	}
+
+	if endpointType == "rpc" {
+		switch method {
+		case rpcMethodBatch, rpcMethodUnknown, rpcMethodOther:
+			return false
+		}
+		return !strings.HasPrefix(method, "eth_send") && !strings.HasPrefix(method, "personal_")
+	}
+	return strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/cosmos/tx/v1beta1/simulate")
+}
+
+// matchRetryRule reports whether a retry rule applies to a request; empty fields match anything
+func matchRetryRule(rule *config.RetryRule, endpointType, method string, r *http.Request) bool {
+	if rule.Type != "" && rule.Type != endpointType {
+		return false
+	}
+	if len(rule.Methods) > 0 && !slices.Contains(rule.Methods, r.Method) {
+		return false
+	}
+	if len(rule.RPCMethods) > 0 && (endpointType != "rpc" || !slices.Contains(rule.RPCMethods, method)) {
+		return false
+	}
+	return rule.Path == "" || matchPath(rule.Path, r.URL.Path)
+}
+
+// replayableBody reads the request body so every attempt can send it again
+// Returns false, with the body restored, when it is larger than maxRetryBody
+func replayableBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRetryBody+1))
+	if err != nil || len(body) > maxRetryBody {
+		r.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		return nil, false
+	}
+	_ = r.Body.Close()
+	return body, true
+}
@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxRPCMethodPeek bounds how much of a request body is read to find the JSON-RPC method
+	maxRPCMethodPeek = 64 << 10
+
+	// maxRPCMethodLabels bounds distinct method label values, since clients choose them
+	maxRPCMethodLabels = 256
+
+	// maxRPCMethodLength bounds a single method label value
+	maxRPCMethodLength = 64
+
+	rpcMethodBatch   = "batch"   // JSON-RPC batch request
+	rpcMethodUnknown = "unknown" // body too large, not JSON-RPC or unreadable
+	rpcMethodOther   = "other"   // label limit reached or invalid method name
+)
+
+// rpcMethodLabels remembers which method names have been used as labels
+var rpcMethodLabels = struct {
+	sync.Mutex
+	seen map[string]struct{}
+}{seen: make(map[string]struct{})}
+
+// rpcMethod extracts the chain method of an RPC request for metrics
+// POST bodies are JSON-RPC ({"method": "..."}); GET requests use the URI form (/status, /block?height=1)
+// The body is restored so it can still be proxied
+func rpcMethod(r *http.Request) string {
+	if r.Method != http.MethodPost {
+		segment := strings.Trim(r.URL.Path, "/")
+		if i := strings.Index(segment, "/"); i >= 0 {
+			segment = segment[:i]
+		}
+		if segment == "" {
+			return r.Method
+		}
+		return rpcMethodLabel(segment)
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return rpcMethodUnknown
+	}
+
+	trimmed, ok := peekRPCBody(r)
+	if !ok {
+		return rpcMethodUnknown
+	}
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return rpcMethodBatch
+	}
+
+	var req struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(trimmed, &req); err != nil || req.Method == "" {
+		return rpcMethodUnknown
+	}
+
+	return rpcMethodLabel(req.Method)
+}
+
+// rpcMethodNames returns every chain method an RPC request calls, for user method allowlists
+// Unlike rpcMethod it returns raw names and expands batches; ok is false when the body can't be read as JSON-RPC
+// The URI form serves /<method> for any HTTP verb, so a POST to a method path calls that method as well
+func rpcMethodNames(r *http.Request) ([]string, bool) {
+	segment := strings.Trim(cleanPath(r.URL.Path), "/")
+	if i := strings.Index(segment, "/"); i >= 0 {
+		segment = segment[:i]
+	}
+	if r.Method != http.MethodPost {
+		return []string{segment}, segment != ""
+	}
+
+	methods, ok := jsonRPCMethodNames(r)
+	if segment == "" {
+		return methods, ok
+	}
+	return append([]string{segment}, methods...), true
+}
+
+// jsonRPCMethodNames returns the methods called by a JSON-RPC body, expanding batches
+func jsonRPCMethodNames(r *http.Request) ([]string, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+	trimmed, ok := peekRPCBody(r)
+	if !ok {
+		return nil, false
+	}
+
+	type call struct {
+		Method string `json:"method"`
+	}
+	var calls []call
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &calls); err != nil || len(calls) == 0 {
+			return nil, false
+		}
+	} else {
+		var single call
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return nil, false
+		}
+		calls = []call{single}
+	}
+
+	methods := make([]string, 0, len(calls))
+	for _, c := range calls {
+		if c.Method == "" {
+			return nil, false
+		}
+		methods = append(methods, c.Method)
+	}
+	return methods, true
+}
+
+// peekRPCBody reads a bounded prefix of the body and restores it so it can still be proxied
+// ok is false when the body is larger than maxRPCMethodPeek or unreadable
+func peekRPCBody(r *http.Request) ([]byte, bool) {
+	peek, err := io.ReadAll(io.LimitReader(r.Body, maxRPCMethodPeek+1))
+	r.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(peek), r.Body), Closer: r.Body}
+	if err != nil || len(peek) > maxRPCMethodPeek {
+		return nil, false
+	}
+	return bytes.TrimSpace(peek), true
+}
+
+// rpcMethodLabel keeps label cardinality bounded: odd names and names past the limit become "other"
+func rpcMethodLabel(method string) string {
+	if len(method) > maxRPCMethodLength {
+		return rpcMethodOther
+	}
+	for _, c := range method {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			return rpcMethodOther
+		}
+	}
+
+	rpcMethodLabels.Lock()
+	defer rpcMethodLabels.Unlock()
+
+	if _, ok := rpcMethodLabels.seen[method]; ok {
+		return method
+	}
+	if len(rpcMethodLabels.seen) >= maxRPCMethodLabels {
+		return rpcMethodOther
+	}
+	rpcMethodLabels.seen[method] = struct{}{}
+	return method
+}
+
+// peekedBody replays the bytes read for method extraction, then the rest of the original body
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"sauron/abuse"
+	"sauron/config"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// JSON-RPC 2.0 error codes returned for requests refused at the edge
+const (
+	jsonRPCParseError     = -32700
+	jsonRPCInvalidRequest = -32600
+	jsonRPCMethodNotFound = -32601
+)
+
+// cometRPCMethods are the public CometBFT (Tendermint) RPC methods
+// Unsafe methods (dial_seeds, dial_peers, unsafe_flush_mempool) are left out on purpose
+var cometRPCMethods = []string{
+	"abci_info", "abci_query",
+	"block", "block_by_hash", "block_results", "block_search", "blockchain",
+	"broadcast_evidence", "broadcast_tx_async", "broadcast_tx_commit", "broadcast_tx_sync",
+	"check_tx", "commit", "consensus_params", "consensus_state", "dump_consensus_state",
+	"genesis", "genesis_chunked", "header", "header_by_hash", "health",
+	"net_info", "num_unconfirmed_txs", "status",
+	"subscribe", "tx", "tx_search", "unconfirmed_txs", "unsubscribe", "unsubscribe_all", "validators",
+}
+
+// rpcRejection is why a request was refused: its metric reason, JSON-RPC error code and message
+type rpcRejection struct {
+	reason  string
+	code    int
+	message string
+}
+
+// rpcValidationMiddleware refuses RPC requests that aren't well-formed JSON-RPC 2.0 calls of known methods
+// with 400 and a JSON-RPC error, so garbage never reaches backends; API requests pass through
+// Bodies over 64KB are not inspected and pass through, bounded by the backend's own limits
+func (p *HTTPProxy) rpcValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.endpointType != "rpc" || isWebSocketRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rejection, id := validateRPCRequest(p.networkConfig().RPCValidation, r)
+		if rejection == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		metrics.RPCValidationRejections.WithLabelValues(p.network, rejection.reason).Inc()
+		p.abuse.Record(r, abuse.EventMalformed)
+		p.logger.Debug("RPC request refused by validation",
+			zap.String("network", p.network),
+			zap.String("reason", rejection.reason),
+			zap.String("detail", rejection.message),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+		writeJSONRPCError(w, id, rejection)
+	})
+}
+
+// validateRPCRequest checks a JSON-RPC POST body or a URI form GET; returns the rejection (nil when valid)
+// and the id to answer with (null when unknown or for batches)
+func validateRPCRequest(settings config.RPCValidation, r *http.Request) (*rpcRejection, json.RawMessage) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// URI form: the method is the first path segment; "/" lists the routes
+		method := strings.Trim(r.URL.Path, "/")
+		if method == "" {
+			return nil, nil
+		}
+		return checkRPCMethod(settings, method), nil
+	case http.MethodPost:
+	default:
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "only GET and POST are supported"}, nil
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return &rpcRejection{"parse_error", jsonRPCParseError, "empty body"}, nil
+	}
+	body, ok := peekRPCBody(r)
+	if !ok {
+		return nil, nil
+	}
+	if len(body) == 0 {
+		return &rpcRejection{"parse_error", jsonRPCParseError, "empty body"}, nil
+	}
+
+	if body[0] != '[' {
+		var call json.RawMessage
+		if err := json.Unmarshal(body, &call); err != nil {
+			return &rpcRejection{"parse_error", jsonRPCParseError, "invalid JSON"}, nil
+		}
+		return validateRPCCall(settings, call)
+	}
+
+	var calls []json.RawMessage
+	if err := json.Unmarshal(body, &calls); err != nil {
+		return &rpcRejection{"parse_error", jsonRPCParseError, "invalid JSON"}, nil
+	}
+	if len(calls) == 0 {
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "empty batch"}, nil
+	}
+	if settings.MaxBatch > 0 && len(calls) > settings.MaxBatch {
+		return &rpcRejection{"batch_too_large", jsonRPCInvalidRequest, "too many calls in batch"}, nil
+	}
+	for _, call := range calls {
+		if rejection, _ := validateRPCCall(settings, call); rejection != nil {
+			return rejection, nil
+		}
+	}
+	return nil, nil
+}
+
+// validateRPCCall checks one call: "jsonrpc": "2.0", a string or number id, a known method,
+// and params, when present, an object or array
+func validateRPCCall(settings config.RPCValidation, raw json.RawMessage) (*rpcRejection, json.RawMessage) {
+	var call struct {
+		JSONRPC *string         `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  *string         `json:"method"`
+		Params  json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(raw, &call); err != nil {
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "call is not an object"}, nil
+	}
+
+	// Answer with the caller's id once it is known to be valid
+	var id json.RawMessage
+	switch {
+	case len(call.ID) == 0 || bytes.Equal(call.ID, []byte("null")):
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "missing id"}, nil
+	case call.ID[0] == '"' || call.ID[0] == '-' || call.ID[0] >= '0' && call.ID[0] <= '9':
+		id = call.ID
+	default:
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "id must be a string or number"}, nil
+	}
+
+	if call.JSONRPC == nil || *call.JSONRPC != "2.0" {
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, `jsonrpc must be "2.0"`}, id
+	}
+	if call.Method == nil || *call.Method == "" {
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "missing method"}, id
+	}
+	if len(call.Params) > 0 && call.Params[0] != '{' && call.Params[0] != '[' && !bytes.Equal(call.Params, []byte("null")) {
+		return &rpcRejection{"invalid_request", jsonRPCInvalidRequest, "params must be an object or array"}, id
+	}
+	return checkRPCMethod(settings, *call.Method), id
+}
+
+// checkRPCMethod refuses methods that are neither CometBFT RPC methods nor extra_methods
+func checkRPCMethod(settings config.RPCValidation, method string) *rpcRejection {
+	if settings.AnyMethod || slices.Contains(cometRPCMethods, method) || slices.Contains(settings.ExtraMethods, method) {
+		return nil
+	}
+	return &rpcRejection{"method_not_found", jsonRPCMethodNotFound, "method not found"}
+}
+
+// writeJSONRPCError answers a refused request with 400 and a JSON-RPC error object
+func writeJSONRPCError(w http.ResponseWriter, id json.RawMessage, rejection *rpcRejection) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	resp := struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}{JSONRPC: "2.0", ID: id}
+	resp.Error.Code = rejection.code
+	resp.Error.Message = rejection.message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sauron/config"
+)
+
+const (
+	// maxCachedResponses bounds the response cache of one proxy; expired entries are evicted first
+	maxCachedResponses = 1000
+	// maxCachedBody is the largest response body the cache keeps
+	maxCachedBody = 1 << 20
+)
+
+// CacheHeader tells clients whether a response came from a cache rule ("hit") or the backend ("miss")
+const CacheHeader = "X-Sauron-Cache"
+
+// matchRule returns the first rule of a network matching the request, or nil
+func matchRule(rules []config.RouteRule, endpointType string, r *http.Request) *config.RouteRule {
+	requestPath := cleanPath(r.URL.Path)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Type != "" && rule.Type != endpointType {
+			continue
+		}
+		if len(rule.Methods) > 0 && !slices.Contains(rule.Methods, r.Method) {
+			continue
+		}
+		if matchPath(rule.Path, requestPath) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// matchPath matches an exact path, or a prefix when the pattern ends in "*"
+func matchPath(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == pattern
+}
+
+// cleanPath resolves dot segments in a request path so scopes and rules see the path a backend would serve
+// The path is already percent-decoded, so encoded slashes and dots are resolved too; a trailing slash is kept
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// rewritePath replaces the part of the cleaned path a rule matched with its rewrite
+func rewritePath(r *http.Request, rule *config.RouteRule) {
+	if prefix, ok := strings.CutSuffix(rule.Path, "*"); ok {
+		r.URL.Path = rule.Rewrite + strings.TrimPrefix(cleanPath(r.URL.Path), prefix)
+	} else {
+		r.URL.Path = rule.Rewrite
+	}
+	r.URL.RawPath = ""
+}
+
+// cachedResponse is a backend response kept by a cache rule
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// responseCache keeps successful GET responses for cache rules
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse // method + request URI -> response
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: make(map[string]cachedResponse)}
+}
+
+// cacheKey identifies a cacheable request
+func cacheKey(r *http.Request) string {
+	return r.Method + " " + r.URL.RequestURI()
+}
+
+// serve writes a cached response if a fresh one exists
+func (c *responseCache) serve(w http.ResponseWriter, key string) bool {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	for name, values := range entry.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(CacheHeader, "hit")
+	w.WriteHeader(entry.status)
+	_, _ = w.Write(entry.body)
+	return true
+}
+
+// store keeps a response until ttl passes
+func (c *responseCache) store(key string, status int, header http.Header, body []byte, ttl time.Duration) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxCachedResponses {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCachedResponses {
+			return
+		}
+	}
+	c.entries[key] = cachedResponse{status: status, header: header, body: body, expires: now.Add(ttl)}
+}
+
+// cacheWriter copies a response as it is written so a cache rule can keep it
+type cacheWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool // body exceeded maxCachedBody, don't cache
+}
+
+func (cw *cacheWriter) WriteHeader(code int) {
+	if cw.status == 0 {
+		cw.status = code
+		cw.ResponseWriter.Header().Set(CacheHeader, "miss")
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.overflow {
+		if cw.body.Len()+len(b) > maxCachedBody {
+			cw.overflow = true
+			cw.body = bytes.Buffer{}
+		} else {
+			cw.body.Write(b)
+		}
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *cacheWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *cacheWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// cacheable reports whether the captured response may be reused
+func (cw *cacheWriter) cacheable() bool {
+	return cw.status == http.StatusOK && !cw.overflow
+}
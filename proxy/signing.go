@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sauron/config"
+	"sauron/storage"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Ring signature headers, set on requests forwarded to the endpoints of an external with a signing_secret
+// gRPC calls carry them as metadata (lowercase)
+const (
+	RingNameHeader      = "X-Sauron-Ring"
+	RingTimestampHeader = "X-Sauron-Timestamp"
+	RingSignatureHeader = "X-Sauron-Signature"
+)
+
+// grpcSignedMethod is the method gRPC calls are signed with, their target being the full method
+// (gRPC calls are HTTP/2 POSTs to it)
+const grpcSignedMethod = http.MethodPost
+
+// DefaultRingSignatureMaxSkew is how far an incoming signature's timestamp may be from our clock
+const DefaultRingSignatureMaxSkew = 5 * time.Minute
+
+// maxSignedBody bounds the HTTP request body hashed into a ring signature; larger requests can't be signed
+const maxSignedBody = 4 << 20
+
+// emptyBodyHash is the body hash of requests without a body, and of gRPC calls, whose messages are streamed
+var emptyBodyHash = bodyHash(nil)
+
+// ringSignature is the hex HMAC-SHA256 of the ring name, unix timestamp, method, target and body hash
+// The target is the path and query of an HTTP request, or the full method of a gRPC call
+func ringSignature(secret, ring, timestamp, method, target, bodyHash string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ring + "\n" + timestamp + "\n" + method + "\n" + target + "\n" + bodyHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// bodyHash is the hex SHA-256 of a request body
+func bodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// requestBodyHash hashes an HTTP request body and restores it so it can still be proxied
+// ok is false when the body is larger than maxSignedBody or unreadable
+func requestBodyHash(r *http.Request) (string, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return emptyBodyHash, true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+	r.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	if err != nil || len(body) > maxSignedBody {
+		return "", false
+	}
+	return bodyHash(body), true
+}
+
+// signingSecret returns the secret requests to a node are signed with
+// Only endpoints advertised by an external with a signing_secret are signed, and only once ring_signing.name is set
+func signingSecret(cfg *config.Config, store *storage.ExternalEndpointStore, network, endpointType, nodeName string) (string, bool) {
+	url, ok := strings.CutPrefix(nodeName, "ext:")
+	if !ok || store == nil || cfg.RingSigning.Name == "" {
+		return "", false
+	}
+
+	name := store.GetExternalName(network, endpointType, url)
+	for _, external := range cfg.Externals {
+		if external.Name == name && external.SigningSecret != "" {
+			return external.SigningSecret, true
+		}
+	}
+	return "", false
+}
+
+// signRequest sets the signature headers through set (http.Header.Set or metadata.MD.Set)
+func signRequest(set func(key, value string), ring, secret, method, target, bodyHash string, now time.Time) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	set(RingNameHeader, ring)
+	set(RingTimestampHeader, timestamp)
+	set(RingSignatureHeader, ringSignature(secret, ring, timestamp, method, target, bodyHash))
+}
+
+// stripRingSignature removes signature headers, so a peer's signature is never passed on
+func stripRingSignature(h http.Header) {
+	h.Del(RingNameHeader)
+	h.Del(RingTimestampHeader)
+	h.Del(RingSignatureHeader)
+}
+
+// stripRingSignatureMetadata is stripRingSignature for gRPC metadata
+func stripRingSignatureMetadata(md metadata.MD) {
+	md.Delete(RingNameHeader)
+	md.Delete(RingTimestampHeader)
+	md.Delete(RingSignatureHeader)
+}
+
+// verifyRingSignature checks a signed request against the trusted rings; returns the failure reason when refused
+func verifyRingSignature(signing config.RingSigning, ring, timestamp, signature, method, target, bodyHash string, now time.Time) (string, bool) {
+	if ring == "" || signature == "" {
+		return "missing_signature", false
+	}
+	trusted := signing.FindTrustedRing(ring)
+	if trusted == nil {
+		return "untrusted_ring", false
+	}
+
+	maxSkew := signing.MaxSkew
+	if maxSkew == 0 {
+		maxSkew = DefaultRingSignatureMaxSkew
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || now.Sub(time.Unix(unix, 0)).Abs() > maxSkew {
+		return "stale_signature", false
+	}
+
+	want := ringSignature(trusted.Secret, ring, timestamp, method, target, bodyHash)
+	if !hmac.Equal([]byte(want), []byte(signature)) {
+		return "invalid_signature", false
+	}
+	return "", true
+}
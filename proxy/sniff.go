@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	"google.golang.org/grpc/codes"
+)
+
+// http2ClientPreface is the connection preface every HTTP/2 (and therefore gRPC) client sends first
+var http2ClientPreface = []byte(http2.ClientPreface)
+
+// http1Methods are the request line prefixes of plain HTTP/1.x requests
+var http1Methods = [][]byte{
+	[]byte("GET "),
+	[]byte("POST "),
+	[]byte("PUT "),
+	[]byte("HEAD "),
+	[]byte("DELETE "),
+	[]byte("OPTIONS "),
+	[]byte("PATCH "),
+	[]byte("CONNECT "),
+	[]byte("TRACE "),
+}
+
+// http1MethodPeekLength covers the longest HTTP/1.x method plus its trailing space
+// Every real HTTP/1.x request is longer, so peeking this much never blocks a valid client
+const http1MethodPeekLength = len("OPTIONS ")
+
+// sniffRejectTimeout bounds how long a rejected gRPC client may take to send its first call
+const sniffRejectTimeout = 5 * time.Second
+
+// ProtocolSniffingListener wraps a proxy listener and rejects clients that speak the
+// wrong protocol for the port (HTTP/1.1 on a gRPC port, gRPC on an API/RPC port)
+// The gatekeeper who turns away travellers knocking on the wrong door
+type ProtocolSniffingListener struct {
+	net.Listener
+	network      string // The network this listener serves
+	endpointType string // "api", "rpc" or "grpc"
+	logger       *zap.Logger
+}
+
+// NewProtocolSniffingListener wraps a listener with protocol mismatch detection
+func NewProtocolSniffingListener(lis net.Listener, network, endpointType string, logger *zap.Logger) *ProtocolSniffingListener {
+	return &ProtocolSniffingListener{
+		Listener:     lis,
+		network:      network,
+		endpointType: endpointType,
+		logger:       logger,
+	}
+}
+
+// Accept waits for the next connection and wraps it for lazy protocol sniffing
+// Sniffing happens on the first Read so a slow client never blocks the accept loop
+func (l *ProtocolSniffingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &sniffConn{Conn: conn, reader: bufio.NewReader(conn), listener: l}, nil
+}
+
+// sniffConn inspects the first bytes of a connection before handing it to the server
+type sniffConn struct {
+	net.Conn
+	reader   *bufio.Reader
+	listener *ProtocolSniffingListener
+	once     sync.Once
+	err      error // set when the connection was rejected
+}
+
+func (c *sniffConn) Read(b []byte) (int, error) {
+	c.once.Do(c.sniff)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// Write also waits for sniffing because the gRPC server sends its SETTINGS frame
+// before reading anything, which would otherwise garble our HTTP/1.1 rejection
+func (c *sniffConn) Write(b []byte) (int, error) {
+	c.once.Do(c.sniff)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Write(b)
+}
+
+// sniff peeks at the client's first bytes and rejects mismatched protocols
+func (c *sniffConn) sniff() {
+	// Peek returns fewer bytes with an error if the client sends less; that is fine,
+	// the prefix checks below simply won't match and the server handles the rest
+	switch c.listener.endpointType {
+	case "grpc":
+		peeked, _ := c.reader.Peek(http1MethodPeekLength)
+		if isHTTP1Request(peeked) {
+			c.reject("http1", c.writeHTTP1Rejection)
+		}
+	case "api", "rpc":
+		// Short HTTP/1.0 requests can be under 24 bytes, so only wait for the full
+		// preface once the "PRI " method proves this is an HTTP/2 client
+		if peeked, _ := c.reader.Peek(4); !bytes.Equal(peeked, http2ClientPreface[:4]) {
+			return
+		}
+		if peeked, _ := c.reader.Peek(len(http2ClientPreface)); bytes.Equal(peeked, http2ClientPreface) {
+			c.reject("grpc", c.writeHTTP2Rejection)
+		}
+	}
+}
+
+// reject records the mismatch, sends a descriptive error and closes the connection
+func (c *sniffConn) reject(detected string, write func(msg string) error) {
+	msg := protocolMismatchMessage(c.listener.endpointType, detected)
+
+	metrics.ProtocolMismatches.WithLabelValues(c.listener.network, c.listener.endpointType, detected).Inc()
+	c.listener.logger.Warn("Protocol mismatch on proxy listener",
+		zap.String("network", c.listener.network),
+		zap.String("listener", c.listener.endpointType),
+		zap.String("detected", detected),
+		zap.String("remote_addr", c.RemoteAddr().String()),
+	)
+
+	if err := write(msg); err != nil {
+		c.listener.logger.Debug("Failed to write protocol mismatch response", zap.Error(err))
+	}
+	_ = c.Conn.Close()
+	c.err = io.EOF
+}
+
+// writeHTTP1Rejection answers an HTTP/1.x client with a plain-text 400 response
+func (c *sniffConn) writeHTTP1Rejection(msg string) error {
+	resp := fmt.Sprintf("HTTP/1.1 400 Bad Request\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Length: %d\r\n"+
+		"Connection: close\r\n\r\n%s", len(msg)+1, msg+"\n")
+	_, err := c.Conn.Write([]byte(resp))
+	return err
+}
+
+// writeHTTP2Rejection answers the first gRPC call with a trailers-only Unavailable status
+// so the client sees the message in its error (e.g. "rpc error: code = Unavailable desc = ...")
+// instead of opaque frame errors, then sends GOAWAY carrying the same message
+func (c *sniffConn) writeHTTP2Rejection(msg string) error {
+	// Discard the already-peeked preface; the framer reads the frames that follow it
+	if _, err := c.reader.Discard(len(http2ClientPreface)); err != nil {
+		return err
+	}
+	_ = c.Conn.SetDeadline(time.Now().Add(sniffRejectTimeout))
+
+	framer := http2.NewFramer(c.Conn, c.reader)
+	if err := framer.WriteSettings(); err != nil {
+		return err
+	}
+
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return err
+		}
+
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				if err := framer.WriteSettingsAck(); err != nil {
+					return err
+				}
+			}
+		case *http2.HeadersFrame:
+			var block bytes.Buffer
+			encoder := hpack.NewEncoder(&block)
+			for _, field := range []hpack.HeaderField{
+				{Name: ":status", Value: "200"},
+				{Name: "content-type", Value: "application/grpc"},
+				{Name: "grpc-status", Value: strconv.Itoa(int(codes.Unavailable))},
+				{Name: "grpc-message", Value: msg},
+			} {
+				if err := encoder.WriteField(field); err != nil {
+					return err
+				}
+			}
+			if err := framer.WriteHeaders(http2.HeadersFrameParam{
+				StreamID:      f.StreamID,
+				BlockFragment: block.Bytes(),
+				EndStream:     true,
+				EndHeaders:    true,
+			}); err != nil {
+				return err
+			}
+			return framer.WriteGoAway(f.StreamID, http2.ErrCodeHTTP11Required, []byte(msg))
+		}
+	}
+}
+
+// isHTTP1Request reports whether the peeked bytes start with an HTTP/1.x request line
+func isHTTP1Request(peeked []byte) bool {
+	for _, method := range http1Methods {
+		if bytes.HasPrefix(peeked, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolMismatchMessage builds the human-readable explanation sent to the client
+func protocolMismatchMessage(listener, detected string) string {
+	switch detected {
+	case "http1":
+		return "sauron: this port serves gRPC (HTTP/2) but received an HTTP/1.1 request; use the api or rpc port for REST/JSON-RPC traffic"
+	case "grpc":
+		return fmt.Sprintf("sauron: this port serves %s over HTTP/1.1 but received an HTTP/2 (gRPC) connection; use the grpc port for gRPC clients", listener)
+	}
+	return "sauron: protocol mismatch"
+}
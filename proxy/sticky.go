@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"sauron/clientip"
+	"sauron/config"
+	"sauron/selector"
+	"sauron/storage"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// stickyKey carries the sticky sessions client through the middleware chain
+type stickyKey struct{}
+
+// withStickyClient stores the client sticky sessions hash, before middleware rewrites headers
+func withStickyClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, stickyKey{}, client)
+}
+
+// stickyClientFrom returns the client stored by withStickyClient ("" when sticky_sessions is off)
+func stickyClientFrom(ctx context.Context) string {
+	client, _ := ctx.Value(stickyKey{}).(string)
+	return client
+}
+
+// httpStickyClient identifies the client of an HTTP request for sticky sessions:
+// the configured header when sent, otherwise the client IP (trusted_proxies honored)
+func httpStickyClient(trusted *clientip.Resolver, sticky config.StickySessions, r *http.Request) string {
+	if sticky.Header != "" {
+		if value := r.Header.Get(sticky.Header); value != "" {
+			return "header:" + value
+		}
+	}
+	return "ip:" + httpClientIP(trusted, r)
+}
+
+// grpcStickyClient identifies the client of a gRPC call for sticky sessions:
+// the configured metadata key when sent, otherwise the peer IP
+func grpcStickyClient(ctx context.Context, sticky config.StickySessions) string {
+	if sticky.Header != "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(sticky.Header); len(values) > 0 && values[0] != "" {
+				return "header:" + values[0]
+			}
+		}
+	}
+	return "ip:" + grpcPeerIP(ctx)
+}
+
+// bestNode returns the node a sticky client hashes to, or the best available without one
+func bestNode(sel selector.NodeSelector, network, endpointType, client string) (*storage.NodeMetrics, string, *selector.SelectionDecision) {
+	if client != "" {
+		return sel.GetStickyNode(network, endpointType, client)
+	}
+	return sel.GetBestNode(network, endpointType)
+}
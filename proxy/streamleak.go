@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sauron/clock"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Stream leak detector defaults (used when stream_leaks values are unset)
+const (
+	// DefaultStreamLeakMaxIdle is how long a gRPC stream may go without a message before it is suspected leaked
+	DefaultStreamLeakMaxIdle = 10 * time.Minute
+	// DefaultStreamLeakCheckInterval is how often open gRPC streams are checked
+	DefaultStreamLeakCheckInterval = 30 * time.Second
+)
+
+// errStreamIdle is the cancel cause of a stream force-closed by the leak detector
+var errStreamIdle = errors.New("stream closed by the leak detector after going idle")
+
+// openStream is a proxied gRPC stream watched by the leak detector
+type openStream struct {
+	node   string
+	method string
+	opened time.Duration // clock.Elapsed when the stream opened
+	active atomic.Int64  // clock.Elapsed of the last message in either direction
+	cancel context.CancelCauseFunc
+	idle   bool // already reported idle; guarded by openStreams.mu
+}
+
+// touch records a message forwarded on the stream
+func (s *openStream) touch() {
+	s.active.Store(int64(clock.Elapsed()))
+}
+
+// openStreams are the gRPC streams a proxy holds open
+type openStreams struct {
+	mu      sync.Mutex
+	next    uint64
+	streams map[uint64]*openStream
+}
+
+// add watches a stream until the returned func is called
+func (o *openStreams) add(stream *openStream) func() {
+	stream.touch()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.streams == nil {
+		o.streams = make(map[uint64]*openStream)
+	}
+	id := o.next
+	o.next++
+	o.streams[id] = stream
+
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		delete(o.streams, id)
+	}
+}
+
+// SweepStreams reports gRPC streams idle for longer than stream_leaks.max_idle, canceling them with force_close
+// It also exports the age of the oldest open stream; the server calls it every stream_leaks.check_interval
+func (p *GRPCProxy) SweepStreams() {
+	settings := p.configLoader.Get().StreamLeaks
+	maxIdle := settings.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = DefaultStreamLeakMaxIdle
+	}
+	now := clock.Elapsed()
+
+	var oldest time.Duration
+	var idle, reported []*openStream
+	p.streams.mu.Lock()
+	for _, stream := range p.streams.streams {
+		oldest = max(oldest, now-stream.opened)
+		if !settings.Enabled || now-time.Duration(stream.active.Load()) < maxIdle {
+			stream.idle = false
+			continue
+		}
+		idle = append(idle, stream)
+		if !stream.idle {
+			stream.idle = true
+			reported = append(reported, stream)
+		}
+	}
+	p.streams.mu.Unlock()
+
+	metrics.GRPCOldestStreamAge.WithLabelValues(p.network).Set(oldest.Seconds())
+	metrics.GRPCIdleStreams.WithLabelValues(p.network).Set(float64(len(idle)))
+
+	for _, stream := range reported {
+		p.logger.Warn("gRPC stream idle, possible leak",
+			zap.String("network", p.network),
+			zap.String("node", stream.node),
+			zap.String("method", stream.method),
+			zap.Duration("age", now-stream.opened),
+			zap.Duration("idle", now-time.Duration(stream.active.Load())),
+			zap.Bool("force_close", settings.ForceClose),
+		)
+	}
+	if settings.ForceClose {
+		for _, stream := range idle {
+			stream.cancel(errStreamIdle)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"sauron/metrics"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Stream kinds used in connection-level metrics
+const (
+	streamKindWebSocket = "websocket"
+	streamKindGRPC      = "grpc_stream"
+)
+
+// streamEnd reports which side of a proxied connection finished first and why
+type streamEnd struct {
+	side string // "client" or "backend"
+	err  error
+}
+
+// streamError records which side of a proxied gRPC stream failed
+type streamError struct {
+	side string
+	err  error
+}
+
+func (e *streamError) Error() string { return e.err.Error() }
+func (e *streamError) Unwrap() error { return e.err }
+
+// trackStreamOpen counts a long-lived connection as open and returns a func that records its close
+func trackStreamOpen(network, node, kind string) func(reason string) {
+	start := time.Now()
+	metrics.ProxyOpenStreams.WithLabelValues(network, node, kind).Inc()
+
+	return func(reason string) {
+		metrics.ProxyOpenStreams.WithLabelValues(network, node, kind).Dec()
+		metrics.ProxyStreamDuration.WithLabelValues(network, kind, reason).Observe(time.Since(start).Seconds())
+		metrics.ProxyStreamCloses.WithLabelValues(network, node, kind, reason).Inc()
+	}
+}
+
+// webSocketCloseReason classifies how a proxied WebSocket connection ended
+func webSocketCloseReason(end streamEnd) string {
+	if end.err == nil || errors.Is(end.err, io.EOF) || errors.Is(end.err, net.ErrClosed) {
+		return end.side + "_closed"
+	}
+	return end.side + "_error"
+}
+
+// grpcCloseReason classifies how a proxied gRPC stream ended
+func grpcCloseReason(err error) string {
+	if err == nil {
+		return "completed"
+	}
+	if status.Code(err) == codes.Canceled {
+		return "client_canceled"
+	}
+	var se *streamError
+	if errors.As(err, &se) {
+		return se.side + "_error"
+	}
+	return "backend_error"
+}
@@ -0,0 +1,141 @@
+// Package ratelimit limits request rates per client with token buckets
+// Shared by the status API and the proxies
+package ratelimit
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"sauron/clientip"
+	"sauron/metrics"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultMaxEntries bounds the number of tracked clients when not configured
+const DefaultMaxEntries = 10000
+
+// Limiter manages per-client rate limiting using token bucket algorithm
+// The number of tracked clients is bounded; the least recently seen client is evicted first
+type Limiter struct {
+	limiters      map[string]*list.Element // ip -> element in lru (value: *limiterEntry)
+	lru           *list.List               // most recently used at the front
+	mu            sync.RWMutex
+	requestsPerIP int                // requests per time window
+	burst         int                // burst capacity
+	maxEntries    int                // maximum number of tracked IPs
+	resolver      *clientip.Resolver // decides whether X-Forwarded-For and similar headers are honored
+	cleanupTicker *time.Ticker       // periodic cleanup of old limiters
+}
+
+// limiterEntry is a tracked client IP and its token bucket
+type limiterEntry struct {
+	ip      string
+	limiter *rate.Limiter
+}
+
+// New creates a new rate limiter
+// requestsPerIP: number of requests allowed per second per IP
+// burst: maximum burst size (should be >= requestsPerIP)
+// maxEntries: maximum number of tracked clients (0 = DefaultMaxEntries)
+// resolver: extracts the client IP, honoring proxy headers (X-Forwarded-For, etc.) from trusted peers
+func New(requestsPerIP int, burst int, maxEntries int, resolver *clientip.Resolver) *Limiter {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	rl := &Limiter{
+		limiters:      make(map[string]*list.Element),
+		lru:           list.New(),
+		requestsPerIP: requestsPerIP,
+		burst:         burst,
+		maxEntries:    maxEntries,
+		resolver:      resolver,
+	}
+
+	// Start cleanup goroutine to prevent memory leaks
+	rl.cleanupTicker = time.NewTicker(5 * time.Minute)
+	go rl.cleanupLoop()
+
+	return rl
+}
+
+// Allow checks if a request from the given IP should be allowed
+func (rl *Limiter) Allow(r *http.Request) bool {
+	return rl.AllowKey(rl.resolver.ClientIP(r))
+}
+
+// AllowKey checks if a request from the given client (IP or other identity) should be allowed
+// Used where there is no *http.Request, e.g. gRPC streams keyed by peer address
+func (rl *Limiter) AllowKey(key string) bool {
+	rl.mu.Lock()
+	var limiter *rate.Limiter
+	if elem, exists := rl.limiters[key]; exists {
+		rl.lru.MoveToFront(elem)
+		limiter = elem.Value.(*limiterEntry).limiter
+	} else {
+		limiter = rate.NewLimiter(rate.Limit(rl.requestsPerIP), rl.burst)
+		rl.limiters[key] = rl.lru.PushFront(&limiterEntry{ip: key, limiter: limiter})
+
+		// Evict the least recently seen IPs once over the bound (e.g. spoofed-IP floods)
+		for len(rl.limiters) > rl.maxEntries {
+			rl.removeElement(rl.lru.Back())
+			metrics.RateLimiterEvictions.WithLabelValues("lru").Inc()
+		}
+		metrics.RateLimiterEntries.Set(float64(len(rl.limiters)))
+	}
+	rl.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// cleanupLoop periodically removes inactive limiters to prevent memory leaks
+func (rl *Limiter) cleanupLoop() {
+	for range rl.cleanupTicker.C {
+		rl.cleanup()
+	}
+}
+
+// cleanup removes limiters that haven't been used recently
+func (rl *Limiter) cleanup() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Remove limiters with no tokens reserved (inactive)
+	for _, elem := range rl.limiters {
+		// If limiter would allow a burst, it's been inactive
+		if elem.Value.(*limiterEntry).limiter.Tokens() >= float64(rl.burst) {
+			rl.removeElement(elem)
+			metrics.RateLimiterEvictions.WithLabelValues("idle").Inc()
+		}
+	}
+	metrics.RateLimiterEntries.Set(float64(len(rl.limiters)))
+}
+
+// removeElement drops a tracked IP (caller must hold the lock)
+func (rl *Limiter) removeElement(elem *list.Element) {
+	rl.lru.Remove(elem)
+	delete(rl.limiters, elem.Value.(*limiterEntry).ip)
+}
+
+// MaxEntries returns the maximum number of tracked clients
+func (rl *Limiter) MaxEntries() int {
+	return rl.maxEntries
+}
+
+// Len returns the number of tracked client IPs
+func (rl *Limiter) Len() int {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	return len(rl.limiters)
+}
+
+// Stop stops the cleanup goroutine
+func (rl *Limiter) Stop() {
+	if rl.cleanupTicker != nil {
+		rl.cleanupTicker.Stop()
+	}
+}
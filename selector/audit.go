@@ -0,0 +1,118 @@
+package selector
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"sauron/config"
+)
+
+// DefaultDecisionAuditSize is how many decisions are kept per network and endpoint type
+const DefaultDecisionAuditSize = 1000
+
+// DecisionRecord is a selection decision kept for auditing, with when and for what it was made
+type DecisionRecord struct {
+	Time    time.Time
+	Network string
+	Type    string
+	SelectionDecision
+}
+
+// decisionRing keeps the newest decisions of one network endpoint type, overwriting the oldest
+type decisionRing struct {
+	network string
+	typ     string
+	records []DecisionRecord
+	next    int // slot the next record goes to
+	count   int // records stored, up to len(records)
+}
+
+// decisionAudit keeps the last decisions of every network and endpoint type
+type decisionAudit struct {
+	mu    sync.Mutex
+	rings map[string]*decisionRing // network:type -> ring
+}
+
+// newDecisionAudit creates an empty decision audit
+func newDecisionAudit() *decisionAudit {
+	return &decisionAudit{
+		rings: make(map[string]*decisionRing),
+	}
+}
+
+// record stores a decision, resizing the ring when decision_audit.size changed
+func (a *decisionAudit) record(network, endpointType string, decision *SelectionDecision, size int) {
+	rec := DecisionRecord{
+		Time:              time.Now(),
+		Network:           network,
+		Type:              endpointType,
+		SelectionDecision: *decision,
+	}
+	key := network + ":" + endpointType
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ring, exists := a.rings[key]
+	if !exists || len(ring.records) != size {
+		resized := &decisionRing{network: network, typ: endpointType, records: make([]DecisionRecord, size)}
+		if exists {
+			// Keep the newest records that still fit
+			for _, old := range slices.Backward(ring.newestFirst()[:min(ring.count, size)]) {
+				resized.add(old)
+			}
+		}
+		ring = resized
+		a.rings[key] = ring
+	}
+	ring.add(rec)
+}
+
+// add stores a record in the next slot
+func (r *decisionRing) add(rec DecisionRecord) {
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	r.count = min(r.count+1, len(r.records))
+}
+
+// newestFirst returns a copy of the stored records, newest first
+func (r *decisionRing) newestFirst() []DecisionRecord {
+	out := make([]DecisionRecord, 0, r.count)
+	for i := 1; i <= r.count; i++ {
+		out = append(out, r.records[(r.next-i+len(r.records))%len(r.records)])
+	}
+	return out
+}
+
+// decisions returns the stored decisions matching network and type ("" matches any), newest first
+func (a *decisionAudit) decisions(network, endpointType string) []DecisionRecord {
+	a.mu.Lock()
+	var out []DecisionRecord
+	for _, ring := range a.rings {
+		if (network == "" || ring.network == network) && (endpointType == "" || ring.typ == endpointType) {
+			out = append(out, ring.newestFirst()...)
+		}
+	}
+	a.mu.Unlock()
+
+	slices.SortStableFunc(out, func(x, y DecisionRecord) int { return y.Time.Compare(x.Time) })
+	return out
+}
+
+// recordDecision keeps a decision for GET /admin/decisions when decision_audit is enabled
+func (s *Selector) recordDecision(cfg config.DecisionAudit, network, endpointType string, decision *SelectionDecision) {
+	if !cfg.Enabled {
+		return
+	}
+	size := cfg.Size
+	if size == 0 {
+		size = DefaultDecisionAuditSize
+	}
+	s.audit.record(network, endpointType, decision, size)
+}
+
+// Decisions returns the audited selection decisions of a network and endpoint type ("" matches any), newest first
+func (s *Selector) Decisions(network, endpointType string) []DecisionRecord {
+	return s.audit.decisions(network, endpointType)
+}
@@ -0,0 +1,75 @@
+package selector
+
+import (
+	"time"
+
+	"sauron/config"
+	"sauron/storage"
+)
+
+// Cost-aware selection defaults (used when values are unset)
+const (
+	// DefaultCostWeight is the cost of a node without cost_weight, and of every external endpoint
+	DefaultCostWeight = 1.0
+	// DefaultCostLatencyBand is how much slower than the fastest max-height candidate a node may be and still count as equal
+	DefaultCostLatencyBand = 50 * time.Millisecond
+)
+
+// cheapestInBand narrows max-height candidates to the cheapest of those within the latency band of the fastest
+// Candidates are returned unchanged when they all cost the same, so cost_aware alone never turns selection latency-based
+func cheapestInBand(cfg *config.Config, network string, nodes []nodeWithName) []nodeWithName {
+	band := cfg.CostAware.LatencyBand
+	if band == 0 {
+		band = DefaultCostLatencyBand
+	}
+
+	// Group nodes keep their name in every network, so costs are looked up per network
+	costs := make(map[string]float64)
+	for _, node := range cfg.Internals {
+		if node.Network == network && node.CostWeight > 0 {
+			costs[node.Name] = node.CostWeight
+		}
+	}
+	cost := func(name string) float64 {
+		if c, ok := costs[name]; ok {
+			return c
+		}
+		return DefaultCostWeight
+	}
+
+	fastest := tiebreakLatency(cfg, nodes[0].metrics)
+	uniform := true
+	for _, node := range nodes[1:] {
+		fastest = min(fastest, tiebreakLatency(cfg, node.metrics))
+		if cost(node.name) != cost(nodes[0].name) {
+			uniform = false
+		}
+	}
+	if uniform {
+		return nodes
+	}
+
+	cheapest := -1.0
+	for _, node := range nodes {
+		if tiebreakLatency(cfg, node.metrics) <= fastest+band && (cheapest < 0 || cost(node.name) < cheapest) {
+			cheapest = cost(node.name)
+		}
+	}
+
+	kept := make([]nodeWithName, 0, len(nodes))
+	for _, node := range nodes {
+		if tiebreakLatency(cfg, node.metrics) <= fastest+band && cost(node.name) == cheapest {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// tiebreakLatency returns the latency selection compares for a candidate: its average, or its p95 per latency_percentiles.tiebreak
+// Candidates without percentiles (externals) fall back to their average
+func tiebreakLatency(cfg *config.Config, m *storage.NodeMetrics) time.Duration {
+	if cfg.LatencyPercentiles.Tiebreak == config.LatencyTiebreakP95 && m.P95Latency > 0 {
+		return m.P95Latency
+	}
+	return m.AvgLatency
+}
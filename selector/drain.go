@@ -0,0 +1,131 @@
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// NodeDrain is an internal node taken out of selection for maintenance; requests it already serves finish
+type NodeDrain struct {
+	Network string
+	Node    string
+	Since   time.Time
+}
+
+// nodeDrains keeps the drained internal nodes until they are undrained
+type nodeDrains struct {
+	mu    sync.Mutex
+	nodes map[string]NodeDrain // network:node -> drain
+}
+
+// Drain stops selecting an internal node for new requests, in one network or every network it serves ("")
+// Manual pins still win; read-your-writes sessions and sticky clients move to other nodes
+// Draining a drained node keeps its original time
+func (s *Selector) Drain(network, nodeName string) ([]NodeDrain, error) {
+	networks := s.nodeNetworks(network, nodeName)
+	if len(networks) == 0 {
+		if network != "" {
+			return nil, fmt.Errorf("no internal node %q in network %s", nodeName, network)
+		}
+		return nil, fmt.Errorf("no internal node %q", nodeName)
+	}
+
+	now := time.Now()
+	drained := make([]NodeDrain, 0, len(networks))
+	s.drains.mu.Lock()
+	if s.drains.nodes == nil {
+		s.drains.nodes = make(map[string]NodeDrain)
+	}
+	for _, name := range networks {
+		drain, ok := s.drains.nodes[name+":"+nodeName]
+		if !ok {
+			drain = NodeDrain{Network: name, Node: nodeName, Since: now}
+			s.drains.nodes[name+":"+nodeName] = drain
+		}
+		drained = append(drained, drain)
+	}
+	s.drains.mu.Unlock()
+
+	for _, drain := range drained {
+		metrics.NodeDraining.WithLabelValues(drain.Network, drain.Node).Set(1)
+		s.logger.Warn("Node drained",
+			zap.String("network", drain.Network),
+			zap.String("node", drain.Node),
+		)
+	}
+	return drained, nil
+}
+
+// Undrain returns a drained internal node to selection, in one network or every network ("")
+// Returns the drains lifted, none if the node wasn't drained
+func (s *Selector) Undrain(network, nodeName string) []NodeDrain {
+	var lifted []NodeDrain
+	s.drains.mu.Lock()
+	for key, drain := range s.drains.nodes {
+		if drain.Node == nodeName && (network == "" || drain.Network == network) {
+			lifted = append(lifted, drain)
+			delete(s.drains.nodes, key)
+		}
+	}
+	s.drains.mu.Unlock()
+
+	sortDrains(lifted)
+	for _, drain := range lifted {
+		metrics.NodeDraining.WithLabelValues(drain.Network, drain.Node).Set(0)
+		s.logger.Info("Node undrained",
+			zap.String("network", drain.Network),
+			zap.String("node", drain.Node),
+		)
+	}
+	return lifted
+}
+
+// Drains returns the drained nodes, sorted by network and node
+func (s *Selector) Drains() []NodeDrain {
+	s.drains.mu.Lock()
+	drains := make([]NodeDrain, 0, len(s.drains.nodes))
+	for _, drain := range s.drains.nodes {
+		drains = append(drains, drain)
+	}
+	s.drains.mu.Unlock()
+
+	sortDrains(drains)
+	return drains
+}
+
+// draining reports whether an internal node of a network is drained
+func (s *Selector) draining(network, nodeName string) bool {
+	s.drains.mu.Lock()
+	defer s.drains.mu.Unlock()
+
+	_, ok := s.drains.nodes[network+":"+nodeName]
+	return ok
+}
+
+// nodeNetworks returns the networks an internal node is configured in, restricted to network unless ""
+// Group nodes keep their name in every network they serve
+func (s *Selector) nodeNetworks(network, nodeName string) []string {
+	var networks []string
+	for _, node := range s.configLoader.Get().Internals {
+		if node.Name == nodeName && (network == "" || node.Network == network) {
+			networks = append(networks, node.Network)
+		}
+	}
+	return networks
+}
+
+// sortDrains orders drains by network and node
+func sortDrains(drains []NodeDrain) {
+	sort.Slice(drains, func(i, j int) bool {
+		if drains[i].Network != drains[j].Network {
+			return drains[i].Network < drains[j].Network
+		}
+		return drains[i].Node < drains[j].Node
+	})
+}
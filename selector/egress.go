@@ -0,0 +1,5 @@
+package selector
+
+// DefaultInternalCapacity is how many in-flight requests an internal node absorbs before overflow goes to externals
+// Selection and the proxies' in-flight accounting are not atomic, so a burst may briefly overshoot it
+const DefaultInternalCapacity = 100
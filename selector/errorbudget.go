@@ -0,0 +1,137 @@
+package selector
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"sauron/clock"
+	"sauron/config"
+	"sauron/metrics"
+)
+
+// Error budget defaults (used when error_budget values are unset)
+const (
+	// DefaultErrorBudgetWindow is the rolling window proxy outcomes are counted over
+	DefaultErrorBudgetWindow = 5 * time.Minute
+	// DefaultErrorBudgetMinRequests is how many requests a node needs in the window before it is weighted
+	DefaultErrorBudgetMinRequests = 20
+	// DefaultErrorBudgetMinWeight is the lowest weight a failing node keeps, so it can still prove recovery
+	DefaultErrorBudgetMinWeight = 0.1
+)
+
+// errorBudgetSlots is how many buckets the window is split into; older buckets roll off one at a time
+const errorBudgetSlots = 10
+
+// outcomeSlot counts proxy outcomes in one slice of the window
+type outcomeSlot struct {
+	index    int64 // slot number since process start (monotonic), to detect stale slots
+	requests uint64
+	errors   uint64
+}
+
+// outcomeWindow keeps rolling proxy outcomes for one internal node endpoint
+type outcomeWindow struct {
+	width time.Duration // duration of one slot
+	slots [errorBudgetSlots]outcomeSlot
+}
+
+// errorBudget tracks rolling proxy error rates of internal nodes
+// Servants who fail the Dark Lord are sent to the gates less often
+type errorBudget struct {
+	mu      sync.Mutex
+	windows map[string]*outcomeWindow
+}
+
+// newErrorBudget creates an empty error budget tracker
+func newErrorBudget() *errorBudget {
+	return &errorBudget{
+		windows: make(map[string]*outcomeWindow),
+	}
+}
+
+// record adds a proxy outcome for an internal node endpoint
+func (b *errorBudget) record(network, endpointType, nodeName string, failed bool, cfg config.ErrorBudget) {
+	window := cfg.Window
+	if window == 0 {
+		window = DefaultErrorBudgetWindow
+	}
+	width := window / errorBudgetSlots
+	key := network + ":" + nodeName + ":" + endpointType
+	index := int64(clock.Elapsed() / width)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w, exists := b.windows[key]
+	if !exists || w.width != width {
+		// New node or the window changed on reload: start counting afresh
+		w = &outcomeWindow{width: width}
+		b.windows[key] = w
+	}
+
+	slot := &w.slots[index%errorBudgetSlots]
+	if slot.index != index {
+		*slot = outcomeSlot{index: index}
+	}
+	slot.requests++
+	if failed {
+		slot.errors++
+	}
+}
+
+// weight returns the selection weight of a node endpoint: 1 when healthy, lower as its error rate grows
+func (b *errorBudget) weight(network, endpointType, nodeName string, cfg config.ErrorBudget) float64 {
+	minRequests := cfg.MinRequests
+	if minRequests == 0 {
+		minRequests = DefaultErrorBudgetMinRequests
+	}
+	minWeight := cfg.MinWeight
+	if minWeight == 0 {
+		minWeight = DefaultErrorBudgetMinWeight
+	}
+	key := network + ":" + nodeName + ":" + endpointType
+
+	b.mu.Lock()
+	w, exists := b.windows[key]
+	var requests, errors uint64
+	if exists {
+		current := int64(clock.Elapsed() / w.width)
+		for _, slot := range w.slots {
+			if slot.index > current-errorBudgetSlots {
+				requests += slot.requests
+				errors += slot.errors
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	errorRate := 0.0
+	if requests > 0 {
+		errorRate = float64(errors) / float64(requests)
+	}
+
+	// Too few requests to judge; don't punish a node for one unlucky error
+	weight := 1.0
+	if requests >= uint64(minRequests) {
+		weight = max(1-errorRate, minWeight)
+	}
+
+	metrics.NodeProxyErrorRate.WithLabelValues(network, nodeName, endpointType).Set(errorRate)
+	metrics.NodeSelectionWeight.WithLabelValues(network, nodeName, endpointType).Set(weight)
+	return weight
+}
+
+// RecordOutcome feeds a proxied request's result into the node's rolling error rate and counts it for the scaling signal
+// Only internal nodes get an error rate; external endpoints have their own error tracking
+func (s *Selector) RecordOutcome(network, endpointType, nodeName string, failed bool) {
+	s.traffic.request(network)
+	if strings.HasPrefix(nodeName, "ext:") {
+		return
+	}
+	cfg := s.configLoader.Get()
+	if !cfg.ErrorBudget.Enabled {
+		return
+	}
+	s.errorBudget.record(network, endpointType, nodeName, failed, cfg.ErrorBudget)
+}
@@ -0,0 +1,31 @@
+package selector
+
+import (
+	"sync/atomic"
+
+	"sauron/config"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSelectorLogSampleEvery is how many selections share one logged decision in sampled mode
+const DefaultSelectorLogSampleEvery = 100
+
+// detailLogger returns where the per-request details of one selection are logged, per selector_log.mode
+// off drops them, sampled logs one selection in sample_every at Info, debug logs every selection at Debug
+// Routing metrics and state changes (failover, stale, no nodes) are recorded whatever the mode
+func (s *Selector) detailLogger(cfg config.SelectorLog) func(msg string, fields ...zap.Field) {
+	switch cfg.Mode {
+	case config.SelectorLogDebug:
+		return s.logger.Debug
+	case config.SelectorLogSampled:
+		every := cfg.SampleEvery
+		if every == 0 {
+			every = DefaultSelectorLogSampleEvery
+		}
+		if atomic.AddUint64(&s.logCounter, 1)%uint64(every) == 0 {
+			return s.logger.Info
+		}
+	}
+	return func(string, ...zap.Field) {}
+}
@@ -0,0 +1,180 @@
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sauron/metrics"
+	"sauron/storage"
+
+	"go.uber.org/zap"
+)
+
+// ManualPinReason is the selection reason of requests routed by a manual pin
+const ManualPinReason = "manual_pin"
+
+// MaxManualPinDuration bounds how long a node can be pinned, so a forgotten pin can't route forever
+const MaxManualPinDuration = 24 * time.Hour
+
+// ManualPin forces a network endpoint type to one node until it expires
+type ManualPin struct {
+	Network string
+	Type    string
+	Node    string
+	Since   time.Time
+	Until   time.Time
+}
+
+// manualPins keeps the active manual pins
+type manualPins struct {
+	mu   sync.Mutex
+	pins map[string]ManualPin // network:type -> pin
+}
+
+// Pin routes every request of a network endpoint type to one node for d, bypassing selection
+// (health, height, ejections and externals are ignored); the node must serve the network and type
+// Pinning a pinned network type replaces its pin
+func (s *Selector) Pin(network, endpointType, nodeName string, d time.Duration) (ManualPin, error) {
+	if d <= 0 || d > MaxManualPinDuration {
+		return ManualPin{}, fmt.Errorf("pin duration must be between 0 and %s", MaxManualPinDuration)
+	}
+	if !s.servesType(network, endpointType, nodeName) {
+		return ManualPin{}, fmt.Errorf("node %q doesn't serve %s %s", nodeName, network, endpointType)
+	}
+
+	now := time.Now()
+	pin := ManualPin{Network: network, Type: endpointType, Node: nodeName, Since: now, Until: now.Add(d)}
+
+	s.pins.mu.Lock()
+	if s.pins.pins == nil {
+		s.pins.pins = make(map[string]ManualPin)
+	}
+	s.pins.pins[network+":"+endpointType] = pin
+	s.pins.mu.Unlock()
+
+	s.logger.Warn("Node manually pinned",
+		zap.String("network", network),
+		zap.String("type", endpointType),
+		zap.String("node", nodeName),
+		zap.Duration("duration", d),
+	)
+	return pin, nil
+}
+
+// Unpin lifts the manual pin of a network endpoint type; returns false if there was none
+func (s *Selector) Unpin(network, endpointType string) bool {
+	s.pins.mu.Lock()
+	_, pinned := s.pins.active(network, endpointType, time.Now())
+	delete(s.pins.pins, network+":"+endpointType)
+	s.pins.mu.Unlock()
+
+	if pinned {
+		s.logger.Info("Manual pin lifted",
+			zap.String("network", network),
+			zap.String("type", endpointType),
+		)
+	}
+	return pinned
+}
+
+// Pins returns the active manual pins, sorted by network and type
+func (s *Selector) Pins() []ManualPin {
+	now := time.Now()
+
+	s.pins.mu.Lock()
+	pins := make([]ManualPin, 0, len(s.pins.pins))
+	for _, pin := range s.pins.pins {
+		if active, ok := s.pins.active(pin.Network, pin.Type, now); ok {
+			pins = append(pins, active)
+		}
+	}
+	s.pins.mu.Unlock()
+
+	sort.Slice(pins, func(i, j int) bool {
+		if pins[i].Network != pins[j].Network {
+			return pins[i].Network < pins[j].Network
+		}
+		return pins[i].Type < pins[j].Type
+	})
+	return pins
+}
+
+// pinnedNode returns the manually pinned node of a network endpoint type, if any
+func (s *Selector) pinnedNode(network, endpointType string) (string, bool) {
+	s.pins.mu.Lock()
+	defer s.pins.mu.Unlock()
+
+	pin, ok := s.pins.active(network, endpointType, time.Now())
+	return pin.Node, ok
+}
+
+// selectPinned routes to a manually pinned node; metrics are the last known ones (empty if none)
+func (s *Selector) selectPinned(network, endpointType, nodeName string) (*storage.NodeMetrics, string, *SelectionDecision) {
+	nodeMetrics := s.knownMetrics(network, endpointType, nodeName)
+	if nodeMetrics == nil {
+		nodeMetrics = &storage.NodeMetrics{}
+	}
+
+	decision := &SelectionDecision{
+		SelectedNode:    nodeName,
+		Reason:          ManualPinReason,
+		Candidates:      1,
+		MaxHeight:       nodeMetrics.Height,
+		SelectedLatency: nodeMetrics.AvgLatency,
+	}
+	metrics.RoutingSelections.WithLabelValues(network, endpointType, nodeName, decision.Reason).Inc()
+	cfg := s.configLoader.Get()
+	s.checkStale(decision, network, endpointType, nodeMetrics.Height, cfg.StaleThreshold)
+	s.recordDecision(cfg.DecisionAudit, network, endpointType, decision)
+
+	return nodeMetrics, nodeName, decision
+}
+
+// servesType reports whether an internal node is configured for a network endpoint type,
+// or an external endpoint ("ext:{url}") is validated for it
+func (s *Selector) servesType(network, endpointType, nodeName string) bool {
+	if url, ok := strings.CutPrefix(nodeName, "ext:"); ok {
+		if s.endpointStore == nil {
+			return false
+		}
+		for _, ep := range s.endpointStore.GetValidatedEndpoints(network, endpointType) {
+			if ep.URL == url {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, node := range s.configLoader.Get().Internals {
+		if node.Name != nodeName || node.Network != network {
+			continue
+		}
+		switch endpointType {
+		case "api":
+			return node.API != ""
+		case "rpc":
+			return node.RPC != ""
+		case "grpc":
+			return node.GRPC != ""
+		}
+	}
+	return false
+}
+
+// active returns the pin of a network endpoint type unless it expired; expired pins are dropped
+// Must be called with mu held
+func (p *manualPins) active(network, endpointType string, now time.Time) (ManualPin, bool) {
+	key := network + ":" + endpointType
+	pin, ok := p.pins[key]
+	if !ok {
+		return ManualPin{}, false
+	}
+	if !now.Before(pin.Until) {
+		delete(p.pins, key)
+		return ManualPin{}, false
+	}
+	return pin, true
+}
@@ -0,0 +1,173 @@
+package selector
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"sauron/clock"
+)
+
+// ScalingWindow is the sliding window the request rate and p95 latency of GET /admin/scaling are measured over
+const ScalingWindow = time.Minute
+
+// scalingSlots splits the window into one-second request counters
+const scalingSlots = int(ScalingWindow / time.Second)
+
+// scalingLatencySamples bounds the latencies kept per network; under heavy traffic the oldest are overwritten first
+const scalingLatencySamples = 4096
+
+// ScalingSignal summarizes the load of a network (or all of them) for autoscalers
+type ScalingSignal struct {
+	Window       time.Duration // what RequestRate and P95 are measured over (shorter right after startup)
+	RequestRate  float64       // proxied requests per second
+	P95          time.Duration // p95 proxy latency (0 without requests)
+	InFlight     int64         // requests being proxied now
+	Capacity     int64         // healthy internal nodes x egress.internal_capacity
+	Saturation   float64       // InFlight / Capacity; 1 when requests are in flight without healthy capacity
+	HealthyNodes int           // internal nodes at a height above 0 for at least one enabled endpoint type
+	Nodes        int           // internal nodes configured
+}
+
+// trafficSlot counts the requests of one second
+type trafficSlot struct {
+	index    int64 // second since process start, to detect stale slots
+	requests uint64
+}
+
+// trafficWindow keeps the recent request counts and latencies of one network
+type trafficWindow struct {
+	slots     [scalingSlots]trafficSlot
+	latencies [scalingLatencySamples]latencySample
+	next      int // slot the next latency goes to
+	count     int // latencies stored, up to scalingLatencySamples
+}
+
+// traffic tracks proxied requests per network, for the scaling signal
+// The tower counts who comes knocking, to know when to raise more orcs
+type traffic struct {
+	mu      sync.Mutex
+	windows map[string]*trafficWindow
+}
+
+// newTraffic creates an empty traffic tracker
+func newTraffic() *traffic {
+	return &traffic{windows: make(map[string]*trafficWindow)}
+}
+
+// window returns the window of a network, creating it on first use
+// Must be called with t.mu held
+func (t *traffic) window(network string) *trafficWindow {
+	w, ok := t.windows[network]
+	if !ok {
+		w = &trafficWindow{}
+		t.windows[network] = w
+	}
+	return w
+}
+
+// request counts a completed proxied request
+func (t *traffic) request(network string) {
+	second := int64(clock.Elapsed() / time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slot := &t.window(network).slots[second%int64(scalingSlots)]
+	if slot.index != second {
+		*slot = trafficSlot{index: second}
+	}
+	slot.requests++
+}
+
+// latency adds a proxied request's latency
+func (t *traffic) latency(network string, latency time.Duration) {
+	now := clock.Elapsed()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w := t.window(network)
+	w.latencies[w.next] = latencySample{at: now, latency: latency}
+	w.next = (w.next + 1) % scalingLatencySamples
+	w.count = min(w.count+1, scalingLatencySamples)
+}
+
+// stats returns the requests and p95 latency over the window of a network ("" = all networks)
+func (t *traffic) stats(network string) (uint64, time.Duration) {
+	now := clock.Elapsed()
+	second := int64(now / time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var requests uint64
+	var latencies []time.Duration
+	for name, w := range t.windows {
+		if network != "" && name != network {
+			continue
+		}
+		for _, slot := range w.slots {
+			if slot.index > second-int64(scalingSlots) {
+				requests += slot.requests
+			}
+		}
+		for _, sample := range w.latencies[:w.count] {
+			if sample.at > now-ScalingWindow {
+				latencies = append(latencies, sample.latency)
+			}
+		}
+	}
+	if len(latencies) == 0 {
+		return requests, 0
+	}
+	slices.Sort(latencies)
+	return requests, latencies[(len(latencies)*95+99)/100-1]
+}
+
+// Scaling returns the load of a network, or of all networks when network is ""
+// Request rate and p95 cover every proxied request, internal and external
+func (s *Selector) Scaling(network string) ScalingSignal {
+	cfg := s.configLoader.Get()
+
+	capacity := int64(cfg.Egress.InternalCapacity)
+	if capacity == 0 {
+		capacity = DefaultInternalCapacity
+	}
+
+	var signal ScalingSignal
+	for _, node := range cfg.Internals {
+		if network != "" && node.Network != network {
+			continue
+		}
+		signal.Nodes++
+		for _, endpointType := range cfg.GetEnabledTypes() {
+			if m, ok := s.store.Get(node.Network, node.Name, endpointType); ok && m.Height > 0 {
+				signal.HealthyNodes++
+				break
+			}
+		}
+	}
+	signal.Capacity = int64(signal.HealthyNodes) * capacity
+
+	for _, n := range cfg.Networks {
+		if network != "" && n.Name != network {
+			continue
+		}
+		for _, endpointType := range cfg.GetEnabledTypes() {
+			signal.InFlight += s.inflight.Total(n.Name, endpointType)
+		}
+	}
+	switch {
+	case signal.Capacity > 0:
+		signal.Saturation = float64(signal.InFlight) / float64(signal.Capacity)
+	case signal.InFlight > 0:
+		signal.Saturation = 1
+	}
+
+	requests, p95 := s.traffic.stats(network)
+	signal.Window = min(ScalingWindow, max(clock.Elapsed(), time.Second))
+	signal.RequestRate = float64(requests) / signal.Window.Seconds()
+	signal.P95 = p95
+	return signal
+}
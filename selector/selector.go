@@ -1,6 +1,9 @@
 package selector
 
 import (
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -11,6 +14,54 @@ import (
 	"go.uber.org/zap"
 )
 
+// NodeSelector is the selection policy consumed by the proxies and the status handler
+// Embedders can plug alternate policies and tests can inject fakes
+type NodeSelector interface {
+	// GetBestNode returns the best node for a network and endpoint type
+	GetBestNode(network, endpointType string) (*storage.NodeMetrics, string, *SelectionDecision)
+	// GetBestTaggedNode returns the best internal node carrying a tag
+	GetBestTaggedNode(network, endpointType, tag string) (*storage.NodeMetrics, string, *SelectionDecision)
+	// GetBestWebSocketNode returns the best node with a working WebSocket endpoint
+	GetBestWebSocketNode(network, endpointType string) (*storage.NodeMetrics, string, *SelectionDecision)
+	// GetStickyNode returns the node a client hashes to among the best ones
+	GetStickyNode(network, endpointType, client string) (*storage.NodeMetrics, string, *SelectionDecision)
+	// GetRetryNode returns the best node not tried yet, from a tagged pool when tag is set
+	GetRetryNode(network, endpointType, tag string, tried []string) (*storage.NodeMetrics, string, *SelectionDecision)
+	// GetEndpointURL returns the backend URL for a node selected in a network
+	GetEndpointURL(network, nodeName, endpointType string) string
+	// GetWebSocketURL returns the backend ws(s):// URL for a node selected in a network
+	GetWebSocketURL(network, nodeName, endpointType string) string
+	// GetHighestHeights returns the highest known height per enabled endpoint type
+	GetHighestHeights(network string, enabledTypes []string) map[string]int64
+	// RecordOutcome reports whether a request proxied to a node failed
+	RecordOutcome(network, endpointType, nodeName string, failed bool)
+	// RecordLatency reports how long a request proxied to a node took
+	RecordLatency(network, endpointType, nodeName string, latency time.Duration)
+	// NetworkHead returns the estimated head height of a network (0 if unknown)
+	NetworkHead(network string) int64
+	// GetPinnedNode returns a specific node if it can still serve the endpoint type at minHeight or above
+	GetPinnedNode(network, endpointType, nodeName string, minHeight int64) (*storage.NodeMetrics, *SelectionDecision)
+	// Decisions returns the audited selection decisions, newest first ("" matches any network or type)
+	Decisions(network, endpointType string) []DecisionRecord
+	// Pin routes a network endpoint type to one node for a bounded duration, bypassing selection
+	Pin(network, endpointType, nodeName string, d time.Duration) (ManualPin, error)
+	// Unpin lifts the manual pin of a network endpoint type; false if there was none
+	Unpin(network, endpointType string) bool
+	// Pins returns the active manual pins
+	Pins() []ManualPin
+	// Drain stops selecting an internal node for new requests, in one network or all ("")
+	Drain(network, nodeName string) ([]NodeDrain, error)
+	// Undrain returns a drained node to selection, in one network or all (""); returns the drains lifted
+	Undrain(network, nodeName string) []NodeDrain
+	// Drains returns the drained nodes
+	Drains() []NodeDrain
+	// Scaling returns the load of a network for autoscalers ("" = all networks)
+	Scaling(network string) ScalingSignal
+}
+
+// Ensure Selector implements NodeSelector
+var _ NodeSelector = (*Selector)(nil)
+
 // Selector chooses the best node for a given network and endpoint type
 // The Dark Lord's judgment - highest height → round-robin distribution
 type Selector struct {
@@ -18,49 +69,215 @@ type Selector struct {
 	endpointStore *storage.ExternalEndpointStore
 	configLoader  *config.Loader
 	logger        *zap.Logger
-	rrCounter     uint64 // Round-robin counter for load distribution
+	errorBudget   *errorBudget             // Rolling proxy error rates of internal nodes
+	slowness      *slowness                // Sliding-window p99 latencies and slow-node ejections
+	audit         *decisionAudit           // Last decisions per network and type, for GET /admin/decisions
+	pins          manualPins               // Nodes pinned through the admin API, per network and type
+	drains        nodeDrains               // Nodes drained through the admin API, per network
+	traffic       *traffic                 // Recent proxied request counts and latencies, for GET /admin/scaling
+	failover      sync.Map                 // network:type -> bool, whether externals were last in the candidate pool
+	stale         sync.Map                 // network:type -> bool, whether the last selection lagged the network head
+	inflight      *storage.InflightTracker // Requests currently proxied to each node
+	rrCounter     uint64                   // Round-robin counter for load distribution
+	logCounter    uint64                   // Selections seen, for sampled decision logging
+}
+
+// nodeWithName is a selection candidate: an internal node, or an external endpoint named "ext:{url}"
+type nodeWithName struct {
+	name    string
+	metrics *storage.NodeMetrics
 }
 
 // SelectionDecision tracks why a node was selected
 type SelectionDecision struct {
 	SelectedNode    string
-	Reason          string // "height_winner", "round_robin", "only_available", "external_endpoint"
+	Reason          string // "height_winner", "round_robin", "weighted", "only_available", "external_endpoint", "internal_preferred", "external_overflow", "session_pinned", "manual_pin", "cost_preferred", "lowest_latency", "least_connections", "sticky"
 	Candidates      int
 	MaxHeight       int64
 	SelectedLatency time.Duration
+
+	// Failover context at selection time
+	MaxInternalHeight int64
+	MaxExternalHeight int64
+	HeightGap         int64 // MaxExternalHeight - MaxInternalHeight (negative when internals lead)
+	ExternalFailover  bool  // whether externals were added to the candidate pool
+
+	// Degradation: every candidate lags the estimated network head by more than stale_threshold
+	NetworkHead  int64
+	BlocksBehind int64 // NetworkHead - height of the selected node
+	Stale        bool  // routed best effort; proxies flag the response as stale
 }
 
 // NewSelector creates a new node selector
-func NewSelector(store *storage.HeightStore, endpointStore *storage.ExternalEndpointStore, configLoader *config.Loader, logger *zap.Logger) *Selector {
+func NewSelector(store *storage.HeightStore, endpointStore *storage.ExternalEndpointStore, inflight *storage.InflightTracker, configLoader *config.Loader, logger *zap.Logger) *Selector {
 	return &Selector{
 		store:         store,
 		endpointStore: endpointStore,
+		inflight:      inflight,
 		configLoader:  configLoader,
 		logger:        logger,
+		errorBudget:   newErrorBudget(),
+		slowness:      newSlowness(logger),
+		audit:         newDecisionAudit(),
+		traffic:       newTraffic(),
 	}
 }
 
 // GetBestNode returns the best node for the given network and endpoint type
 // The Eye sees all, the Dark Lord judges
 func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetrics, string, *SelectionDecision) {
+	return s.selectNode(network, endpointType, false, "", "", nil)
+}
+
+// GetBestTaggedNode returns the best internal node carrying a tag (e.g. an "archive" pool)
+// Externals have no tags, so they are never candidates
+func (s *Selector) GetBestTaggedNode(network, endpointType, tag string) (*storage.NodeMetrics, string, *SelectionDecision) {
+	return s.selectNode(network, endpointType, false, tag, "", nil)
+}
+
+// GetStickyNode returns the node a client hashes to among the nodes at max height (sticky_sessions)
+// The client keeps that node while it stays at max height; retries fall back to GetRetryNode
+func (s *Selector) GetStickyNode(network, endpointType, client string) (*storage.NodeMetrics, string, *SelectionDecision) {
+	return s.selectNode(network, endpointType, false, "", client, nil)
+}
+
+// GetRetryNode returns the best node not tried yet, for retrying a failed request elsewhere
+// From the tagged pool when tag is set; nil when every candidate was tried
+func (s *Selector) GetRetryNode(network, endpointType, tag string, tried []string) (*storage.NodeMetrics, string, *SelectionDecision) {
+	exclude := make(map[string]bool, len(tried))
+	for _, name := range tried {
+		exclude[name] = true
+	}
+	return s.selectNode(network, endpointType, false, tag, "", exclude)
+}
+
+// GetPinnedNode returns a specific node if it can still serve the endpoint type at minHeight or above
+// Used for read-your-writes: a client's queries follow the node that took its transaction
+// A manual pin of another node wins over the session, and a drained node ends it
+func (s *Selector) GetPinnedNode(network, endpointType, nodeName string, minHeight int64) (*storage.NodeMetrics, *SelectionDecision) {
+	if pinned, ok := s.pinnedNode(network, endpointType); ok && pinned != nodeName {
+		return nil, nil
+	}
+	if s.draining(network, nodeName) {
+		return nil, nil
+	}
+	nodeMetrics := s.knownMetrics(network, endpointType, nodeName)
+	if nodeMetrics == nil || nodeMetrics.Height == 0 || nodeMetrics.Height < minHeight {
+		return nil, nil
+	}
+
+	decision := &SelectionDecision{
+		SelectedNode:    nodeName,
+		Reason:          "session_pinned",
+		Candidates:      1,
+		MaxHeight:       nodeMetrics.Height,
+		SelectedLatency: nodeMetrics.AvgLatency,
+	}
+	metrics.RoutingSelections.WithLabelValues(network, endpointType, nodeName, decision.Reason).Inc()
+	cfg := s.configLoader.Get()
+	s.checkStale(decision, network, endpointType, nodeMetrics.Height, cfg.StaleThreshold)
+	s.recordDecision(cfg.DecisionAudit, network, endpointType, decision)
+
+	return nodeMetrics, decision
+}
+
+// knownMetrics returns the last known metrics of an internal node, or of a validated external endpoint ("ext:{url}")
+func (s *Selector) knownMetrics(network, endpointType, nodeName string) *storage.NodeMetrics {
+	if url, ok := strings.CutPrefix(nodeName, "ext:"); ok {
+		if s.endpointStore == nil {
+			return nil
+		}
+		for _, ep := range s.endpointStore.GetValidatedEndpoints(network, endpointType) {
+			if ep.URL == url {
+				return &storage.NodeMetrics{
+					Height:             ep.Height,
+					AvgLatency:         ep.Latency,
+					Timestamp:          ep.LastValidated,
+					Source:             "external",
+					WebSocketAvailable: ep.WebSocketAvailable,
+				}
+			}
+		}
+		return nil
+	}
+	if m, ok := s.store.Get(network, nodeName, endpointType); ok {
+		return m
+	}
+	return nil
+}
+
+// GetBestWebSocketNode returns the best node whose WebSocket endpoint is working
+// Only WebSocket-capable nodes (internal or external) are considered as candidates
+func (s *Selector) GetBestWebSocketNode(network, endpointType string) (*storage.NodeMetrics, string, *SelectionDecision) {
+	return s.selectNode(network, endpointType, true, "", "", nil)
+}
+
+// selectNode runs the selection algorithm, optionally restricted to WebSocket-capable nodes
+// Nodes in exclude (retries) and drained nodes are never candidates; a client (sticky sessions) replaces the strategy with its hash
+// A manual pin bypasses selection; once the pinned node was tried there is no other candidate
+func (s *Selector) selectNode(network, endpointType string, requireWebSocket bool, tag, client string, exclude map[string]bool) (*storage.NodeMetrics, string, *SelectionDecision) {
+	if pinned, ok := s.pinnedNode(network, endpointType); ok {
+		if exclude[pinned] {
+			return nil, "", nil
+		}
+		return s.selectPinned(network, endpointType, pinned)
+	}
+
+	cfg := s.configLoader.Get()
+	logDetail := s.detailLogger(cfg.SelectorLog)
+
+	// Restrict internals to a tagged pool when a routing rule asks for one
+	var tagged map[string]bool
+	if tag != "" {
+		tagged = make(map[string]bool)
+		for _, node := range cfg.Internals {
+			if node.Network == network && slices.Contains(node.Tags, tag) {
+				tagged[node.Name] = true
+			}
+		}
+	}
+
 	// Get all internal nodes for this network and type
 	nodesMap := s.store.GetByNetwork(network, endpointType)
 
 	// Convert map to slice for easier processing
-	type nodeWithName struct {
-		name    string
-		metrics *storage.NodeMetrics
-	}
-
 	nodes := make([]nodeWithName, 0, len(nodesMap))
 	for name, m := range nodesMap {
+		if requireWebSocket && !m.WebSocketAvailable {
+			continue
+		}
+		if tagged != nil && !tagged[name] {
+			continue
+		}
+		if exclude[name] || s.draining(network, name) {
+			continue
+		}
 		nodes = append(nodes, nodeWithName{name: name, metrics: m})
 	}
 
-	s.logger.Debug("Selector: internal nodes retrieved",
+	// Slow nodes sit out until eject_for passes, unless that would leave no internal candidate
+	if cfg.SlowEjection.Enabled {
+		if ejected := s.slowness.ejected(network, endpointType, cfg.SlowEjection); len(ejected) > 0 {
+			kept := make([]nodeWithName, 0, len(nodes))
+			for _, node := range nodes {
+				if !ejected[node.name] {
+					kept = append(kept, node)
+				}
+			}
+			if len(kept) > 0 {
+				nodes = kept
+			}
+		}
+	}
+
+	// Priority tiers: only the first tier with a healthy, caught-up node serves; the others are warm standbys
+	nodes, tier := firstTier(cfg, network, nodes)
+
+	logDetail("Selector: internal nodes retrieved",
 		zap.String("network", network),
 		zap.String("type", endpointType),
 		zap.Int("count", len(nodes)),
+		zap.Int("tier", tier),
 	)
 
 	// Find max internal height
@@ -73,29 +290,36 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 
 	// Get external endpoints and check if we should include them
 	// Externals are added when: no healthy internals OR externals are ahead by threshold
-	if s.endpointStore != nil {
+	var maxExternalHeight int64
+	var shouldAddExternals bool
+	if s.endpointStore != nil && tag == "" {
 		externalEndpoints := s.endpointStore.GetValidatedEndpoints(network, endpointType)
 
 		// Get threshold from config (default to 2 blocks)
-		cfg := s.configLoader.Get()
 		threshold := cfg.ExternalFailoverThreshold
 		if threshold == 0 {
 			threshold = 2 // default threshold
 		}
 
 		// Find max external height
-		var maxExternalHeight int64
 		for _, ep := range externalEndpoints {
+			if requireWebSocket && !ep.WebSocketAvailable {
+				continue
+			}
+			if exclude["ext:"+ep.URL] {
+				continue
+			}
 			if ep.Height > maxExternalHeight {
 				maxExternalHeight = ep.Height
 			}
 		}
 
 		// Add externals if: no healthy internals OR externals are significantly ahead
-		shouldAddExternals := maxInternalHeight == 0 || maxExternalHeight > maxInternalHeight+threshold
+		shouldAddExternals = maxInternalHeight == 0 || maxExternalHeight > maxInternalHeight+threshold
 
-		if shouldAddExternals && len(externalEndpoints) > 0 {
-			s.logger.Info("Selector: adding external endpoints to candidates",
+		shouldAddExternals = shouldAddExternals && len(externalEndpoints) > 0
+		if shouldAddExternals {
+			logDetail("Selector: adding external endpoints to candidates",
 				zap.String("network", network),
 				zap.String("type", endpointType),
 				zap.Int("external_count", len(externalEndpoints)),
@@ -105,6 +329,13 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 			)
 
 			for _, ep := range externalEndpoints {
+				if requireWebSocket && !ep.WebSocketAvailable {
+					continue
+				}
+				if exclude["ext:"+ep.URL] {
+					continue
+				}
+
 				// Create a synthetic "node" entry for this external endpoint
 				// Use URL as the identifier (prefixed with "ext:" to distinguish from internal nodes)
 				nodeName := "ext:" + ep.URL
@@ -117,14 +348,14 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 				}
 				nodes = append(nodes, nodeWithName{name: nodeName, metrics: nodeMetrics})
 
-				s.logger.Debug("Selector: added external endpoint to candidates",
+				logDetail("Selector: added external endpoint to candidates",
 					zap.String("url", ep.URL),
 					zap.Int64("height", ep.Height),
 					zap.Duration("latency", ep.Latency),
 				)
 			}
 		} else {
-			s.logger.Debug("Selector: using internal nodes only",
+			logDetail("Selector: using internal nodes only",
 				zap.String("network", network),
 				zap.String("type", endpointType),
 				zap.Int64("max_internal_height", maxInternalHeight),
@@ -134,35 +365,111 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 		}
 	}
 
+	// Regular (non-WebSocket, first attempt) selections drive the failover gauges, so dashboards show when traffic leaves internals
+	if !requireWebSocket && tag == "" && exclude == nil {
+		s.recordFailoverState(network, endpointType, shouldAddExternals, maxInternalHeight, maxExternalHeight)
+	}
+
 	if len(nodes) == 0 {
+		reason := "no_nodes"
+		if requireWebSocket {
+			reason = "no_websocket_nodes"
+		} else if tag != "" {
+			reason = "no_tagged_nodes"
+		}
 		s.logger.Warn("No nodes available for routing",
 			zap.String("network", network),
 			zap.String("type", endpointType),
+			zap.Bool("websocket", requireWebSocket),
+			zap.String("tag", tag),
 		)
-		metrics.RoutingFailures.WithLabelValues(network, endpointType, "no_nodes").Inc()
+		metrics.RoutingFailures.WithLabelValues(network, endpointType, reason).Inc()
 		return nil, "", nil
 	}
 
-	s.logger.Info("Selector: total candidates",
+	logDetail("Selector: total candidates",
 		zap.String("network", network),
 		zap.String("type", endpointType),
 		zap.Int("total", len(nodes)),
 	)
 
 	decision := &SelectionDecision{
-		Candidates: len(nodes),
+		Candidates:        len(nodes),
+		MaxInternalHeight: maxInternalHeight,
+		MaxExternalHeight: maxExternalHeight,
+		HeightGap:         maxExternalHeight - maxInternalHeight,
+		ExternalFailover:  shouldAddExternals,
 	}
 
 	// Record alternatives considered
 	metrics.RoutingAlternativesConsidered.WithLabelValues(network, endpointType).Observe(float64(len(nodes)))
 
+	// Drain externals first: internals at their max height keep the traffic up to their capacity,
+	// externals only take the overflow instead of competing on height
+	overflow := false
+	if cfg.Egress.PreferInternals && shouldAddExternals && maxInternalHeight > 0 && !requireWebSocket {
+		capacity := int64(cfg.Egress.InternalCapacity)
+		if capacity == 0 {
+			capacity = DefaultInternalCapacity
+		}
+
+		// Least loaded internal with room, starting from a rotating offset so ties spread round-robin
+		counter := atomic.AddUint64(&s.rrCounter, 1)
+		best := -1
+		var bestLoad int64
+		for i := range nodes {
+			idx := (i + int(counter%uint64(len(nodes)))) % len(nodes)
+			node := nodes[idx]
+			if node.metrics.Source == "external" || node.metrics.Height != maxInternalHeight {
+				continue
+			}
+			load := s.inflight.Load(network, node.name, endpointType)
+			if load < capacity && (best < 0 || load < bestLoad) {
+				best, bestLoad = idx, load
+			}
+		}
+
+		if best >= 0 {
+			bestNode := nodes[best]
+			decision.MaxHeight = maxInternalHeight
+			decision.Reason = "internal_preferred"
+			decision.SelectedNode = bestNode.name
+			decision.SelectedLatency = bestNode.metrics.AvgLatency
+			metrics.RoutingSelections.WithLabelValues(network, endpointType, bestNode.name, decision.Reason).Inc()
+
+			logDetail("Node selected",
+				zap.String("network", network),
+				zap.String("type", endpointType),
+				zap.String("selected_node", bestNode.name),
+				zap.String("reason", decision.Reason),
+				zap.Int64("in_flight", bestLoad),
+				zap.Int64("height_gap", decision.HeightGap),
+			)
+			s.checkStale(decision, network, endpointType, bestNode.metrics.Height, cfg.StaleThreshold)
+			s.recordDecision(cfg.DecisionAudit, network, endpointType, decision)
+			return bestNode.metrics, bestNode.name, decision
+		}
+
+		// Every internal is at capacity: only externals take the overflow
+		externals := make([]nodeWithName, 0, len(nodes))
+		for _, node := range nodes {
+			if node.metrics.Source == "external" {
+				externals = append(externals, node)
+			}
+		}
+		if len(externals) > 0 {
+			nodes = externals
+			overflow = true
+		}
+	}
+
 	// Step 1: Find the maximum height
 	var maxHeight int64
 	for _, node := range nodes {
 		if node.metrics.Height > maxHeight {
 			maxHeight = node.metrics.Height
 		}
-		s.logger.Info("Selector: candidate node",
+		logDetail("Selector: candidate node",
 			zap.String("node", node.name),
 			zap.Int64("height", node.metrics.Height),
 			zap.Duration("latency", node.metrics.AvgLatency),
@@ -171,7 +478,7 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 	}
 	decision.MaxHeight = maxHeight
 
-	s.logger.Info("Selector: max height determined",
+	logDetail("Selector: max height determined",
 		zap.String("network", network),
 		zap.String("type", endpointType),
 		zap.Int64("max_height", maxHeight),
@@ -195,19 +502,42 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 		}
 	}
 
-	// Step 3: Among nodes with max height, distribute using round-robin
-	// Increment counter atomically and select node by index
-	counter := atomic.AddUint64(&s.rrCounter, 1)
-	selectedIndex := int(counter % uint64(len(maxHeightNodes)))
+	// Cost-aware: equally good candidates narrow to the cheapest, so owned nodes take traffic before metered ones
+	costPreferred := false
+	if cfg.CostAware.Enabled && len(maxHeightNodes) > 1 {
+		if cheapest := cheapestInBand(cfg, network, maxHeightNodes); len(cheapest) < len(maxHeightNodes) {
+			maxHeightNodes = cheapest
+			costPreferred = true
+		}
+	}
+
+	// Step 3: Among nodes with max height, the network's selection strategy (or the client's hash) picks one
+	pick := strategyFor(cfg, network)
+	if client != "" {
+		pick = stickyStrategy{}
+	}
+	selectedIndex, reason := pick.pick(s, strategyRequest{
+		cfg:          cfg,
+		network:      network,
+		endpointType: endpointType,
+		candidates:   maxHeightNodes,
+		counter:      atomic.AddUint64(&s.rrCounter, 1),
+		client:       client,
+	})
 	bestNode := maxHeightNodes[selectedIndex]
 
 	// Determine selection reason
 	if len(nodes) == 1 {
 		decision.Reason = "only_available"
+	} else if costPreferred {
+		decision.Reason = "cost_preferred"
 	} else if len(maxHeightNodes) == 1 {
 		decision.Reason = "height_winner"
 	} else {
-		decision.Reason = "round_robin"
+		decision.Reason = reason
+	}
+	if overflow {
+		decision.Reason = "external_overflow"
 	}
 
 	decision.SelectedNode = bestNode.name
@@ -221,7 +551,7 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 		decision.Reason,
 	).Inc()
 
-	s.logger.Debug("Node selected",
+	logDetail("Node selected",
 		zap.String("network", network),
 		zap.String("type", endpointType),
 		zap.String("selected_node", bestNode.name),
@@ -230,18 +560,115 @@ func (s *Selector) GetBestNode(network, endpointType string) (*storage.NodeMetri
 		zap.Int64("height", maxHeight),
 		zap.Duration("latency", bestNode.metrics.AvgLatency),
 		zap.Int("max_height_nodes", len(maxHeightNodes)),
+		zap.Bool("external_failover", decision.ExternalFailover),
+		zap.Int64("height_gap", decision.HeightGap),
 	)
 
+	s.checkStale(decision, network, endpointType, bestNode.metrics.Height, cfg.StaleThreshold)
+	s.recordDecision(cfg.DecisionAudit, network, endpointType, decision)
 	return bestNode.metrics, bestNode.name, decision
 }
 
-// GetEndpointURL returns the full endpoint URL for a node
-func (s *Selector) GetEndpointURL(nodeName, endpointType string) string {
+// checkStale flags a decision whose selected node lags the estimated network head
+// Usually the selected node is the highest candidate, so the others lag too; internal_preferred, pins and
+// sessions may pick a node below higher candidates, in which case only that node lags
+// Every stale selection counts in DegradedSelections; logs only mark when a network type starts and stops lagging
+func (s *Selector) checkStale(decision *SelectionDecision, network, endpointType string, height, threshold int64) {
+	if threshold == 0 {
+		threshold = DefaultStaleThreshold
+	}
+
+	decision.NetworkHead = s.NetworkHead(network)
+	if decision.NetworkHead == 0 {
+		return
+	}
+	decision.BlocksBehind = decision.NetworkHead - height
+	decision.Stale = decision.BlocksBehind > threshold
+	if decision.Stale {
+		metrics.DegradedSelections.WithLabelValues(network, endpointType).Inc()
+	}
+
+	previous, loaded := s.stale.Swap(network+":"+endpointType, decision.Stale)
+	if loaded && previous.(bool) == decision.Stale || !loaded && !decision.Stale {
+		return
+	}
+	if decision.Stale {
+		s.logger.Warn("Selected node lags the network head, routing best effort",
+			zap.String("network", network),
+			zap.String("type", endpointType),
+			zap.String("selected_node", decision.SelectedNode),
+			zap.Int64("network_head", decision.NetworkHead),
+			zap.Int64("blocks_behind", decision.BlocksBehind),
+		)
+		return
+	}
+	s.logger.Info("Selected node caught up with the network head",
+		zap.String("network", network),
+		zap.String("type", endpointType),
+		zap.String("selected_node", decision.SelectedNode),
+		zap.Int64("network_head", decision.NetworkHead),
+		zap.Int64("blocks_behind", decision.BlocksBehind),
+	)
+}
+
+// DefaultStaleThreshold is how many blocks behind the network head the best candidate may be before responses are flagged stale
+const DefaultStaleThreshold = 5
+
+// NetworkHead returns the estimated head height of a network (median of internal nodes and external rings)
+func (s *Selector) NetworkHead(network string) int64 {
+	return storage.EstimateHead(s.store, s.endpointStore, network)
+}
+
+// recordFailoverState publishes whether externals are in the candidate pool and logs when that changes
+func (s *Selector) recordFailoverState(network, endpointType string, active bool, maxInternalHeight, maxExternalHeight int64) {
+	gap := maxExternalHeight - maxInternalHeight
+	value := 0.0
+	if active {
+		value = 1
+	}
+	metrics.ExternalFailoverActive.WithLabelValues(network, endpointType).Set(value)
+	metrics.ExternalHeightGap.WithLabelValues(network, endpointType).Set(float64(gap))
+
+	previous, loaded := s.failover.Swap(network+":"+endpointType, active)
+	if loaded && previous.(bool) == active || !loaded && !active {
+		return
+	}
+
+	if active {
+		reason := "externals_ahead"
+		if maxInternalHeight == 0 {
+			reason = "no_healthy_internals"
+		}
+		metrics.ExternalFailoverTransitions.WithLabelValues(network, endpointType, "activated").Inc()
+		s.logger.Warn("External failover activated - traffic leaving internal nodes",
+			zap.String("network", network),
+			zap.String("type", endpointType),
+			zap.String("reason", reason),
+			zap.Int64("max_internal_height", maxInternalHeight),
+			zap.Int64("max_external_height", maxExternalHeight),
+			zap.Int64("height_gap", gap),
+		)
+		return
+	}
+
+	metrics.ExternalFailoverTransitions.WithLabelValues(network, endpointType, "deactivated").Inc()
+	s.logger.Info("External failover deactivated - internal nodes caught up",
+		zap.String("network", network),
+		zap.String("type", endpointType),
+		zap.Int64("max_internal_height", maxInternalHeight),
+		zap.Int64("max_external_height", maxExternalHeight),
+		zap.Int64("height_gap", gap),
+	)
+}
+
+// GetEndpointURL returns the full endpoint URL for a node of a network
+// Group nodes share a name across networks, with each network's scheme and ports
+func (s *Selector) GetEndpointURL(network, nodeName, endpointType string) string {
 	cfg := s.configLoader.Get()
 
 	// Search in internal nodes
 	for _, node := range cfg.Internals {
-		if node.Name == nodeName {
+		if node.Name == nodeName && node.Network == network {
 			switch endpointType {
 			case "api":
 				return normalizeURL(node.API)
@@ -261,6 +688,7 @@ func (s *Selector) GetEndpointURL(nodeName, endpointType string) string {
 	}
 
 	s.logger.Warn("Node not found in configuration",
+		zap.String("network", network),
 		zap.String("node", nodeName),
 		zap.String("type", endpointType),
 	)
@@ -268,6 +696,61 @@ func (s *Selector) GetEndpointURL(nodeName, endpointType string) string {
 	return ""
 }
 
+// GetWebSocketURL returns the WebSocket base URL (ws:// or wss://) for a node
+// Uses the node's ws override for API endpoints, otherwise derives it from the HTTP URL
+func (s *Selector) GetWebSocketURL(network, nodeName, endpointType string) string {
+	cfg := s.configLoader.Get()
+
+	// Search in internal nodes
+	for _, node := range cfg.Internals {
+		if node.Name == nodeName && node.Network == network {
+			switch endpointType {
+			case "api":
+				if node.WS != "" {
+					return node.WS
+				}
+				return toWebSocketURL(normalizeURL(node.API))
+			case "rpc":
+				if node.RPCWS != "" {
+					return strings.TrimSuffix(node.RPCWS, "/")
+				}
+				return toWebSocketURL(normalizeURL(node.RPC))
+			}
+			return ""
+		}
+	}
+
+	// External endpoints may advertise a dedicated WebSocket URL
+	if len(nodeName) > 4 && nodeName[:4] == "ext:" {
+		url := nodeName[4:]
+		if s.endpointStore != nil {
+			if wsURL := s.endpointStore.GetWebSocketURL(endpointType, url); wsURL != "" {
+				return wsURL
+			}
+		}
+		return toWebSocketURL(url)
+	}
+
+	s.logger.Warn("Node not found in configuration",
+		zap.String("network", network),
+		zap.String("node", nodeName),
+		zap.String("type", endpointType),
+	)
+
+	return ""
+}
+
+// toWebSocketURL converts an http(s):// URL to its ws(s):// equivalent
+func toWebSocketURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "http://"):
+		return "ws://" + url[len("http://"):]
+	case strings.HasPrefix(url, "https://"):
+		return "wss://" + url[len("https://"):]
+	}
+	return url
+}
+
 // normalizeURL ensures URL has proper scheme
 func normalizeURL(url string) string {
 	if url == "" {
@@ -1,6 +1,7 @@
 package selector
 
 import (
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"sauron/storage"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // createTestConfig creates a temporary config file and returns a Loader
@@ -129,7 +131,7 @@ func TestSelectorInternalsOnlyWhenWithinThreshold(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 102, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -169,7 +171,7 @@ func TestSelectorExternalsAddedWhenAheadByThreshold(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 103, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -209,7 +211,7 @@ func TestSelectorExternalsAddedWhenNoHealthyInternals(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 100, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, _ := selector.GetBestNode("pocket", "api")
 
@@ -240,7 +242,7 @@ func TestSelectorLatencyTiebreakerSameHeight(t *testing.T) {
 	heightStore.Update("pocket", "node-1", "api", 100, 100*time.Millisecond, "internal")
 	heightStore.Update("pocket", "node-2", "api", 100, 20*time.Millisecond, "internal")
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -270,7 +272,7 @@ func TestSelectorHeightWinner(t *testing.T) {
 	// node-2 has lower height but lower latency
 	heightStore.Update("pocket", "node-2", "api", 100, 20*time.Millisecond, "internal")
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -307,7 +309,7 @@ func TestSelectorDefaultThreshold(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 102, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	_, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -335,7 +337,7 @@ func TestSelectorCustomThreshold(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 103, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	_, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -380,7 +382,7 @@ func TestSelectorMultipleExternals(t *testing.T) {
 	endpointStore.StoreAdvertised("external-2", "https://ring2.example.com", "pocket", "api", "https://ext2.example.com")
 	endpointStore.MarkValidated("external-2", "https://ring2.example.com", "pocket", "api", "https://ext2.example.com", 105, 30*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -410,7 +412,7 @@ func TestSelectorNoNodes(t *testing.T) {
 	endpointStore := storage.NewExternalEndpointStore(logger)
 	configLoader := createTestConfig(t, 2)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -437,7 +439,7 @@ func TestSelectorOnlyAvailable(t *testing.T) {
 	// Only one internal node
 	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	_, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -465,7 +467,7 @@ func TestGetHighestHeightsIncludesExternals(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 150, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	heights := selector.GetHighestHeights("pocket", []string{"api"})
 
@@ -488,7 +490,7 @@ func TestSelectorExternalNotValidated(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	// Not calling MarkValidated, so it's not validated
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	_, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -522,7 +524,7 @@ func TestSelectorInternalWinsOverExternalSameHeight(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 105, 50*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, _ := selector.GetBestNode("pocket", "api")
 
@@ -571,7 +573,7 @@ func TestSelectorNilEndpointStore(t *testing.T) {
 	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
 
 	// Create selector with nil endpointStore
-	selector := NewSelector(heightStore, nil, configLoader, logger)
+	selector := NewSelector(heightStore, nil, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -602,7 +604,7 @@ func TestSelectorAllNodesZeroHeight(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 0, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -639,7 +641,7 @@ func TestSelectorExternalNotWorking(t *testing.T) {
 	endpointStore.IncrementErrorCount("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.IncrementErrorCount("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	_, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -668,7 +670,7 @@ func TestSelectorExternalLowerThanInternalNotAdded(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 95, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	_, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -697,7 +699,7 @@ func TestSelectorNoInternalsOnlyExternals(t *testing.T) {
 	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
 	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 100, 20*time.Millisecond)
 
-	selector := NewSelector(heightStore, endpointStore, configLoader, logger)
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
 
 	metrics, nodeName, decision := selector.GetBestNode("pocket", "api")
 
@@ -719,3 +721,959 @@ func TestSelectorNoInternalsOnlyExternals(t *testing.T) {
 		t.Errorf("Expected 1 candidate, got %d", decision.Candidates)
 	}
 }
+
+// TestSelectorWebSocketOnlyCapableNodes tests that WebSocket selection skips
+// nodes whose WebSocket endpoint is not available, even when they are higher
+func TestSelectorWebSocketOnlyCapableNodes(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	// node-1 is higher but has no working WebSocket
+	heightStore.Update("pocket", "node-1", "api", 105, 20*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 30*time.Millisecond, "internal")
+	heightStore.UpdateWebSocketAvailability("pocket", "node-2", "api", true)
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	// Plain HTTP selection still picks the highest node
+	_, nodeName, _ := selector.GetBestNode("pocket", "api")
+	if nodeName != "node-1" {
+		t.Errorf("Expected node-1 for HTTP, got %s", nodeName)
+	}
+
+	metrics, nodeName, decision := selector.GetBestWebSocketNode("pocket", "api")
+	if metrics == nil {
+		t.Fatal("Expected metrics to be returned")
+	}
+	if nodeName != "node-2" {
+		t.Errorf("Expected node-2 for WebSocket, got %s", nodeName)
+	}
+	if decision.Candidates != 1 {
+		t.Errorf("Expected 1 candidate, got %d", decision.Candidates)
+	}
+
+	// No WebSocket-capable nodes at all
+	heightStore.UpdateWebSocketAvailability("pocket", "node-2", "api", false)
+	metrics, nodeName, _ = selector.GetBestWebSocketNode("pocket", "api")
+	if metrics != nil || nodeName != "" {
+		t.Errorf("Expected no WebSocket node, got %s", nodeName)
+	}
+}
+
+// TestSelectorErrorBudgetDeprioritizesFailingNode tests that a node failing proxied
+// requests gets a smaller share of traffic than its healthy peer
+func TestSelectorErrorBudgetDeprioritizesFailingNode(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.ErrorBudget.Enabled = true
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable error budget: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	// node-2 fails 80% of its requests, node-1 none
+	for i := 0; i < 50; i++ {
+		selector.RecordOutcome("pocket", "api", "node-1", false)
+		selector.RecordOutcome("pocket", "api", "node-2", i%5 != 0)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 1200; i++ {
+		_, nodeName, decision := selector.GetBestNode("pocket", "api")
+		if decision.Reason != "weighted" {
+			t.Fatalf("Expected reason 'weighted', got %s", decision.Reason)
+		}
+		counts[nodeName]++
+	}
+
+	// Weights 1.0 and 0.2: node-2 should get about a sixth of the traffic
+	if counts["node-2"] < 150 || counts["node-2"] > 250 {
+		t.Errorf("Expected node-2 to get ~200 of 1200 selections, got %d (node-1: %d)", counts["node-2"], counts["node-1"])
+	}
+}
+
+// TestSelectorErrorBudgetIgnoresFewRequests tests that nodes are not weighted
+// until they have served enough requests to judge
+func TestSelectorErrorBudgetIgnoresFewRequests(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.ErrorBudget.Enabled = true
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable error budget: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	// A few failures are below min_requests (default 20)
+	for i := 0; i < 5; i++ {
+		selector.RecordOutcome("pocket", "api", "node-2", true)
+	}
+
+	_, _, decision := selector.GetBestNode("pocket", "api")
+	if decision.Reason != "round_robin" {
+		t.Errorf("Expected reason 'round_robin', got %s", decision.Reason)
+	}
+}
+
+// TestSelectorSlowEjectionEjectsSlowNode tests that a node far slower than its peers
+// leaves rotation even though its height is the best, and returns after eject_for
+func TestSelectorSlowEjectionEjectsSlowNode(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.SlowEjection.Enabled = true
+	cfg.SlowEjection.EjectFor = 50 * time.Millisecond
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable slow ejection: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-3", "api", 101, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	// node-3 leads on height but chokes: 2s against 100ms
+	for i := 0; i < 30; i++ {
+		selector.RecordLatency("pocket", "api", "node-1", 100*time.Millisecond)
+		selector.RecordLatency("pocket", "api", "node-2", 120*time.Millisecond)
+		selector.RecordLatency("pocket", "api", "node-3", 2*time.Second)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, nodeName, _ := selector.GetBestNode("pocket", "api")
+		if nodeName == "node-3" {
+			t.Fatalf("Expected slow node-3 to be ejected, got it selected")
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	_, nodeName, decision := selector.GetBestNode("pocket", "api")
+	if nodeName != "node-3" || decision.Reason != "height_winner" {
+		t.Errorf("Expected node-3 back in rotation as height winner, got %s (%s)", nodeName, decision.Reason)
+	}
+}
+
+// TestSelectorSlowEjectionIgnoresFewRequests tests that nodes are not judged
+// until they have served enough requests
+func TestSelectorSlowEjectionIgnoresFewRequests(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.SlowEjection.Enabled = true
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable slow ejection: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 101, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	// A few slow requests are below min_requests (default 20)
+	for i := 0; i < 30; i++ {
+		selector.RecordLatency("pocket", "api", "node-1", 100*time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		selector.RecordLatency("pocket", "api", "node-2", 2*time.Second)
+	}
+
+	_, nodeName, _ := selector.GetBestNode("pocket", "api")
+	if nodeName != "node-2" {
+		t.Errorf("Expected node-2 to keep winning on height, got %s", nodeName)
+	}
+}
+
+// TestSelectorDecisionRecordsFailoverGap tests that decisions carry the internal/external
+// height gap and whether externals were in the candidate pool
+func TestSelectorDecisionRecordsFailoverGap(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+
+	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
+	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 102, 20*time.Millisecond)
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	// At the threshold boundary (gap == threshold) internals keep the traffic
+	_, _, decision := selector.GetBestNode("pocket", "api")
+	if decision.ExternalFailover {
+		t.Error("Expected no external failover at the threshold boundary")
+	}
+	if decision.HeightGap != 2 {
+		t.Errorf("Expected height gap 2, got %d", decision.HeightGap)
+	}
+
+	// One block past the threshold externals join the pool
+	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 103, 20*time.Millisecond)
+	_, _, decision = selector.GetBestNode("pocket", "api")
+	if !decision.ExternalFailover {
+		t.Error("Expected external failover past the threshold")
+	}
+	if decision.MaxInternalHeight != 100 || decision.MaxExternalHeight != 103 || decision.HeightGap != 3 {
+		t.Errorf("Expected heights 100/103 and gap 3, got %d/%d and gap %d",
+			decision.MaxInternalHeight, decision.MaxExternalHeight, decision.HeightGap)
+	}
+}
+
+// TestSelectorPreferInternalsDrainsExternals tests that with prefer_internals the internal node
+// keeps the traffic during failover and externals only get the overflow beyond its capacity
+func TestSelectorPreferInternalsDrainsExternals(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Egress.PreferInternals = true
+	cfg.Egress.InternalCapacity = 2
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable prefer_internals: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+
+	// External is well past the failover threshold and faster
+	endpointStore.StoreAdvertised("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com")
+	endpointStore.MarkValidated("external-1", "https://ring1.example.com", "pocket", "api", "https://ext1.example.com", 110, 10*time.Millisecond)
+
+	inflight := storage.NewInflightTracker()
+	selector := NewSelector(heightStore, endpointStore, inflight, configLoader, logger)
+
+	// Below capacity the internal node wins despite being behind
+	for i := 0; i < 2; i++ {
+		_, nodeName, decision := selector.GetBestNode("pocket", "api")
+		if nodeName != "node-1" || decision.Reason != "internal_preferred" {
+			t.Fatalf("Expected node-1 (internal_preferred), got %s (%s)", nodeName, decision.Reason)
+		}
+		inflight.Increment("pocket", nodeName, "api")
+	}
+
+	// At capacity the overflow goes to the external
+	_, nodeName, decision := selector.GetBestNode("pocket", "api")
+	if nodeName != "ext:https://ext1.example.com" || decision.Reason != "external_overflow" {
+		t.Fatalf("Expected external overflow, got %s (%s)", nodeName, decision.Reason)
+	}
+
+	// Once a request completes the internal node takes traffic again
+	inflight.Decrement("pocket", "node-1", "api")
+	_, nodeName, _ = selector.GetBestNode("pocket", "api")
+	if nodeName != "node-1" {
+		t.Errorf("Expected node-1 after a request completed, got %s", nodeName)
+	}
+}
+
+// TestSelectorFlagsStaleWhenAllCandidatesLag tests that a selection is still made but
+// flagged stale when every candidate is behind the estimated network head
+func TestSelectorFlagsStaleWhenAllCandidatesLag(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 98, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	_, _, decision := selector.GetBestNode("pocket", "api")
+	if decision.Stale {
+		t.Fatal("Expected no stale flag when internals are the only height source")
+	}
+
+	// Three rings agree the chain is at 120; no endpoints advertised, so they are not candidates
+	for _, ring := range []string{"https://ring1.example.com", "https://ring2.example.com", "https://ring3.example.com"} {
+		endpointStore.RecordRingCheck("external-1", ring, "pocket", 120, 20*time.Millisecond, nil)
+	}
+
+	nodeMetrics, nodeName, decision := selector.GetBestNode("pocket", "api")
+	if nodeMetrics == nil || nodeName != "node-1" {
+		t.Fatalf("Expected best effort selection of node-1, got %q", nodeName)
+	}
+	if !decision.Stale || decision.NetworkHead != 120 || decision.BlocksBehind != 20 {
+		t.Errorf("Expected stale decision 20 blocks behind head 120, got stale=%v head=%d behind=%d",
+			decision.Stale, decision.NetworkHead, decision.BlocksBehind)
+	}
+}
+
+// TestSelectorStaleLogsOncePerTransition tests that repeated stale selections warn once
+// and that catching up logs the recovery once
+func TestSelectorStaleLogsOncePerTransition(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 98, 50*time.Millisecond, "internal")
+	for _, ring := range []string{"https://ring1.example.com", "https://ring2.example.com", "https://ring3.example.com"} {
+		endpointStore.RecordRingCheck("external-1", ring, "pocket", 120, 20*time.Millisecond, nil)
+	}
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	for i := 0; i < 10; i++ {
+		selector.GetBestNode("pocket", "api")
+	}
+	if n := logs.FilterMessage("Selected node lags the network head, routing best effort").Len(); n != 1 {
+		t.Errorf("Expected 1 stale warning for 10 stale selections, got %d", n)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 120, 50*time.Millisecond, "internal")
+	for i := 0; i < 10; i++ {
+		selector.GetBestNode("pocket", "api")
+	}
+	if n := logs.FilterMessage("Selected node caught up with the network head").Len(); n != 1 {
+		t.Errorf("Expected 1 recovery log, got %d", n)
+	}
+}
+
+// TestSelectorTaggedNodeOnlyFromPool tests that tagged selection only considers
+// internal nodes carrying the tag, even when another node is higher
+func TestSelectorTaggedNodeOnlyFromPool(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Internals[1].Tags = []string{"archive"}
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to tag node-2: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 101, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	_, nodeName, _ := selector.GetBestTaggedNode("pocket", "api", "archive")
+	if nodeName != "node-2" {
+		t.Errorf("Expected archive node node-2, got %q", nodeName)
+	}
+
+	nodeMetrics, _, _ := selector.GetBestTaggedNode("pocket", "api", "missing")
+	if nodeMetrics != nil {
+		t.Error("Expected no node for a tag nobody carries")
+	}
+}
+
+// TestSelectorSampledDecisionLogging tests that sampled mode logs one selection in sample_every
+// and that the default mode keeps per-request details out of the logs
+func TestSelectorSampledDecisionLogging(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	for i := 0; i < 10; i++ {
+		selector.GetBestNode("pocket", "api")
+	}
+	if n := logs.FilterMessage("Node selected").Len(); n != 0 {
+		t.Errorf("Expected no selection logs by default, got %d", n)
+	}
+
+	cfg := configLoader.Get()
+	cfg.SelectorLog = config.SelectorLog{Mode: config.SelectorLogSampled, SampleEvery: 5}
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable sampled logging: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		selector.GetBestNode("pocket", "api")
+	}
+	selected := logs.FilterMessage("Node selected")
+	if selected.Len() != 2 {
+		t.Errorf("Expected 2 sampled selection logs, got %d", selected.Len())
+	}
+	for _, entry := range selected.All() {
+		if entry.Level != zap.InfoLevel {
+			t.Errorf("Expected sampled selections at Info, got %s", entry.Level)
+		}
+	}
+}
+
+// TestSelectorDecisionAuditKeepsNewest tests that audited decisions are kept per network and type,
+// newest first, and that only the last size decisions are kept
+func TestSelectorDecisionAuditKeepsNewest(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-1", "rpc", 100, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	selector.GetBestNode("pocket", "api")
+	if n := len(selector.Decisions("", "")); n != 0 {
+		t.Fatalf("Expected no decisions while decision_audit is disabled, got %d", n)
+	}
+
+	cfg := configLoader.Get()
+	cfg.DecisionAudit = config.DecisionAudit{Enabled: true, Size: 3}
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable decision audit: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		selector.GetBestNode("pocket", "api")
+	}
+	heightStore.Update("pocket", "node-1", "api", 101, 50*time.Millisecond, "internal")
+	selector.GetBestNode("pocket", "api")
+	selector.GetBestNode("pocket", "rpc")
+
+	api := selector.Decisions("pocket", "api")
+	if len(api) != 3 {
+		t.Fatalf("Expected the last 3 api decisions, got %d", len(api))
+	}
+	if api[0].MaxHeight != 101 || api[1].MaxHeight != 100 {
+		t.Errorf("Expected newest decision first, got heights %d, %d", api[0].MaxHeight, api[1].MaxHeight)
+	}
+	if api[0].Network != "pocket" || api[0].Type != "api" || api[0].SelectedNode != "node-1" {
+		t.Errorf("Expected pocket/api decision for node-1, got %+v", api[0])
+	}
+
+	if all := selector.Decisions("", ""); len(all) != 4 {
+		t.Errorf("Expected 4 decisions across types, got %d", len(all))
+	}
+}
+
+// TestSelectorManualPinBypassesSelection tests that a manual pin routes to the pinned node
+// even when another node is higher, that retries don't leave it, and that unpinning restores selection
+func TestSelectorManualPinBypassesSelection(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "rpc", 110, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "rpc", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-1", "api", 110, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	if _, err := selector.Pin("pocket", "rpc", "node-3", time.Minute); err == nil {
+		t.Error("Expected an unknown node to be refused")
+	}
+	if _, err := selector.Pin("pocket", "rpc", "node-2", 25*time.Hour); err == nil {
+		t.Error("Expected a pin over MaxManualPinDuration to be refused")
+	}
+	if _, err := selector.Pin("pocket", "rpc", "node-2", time.Minute); err != nil {
+		t.Fatalf("Failed to pin node-2: %v", err)
+	}
+
+	_, nodeName, decision := selector.GetBestNode("pocket", "rpc")
+	if nodeName != "node-2" || decision.Reason != ManualPinReason {
+		t.Errorf("Expected pinned node-2 with reason %s, got %q (%+v)", ManualPinReason, nodeName, decision)
+	}
+	if nodeMetrics, _, _ := selector.GetRetryNode("pocket", "rpc", "", []string{"node-2"}); nodeMetrics != nil {
+		t.Error("Expected no retry node while pinned")
+	}
+	if nodeMetrics, _ := selector.GetPinnedNode("pocket", "rpc", "node-1", 0); nodeMetrics != nil {
+		t.Error("Expected the manual pin to win over a session pin")
+	}
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName == "" {
+		t.Error("Expected other endpoint types to keep selecting")
+	}
+	if pins := selector.Pins(); len(pins) != 1 || pins[0].Node != "node-2" {
+		t.Errorf("Expected one pin on node-2, got %+v", pins)
+	}
+
+	if !selector.Unpin("pocket", "rpc") {
+		t.Error("Expected the pin to be lifted")
+	}
+	if selector.Unpin("pocket", "rpc") {
+		t.Error("Expected nothing to lift twice")
+	}
+	if _, nodeName, _ := selector.GetBestNode("pocket", "rpc"); nodeName != "node-1" {
+		t.Errorf("Expected height winner node-1 after unpinning, got %q", nodeName)
+	}
+}
+
+// TestSelectorCostAwarePrefersCheaperNode tests that the cheaper of two height-tied nodes takes
+// the traffic while it is within the latency band, and that a much faster node wins outside it
+func TestSelectorCostAwarePrefersCheaperNode(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.CostAware = config.CostAware{Enabled: true, LatencyBand: 30 * time.Millisecond}
+	cfg.Internals[1].CostWeight = 5 // node-2 is a metered provider
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to enable cost-aware selection: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 60*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 40*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	for i := 0; i < 10; i++ {
+		_, nodeName, decision := selector.GetBestNode("pocket", "api")
+		if nodeName != "node-1" || decision.Reason != "cost_preferred" {
+			t.Fatalf("Expected cheaper node-1 (cost_preferred), got %s (%s)", nodeName, decision.Reason)
+		}
+	}
+
+	// node-1 falls outside the band of the fastest node
+	heightStore.Update("pocket", "node-1", "api", 100, 120*time.Millisecond, "internal")
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-2" {
+		t.Errorf("Expected node-2 once node-1 is out of the latency band, got %s", nodeName)
+	}
+
+	// Equal costs leave round-robin untouched
+	cfg = configLoader.Get()
+	cfg.Internals[1].CostWeight = 0
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to reset cost_weight: %v", err)
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		_, nodeName, decision := selector.GetBestNode("pocket", "api")
+		if decision.Reason != "round_robin" {
+			t.Fatalf("Expected round_robin with equal costs, got %s", decision.Reason)
+		}
+		counts[nodeName]++
+	}
+	if counts["node-1"] == 0 || counts["node-2"] == 0 {
+		t.Errorf("Expected both nodes selected with equal costs, got %v", counts)
+	}
+}
+
+// TestSelectorNodeWeightSkewsTraffic tests that height-tied nodes share traffic in proportion
+// to their configured weight, and that a lagging heavy node gets nothing
+func TestSelectorNodeWeightSkewsTraffic(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Internals[0].Weight = 3
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set node weight: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 50*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	counts := make(map[string]int)
+	for i := 0; i < 1200; i++ {
+		_, nodeName, decision := selector.GetBestNode("pocket", "api")
+		if decision.Reason != "weighted" {
+			t.Fatalf("Expected reason 'weighted', got %s", decision.Reason)
+		}
+		counts[nodeName]++
+	}
+
+	// Weights 3 and 1: node-1 should get about three quarters of the traffic
+	if counts["node-1"] < 850 || counts["node-1"] > 950 {
+		t.Errorf("Expected node-1 to get ~900 of 1200 selections, got %d (node-2: %d)", counts["node-1"], counts["node-2"])
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 99, 50*time.Millisecond, "internal")
+	if _, nodeName, decision := selector.GetBestNode("pocket", "api"); nodeName != "node-2" || decision.Reason != "height_winner" {
+		t.Errorf("Expected height winner node-2 over the lagging heavy node, got %s (%s)", nodeName, decision.Reason)
+	}
+}
+
+// TestSelectorSelectionStrategies tests that a network's selection_strategy decides among
+// nodes at max height: round_robin ignores weight, height_latency follows the fastest node
+func TestSelectorSelectionStrategies(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Internals[0].Weight = 3
+	cfg.Networks[0].SelectionStrategy = config.SelectionStrategyRoundRobin
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set round_robin: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 80*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 20*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		_, nodeName, decision := selector.GetBestNode("pocket", "api")
+		if decision.Reason != "round_robin" {
+			t.Fatalf("Expected reason 'round_robin', got %s", decision.Reason)
+		}
+		counts[nodeName]++
+	}
+	if counts["node-1"] < 30 || counts["node-1"] > 70 {
+		t.Errorf("Expected round_robin to ignore node-1's weight, got %v", counts)
+	}
+
+	cfg = configLoader.Get()
+	cfg.Networks[0].SelectionStrategy = config.SelectionStrategyHeightLatency
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set height_latency: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, nodeName, decision := selector.GetBestNode("pocket", "api"); nodeName != "node-2" || decision.Reason != "lowest_latency" {
+			t.Fatalf("Expected fastest node-2 (lowest_latency), got %s (%s)", nodeName, decision.Reason)
+		}
+	}
+
+	// Height still comes first
+	heightStore.Update("pocket", "node-1", "api", 101, 80*time.Millisecond, "internal")
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-1" {
+		t.Errorf("Expected height winner node-1 over the faster node, got %s", nodeName)
+	}
+}
+
+// TestSelectorP95Tiebreak tests that with latency_percentiles.tiebreak p95, height_latency avoids
+// a node whose average looks fast but whose tail is slow
+func TestSelectorP95Tiebreak(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Networks[0].SelectionStrategy = config.SelectionStrategyHeightLatency
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set height_latency: %v", err)
+	}
+
+	// node-1 is usually fast with occasional 500ms spikes; node-2 is steady at 60ms
+	for i := 0; i < 20; i++ {
+		latency := 10 * time.Millisecond
+		if i%10 == 0 {
+			latency = 500 * time.Millisecond
+		}
+		heightStore.Update("pocket", "node-1", "api", 100, latency, "internal")
+		heightStore.Update("pocket", "node-2", "api", 100, 60*time.Millisecond, "internal")
+	}
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-1" {
+		t.Fatalf("Expected node-1 by average latency, got %s", nodeName)
+	}
+
+	cfg = configLoader.Get()
+	cfg.LatencyPercentiles.Tiebreak = config.LatencyTiebreakP95
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set p95 tiebreak: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, nodeName, decision := selector.GetBestNode("pocket", "api"); nodeName != "node-2" || decision.Reason != "lowest_latency" {
+			t.Fatalf("Expected node-2 by p95 latency (lowest_latency), got %s (%s)", nodeName, decision.Reason)
+		}
+	}
+}
+
+// TestSelectorPriorityTiers tests that a fallback tier only serves while no first-tier node is
+// healthy and caught up, and that externals still take over when every internal lags
+func TestSelectorPriorityTiers(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Internals[1].Tier = 1
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set tiers: %v", err)
+	}
+
+	heightStore.Update("pocket", "node-1", "api", 100, 80*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 20*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	for i := 0; i < 10; i++ {
+		if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-1" {
+			t.Fatalf("Expected first-tier node-1 while it is healthy, got %s", nodeName)
+		}
+	}
+
+	// Within the threshold node-1 still serves, even if the standby is a block ahead
+	heightStore.Update("pocket", "node-2", "api", 101, 20*time.Millisecond, "internal")
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-1" {
+		t.Errorf("Expected node-1 within the threshold, got %s", nodeName)
+	}
+
+	// Behind by more than the threshold, the standby takes over
+	heightStore.Update("pocket", "node-2", "api", 105, 20*time.Millisecond, "internal")
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-2" {
+		t.Errorf("Expected standby node-2 with node-1 behind, got %s", nodeName)
+	}
+
+	// Unhealthy (height 0), the standby takes over too
+	heightStore.Update("pocket", "node-1", "api", 0, 0, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 20*time.Millisecond, "internal")
+	if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-2" {
+		t.Errorf("Expected standby node-2 with node-1 unhealthy, got %s", nodeName)
+	}
+}
+
+// TestSelectorDrain tests that a drained node takes no new requests or read-your-writes sessions,
+// and returns to selection once undrained
+func TestSelectorDrain(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "api", 100, 20*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "api", 100, 20*time.Millisecond, "internal")
+
+	selector := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	if _, err := selector.Drain("", "node-9"); err == nil {
+		t.Error("Expected an unknown node to be refused")
+	}
+	drains, err := selector.Drain("", "node-1")
+	if err != nil {
+		t.Fatalf("Failed to drain node-1: %v", err)
+	}
+	if len(drains) != 1 || drains[0].Network != "pocket" || drains[0].Node != "node-1" {
+		t.Fatalf("Expected node-1 drained in pocket, got %+v", drains)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, nodeName, _ := selector.GetBestNode("pocket", "api"); nodeName != "node-2" {
+			t.Fatalf("Expected node-2 while node-1 is drained, got %s", nodeName)
+		}
+	}
+	if m, _ := selector.GetPinnedNode("pocket", "api", "node-1", 0); m != nil {
+		t.Error("Expected the session on drained node-1 to end")
+	}
+	if len(selector.Drains()) != 1 {
+		t.Errorf("Expected 1 drain listed, got %d", len(selector.Drains()))
+	}
+
+	if lifted := selector.Undrain("pocket", "node-1"); len(lifted) != 1 {
+		t.Fatalf("Expected node-1 undrained, got %+v", lifted)
+	}
+	if lifted := selector.Undrain("", "node-1"); len(lifted) != 0 {
+		t.Errorf("Expected nothing left to undrain, got %+v", lifted)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		_, nodeName, _ := selector.GetBestNode("pocket", "api")
+		seen[nodeName] = true
+	}
+	if !seen["node-1"] {
+		t.Error("Expected node-1 back in rotation after undrain")
+	}
+}
+
+// TestSelectorScalingSignal tests that the scaling signal counts proxied requests, in-flight load
+// and healthy nodes, per network and across networks
+func TestSelectorScalingSignal(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	inflight := storage.NewInflightTracker()
+	configLoader := createTestConfig(t, 2)
+
+	heightStore.Update("pocket", "node-1", "rpc", 100, 50*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "rpc", 0, 0, "internal")
+
+	selector := NewSelector(heightStore, storage.NewExternalEndpointStore(logger), inflight, configLoader, logger)
+
+	for i := 1; i <= 100; i++ {
+		selector.RecordOutcome("pocket", "rpc", "node-1", false)
+		selector.RecordLatency("pocket", "rpc", "node-1", time.Duration(i)*time.Millisecond)
+	}
+	for range 25 {
+		inflight.Increment("pocket", "node-1", "rpc")
+	}
+
+	signal := selector.Scaling("pocket")
+	if signal.Nodes != 2 || signal.HealthyNodes != 1 {
+		t.Errorf("Expected 1 of 2 nodes healthy, got %d of %d", signal.HealthyNodes, signal.Nodes)
+	}
+	if signal.InFlight != 25 || signal.Capacity != DefaultInternalCapacity || signal.Saturation != 0.25 {
+		t.Errorf("Expected 25 in flight of %d (0.25), got %d of %d (%g)", DefaultInternalCapacity, signal.InFlight, signal.Capacity, signal.Saturation)
+	}
+	if signal.P95 != 95*time.Millisecond {
+		t.Errorf("Expected p95 of 95ms, got %s", signal.P95)
+	}
+	if want := 100 / signal.Window.Seconds(); signal.RequestRate != want {
+		t.Errorf("Expected %g requests per second, got %g", want, signal.RequestRate)
+	}
+
+	if all := selector.Scaling(""); all.InFlight != 25 || all.Nodes != 2 {
+		t.Errorf("Expected all networks to include pocket, got %+v", all)
+	}
+	if other := selector.Scaling("osmosis"); other.RequestRate != 0 || other.Nodes != 0 {
+		t.Errorf("Expected nothing for another network, got %+v", other)
+	}
+}
+
+// TestSelectorLeastConnections tests that least_connections routes to the node holding the fewest
+// requests and WebSockets, and spreads ties
+func TestSelectorLeastConnections(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	inflight := storage.NewInflightTracker()
+	configLoader := createTestConfig(t, 2)
+
+	cfg := configLoader.Get()
+	cfg.Networks[0].SelectionStrategy = config.SelectionStrategyLeastConns
+	if err := configLoader.Update(cfg); err != nil {
+		t.Fatalf("Failed to set least_connections: %v", err)
+	}
+
+	// node-1 is faster but busy with long-running queries
+	heightStore.Update("pocket", "node-1", "rpc", 100, 10*time.Millisecond, "internal")
+	heightStore.Update("pocket", "node-2", "rpc", 100, 40*time.Millisecond, "internal")
+	inflight.Increment("pocket", "node-1", "rpc")
+	inflight.OpenWebSocket("pocket", "node-1", "rpc")
+	inflight.Increment("pocket", "node-2", "rpc")
+
+	selector := NewSelector(heightStore, storage.NewExternalEndpointStore(logger), inflight, configLoader, logger)
+
+	for i := 0; i < 10; i++ {
+		if _, nodeName, decision := selector.GetBestNode("pocket", "rpc"); nodeName != "node-2" || decision.Reason != "least_connections" {
+			t.Fatalf("Expected node-2 (least_connections), got %s (%s)", nodeName, decision.Reason)
+		}
+	}
+
+	inflight.CloseWebSocket("pocket", "node-1", "rpc")
+	counts := make(map[string]int)
+	for i := 0; i < 50; i++ {
+		_, nodeName, _ := selector.GetBestNode("pocket", "rpc")
+		counts[nodeName]++
+	}
+	if counts["node-1"] == 0 || counts["node-2"] == 0 {
+		t.Errorf("Expected ties to rotate, got %v", counts)
+	}
+}
+
+// TestSelectorStickySessions tests that a client keeps its node, clients spread over nodes,
+// and a client moves only while its node lags
+func TestSelectorStickySessions(t *testing.T) {
+	logger := zap.NewNop()
+	heightStore := storage.NewHeightStore()
+	configLoader := createTestConfig(t, 3)
+
+	for _, node := range []string{"node-1", "node-2", "node-3"} {
+		heightStore.Update("pocket", node, "rpc", 100, 20*time.Millisecond, "internal")
+	}
+	selector := NewSelector(heightStore, storage.NewExternalEndpointStore(logger), storage.NewInflightTracker(), configLoader, logger)
+
+	assigned := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 60; i++ {
+		client := fmt.Sprintf("ip:10.0.0.%d", i)
+		_, nodeName, decision := selector.GetStickyNode("pocket", "rpc", client)
+		if decision.Reason != "sticky" {
+			t.Fatalf("Expected reason sticky, got %s", decision.Reason)
+		}
+		for j := 0; j < 5; j++ {
+			if _, again, _ := selector.GetStickyNode("pocket", "rpc", client); again != nodeName {
+				t.Fatalf("Expected %s to stay on %s, got %s", client, nodeName, again)
+			}
+		}
+		assigned[client] = nodeName
+		counts[nodeName]++
+	}
+	if len(counts) != 3 {
+		t.Errorf("Expected clients spread over all nodes, got %v", counts)
+	}
+
+	// node-1 falls behind: its clients move, everyone else stays put
+	heightStore.Update("pocket", "node-1", "rpc", 99, 20*time.Millisecond, "internal")
+	for client, node := range assigned {
+		_, nodeName, _ := selector.GetStickyNode("pocket", "rpc", client)
+		if node == "node-1" && nodeName == "node-1" {
+			t.Fatalf("Expected %s to leave lagging node-1", client)
+		}
+		if node != "node-1" && nodeName != node {
+			t.Fatalf("Expected %s to stay on %s, got %s", client, node, nodeName)
+		}
+	}
+
+	// Once node-1 catches up its clients return
+	heightStore.Update("pocket", "node-1", "rpc", 100, 20*time.Millisecond, "internal")
+	for client, node := range assigned {
+		if _, nodeName, _ := selector.GetStickyNode("pocket", "rpc", client); nodeName != node {
+			t.Fatalf("Expected %s back on %s, got %s", client, node, nodeName)
+		}
+	}
+}
+
+// TestSimulateReplaysSamples tests that samples replay in time order and that externals take over
+// once internals fail
+func TestSimulateReplaysSamples(t *testing.T) {
+	configLoader := createTestConfig(t, 2)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	samples := []SimulationSample{
+		{Time: start.Add(time.Minute), Network: "pocket", Type: "rpc", Node: "node-1", Height: 0},
+		{Time: start.Add(time.Minute), Network: "pocket", Type: "rpc", Node: "node-2", Height: 0},
+		{Time: start.Add(time.Minute), Network: "pocket", Type: "rpc", Node: "ext:https://rpc.example.com", Height: 112, LatencyMS: 80},
+		{Time: start, Network: "pocket", Type: "rpc", Node: "node-1", Height: 106, LatencyMS: 20},
+		{Time: start, Network: "pocket", Type: "rpc", Node: "node-2", Height: 103, LatencyMS: 30},
+	}
+
+	picks, err := Simulate(configLoader, samples, 2, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+	if len(picks) != 4 {
+		t.Fatalf("Expected 2 picks per step over 2 steps, got %d", len(picks))
+	}
+	for _, pick := range picks[:2] {
+		if pick.Node != "node-1" || pick.Reason != "height_winner" || !pick.Time.Equal(start) {
+			t.Errorf("Expected node-1 to win the first step, got %+v", pick)
+		}
+	}
+	for _, pick := range picks[2:] {
+		if pick.Node != "ext:https://rpc.example.com" || !pick.Failover {
+			t.Errorf("Expected failover to the external, got %+v", pick)
+		}
+	}
+
+	if _, err := Simulate(configLoader, []SimulationSample{{Network: "pocket", Type: "ws", Node: "node-1"}}, 1, zap.NewNop()); err == nil {
+		t.Error("Expected an invalid type to be refused")
+	}
+}
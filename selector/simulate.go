@@ -0,0 +1,114 @@
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sauron/config"
+	"sauron/storage"
+
+	"go.uber.org/zap"
+)
+
+// simulatedRing names the ring simulated external endpoints are advertised by
+const simulatedRing = "simulate"
+
+// SimulationSample is one captured health check result replayed by Simulate
+// Nodes named "ext:{url}" are validated external endpoints; height 0 means the check failed
+type SimulationSample struct {
+	Time      time.Time `json:"time"`
+	Network   string    `json:"network"`
+	Type      string    `json:"type"` // api | rpc | grpc
+	Node      string    `json:"node"`
+	Height    int64     `json:"height"`
+	LatencyMS float64   `json:"latency_ms"`
+}
+
+// SimulationPick is the node the selector would have picked for a network endpoint type at a point in time
+type SimulationPick struct {
+	Time     time.Time
+	Network  string
+	Type     string
+	Node     string // empty when no node could serve
+	Reason   string
+	Height   int64
+	Failover bool // externals were candidates
+}
+
+// Simulate replays samples, in time order, through a selector using the given configuration
+// After all samples of a timestamp are applied, each network endpoint type seen so far gets picksPerStep selections
+// Proxy-driven signals (error budgets, slow ejection, in-flight load) aren't part of samples and stay neutral
+func Simulate(configLoader *config.Loader, samples []SimulationSample, picksPerStep int, logger *zap.Logger) ([]SimulationPick, error) {
+	if picksPerStep < 1 {
+		picksPerStep = 1
+	}
+	for i, sample := range samples {
+		if sample.Network == "" || sample.Node == "" {
+			return nil, fmt.Errorf("sample %d: network and node are required", i)
+		}
+		if sample.Type != "api" && sample.Type != "rpc" && sample.Type != "grpc" {
+			return nil, fmt.Errorf("sample %d: invalid type %q (expected api, rpc or grpc)", i, sample.Type)
+		}
+	}
+
+	ordered := make([]SimulationSample, len(samples))
+	copy(ordered, samples)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Time.Before(ordered[j].Time) })
+
+	heightStore := storage.NewHeightStore()
+	heightStore.SetLatencyHalfLife(configLoader.Get().LatencyEWMA.HalfLife)
+	heightStore.SetLatencyWindow(configLoader.Get().LatencyPercentiles.Window)
+	endpointStore := storage.NewExternalEndpointStore(logger)
+	s := NewSelector(heightStore, endpointStore, storage.NewInflightTracker(), configLoader, logger)
+
+	var targets []string // network:type, in order of appearance
+	seen := make(map[string]bool)
+	var picks []SimulationPick
+
+	for i := 0; i < len(ordered); {
+		step := ordered[i].Time
+		for ; i < len(ordered) && ordered[i].Time.Equal(step); i++ {
+			sample := ordered[i]
+			applySample(heightStore, endpointStore, sample)
+			if key := sample.Network + ":" + sample.Type; !seen[key] {
+				seen[key] = true
+				targets = append(targets, key)
+			}
+		}
+
+		for _, key := range targets {
+			network, endpointType, _ := strings.Cut(key, ":")
+			for range picksPerStep {
+				pick := SimulationPick{Time: step, Network: network, Type: endpointType, Reason: "no_nodes"}
+				if nodeMetrics, nodeName, decision := s.GetBestNode(network, endpointType); nodeMetrics != nil {
+					pick.Node = nodeName
+					pick.Reason = decision.Reason
+					pick.Height = nodeMetrics.Height
+					pick.Failover = decision.ExternalFailover
+				}
+				picks = append(picks, pick)
+			}
+		}
+	}
+	return picks, nil
+}
+
+// applySample stores a sample the way the health checkers would
+func applySample(heightStore *storage.HeightStore, endpointStore *storage.ExternalEndpointStore, sample SimulationSample) {
+	latency := time.Duration(sample.LatencyMS * float64(time.Millisecond))
+
+	url, external := strings.CutPrefix(sample.Node, "ext:")
+	if !external {
+		heightStore.Update(sample.Network, sample.Node, sample.Type, sample.Height, latency, "internal")
+		return
+	}
+
+	endpointStore.StoreAdvertised(simulatedRing, "", sample.Network, sample.Type, url)
+	if sample.Height == 0 {
+		endpointStore.MarkValidationFailed(simulatedRing, "", sample.Network, sample.Type, url)
+		return
+	}
+	endpointStore.MarkValidated(simulatedRing, "", sample.Network, sample.Type, url, sample.Height, latency)
+}
@@ -0,0 +1,211 @@
+package selector
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sauron/clock"
+	"sauron/config"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Slow ejection defaults (used when slow_ejection values are unset)
+const (
+	// DefaultSlowEjectionWindow is the sliding window each node's p99 is measured over
+	DefaultSlowEjectionWindow = time.Minute
+	// DefaultSlowEjectionMultiplier is how many times the network median p99 a node may reach before it is ejected
+	DefaultSlowEjectionMultiplier = 3.0
+	// DefaultSlowEjectionMinRequests is how many requests a node needs in the window before its p99 is judged
+	DefaultSlowEjectionMinRequests = 20
+	// DefaultSlowEjectionEjectFor is how long an ejected node stays out of rotation
+	DefaultSlowEjectionEjectFor = 30 * time.Second
+)
+
+// latencySamples bounds the samples kept per node; under heavy traffic the oldest are overwritten first
+const latencySamples = 1024
+
+// slowEvaluateEvery throttles how often the p99s of a network's nodes are recomputed
+const slowEvaluateEvery = time.Second
+
+// latencySample is one proxied request's latency, stamped with clock.Elapsed
+type latencySample struct {
+	at      time.Duration
+	latency time.Duration
+}
+
+// latencyWindow keeps the recent proxy latencies of one internal node endpoint
+type latencyWindow struct {
+	samples      [latencySamples]latencySample
+	next         int           // slot the next sample goes to
+	count        int           // samples stored, up to latencySamples
+	ejectedUntil time.Duration // clock.Elapsed when the node returns to rotation (0 = in rotation)
+}
+
+// slowness tracks sliding-window p99 latencies of internal nodes and ejects the ones far slower than their peers
+type slowness struct {
+	mu        sync.Mutex
+	logger    *zap.Logger
+	windows   map[string]map[string]*latencyWindow // network:type -> node -> window
+	evaluated map[string]time.Duration             // network:type -> clock.Elapsed of the last evaluation
+}
+
+// newSlowness creates an empty slow node tracker
+func newSlowness(logger *zap.Logger) *slowness {
+	return &slowness{
+		logger:    logger,
+		windows:   make(map[string]map[string]*latencyWindow),
+		evaluated: make(map[string]time.Duration),
+	}
+}
+
+// record adds a proxied request's latency for an internal node endpoint
+func (sl *slowness) record(network, endpointType, nodeName string, latency time.Duration) {
+	key := network + ":" + endpointType
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	nodes, exists := sl.windows[key]
+	if !exists {
+		nodes = make(map[string]*latencyWindow)
+		sl.windows[key] = nodes
+	}
+	w, exists := nodes[nodeName]
+	if !exists {
+		w = &latencyWindow{}
+		nodes[nodeName] = w
+	}
+
+	w.samples[w.next] = latencySample{at: clock.Elapsed(), latency: latency}
+	w.next = (w.next + 1) % latencySamples
+	w.count = min(w.count+1, latencySamples)
+}
+
+// ejected returns the nodes of a network endpoint type currently out of rotation
+// Re-evaluates p99s at most once per slowEvaluateEvery
+func (sl *slowness) ejected(network, endpointType string, cfg config.SlowEjection) map[string]bool {
+	key := network + ":" + endpointType
+	now := clock.Elapsed()
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	if last, ok := sl.evaluated[key]; !ok || now-last >= slowEvaluateEvery {
+		sl.evaluated[key] = now
+		sl.evaluate(network, endpointType, now, cfg)
+	}
+
+	var out map[string]bool
+	for nodeName, w := range sl.windows[key] {
+		if w.ejectedUntil == 0 {
+			continue
+		}
+		if now >= w.ejectedUntil {
+			w.ejectedUntil = 0
+			metrics.NodeEjected.WithLabelValues(network, nodeName, endpointType).Set(0)
+			sl.logger.Info("Slow node returned to rotation",
+				zap.String("network", network),
+				zap.String("type", endpointType),
+				zap.String("node", nodeName),
+			)
+			continue
+		}
+		if out == nil {
+			out = make(map[string]bool)
+		}
+		out[nodeName] = true
+	}
+	return out
+}
+
+// evaluate computes each node's p99 over the window and ejects nodes above multiplier x the median p99
+// Must be called with sl.mu held
+func (sl *slowness) evaluate(network, endpointType string, now time.Duration, cfg config.SlowEjection) {
+	window := cfg.Window
+	if window == 0 {
+		window = DefaultSlowEjectionWindow
+	}
+	multiplier := cfg.Multiplier
+	if multiplier == 0 {
+		multiplier = DefaultSlowEjectionMultiplier
+	}
+	minRequests := cfg.MinRequests
+	if minRequests == 0 {
+		minRequests = DefaultSlowEjectionMinRequests
+	}
+	ejectFor := cfg.EjectFor
+	if ejectFor == 0 {
+		ejectFor = DefaultSlowEjectionEjectFor
+	}
+
+	// p99 of every node with enough requests in the window
+	p99s := make(map[string]time.Duration)
+	for nodeName, w := range sl.windows[network+":"+endpointType] {
+		latencies := make([]time.Duration, 0, w.count)
+		for _, sample := range w.samples[:w.count] {
+			if sample.at > now-window {
+				latencies = append(latencies, sample.latency)
+			}
+		}
+		if len(latencies) < minRequests {
+			continue
+		}
+		slices.Sort(latencies)
+		p99 := latencies[(len(latencies)*99+99)/100-1]
+		p99s[nodeName] = p99
+		metrics.NodeLatencyP99.WithLabelValues(network, nodeName, endpointType).Set(p99.Seconds())
+	}
+
+	// A single node has no peers to be compared with
+	if len(p99s) < 2 {
+		return
+	}
+
+	// Lower median, so with two nodes the slow one is judged against the fast one
+	sorted := make([]time.Duration, 0, len(p99s))
+	for _, p99 := range p99s {
+		sorted = append(sorted, p99)
+	}
+	slices.Sort(sorted)
+	median := sorted[(len(sorted)-1)/2]
+	limit := time.Duration(float64(median) * multiplier)
+
+	for nodeName, p99 := range p99s {
+		w := sl.windows[network+":"+endpointType][nodeName]
+		if p99 <= limit || w.ejectedUntil != 0 {
+			continue
+		}
+
+		// Forget the slow samples, so the node is judged afresh once it is back in rotation
+		w.ejectedUntil = now + ejectFor
+		w.next, w.count = 0, 0
+
+		metrics.NodeEjected.WithLabelValues(network, nodeName, endpointType).Set(1)
+		metrics.NodeEjections.WithLabelValues(network, nodeName, endpointType).Inc()
+		sl.logger.Warn("Ejecting slow node",
+			zap.String("network", network),
+			zap.String("type", endpointType),
+			zap.String("node", nodeName),
+			zap.Duration("p99", p99),
+			zap.Duration("median_p99", median),
+			zap.Duration("eject_for", ejectFor),
+		)
+	}
+}
+
+// RecordLatency feeds a proxied request's latency into the node's sliding-window p99 and the network's scaling signal
+// Only internal nodes are judged for slowness; external endpoints are only used as failover
+func (s *Selector) RecordLatency(network, endpointType, nodeName string, latency time.Duration) {
+	s.traffic.latency(network, latency)
+	if strings.HasPrefix(nodeName, "ext:") {
+		return
+	}
+	if !s.configLoader.Get().SlowEjection.Enabled {
+		return
+	}
+	s.slowness.record(network, endpointType, nodeName, latency)
+}
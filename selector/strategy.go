@@ -0,0 +1,180 @@
+package selector
+
+import (
+	"hash/fnv"
+	"math"
+
+	"sauron/config"
+)
+
+// strategy picks which of the candidates at max height takes a request
+// New algorithms plug in here (and in strategies) without touching selectNode
+type strategy interface {
+	// pick returns the index of the chosen candidate and the decision reason
+	pick(s *Selector, req strategyRequest) (int, string)
+}
+
+// strategyRequest is what a strategy chooses among; candidates are never empty
+type strategyRequest struct {
+	cfg          *config.Config
+	network      string
+	endpointType string
+	candidates   []nodeWithName
+	counter      uint64 // round-robin tick, so strategies can rotate among ties
+	client       string // sticky sessions key, only set for stickyStrategy
+}
+
+// strategies are the selection strategies by selection_strategy name
+var strategies = map[string]strategy{
+	config.SelectionStrategyWeighted:      weightedStrategy{},
+	config.SelectionStrategyRoundRobin:    roundRobinStrategy{},
+	config.SelectionStrategyHeightLatency: heightLatencyStrategy{},
+	config.SelectionStrategyLeastConns:    leastConnectionsStrategy{},
+}
+
+// strategyFor returns the selection strategy of a network (weighted when unset)
+func strategyFor(cfg *config.Config, network string) strategy {
+	for _, n := range cfg.Networks {
+		if n.Name == network {
+			if st, ok := strategies[n.SelectionStrategy]; ok {
+				return st
+			}
+			break
+		}
+	}
+	return strategies[config.SelectionStrategyWeighted]
+}
+
+// DefaultNodeWeight is the share of a node without weight, and of every external endpoint
+const DefaultNodeWeight = 1.0
+
+// weightedStrategy rotates among candidates, bigger nodes (weight) getting a proportionally larger share
+// Equal weights rotate evenly, like round_robin
+type weightedStrategy struct{}
+
+func (weightedStrategy) pick(s *Selector, req strategyRequest) (int, string) {
+	return s.rotate(req, nodeWeights(req.cfg, req.network))
+}
+
+// roundRobinStrategy rotates evenly among candidates, ignoring weight
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) pick(s *Selector, req strategyRequest) (int, string) {
+	return s.rotate(req, nil)
+}
+
+// heightLatencyStrategy picks the candidate with the lowest health check latency (per latency_percentiles.tiebreak); ties rotate
+type heightLatencyStrategy struct{}
+
+func (heightLatencyStrategy) pick(s *Selector, req strategyRequest) (int, string) {
+	best := -1
+	for i := range req.candidates {
+		idx := (i + int(req.counter%uint64(len(req.candidates)))) % len(req.candidates)
+		if best < 0 || tiebreakLatency(req.cfg, req.candidates[idx].metrics) < tiebreakLatency(req.cfg, req.candidates[best].metrics) {
+			best = idx
+		}
+	}
+	return best, "lowest_latency"
+}
+
+// leastConnectionsStrategy picks the candidate with the fewest requests in flight and WebSockets open; ties rotate
+// Long-running queries then don't pile up on one node while others sit idle
+type leastConnectionsStrategy struct{}
+
+func (leastConnectionsStrategy) pick(s *Selector, req strategyRequest) (int, string) {
+	best := -1
+	var bestConns int64
+	for i := range req.candidates {
+		idx := (i + int(req.counter%uint64(len(req.candidates)))) % len(req.candidates)
+		conns := s.inflight.Connections(req.network, req.candidates[idx].name, req.endpointType)
+		if best < 0 || conns < bestConns {
+			best, bestConns = idx, conns
+		}
+	}
+	return best, "least_connections"
+}
+
+// rotate spreads picks over candidates in proportion to their capacity (nil = equal shares)
+// With error budgets, nodes whose proxied requests fail get a proportionally smaller share
+func (s *Selector) rotate(req strategyRequest, capacities map[string]float64) (int, string) {
+	selectedIndex := int(req.counter % uint64(len(req.candidates)))
+	if len(req.candidates) == 1 {
+		return selectedIndex, "round_robin"
+	}
+
+	weighted := false
+	weights := make([]float64, len(req.candidates))
+	var total float64
+	for i, node := range req.candidates {
+		weights[i] = DefaultNodeWeight
+		if w, ok := capacities[node.name]; ok {
+			weights[i] = w
+		}
+		if weights[i] != weights[0] {
+			weighted = true
+		}
+		if req.cfg.ErrorBudget.Enabled && node.metrics.Source != "external" {
+			budget := s.errorBudget.weight(req.network, req.endpointType, node.name, req.cfg.ErrorBudget)
+			if budget < 1 {
+				weighted = true
+			}
+			weights[i] *= budget
+		}
+		total += weights[i]
+	}
+	if !weighted {
+		return selectedIndex, "round_robin"
+	}
+	return weightedIndex(weights, total, req.counter), "weighted"
+}
+
+// nodeWeights returns the configured weight of each internal node of a network
+// Group nodes keep their name in every network, so weights are looked up per network
+func nodeWeights(cfg *config.Config, network string) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, node := range cfg.Internals {
+		if node.Network == network && node.Weight > 0 {
+			weights[node.Name] = node.Weight
+		}
+	}
+	return weights
+}
+
+// weightedIndex spreads successive picks over nodes in proportion to their weights
+// The golden ratio sequence visits the [0, total) range evenly, like round-robin but weighted
+func weightedIndex(weights []float64, total float64, counter uint64) int {
+	_, frac := math.Modf(float64(counter) * 0.6180339887498949)
+	point := frac * total
+	for i, w := range weights {
+		if point < w {
+			return i
+		}
+		point -= w
+	}
+	return len(weights) - 1
+}
+
+// stickyStrategy hashes the client to a candidate (weighted rendezvous hashing), bigger nodes taking more clients
+// When a candidate leaves or joins, only the clients hashed to it move
+type stickyStrategy struct{}
+
+func (stickyStrategy) pick(s *Selector, req strategyRequest) (int, string) {
+	weights := nodeWeights(req.cfg, req.network)
+	best, bestScore := 0, math.Inf(-1)
+	for i, node := range req.candidates {
+		h := fnv.New64a()
+		h.Write([]byte(req.client))
+		h.Write([]byte{0})
+		h.Write([]byte(node.name))
+		// Map the hash to (0,1); -w/ln(u) keeps each node's share proportional to its weight
+		u := (float64(h.Sum64()>>11) + 0.5) / (1 << 53)
+		weight := DefaultNodeWeight
+		if w, ok := weights[node.name]; ok {
+			weight = w
+		}
+		if score := -weight / math.Log(u); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best, "sticky"
+}
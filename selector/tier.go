@@ -0,0 +1,49 @@
+package selector
+
+import (
+	"sauron/config"
+)
+
+// firstTier narrows internal candidates to the first priority tier with a healthy node caught up with the others
+// Caught up means within external_failover_threshold blocks of the highest internal node; when no node qualifies,
+// candidates are returned unchanged and the usual failover applies
+// Returns the tier used (0 when the network has no tiers)
+func firstTier(cfg *config.Config, network string, nodes []nodeWithName) ([]nodeWithName, int) {
+	// Group nodes keep their name in every network, so tiers are looked up per network
+	tiers := make(map[string]int)
+	for _, node := range cfg.Internals {
+		if node.Network == network && node.Tier > 0 {
+			tiers[node.Name] = node.Tier
+		}
+	}
+	if len(tiers) == 0 {
+		return nodes, 0
+	}
+
+	threshold := cfg.ExternalFailoverThreshold
+	if threshold == 0 {
+		threshold = 2
+	}
+	var maxHeight int64
+	for _, node := range nodes {
+		maxHeight = max(maxHeight, node.metrics.Height)
+	}
+
+	best := -1
+	for _, node := range nodes {
+		if node.metrics.Height > 0 && node.metrics.Height >= maxHeight-threshold && (best < 0 || tiers[node.name] < best) {
+			best = tiers[node.name]
+		}
+	}
+	if best < 0 {
+		return nodes, 0
+	}
+
+	kept := make([]nodeWithName, 0, len(nodes))
+	for _, node := range nodes {
+		if tiers[node.name] == best {
+			kept = append(kept, node)
+		}
+	}
+	return kept, best
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+
+	"sauron/config"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log sampling defaults (used when logging.sampling values are unset), as in zap's production preset
+const (
+	DefaultLogSamplingInitial    = 100
+	DefaultLogSamplingThereafter = 100
+)
+
+// newLogger builds the process logger from the logging configuration
+// The defaults match zap.NewProduction: info level, sampled JSON on stderr
+func newLogger(cfg config.Logging) (*zap.Logger, error) {
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	if cfg.Level != "" {
+		parsed, err := zap.ParseAtomicLevel(cfg.Level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logging level: %w", err)
+		}
+		level = parsed
+	}
+
+	zapCfg := zap.NewProductionConfig()
+	zapCfg.Level = level
+	if len(cfg.Outputs) > 0 {
+		zapCfg.OutputPaths = cfg.Outputs
+	}
+
+	if cfg.Encoding == config.LogEncodingConsole {
+		zapCfg.Encoding = config.LogEncodingConsole
+		zapCfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		zapCfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+
+	if cfg.Sampling.Disabled {
+		zapCfg.Sampling = nil
+	} else {
+		initial := cfg.Sampling.Initial
+		if initial == 0 {
+			initial = DefaultLogSamplingInitial
+		}
+		thereafter := cfg.Sampling.Thereafter
+		if thereafter == 0 {
+			thereafter = DefaultLogSamplingThereafter
+		}
+		zapCfg.Sampling = &zap.SamplingConfig{Initial: initial, Thereafter: thereafter}
+	}
+
+	return zapCfg.Build()
+}
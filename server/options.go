@@ -0,0 +1,69 @@
+package server
+
+import (
+	"sauron/selector"
+	"sauron/storage"
+)
+
+// Checker runs the periodic health checks that feed the height store
+// The default is checker.Scheduler; embedders may supply their own
+type Checker interface {
+	Start() error
+	Stop()
+}
+
+// Option customizes a Server at construction time
+type Option func(*options)
+
+// options holds components injected through Option
+type options struct {
+	store         *storage.HeightStore
+	endpointStore *storage.ExternalEndpointStore
+	inflight      *storage.InflightTracker
+	cache         *storage.Cache
+	selector      selector.NodeSelector
+	checker       Checker
+}
+
+// WithHeightStore uses the given height store instead of creating one
+func WithHeightStore(store *storage.HeightStore) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithEndpointStore uses the given external endpoint store instead of creating one
+func WithEndpointStore(endpointStore *storage.ExternalEndpointStore) Option {
+	return func(o *options) {
+		o.endpointStore = endpointStore
+	}
+}
+
+// WithInflightTracker uses the given in-flight tracker instead of creating one
+// Embedders can share it with their own load-aware selector
+func WithInflightTracker(inflight *storage.InflightTracker) Option {
+	return func(o *options) {
+		o.inflight = inflight
+	}
+}
+
+// WithCache uses the given cache instead of connecting to the configured Redis
+func WithCache(cache *storage.Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithSelector plugs an alternate selection policy into the proxies and status API
+func WithSelector(sel selector.NodeSelector) Option {
+	return func(o *options) {
+		o.selector = sel
+	}
+}
+
+// WithChecker replaces the built-in health check scheduler
+func WithChecker(c Checker) Option {
+	return func(o *options) {
+		o.checker = c
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
+	"sauron/abuse"
 	"sauron/checker"
 	"sauron/config"
+	"sauron/metrics"
 	"sauron/proxy"
 	"sauron/selector"
 	"sauron/status"
@@ -22,26 +26,45 @@ import (
 	"google.golang.org/grpc"
 )
 
+// Listener timeout defaults (used when timeouts values are unset)
+const (
+	// DefaultReadHeaderTimeout bounds how long a client may take to send request headers
+	DefaultReadHeaderTimeout = 10 * time.Second
+	// DefaultIdleTimeout bounds how long a keep-alive connection may sit idle
+	DefaultIdleTimeout = 120 * time.Second
+)
+
 // Server orchestrates all components of Sauron
 // The foundation of Barad-dûr
 type Server struct {
 	configLoader  *config.Loader
 	logger        *zap.Logger
 	pool          pond.Pool
-	scheduler     *checker.Scheduler
+	scheduler     Checker
 	store         *storage.HeightStore
 	cache         *storage.Cache
 	endpointStore *storage.ExternalEndpointStore
-	selector      *selector.Selector
+	inflight      *storage.InflightTracker
+	selector      selector.NodeSelector
+	abuse         *abuse.Detector // Bans abusive client IPs (abuse_detection, optional)
 	statusServer  *http.Server
-	httpServers   []*http.Server // All HTTP proxy servers (API + RPC)
-	grpcServers   []*grpc.Server // All gRPC proxy servers
+	metricsServer *http.Server       // Dedicated /metrics listener (optional)
+	httpServers   []*http.Server     // All HTTP proxy servers (API + RPC)
+	grpcServers   []*grpc.Server     // All gRPC proxy servers
+	httpProxies   []*proxy.HTTPProxy // API/RPC proxies, rebalanced by transport.rebalance
+	grpcProxies   []*proxy.GRPCProxy // gRPC proxies, swept for leaked streams and rebalanced
+	stopLoops     chan struct{}      // Stops the periodic loops (metrics export, runtime monitor, stream sweeper, rebalancer)
+	loopsDone     sync.WaitGroup
 }
 
-// New creates a new Sauron server
-func New(configPath string) (*Server, error) {
-	// Initialize logger
-	logger, err := zap.NewProduction()
+// New creates a new Sauron server from a configuration file (with hot reload)
+func New(configPath string, opts ...Option) (*Server, error) {
+	// Read the logging section first, so the loader already logs the configured way
+	cfg, _, err := config.LoadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	logger, err := newLogger(cfg.Logging)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}
@@ -54,22 +77,88 @@ func New(configPath string) (*Server, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	return newServer(configLoader, logger, opts...), nil
+}
+
+// NewWithConfig creates a new Sauron server from an in-memory configuration
+// Intended for embedding Sauron in other Go programs and in-process integration tests
+func NewWithConfig(cfg *config.Config, logger *zap.Logger, opts ...Option) (*Server, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	logger.Info("The Eye of Sauron awakens...", zap.String("config", "(static)"))
+
+	configLoader, err := config.NewStaticLoader(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return newServer(configLoader, logger, opts...), nil
+}
+
+// newServer wires all components, using injected ones from options where provided
+func newServer(configLoader *config.Loader, logger *zap.Logger, opts ...Option) *Server {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	cfg := configLoader.Get()
 
+	// Count reload attempts by result
+	configLoader.OnReload(func(err error) {
+		result := config.ReloadSuccess
+		if err != nil {
+			result = config.ReloadFailure
+		}
+		metrics.ConfigReloads.WithLabelValues(result).Inc()
+	})
+
 	// Initialize storage
-	store := storage.NewHeightStore()
+	store := o.store
+	if store == nil {
+		store = storage.NewHeightStore()
+	}
+	store.SetLatencyHalfLife(cfg.LatencyEWMA.HalfLife)
+	store.SetLatencyWindow(cfg.LatencyPercentiles.Window)
+	configLoader.OnReload(func(err error) {
+		if err == nil {
+			store.SetLatencyHalfLife(configLoader.Get().LatencyEWMA.HalfLife)
+			store.SetLatencyWindow(configLoader.Get().LatencyPercentiles.Window)
+		}
+	})
 	logger.Info("The Dark Lord's memory initialized")
 
 	// Initialize external endpoint store
-	endpointStore := storage.NewExternalEndpointStore(logger)
+	endpointStore := o.endpointStore
+	if endpointStore == nil {
+		endpointStore = storage.NewExternalEndpointStore(logger)
+	}
 	logger.Info("External endpoint tracking initialized")
 
+	// Initialize in-flight request tracking (shared by the proxies and the selector)
+	inflight := o.inflight
+	if inflight == nil {
+		inflight = storage.NewInflightTracker()
+	}
+
+	// Restore endpoint reputation from the previous run (optional)
+	if cfg.Reputation.Path != "" {
+		if err := endpointStore.LoadReputation(cfg.Reputation.Path); err != nil {
+			logger.Warn("Failed to load endpoint reputation, starting fresh", zap.Error(err))
+		}
+	}
+
 	// Initialize cache (optional)
-	var cacheURI string
-	if cfg.Redis.Enabled {
-		cacheURI = cfg.Redis.URI
+	cache := o.cache
+	if cache == nil {
+		var cacheURI string
+		if cfg.Redis.Enabled {
+			cacheURI = cfg.Redis.URI
+		}
+		cache = storage.NewCache(cacheURI, logger)
 	}
-	cache := storage.NewCache(cacheURI, logger)
 
 	// Initialize worker pool (The servants of Sauron)
 	ctx := context.Background()
@@ -77,11 +166,17 @@ func New(configPath string) (*Server, error) {
 	logger.Info("Worker pool created", zap.Int("workers", 100))
 
 	// Initialize selector
-	sel := selector.NewSelector(store, endpointStore, configLoader, logger)
+	sel := o.selector
+	if sel == nil {
+		sel = selector.NewSelector(store, endpointStore, inflight, configLoader, logger)
+	}
 	logger.Info("The Dark Lord's judgment ready")
 
 	// Initialize scheduler
-	sched := checker.NewScheduler(store, cache, endpointStore, configLoader, pool, logger)
+	sched := o.checker
+	if sched == nil {
+		sched = checker.NewScheduler(store, cache, endpointStore, configLoader, pool, logger)
+	}
 
 	return &Server{
 		configLoader:  configLoader,
@@ -91,8 +186,9 @@ func New(configPath string) (*Server, error) {
 		store:         store,
 		cache:         cache,
 		endpointStore: endpointStore,
+		inflight:      inflight,
 		selector:      sel,
-	}, nil
+	}
 }
 
 // Start begins all Sauron services
@@ -104,6 +200,12 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to start scheduler: %w", err)
 	}
 
+	// Abuse detection is shared by the status API and every proxy, so a ban applies on all listeners
+	if cfg.AbuseDetection.Enabled {
+		s.abuse = abuse.New(s.configLoader, s.cache, s.logger)
+		s.logger.Info("Abuse detection enabled")
+	}
+
 	// Start status server (The Palantír)
 	if err := s.startStatusServer(cfg); err != nil {
 		return err
@@ -114,6 +216,19 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// Push or emit metrics where Prometheus doesn't scrape, and run the periodic checks
+	s.stopLoops = make(chan struct{})
+	s.startMetricsExport(cfg)
+	s.startRuntimeMonitor(cfg)
+	s.startStreamLeakDetector(cfg)
+	s.startConnectionRebalancer(cfg)
+
+	if len(cfg.Internals) == 0 {
+		s.logger.Info("No internal nodes configured - relaying to validated external endpoints only",
+			zap.Int("external_rings", len(cfg.Externals)),
+		)
+	}
+
 	s.logger.Info("Sauron is fully operational - The tower stands",
 		zap.String("status_listen", cfg.Listen),
 		zap.Int("networks", len(cfg.Networks)),
@@ -122,19 +237,174 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// startMetricsExport starts metrics push and the StatsD emitter when configured
+// Both run every interval and once more on shutdown, so the last interval isn't lost
+func (s *Server) startMetricsExport(cfg *config.Config) {
+	if cfg.Metrics.Push.Mode != "" {
+		pusher := metrics.NewPusher(cfg.Metrics.Push)
+		push := func() {
+			if err := pusher.Push(context.Background()); err != nil {
+				s.logger.Warn("Metrics push failed",
+					zap.String("mode", cfg.Metrics.Push.Mode),
+					zap.Error(err),
+				)
+			}
+		}
+		s.every(pusher.Interval(), push, push)
+		s.logger.Info("Metrics push started",
+			zap.String("mode", cfg.Metrics.Push.Mode),
+			zap.Duration("interval", pusher.Interval()),
+		)
+	}
+
+	if cfg.Metrics.StatsD.Address != "" {
+		emitter, err := metrics.NewStatsDEmitter(cfg.Metrics.StatsD)
+		if err != nil {
+			s.logger.Error("StatsD emitter not started", zap.Error(err))
+			return
+		}
+		emit := func() {
+			if err := emitter.Emit(); err != nil {
+				s.logger.Warn("StatsD emit failed",
+					zap.String("address", cfg.Metrics.StatsD.Address),
+					zap.Error(err),
+				)
+			}
+		}
+		s.every(emitter.Interval(), emit, func() {
+			emit()
+			_ = emitter.Close()
+		})
+		s.logger.Info("StatsD emitter started",
+			zap.String("address", cfg.Metrics.StatsD.Address),
+			zap.Duration("interval", emitter.Interval()),
+		)
+	}
+}
+
+// startRuntimeMonitor checks runtime_limits every check_interval, logging when a soft limit is crossed
+// Limits follow config reloads; the interval is fixed at startup
+func (s *Server) startRuntimeMonitor(cfg *config.Config) {
+	interval := cfg.RuntimeLimits.CheckInterval
+	if interval == 0 {
+		interval = metrics.DefaultRuntimeCheckInterval
+	}
+
+	exceeded := make(map[string]bool)
+	s.every(interval, func() {
+		limits := s.configLoader.Get().RuntimeLimits
+		stats := metrics.SampleRuntimeStats()
+		over := stats.Exceeded(limits)
+		metrics.ObserveRuntimeLimits(limits, over)
+
+		for _, resource := range []string{metrics.RuntimeGoroutines, metrics.RuntimeOpenFDs} {
+			now := slices.Contains(over, resource)
+			if now == exceeded[resource] {
+				continue
+			}
+			exceeded[resource] = now
+			fields := []zap.Field{
+				zap.String("resource", resource),
+				zap.Int("goroutines", stats.Goroutines),
+				zap.Int("open_fds", stats.OpenFDs),
+				zap.Int("max_goroutines", limits.MaxGoroutines),
+				zap.Int("max_open_fds", limits.MaxOpenFDs),
+			}
+			if now {
+				s.logger.Warn("Runtime soft limit exceeded, possible leak", fields...)
+			} else {
+				s.logger.Info("Runtime back within soft limit", fields...)
+			}
+		}
+	}, nil)
+}
+
+// startStreamLeakDetector checks the open gRPC streams of every network each stream_leaks.check_interval
+// max_idle and force_close follow config reloads; the interval is fixed at startup
+func (s *Server) startStreamLeakDetector(cfg *config.Config) {
+	if len(s.grpcProxies) == 0 {
+		return
+	}
+	interval := cfg.StreamLeaks.CheckInterval
+	if interval == 0 {
+		interval = proxy.DefaultStreamLeakCheckInterval
+	}
+
+	s.every(interval, func() {
+		for _, grpcProxy := range s.grpcProxies {
+			grpcProxy.SweepStreams()
+		}
+	}, nil)
+}
+
+// startConnectionRebalancer recycles a slice of every proxy's backend connections each transport.rebalance.interval
+// fraction and min_age follow config reloads; the interval is fixed at startup
+func (s *Server) startConnectionRebalancer(cfg *config.Config) {
+	interval := cfg.Transport.Rebalance.Interval
+	if interval == 0 {
+		return
+	}
+
+	s.every(interval, func() {
+		for _, httpProxy := range s.httpProxies {
+			httpProxy.RebalanceConnections()
+		}
+		for _, grpcProxy := range s.grpcProxies {
+			grpcProxy.RebalanceConnections()
+		}
+	}, nil)
+	s.logger.Info("Backend connection rebalancing started", zap.Duration("interval", interval))
+}
+
+// every runs fn each interval until shutdown, then final (optional) once
+func (s *Server) every(interval time.Duration, fn func(), final func()) {
+	s.loopsDone.Add(1)
+	go func() {
+		defer s.loopsDone.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-s.stopLoops:
+				if final != nil {
+					final()
+				}
+				return
+			}
+		}
+	}()
+}
+
 // startStatusServer starts the status API server
 func (s *Server) startStatusServer(cfg *config.Config) error {
 	mux := http.NewServeMux()
 
 	// Setup status routes
-	handler := status.NewHandler(s.selector, s.configLoader, s.logger)
+	handler := status.NewHandler(s.selector, s.endpointStore, s.configLoader, s.logger)
+	handler.SetAbuseDetector(s.abuse)
+	if scheduler, ok := s.scheduler.(status.SchedulerControl); ok {
+		handler.SetScheduler(scheduler)
+	}
 	handler.SetupRoutes(mux)
 
-	s.statusServer = &http.Server{
-		Addr:    cfg.Listen,
-		Handler: mux,
+	// Metrics on their own listener keep infrastructure details off the public status port
+	if cfg.Metrics.Listen != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", handler.MetricsHandler())
+		s.metricsServer = newHTTPServer(cfg.Metrics.Listen, metricsMux, cfg.Timeouts)
+
+		go func() {
+			s.logger.Info("Metrics server starting", zap.String("addr", cfg.Metrics.Listen))
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Fatal("Metrics server failed", zap.Error(err))
+			}
+		}()
 	}
 
+	s.statusServer = newHTTPServer(cfg.Listen, s.abuse.Middleware("status", mux), cfg.Timeouts)
+
 	go func() {
 		s.logger.Info("Status server starting", zap.String("addr", cfg.Listen))
 		if err := s.statusServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -145,24 +415,52 @@ func (s *Server) startStatusServer(cfg *config.Config) error {
 	return nil
 }
 
+// newHTTPServer creates an HTTP server with the configured listener timeouts
+// Without a header timeout, clients trickling bytes (slowloris) can hold connections open forever
+func newHTTPServer(addr string, handler http.Handler, t config.Timeouts) *http.Server {
+	readHeader := t.ReadHeader
+	if readHeader == 0 {
+		readHeader = DefaultReadHeaderTimeout
+	}
+	idle := t.Idle
+	if idle == 0 {
+		idle = DefaultIdleTimeout
+	}
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       idle,
+	}
+}
+
 // startNetworkProxies starts proxy servers for each configured network
 func (s *Server) startNetworkProxies(cfg *config.Config) error {
 	for _, network := range cfg.Networks {
 		// Start API proxy for this network
 		if cfg.API && network.APIListen != "" {
-			proxyHandler := proxy.NewHTTPProxy(s.selector, s.configLoader, s.endpointStore, s.logger, "api", network.Name)
-			server := &http.Server{
-				Addr:    network.APIListen,
-				Handler: proxyHandler,
-			}
+			proxyHandler := proxy.NewHTTPProxy(s.selector, s.configLoader, s.endpointStore, s.inflight, s.logger, "api", network.Name)
+			proxyHandler.SetAbuseDetector(s.abuse)
+			server := newHTTPServer(network.APIListen, s.abuse.Middleware("api", proxyHandler), cfg.Timeouts)
 			s.httpServers = append(s.httpServers, server)
+			s.httpProxies = append(s.httpProxies, proxyHandler)
 
 			go func(netName, addr string) {
 				s.logger.Info("API proxy starting",
 					zap.String("network", netName),
 					zap.String("addr", addr),
 				)
-				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				lis, err := net.Listen("tcp", addr)
+				if err != nil {
+					s.logger.Fatal("API proxy failed to listen", zap.String("network", netName), zap.Error(err))
+				}
+
+				// Reject gRPC clients that hit the API port with a descriptive error
+				sniffer := proxy.NewProtocolSniffingListener(lis, netName, "api", s.logger)
+				if err := server.Serve(sniffer); err != nil && err != http.ErrServerClosed {
 					s.logger.Fatal("API proxy failed", zap.String("network", netName), zap.Error(err))
 				}
 			}(network.Name, network.APIListen)
@@ -170,19 +468,25 @@ func (s *Server) startNetworkProxies(cfg *config.Config) error {
 
 		// Start RPC proxy for this network
 		if cfg.RPC && network.RPCListen != "" {
-			proxyHandler := proxy.NewHTTPProxy(s.selector, s.configLoader, s.endpointStore, s.logger, "rpc", network.Name)
-			server := &http.Server{
-				Addr:    network.RPCListen,
-				Handler: proxyHandler,
-			}
+			proxyHandler := proxy.NewHTTPProxy(s.selector, s.configLoader, s.endpointStore, s.inflight, s.logger, "rpc", network.Name)
+			proxyHandler.SetAbuseDetector(s.abuse)
+			server := newHTTPServer(network.RPCListen, s.abuse.Middleware("rpc", proxyHandler), cfg.Timeouts)
 			s.httpServers = append(s.httpServers, server)
+			s.httpProxies = append(s.httpProxies, proxyHandler)
 
 			go func(netName, addr string) {
 				s.logger.Info("RPC proxy starting",
 					zap.String("network", netName),
 					zap.String("addr", addr),
 				)
-				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				lis, err := net.Listen("tcp", addr)
+				if err != nil {
+					s.logger.Fatal("RPC proxy failed to listen", zap.String("network", netName), zap.Error(err))
+				}
+
+				// Reject gRPC clients that hit the RPC port with a descriptive error
+				sniffer := proxy.NewProtocolSniffingListener(lis, netName, "rpc", s.logger)
+				if err := server.Serve(sniffer); err != nil && err != http.ErrServerClosed {
 					s.logger.Fatal("RPC proxy failed", zap.String("network", netName), zap.Error(err))
 				}
 			}(network.Name, network.RPCListen)
@@ -190,9 +494,11 @@ func (s *Server) startNetworkProxies(cfg *config.Config) error {
 
 		// Start gRPC proxy for this network
 		if cfg.GRPC && network.GRPCListen != "" {
-			grpcProxy := proxy.NewGRPCProxy(s.selector, s.configLoader, s.endpointStore, s.logger, network.Name)
+			grpcProxy := proxy.NewGRPCProxy(s.selector, s.configLoader, s.endpointStore, s.inflight, s.logger, network.Name)
+			grpcProxy.SetAbuseDetector(s.abuse)
 			grpcServer := grpcProxy.GetServer()
 			s.grpcServers = append(s.grpcServers, grpcServer)
+			s.grpcProxies = append(s.grpcProxies, grpcProxy)
 
 			go func(netName, addr string) {
 				s.logger.Info("gRPC proxy starting",
@@ -208,7 +514,9 @@ func (s *Server) startNetworkProxies(cfg *config.Config) error {
 						zap.Error(err))
 				}
 
-				if err := grpcServer.Serve(lis); err != nil {
+				// Reject HTTP/1.1 clients that hit the gRPC port with a descriptive error
+				sniffer := proxy.NewProtocolSniffingListener(lis, netName, "grpc", s.logger)
+				if err := grpcServer.Serve(sniffer); err != nil {
 					s.logger.Fatal("gRPC proxy failed",
 						zap.String("network", netName),
 						zap.Error(err))
@@ -248,6 +556,20 @@ func (s *Server) Shutdown() {
 		}
 	}
 
+	// Stop metrics server
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			s.logger.Error("Metrics server shutdown error", zap.Error(err))
+		}
+	}
+
+	// Stop the periodic loops; metrics push and StatsD emit once more so the last interval isn't lost
+	if s.stopLoops != nil {
+		close(s.stopLoops)
+		s.loopsDone.Wait()
+		s.stopLoops = nil
+	}
+
 	// Stop all HTTP proxy servers
 	for i, httpServer := range s.httpServers {
 		if err := httpServer.Shutdown(ctx); err != nil {
@@ -268,9 +590,22 @@ func (s *Server) Shutdown() {
 			zap.Int("server_index", i))
 	}
 
+	// Stop proxy rate limiters and close pooled gRPC connections once nothing is served
+	for _, httpProxy := range s.httpProxies {
+		httpProxy.Close()
+	}
+	for _, grpcProxy := range s.grpcProxies {
+		if err := grpcProxy.Close(); err != nil {
+			s.logger.Error("gRPC proxy close error", zap.Error(err))
+		}
+	}
+
 	// Stop worker pool
 	s.pool.StopAndWait()
 
+	// Stop abuse detection before the cache it stores bans in
+	s.abuse.Stop()
+
 	// Close cache
 	if err := s.cache.Close(); err != nil {
 		s.logger.Error("Cache close error", zap.Error(err))
@@ -0,0 +1,231 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"sauron/client"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// adminRoute wraps an admin endpoint with request IDs, admin auth and rate limiting
+// When auth is disabled, admin endpoints are as open as the status API
+func (h *Handler) adminRoute(handler http.HandlerFunc) http.Handler {
+	var next http.Handler = handler
+
+	next = h.requestIDMiddleware(next)
+
+	if h.configLoader.Get().Auth {
+		next = h.authMiddleware(h.adminMiddleware(next))
+	}
+
+	if h.rateLimiter != nil {
+		next = h.rateLimitMiddleware(next)
+	}
+
+	return next
+}
+
+// adminWriteRoute wraps an admin endpoint that changes state like adminRoute
+// Writes always need an admin token, even when auth is disabled; only reads are as open as the status API then
+func (h *Handler) adminWriteRoute(handler http.HandlerFunc) http.Handler {
+	if h.configLoader.Get().Auth {
+		return h.adminRoute(handler)
+	}
+
+	open := h.requestIDMiddleware(handler)
+	guarded := h.authMiddleware(h.adminMiddleware(open))
+
+	var next http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			open.ServeHTTP(w, r)
+			return
+		}
+		guarded.ServeHTTP(w, r)
+	})
+
+	if h.rateLimiter != nil {
+		next = h.rateLimitMiddleware(next)
+	}
+
+	return next
+}
+
+// adminOnlyRoute wraps an admin endpoint that needs an admin token even when auth is disabled
+// For reads that are costly to serve or that reveal credentials
+func (h *Handler) adminOnlyRoute(handler http.HandlerFunc) http.Handler {
+	var next http.Handler = h.authMiddleware(h.adminMiddleware(h.requestIDMiddleware(handler)))
+
+	if h.rateLimiter != nil {
+		next = h.rateLimitMiddleware(next)
+	}
+
+	return next
+}
+
+// adminMiddleware only lets users with admin permission through
+// Must run after authMiddleware
+func (h *Handler) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userName, _ := r.Context().Value(contextKeyUser).(string)
+
+		cfg := h.configLoader.Get()
+		for _, user := range cfg.Users {
+			if user.Name == userName && user.Admin {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		h.logger.Warn("Admin access denied",
+			zap.String("user", userName),
+			zap.String("path", r.URL.Path),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+		metrics.AuthFailures.WithLabelValues("not_admin").Inc()
+		http.Error(w, "Admin permission required", http.StatusForbidden)
+	})
+}
+
+// Admin response types are shared with the Go client
+type (
+	RingsResponse = client.RingsResponse
+	ExternalView  = client.ExternalView
+	RingView      = client.RingView
+	EndpointView  = client.EndpointView
+)
+
+// handleRings returns the configured externals, their rings and advertised endpoints
+// GET /admin/rings
+func (h *Handler) handleRings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := h.configLoader.Get()
+	resp := RingsResponse{Externals: make([]ExternalView, 0, len(cfg.Externals))}
+
+	// Index ring checks and endpoints by external and ring
+	ringChecks := make(map[string]map[string]RingView) // external -> ring:network -> view
+	if h.endpointStore != nil {
+		for _, rs := range h.endpointStore.GetRingStatuses() {
+			if ringChecks[rs.ExternalName] == nil {
+				ringChecks[rs.ExternalName] = make(map[string]RingView)
+			}
+			view := RingView{
+				URL:         rs.RingURL,
+				Network:     rs.Network,
+				Checked:     true,
+				Healthy:     rs.Healthy,
+				Height:      rs.Height,
+				Protocol:    rs.ProtocolVersion,
+				LatencyMs:   rs.Latency.Milliseconds(),
+				LastError:   rs.LastError,
+				SuccessRate: rs.SuccessRate,
+				AvgLatency:  rs.AvgLatency.Milliseconds(),
+				Endpoints:   []EndpointView{},
+			}
+			view.LastChecked = timePtr(rs.LastChecked)
+			view.LastSuccess = timePtr(rs.LastSuccess)
+			ringChecks[rs.ExternalName][rs.RingURL+" "+rs.Network] = view
+		}
+
+		for _, ep := range h.endpointStore.GetAll() {
+			key := ep.RingURL + " " + ep.Network
+			view, ok := ringChecks[ep.ExternalName][key]
+			if !ok {
+				continue
+			}
+			view.Endpoints = append(view.Endpoints, EndpointView{
+				Type:               ep.Type,
+				URL:                ep.URL,
+				WebSocketURL:       ep.WebSocketURL,
+				Validated:          ep.IsValidated,
+				Working:            ep.IsWorking,
+				WebSocketAvailable: ep.WebSocketAvailable,
+				Height:             ep.Height,
+				LatencyMs:          ep.Latency.Milliseconds(),
+				ErrorCount:         ep.ErrorCount,
+				LastValidated:      timePtr(ep.LastValidated),
+				QuarantinedUntil:   timePtr(ep.QuarantinedUntil),
+			})
+			ringChecks[ep.ExternalName][key] = view
+		}
+	}
+
+	for _, external := range cfg.Externals {
+		ev := ExternalView{Name: external.Name, Rings: []RingView{}}
+		for _, ringURL := range external.Rings {
+			found := false
+			for _, view := range ringChecks[external.Name] {
+				if view.URL != ringURL {
+					continue
+				}
+				sort.Slice(view.Endpoints, func(i, j int) bool { return view.Endpoints[i].Type < view.Endpoints[j].Type })
+				ev.Rings = append(ev.Rings, view)
+				found = true
+			}
+			if !found {
+				// Configured but not queried yet
+				ev.Rings = append(ev.Rings, RingView{URL: ringURL, Endpoints: []EndpointView{}})
+			}
+		}
+		sort.SliceStable(ev.Rings, func(i, j int) bool {
+			if ev.Rings[i].URL != ev.Rings[j].URL {
+				return ev.Rings[i].URL < ev.Rings[j].URL
+			}
+			return ev.Rings[i].Network < ev.Rings[j].Network
+		})
+		resp.Externals = append(resp.Externals, ev)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode rings response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
+
+// ConfigStatusResponse reports the active configuration and the last reload attempt
+type ConfigStatusResponse = client.ConfigStatusResponse
+
+// handleConfigStatus reports when the configuration was last reloaded and whether it was accepted
+// GET /admin/config/status
+func (h *Handler) handleConfigStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rs := h.configLoader.ReloadStatus()
+	resp := ConfigStatusResponse{
+		LoadedAt:   rs.LoadedAt,
+		LastReload: timePtr(rs.LastReload),
+		Result:     rs.Result,
+		Error:      rs.Error,
+		Warnings:   rs.Warnings,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode config status response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
+
+// timePtr returns nil for the zero time so it is omitted from JSON
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
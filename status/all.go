@@ -0,0 +1,72 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sauron/client"
+	"sauron/selector"
+
+	"go.uber.org/zap"
+)
+
+// All-networks response types are shared with the Go client
+type (
+	AllStatusResponse = client.AllStatusResponse
+	NetworkStatus     = client.NetworkStatus
+)
+
+// allStatusCacheKey is the status cache slot of /status; network names can't be empty
+const allStatusCacheKey = ""
+
+// handleAllStatus returns the status of every configured network, so monitors and peers need one round trip
+// GET /status
+func (h *Handler) handleAllStatus(w http.ResponseWriter, r *http.Request) {
+	enabledTypes := h.getEnabledTypes(r)
+
+	key := statusCacheKey(allStatusCacheKey, enabledTypes)
+	if entry, ok := h.statusCache.get(key); ok {
+		writeCachedStatus(w, r, entry)
+		return
+	}
+
+	cfg := h.configLoader.Get()
+	threshold := cfg.StaleThreshold
+	if threshold == 0 {
+		threshold = selector.DefaultStaleThreshold
+	}
+
+	resp := AllStatusResponse{Networks: make(map[string]NetworkStatus, len(cfg.Networks))}
+	for _, network := range cfg.Networks {
+		status, ok := h.buildStatus(network.Name, enabledTypes)
+		if !ok {
+			// Known network, no height yet: listed so monitors see it rather than a gap
+			resp.Networks[network.Name] = NetworkStatus{
+				StatusResponse: StatusResponse{
+					ProtocolVersion: client.ProtocolVersion,
+					NetworkHead:     h.selector.NetworkHead(network.Name),
+				},
+			}
+			continue
+		}
+
+		entry := NetworkStatus{StatusResponse: *status, Available: true}
+		if status.NetworkHead > 0 {
+			entry.BlocksBehind = max(status.NetworkHead-status.Height, 0)
+			entry.Degraded = entry.BlocksBehind > threshold
+		}
+		resp.Networks[network.Name] = entry
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		h.logger.Error("Failed to encode status response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+		http.Error(w, "Failed to encode response. Please try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	writeCachedStatus(w, r, h.statusCache.put(key, append(body, '\n')))
+}
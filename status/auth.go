@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
+	"sauron/abuse"
 	"sauron/metrics"
 
 	"go.uber.org/zap"
@@ -30,6 +32,7 @@ func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 				zap.String("remote_addr", r.RemoteAddr),
 			)
 			metrics.AuthFailures.WithLabelValues("missing_token").Inc()
+			h.abuse.Record(r, abuse.EventAuthFailure)
 			http.Error(w, "Authorization required", http.StatusUnauthorized)
 			return
 		}
@@ -40,6 +43,7 @@ func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 				zap.String("remote_addr", r.RemoteAddr),
 			)
 			metrics.AuthFailures.WithLabelValues("invalid_format").Inc()
+			h.abuse.Record(r, abuse.EventAuthFailure)
 			http.Error(w, "Invalid Authorization format. Expected: Bearer <token>", http.StatusUnauthorized)
 			return
 		}
@@ -54,9 +58,20 @@ func (h *Handler) authMiddleware(next http.Handler) http.Handler {
 				zap.String("remote_addr", r.RemoteAddr),
 			)
 			metrics.AuthFailures.WithLabelValues("invalid_token").Inc()
+			h.abuse.Record(r, abuse.EventAuthFailure)
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
+		if !user.Active(time.Now()) {
+			h.logger.Warn("Expired or not yet valid token",
+				zap.String("user", user.Name),
+				zap.String("remote_addr", r.RemoteAddr),
+			)
+			metrics.AuthFailures.WithLabelValues("expired_token").Inc()
+			h.abuse.Record(r, abuse.EventAuthFailure)
+			http.Error(w, "Token expired or not yet valid", http.StatusUnauthorized)
+			return
+		}
 
 		// Get user's enabled types
 		enabledTypes := cfg.GetUserPermissions(token)
@@ -0,0 +1,106 @@
+package status
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"sauron/abuse"
+	"sauron/client"
+
+	"go.uber.org/zap"
+)
+
+// maxBanRequestBody caps the JSON body of POST /admin/bans
+const maxBanRequestBody = 4 << 10
+
+// Ban response types are shared with the Go client
+type (
+	BansResponse = client.BansResponse
+	BanView      = client.BanView
+	BanRequest   = client.BanRequest
+)
+
+// handleBans lists, adds and lifts bans of the abuse detector
+// GET /admin/bans
+// POST /admin/bans {"ip": "203.0.113.7", "duration": "1h", "reason": "scraper"}
+// DELETE /admin/bans?ip=203.0.113.7
+func (h *Handler) handleBans(w http.ResponseWriter, r *http.Request) {
+	if h.abuse == nil {
+		http.Error(w, "Abuse detection disabled", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resp := BansResponse{Bans: []BanView{}}
+		for _, ban := range h.abuse.Bans() {
+			resp.Bans = append(resp.Bans, banView(ban))
+		}
+		h.writeBansJSON(w, r, http.StatusOK, resp)
+
+	case http.MethodPost:
+		var req BanRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBanRequestBody)).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if net.ParseIP(req.IP) == nil {
+			http.Error(w, "Invalid ip", http.StatusBadRequest)
+			return
+		}
+		var duration time.Duration
+		if req.Duration != "" {
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				http.Error(w, "Invalid duration (expected a positive duration, e.g. 1h)", http.StatusBadRequest)
+				return
+			}
+			duration = d
+		}
+		reason := req.Reason
+		if reason == "" {
+			reason = abuse.ReasonManual
+		}
+
+		ban := h.abuse.Ban(req.IP, reason, duration)
+		h.logger.Info("Client IP banned through the admin API",
+			zap.String("ip", ban.IP),
+			zap.String("request_id", getRequestID(r)),
+		)
+		h.writeBansJSON(w, r, http.StatusCreated, banView(ban))
+
+	case http.MethodDelete:
+		ip := r.URL.Query().Get("ip")
+		if ip == "" {
+			http.Error(w, "Missing ip", http.StatusBadRequest)
+			return
+		}
+		if !h.abuse.Unban(ip) {
+			http.Error(w, "IP not banned", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// banView converts a ban to its API form
+func banView(ban abuse.Ban) BanView {
+	return BanView{IP: ban.IP, Reason: ban.Reason, Since: ban.Since, Until: ban.Until}
+}
+
+// writeBansJSON writes a bans response with a status code
+func (h *Handler) writeBansJSON(w http.ResponseWriter, r *http.Request, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode bans response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
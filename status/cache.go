@@ -0,0 +1,96 @@
+package status
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusCacheTTL is how long an encoded status response is reused
+// Rings poll every 10s, so a short TTL absorbs bursts without serving old heights
+const StatusCacheTTL = 2 * time.Second
+
+// cachedStatus is an encoded status response and its entity tag
+type cachedStatus struct {
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// statusCache keeps encoded status responses per network and permission set
+// Only networks with height data and the all-networks /status are stored, so it stays as small as the config
+type statusCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cachedStatus
+}
+
+// newStatusCache creates an empty status cache
+func newStatusCache(ttl time.Duration) *statusCache {
+	return &statusCache{
+		ttl:     ttl,
+		entries: make(map[string]*cachedStatus),
+	}
+}
+
+// statusCacheKey identifies a response by network and the endpoint types it advertises
+func statusCacheKey(network string, enabledTypes []string) string {
+	return network + "|" + strings.Join(enabledTypes, ",")
+}
+
+// get returns a still fresh response
+func (c *statusCache) get(key string) (*cachedStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// put stores an encoded response and returns it with its entity tag
+func (c *statusCache) put(key string, body []byte) *cachedStatus {
+	sum := sha256.Sum256(body)
+	entry := &cachedStatus{
+		body:    body,
+		etag:    `"` + hex.EncodeToString(sum[:8]) + `"`,
+		expires: time.Now().Add(c.ttl),
+	}
+
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	return entry
+}
+
+// etagMatches reports whether an If-None-Match header covers the entity tag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCachedStatus answers with the response, or 304 when the client already has it
+func writeCachedStatus(w http.ResponseWriter, r *http.Request, entry *cachedStatus) {
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.body)))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, entry.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	_, _ = w.Write(entry.body)
+}
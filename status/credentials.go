@@ -0,0 +1,89 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"sauron/client"
+
+	"go.uber.org/zap"
+)
+
+// DefaultCredentialsWithin is how far ahead GET /admin/credentials looks without ?within
+const DefaultCredentialsWithin = 7 * 24 * time.Hour
+
+// Credential response types are shared with the Go client
+type (
+	CredentialsResponse = client.CredentialsResponse
+	CredentialView      = client.CredentialView
+)
+
+// handleCredentials lists user and external tokens that are expired, not valid yet, or expire within the window
+// Credentials without expires_at or not_before never show up
+// GET /admin/credentials?within=168h
+func (h *Handler) handleCredentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	within := DefaultCredentialsWithin
+	if raw := r.URL.Query().Get("within"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			http.Error(w, "Invalid within (expected a duration, e.g. 168h)", http.StatusBadRequest)
+			return
+		}
+		within = d
+	}
+
+	cfg := h.configLoader.Get()
+	now := time.Now()
+	resp := CredentialsResponse{Within: within.String(), Credentials: []CredentialView{}}
+
+	add := func(kind, name string, notBefore, expiresAt time.Time) {
+		var status string
+		switch {
+		case !expiresAt.IsZero() && !now.Before(expiresAt):
+			status = "expired"
+		case !notBefore.IsZero() && now.Before(notBefore):
+			status = "not_yet_valid"
+		case !expiresAt.IsZero() && expiresAt.Sub(now) <= within:
+			status = "expiring"
+		default:
+			return
+		}
+		resp.Credentials = append(resp.Credentials, CredentialView{
+			Kind:      kind,
+			Name:      name,
+			Status:    status,
+			NotBefore: timePtr(notBefore),
+			ExpiresAt: timePtr(expiresAt),
+		})
+	}
+	for _, user := range cfg.Users {
+		add("user", user.Name, user.NotBefore, user.ExpiresAt)
+	}
+	for _, external := range cfg.Externals {
+		add("external", external.Name, external.NotBefore, external.ExpiresAt)
+	}
+
+	// Soonest expiry first; credentials that only have not_before go last
+	sort.SliceStable(resp.Credentials, func(i, j int) bool {
+		a, b := resp.Credentials[i].ExpiresAt, resp.Credentials[j].ExpiresAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode credentials response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,94 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"sauron/client"
+
+	"go.uber.org/zap"
+)
+
+// Decision limits of GET /admin/decisions
+const (
+	// DefaultDecisionsLimit is how many decisions are returned without ?limit
+	DefaultDecisionsLimit = 100
+	// MaxDecisionsLimit caps ?limit
+	MaxDecisionsLimit = 10000
+)
+
+// Decision audit response types are shared with the Go client
+type (
+	DecisionsResponse = client.DecisionsResponse
+	DecisionView      = client.DecisionView
+)
+
+// handleDecisions returns recent selection decisions, newest first, to explain where requests went
+// GET /admin/decisions?network=pocket&type=api&limit=100
+func (h *Handler) handleDecisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := h.configLoader.Get()
+	query := r.URL.Query()
+
+	network := query.Get("network")
+	if network != "" {
+		canonical, ok := cfg.ResolveNetwork(network)
+		if !ok {
+			http.Error(w, "Unknown network", http.StatusNotFound)
+			return
+		}
+		network = canonical
+	}
+
+	endpointType := query.Get("type")
+	switch endpointType {
+	case "", "api", "rpc", "grpc":
+	default:
+		http.Error(w, "Invalid type (expected api, rpc or grpc)", http.StatusBadRequest)
+		return
+	}
+
+	limit := DefaultDecisionsLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, MaxDecisionsLimit)
+	}
+
+	records := h.selector.Decisions(network, endpointType)
+	resp := DecisionsResponse{Decisions: make([]DecisionView, 0, min(len(records), limit))}
+	for _, rec := range records[:min(len(records), limit)] {
+		resp.Decisions = append(resp.Decisions, DecisionView{
+			Time:              rec.Time,
+			Network:           rec.Network,
+			Type:              rec.Type,
+			Node:              rec.SelectedNode,
+			Reason:            rec.Reason,
+			Candidates:        rec.Candidates,
+			MaxHeight:         rec.MaxHeight,
+			LatencyMs:         rec.SelectedLatency.Milliseconds(),
+			MaxInternalHeight: rec.MaxInternalHeight,
+			MaxExternalHeight: rec.MaxExternalHeight,
+			ExternalFailover:  rec.ExternalFailover,
+			NetworkHead:       rec.NetworkHead,
+			BlocksBehind:      rec.BlocksBehind,
+			Stale:             rec.Stale,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode decisions response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
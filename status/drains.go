@@ -0,0 +1,95 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sauron/client"
+	"sauron/selector"
+
+	"go.uber.org/zap"
+)
+
+// Drain response types are shared with the Go client
+type (
+	DrainsResponse = client.DrainsResponse
+	DrainView      = client.DrainView
+)
+
+// handleDrains lists the internal nodes drained for maintenance
+// GET /admin/drains
+func (h *Handler) handleDrains(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.writeDrainsJSON(w, r, http.StatusOK, drainsResponse(h.selector.Drains()))
+}
+
+// handleNodeDrain drains an internal node for maintenance, or returns it to selection
+// POST /admin/nodes/{name}/drain?network=pocket
+// DELETE /admin/nodes/{name}/drain?network=pocket
+// Without network, the node is drained in every network it serves
+func (h *Handler) handleNodeDrain(w http.ResponseWriter, r *http.Request) {
+	node := r.PathValue("name")
+	network := r.URL.Query().Get("network")
+	if network != "" {
+		canonical, ok := h.configLoader.Get().ResolveNetwork(network)
+		if !ok {
+			http.Error(w, "Unknown network", http.StatusBadRequest)
+			return
+		}
+		network = canonical
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		drains, err := h.selector.Drain(network, node)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Info("Node drained through the admin API",
+			zap.String("node", node),
+			zap.String("network", network),
+			zap.String("request_id", getRequestID(r)),
+		)
+		h.writeDrainsJSON(w, r, http.StatusOK, drainsResponse(drains))
+
+	case http.MethodDelete:
+		if len(h.selector.Undrain(network, node)) == 0 {
+			http.Error(w, "Not drained", http.StatusNotFound)
+			return
+		}
+		h.logger.Info("Node undrained through the admin API",
+			zap.String("node", node),
+			zap.String("network", network),
+			zap.String("request_id", getRequestID(r)),
+		)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// drainsResponse converts drains to their API form
+func drainsResponse(drains []selector.NodeDrain) DrainsResponse {
+	resp := DrainsResponse{Drains: []DrainView{}}
+	for _, drain := range drains {
+		resp.Drains = append(resp.Drains, DrainView{Network: drain.Network, Node: drain.Node, Since: drain.Since})
+	}
+	return resp
+}
+
+// writeDrainsJSON writes a drains response with a status code
+func (h *Handler) writeDrainsJSON(w http.ResponseWriter, r *http.Request, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode drains response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
@@ -7,38 +7,40 @@ import (
 	"net/http"
 	"strings"
 
+	"sauron/abuse"
+	"sauron/client"
+	"sauron/clientip"
 	"sauron/config"
+	"sauron/ratelimit"
 	"sauron/selector"
+	"sauron/storage"
 
 	"github.com/google/uuid"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
 
 // Handler provides the status API endpoints
 // The Palantír - how others peer into this tower
 type Handler struct {
-	selector     *selector.Selector
-	configLoader *config.Loader
-	logger       *zap.Logger
-	rateLimiter  *RateLimiter
+	selector      selector.NodeSelector
+	endpointStore *storage.ExternalEndpointStore
+	configLoader  *config.Loader
+	logger        *zap.Logger
+	rateLimiter   *ratelimit.Limiter
+	statusCache   *statusCache
+	abuse         *abuse.Detector  // Bans abusive clients, if abuse_detection is enabled
+	scheduler     SchedulerControl // Pauses health checks, if the server's checker supports it
 }
 
 // StatusResponse represents the response format
 // Returns the maximum height and advertised endpoints for connecting to this Sauron
-type StatusResponse struct {
-	Height       int64  `json:"height"`                  // Maximum height across all endpoint types
-	API          string `json:"api,omitempty"`           // Advertised API endpoint URL
-	RPC          string `json:"rpc,omitempty"`           // Advertised RPC endpoint URL
-	GRPC         string `json:"grpc,omitempty"`          // Advertised gRPC endpoint URL
-	GRPCInsecure bool   `json:"grpc_insecure,omitempty"` // Whether advertised gRPC endpoint uses insecure (no TLS)
-}
+type StatusResponse = client.StatusResponse
 
 // NewHandler creates a new status handler
-func NewHandler(selector *selector.Selector, configLoader *config.Loader, logger *zap.Logger) *Handler {
+func NewHandler(selector selector.NodeSelector, endpointStore *storage.ExternalEndpointStore, configLoader *config.Loader, logger *zap.Logger) *Handler {
 	cfg := configLoader.Get()
 
-	var rateLimiter *RateLimiter
+	var rateLimiter *ratelimit.Limiter
 	if cfg.RateLimit.Enabled {
 		// Set defaults if not configured
 		reqPerSec := cfg.RateLimit.RequestsPerSecond
@@ -50,52 +52,106 @@ func NewHandler(selector *selector.Selector, configLoader *config.Loader, logger
 			burst = reqPerSec * 2 // default: 2x burst
 		}
 
-		rateLimiter = NewRateLimiter(reqPerSec, burst, cfg.RateLimit.TrustProxy)
+		// trust_proxy honors forwarding headers; trusted_proxies narrows it down to known peers
+		var trustedProxies []string
+		if cfg.RateLimit.TrustProxy {
+			trustedProxies = cfg.TrustedProxies
+		}
+		resolver, err := clientip.NewResolver(cfg.RateLimit.TrustProxy && len(trustedProxies) == 0, trustedProxies)
+		if err != nil {
+			// Validation rejects bad entries, so only reachable for hand-built configs
+			logger.Error("Invalid trusted_proxies, ignoring forwarding headers", zap.Error(err))
+			resolver, _ = clientip.NewResolver(false, nil)
+		}
+		if cfg.RateLimit.TrustProxy && len(trustedProxies) == 0 {
+			logger.Warn("trust_proxy honors forwarding headers from any peer, set trusted_proxies to restrict it")
+		}
+
+		rateLimiter = ratelimit.New(reqPerSec, burst, cfg.RateLimit.MaxEntries, resolver)
 		logger.Info("Rate limiting enabled",
 			zap.Int("requests_per_second", reqPerSec),
 			zap.Int("burst", burst),
+			zap.Int("max_entries", rateLimiter.MaxEntries()),
 			zap.Bool("trust_proxy", cfg.RateLimit.TrustProxy),
+			zap.Strings("trusted_proxies", trustedProxies),
 		)
 	}
 
 	return &Handler{
-		selector:     selector,
-		configLoader: configLoader,
-		logger:       logger,
-		rateLimiter:  rateLimiter,
+		selector:      selector,
+		endpointStore: endpointStore,
+		configLoader:  configLoader,
+		logger:        logger,
+		rateLimiter:   rateLimiter,
+		statusCache:   newStatusCache(StatusCacheTTL),
 	}
 }
 
+// SetAbuseDetector counts auth failures and rate limit refusals towards bans and serves /admin/bans
+// Must be called before SetupRoutes
+func (h *Handler) SetAbuseDetector(detector *abuse.Detector) {
+	h.abuse = detector
+}
+
 // SetupRoutes configures all status API routes
 func (h *Handler) SetupRoutes(mux *http.ServeMux) {
 	cfg := h.configLoader.Get()
 
-	// Prometheus metrics endpoint (no auth required)
-	mux.Handle("/metrics", promhttp.Handler())
+	// Prometheus metrics endpoint (optional credentials), unless it has its own listener or is turned off
+	if cfg.Metrics.Listen == "" && !cfg.Metrics.DisableScrape {
+		mux.Handle("/metrics", h.MetricsHandler())
+	}
 
 	// Health check (no auth required)
-	mux.HandleFunc("/health", h.handleHealth)
+	mux.Handle("/health", gzipMiddleware(getOrHead(h.handleHealth)))
+	mux.Handle("/health/deep", gzipMiddleware(getOrHead(h.handleDeepHealth)))
 
 	// Readiness check (no auth required)
-	mux.HandleFunc("/ready", h.handleReady)
+	mux.Handle("/ready", gzipMiddleware(getOrHead(h.handleReady)))
+
+	// API description (no auth required)
+	mux.Handle("/openapi.json", gzipMiddleware(getOrHead(h.handleOpenAPI)))
+
+	// Admin endpoints (admin users only when auth is enabled; writes and costly or sensitive reads always need an admin token)
+	mux.Handle("/admin/rings", h.adminRoute(h.handleRings))
+	mux.Handle("/admin/config/status", h.adminRoute(h.handleConfigStatus))
+	mux.Handle("/admin/decisions", h.adminRoute(h.handleDecisions))
+	mux.Handle("/admin/self-check", h.adminOnlyRoute(h.handleSelfCheck))
+	mux.Handle("/admin/credentials", h.adminOnlyRoute(h.handleCredentials))
+	mux.Handle("/admin/bans", h.adminWriteRoute(h.handleBans))
+	mux.Handle("/admin/pins", h.adminWriteRoute(h.handlePins))
+	mux.Handle("/admin/drains", h.adminRoute(h.handleDrains))
+	mux.Handle("/admin/nodes/{name}/drain", h.adminWriteRoute(h.handleNodeDrain))
+	mux.Handle("/admin/scaling", h.adminRoute(h.handleScaling))
+	if h.scheduler != nil {
+		mux.Handle("/admin/scheduler", h.adminRoute(h.handleScheduler))
+		mux.Handle("/admin/scheduler/pause", h.adminWriteRoute(h.handleSchedulerPause))
+		mux.Handle("/admin/scheduler/resume", h.adminWriteRoute(h.handleSchedulerResume))
+	}
 
-	// Status endpoint (with optional request ID, auth, and rate limiting)
-	var statusHandler http.Handler = http.HandlerFunc(h.handleStatus)
+	// Status endpoints (with optional request ID, auth, rate limiting and compression)
+	mux.Handle("/status", h.statusRoute(h.handleAllStatus))
+	mux.Handle("/", h.statusRoute(h.handleStatus))
+}
+
+// statusRoute wraps a status endpoint with request IDs, auth, rate limiting and compression
+func (h *Handler) statusRoute(handler http.HandlerFunc) http.Handler {
+	var next http.Handler = gzipMiddleware(getOrHead(handler))
 
 	// Apply request ID middleware (outermost - all requests get an ID)
-	statusHandler = h.requestIDMiddleware(statusHandler)
+	next = h.requestIDMiddleware(next)
 
 	// Apply auth middleware if enabled
-	if cfg.Auth {
-		statusHandler = h.authMiddleware(statusHandler)
+	if h.configLoader.Get().Auth {
+		next = h.authMiddleware(next)
 	}
 
 	// Apply rate limiting middleware if enabled
 	if h.rateLimiter != nil {
-		statusHandler = h.rateLimitMiddleware(statusHandler)
+		next = h.rateLimitMiddleware(next)
 	}
 
-	mux.Handle("/", statusHandler)
+	return next
 }
 
 // requestIDMiddleware generates and attaches a unique request ID to each request
@@ -123,6 +179,7 @@ func (h *Handler) requestIDMiddleware(next http.Handler) http.Handler {
 func (h *Handler) rateLimitMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !h.rateLimiter.Allow(r) {
+			h.abuse.Record(r, abuse.EventRateLimited)
 			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 			h.logger.Warn("Rate limit exceeded",
 				zap.String("path", r.URL.Path),
@@ -159,15 +216,24 @@ func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Aliases answer with the canonical network's status
 	network := parts[0]
+	if canonical, ok := h.configLoader.Get().ResolveNetwork(network); ok {
+		network = canonical
+	}
 
 	// Get user permissions from context (set by auth middleware)
 	enabledTypes := h.getEnabledTypes(r)
 
-	// Get highest heights for each endpoint type
-	heights := h.selector.GetHighestHeights(network, enabledTypes)
+	// Peers poll every few seconds, reuse the encoded response while it is fresh
+	key := statusCacheKey(network, enabledTypes)
+	if entry, ok := h.statusCache.get(key); ok {
+		writeCachedStatus(w, r, entry)
+		return
+	}
 
-	if len(heights) == 0 {
+	resp, ok := h.buildStatus(network, enabledTypes)
+	if !ok {
 		msg := fmt.Sprintf("No height data available for network: %s", network)
 		http.Error(w, msg, http.StatusNotFound)
 		h.logger.Warn("No heights available",
@@ -177,6 +243,37 @@ func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body, err := json.Marshal(resp)
+	if err != nil {
+		h.logger.Error("Failed to encode status response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+		http.Error(w, "Failed to encode response. Please try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	writeCachedStatus(w, r, h.statusCache.put(key, append(body, '\n')))
+
+	h.logger.Debug("Status request served",
+		zap.String("request_id", getRequestID(r)),
+		zap.String("network", network),
+		zap.Int64("height", resp.Height),
+		zap.String("api", resp.API),
+		zap.String("rpc", resp.RPC),
+		zap.String("grpc", resp.GRPC),
+	)
+}
+
+// buildStatus computes the status response for a network and the endpoint types the caller may see
+// Returns false when the network has no height data
+func (h *Handler) buildStatus(network string, enabledTypes []string) (*StatusResponse, bool) {
+	// Get highest heights for each endpoint type
+	heights := h.selector.GetHighestHeights(network, enabledTypes)
+	if len(heights) == 0 {
+		return nil, false
+	}
+
 	// Find maximum height across all endpoint types
 	maxHeight := int64(0)
 	for _, height := range heights {
@@ -187,8 +284,10 @@ func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
 
 	// Build response with maximum height and advertised endpoints
 	cfg := h.configLoader.Get()
-	resp := StatusResponse{
-		Height: maxHeight,
+	resp := &StatusResponse{
+		ProtocolVersion: client.ProtocolVersion,
+		Height:          maxHeight,
+		NetworkHead:     h.selector.NetworkHead(network),
 	}
 
 	// Find the network config to get advertised endpoints
@@ -207,6 +306,7 @@ func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
 			case "api":
 				if networkConfig.API != "" {
 					resp.API = networkConfig.API
+					resp.APIWS = networkConfig.APIWS
 				}
 			case "rpc":
 				if networkConfig.RPC != "" {
@@ -221,24 +321,7 @@ func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		h.logger.Error("Failed to encode status response",
-			zap.String("request_id", getRequestID(r)),
-			zap.Error(err),
-		)
-		http.Error(w, "Failed to encode response. Please try again later.", http.StatusInternalServerError)
-		return
-	}
-
-	h.logger.Debug("Status request served",
-		zap.String("request_id", getRequestID(r)),
-		zap.String("network", network),
-		zap.Int64("height", resp.Height),
-		zap.String("api", resp.API),
-		zap.String("rpc", resp.RPC),
-		zap.String("grpc", resp.GRPC),
-	)
+	return resp, true
 }
 
 // handleHealth returns 200 if the service is running
@@ -252,17 +335,39 @@ func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
 	// Simple readiness check: are we tracking any heights?
 	cfg := h.configLoader.Get()
 	if len(cfg.Internals) == 0 {
-		http.Error(w, "Service not ready: no internal nodes configured", http.StatusServiceUnavailable)
-		h.logger.Warn("Readiness check failed: no internal nodes",
-			zap.String("request_id", getRequestID(r)),
-		)
-		return
+		if len(cfg.Externals) == 0 {
+			http.Error(w, "Service not ready: no internal nodes or external rings configured", http.StatusServiceUnavailable)
+			h.logger.Warn("Readiness check failed: no internal nodes or external rings",
+				zap.String("request_id", getRequestID(r)),
+			)
+			return
+		}
+
+		// Externals-only relay mode: ready once any network can be routed to a validated external
+		if !h.hasRoutableNetwork(cfg) {
+			http.Error(w, "Service not ready: no validated external endpoints yet", http.StatusServiceUnavailable)
+			h.logger.Warn("Readiness check failed: no validated external endpoints",
+				zap.String("request_id", getRequestID(r)),
+			)
+			return
+		}
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("Ready"))
 }
 
+// hasRoutableNetwork reports whether any configured network has a known height
+func (h *Handler) hasRoutableNetwork(cfg *config.Config) bool {
+	enabledTypes := cfg.GetEnabledTypes()
+	for _, network := range cfg.Networks {
+		if len(h.selector.GetHighestHeights(network.Name, enabledTypes)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // getRequestID extracts the request ID from context
 func getRequestID(r *http.Request) string {
 	if id, ok := r.Context().Value(contextKeyRequestID).(string); ok {
@@ -0,0 +1,52 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sauron/client"
+	"sauron/metrics"
+
+	"go.uber.org/zap"
+)
+
+// DeepHealthResponse is shared with the Go client
+type DeepHealthResponse = client.DeepHealthResponse
+
+// handleDeepHealth reports goroutines, file descriptors and GC state against runtime_limits
+// Always 200: "degraded" flags a likely leak to watch, not a process to restart
+// Serves the runtime monitor's last sample, so requests never stop the world themselves
+func (h *Handler) handleDeepHealth(w http.ResponseWriter, r *http.Request) {
+	limits := h.configLoader.Get().RuntimeLimits
+	interval := limits.CheckInterval
+	if interval == 0 {
+		interval = metrics.DefaultRuntimeCheckInterval
+	}
+	// Two intervals leave room for a late monitor tick; without a monitor one request samples per period
+	stats := metrics.LastRuntimeStats(2 * interval)
+	exceeded := stats.Exceeded(limits)
+
+	resp := DeepHealthResponse{
+		Status:        "ok",
+		Goroutines:    stats.Goroutines,
+		OpenFDs:       stats.OpenFDs,
+		HeapAllocMB:   float64(stats.HeapAlloc) / (1 << 20),
+		NumGC:         stats.NumGC,
+		LastGCPauseMs: float64(stats.LastGCPause.Microseconds()) / 1000,
+		MaxGoroutines: limits.MaxGoroutines,
+		MaxOpenFDs:    limits.MaxOpenFDs,
+		Exceeded:      []string{},
+	}
+	if len(exceeded) > 0 {
+		resp.Status = "degraded"
+		resp.Exceeded = exceeded
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode deep health response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,48 @@
+package status
+
+import (
+	"net/http"
+	"strings"
+
+	"sauron/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// MetricsHandler serves Prometheus metrics, requiring the metrics credentials when configured
+// Credentials are read on every request so they follow config reloads
+func (h *Handler) MetricsHandler() http.Handler {
+	return h.metricsAuthMiddleware(promhttp.Handler())
+}
+
+// metricsAuthMiddleware checks the metrics bearer token or basic auth (separate from users)
+func (h *Handler) metricsAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := h.configLoader.Get()
+		if !cfg.Metrics.AuthEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var bearer string
+		if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			bearer = strings.TrimPrefix(authHeader, "Bearer ")
+		}
+		username, password, _ := r.BasicAuth()
+
+		if !cfg.Metrics.Authorized(bearer, username, password) {
+			h.logger.Warn("Unauthorized metrics request",
+				zap.String("remote_addr", r.RemoteAddr),
+			)
+			metrics.AuthFailures.WithLabelValues("metrics_unauthorized").Inc()
+			if cfg.Metrics.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="sauron metrics"`)
+			}
+			http.Error(w, "Authorization required", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,21 @@
+package status
+
+import (
+	_ "embed"
+	"net/http"
+	"strconv"
+)
+
+// openAPISpec describes the status, health, metrics and admin endpoints
+// Keep it in step with the response types when they change
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// handleOpenAPI serves the OpenAPI document
+// GET /openapi.json
+func (h *Handler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(openAPISpec)))
+	_, _ = w.Write(openAPISpec)
+}
@@ -0,0 +1,118 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"sauron/client"
+	"sauron/selector"
+
+	"go.uber.org/zap"
+)
+
+// maxPinRequestBody caps the JSON body of POST /admin/pins
+const maxPinRequestBody = 4 << 10
+
+// Pin response types are shared with the Go client
+type (
+	PinsResponse = client.PinsResponse
+	PinView      = client.PinView
+	PinRequest   = client.PinRequest
+)
+
+// handlePins lists, sets and lifts manual pins, for emergency debugging or forced failover
+// GET /admin/pins
+// POST /admin/pins {"network": "pocket", "type": "rpc", "node": "pocket-2", "duration": "15m"}
+// DELETE /admin/pins?network=pocket&type=rpc
+func (h *Handler) handlePins(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		resp := PinsResponse{Pins: []PinView{}}
+		for _, pin := range h.selector.Pins() {
+			resp.Pins = append(resp.Pins, pinView(pin))
+		}
+		h.writePinsJSON(w, r, http.StatusOK, resp)
+
+	case http.MethodPost:
+		var req PinRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPinRequestBody)).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		network, ok := h.pinTarget(w, req.Network, req.Type)
+		if !ok {
+			return
+		}
+		if req.Node == "" {
+			http.Error(w, "Missing node", http.StatusBadRequest)
+			return
+		}
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			http.Error(w, "Invalid duration (expected a positive duration, e.g. 15m)", http.StatusBadRequest)
+			return
+		}
+
+		pin, err := h.selector.Pin(network, req.Type, req.Node, duration)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Info("Node pinned through the admin API",
+			zap.String("network", pin.Network),
+			zap.String("type", pin.Type),
+			zap.String("node", pin.Node),
+			zap.String("request_id", getRequestID(r)),
+		)
+		h.writePinsJSON(w, r, http.StatusCreated, pinView(pin))
+
+	case http.MethodDelete:
+		query := r.URL.Query()
+		network, ok := h.pinTarget(w, query.Get("network"), query.Get("type"))
+		if !ok {
+			return
+		}
+		if !h.selector.Unpin(network, query.Get("type")) {
+			http.Error(w, "Not pinned", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// pinTarget resolves the network (or alias) and checks the endpoint type of a pin request
+// Writes a 400 and returns false when either is invalid
+func (h *Handler) pinTarget(w http.ResponseWriter, network, endpointType string) (string, bool) {
+	canonical, ok := h.configLoader.Get().ResolveNetwork(network)
+	if !ok {
+		http.Error(w, "Unknown network", http.StatusBadRequest)
+		return "", false
+	}
+	if !slices.Contains([]string{"api", "rpc", "grpc"}, endpointType) {
+		http.Error(w, "Invalid type (expected api, rpc or grpc)", http.StatusBadRequest)
+		return "", false
+	}
+	return canonical, true
+}
+
+// pinView converts a manual pin to its API form
+func pinView(pin selector.ManualPin) PinView {
+	return PinView{Network: pin.Network, Type: pin.Type, Node: pin.Node, Since: pin.Since, Until: pin.Until}
+}
+
+// writePinsJSON writes a pins response with a status code
+func (h *Handler) writePinsJSON(w http.ResponseWriter, r *http.Request, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("Failed to encode pins response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,53 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sauron/client"
+
+	"go.uber.org/zap"
+)
+
+// ScalingResponse is shared with the Go client
+type ScalingResponse = client.ScalingResponse
+
+// handleScaling returns a compact load signal, e.g. for KEDA's metrics-api scaler or custom HPA adapters
+// GET /admin/scaling?network=pocket (all networks without network)
+func (h *Handler) handleScaling(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	network := r.URL.Query().Get("network")
+	if network != "" {
+		canonical, ok := h.configLoader.Get().ResolveNetwork(network)
+		if !ok {
+			http.Error(w, "Unknown network", http.StatusNotFound)
+			return
+		}
+		network = canonical
+	}
+
+	signal := h.selector.Scaling(network)
+	resp := ScalingResponse{
+		Network:       network,
+		WindowSeconds: signal.Window.Seconds(),
+		RequestRate:   signal.RequestRate,
+		P95Ms:         signal.P95.Milliseconds(),
+		InFlight:      signal.InFlight,
+		Capacity:      signal.Capacity,
+		Saturation:    signal.Saturation,
+		HealthyNodes:  signal.HealthyNodes,
+		Nodes:         signal.Nodes,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode scaling response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
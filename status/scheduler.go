@@ -0,0 +1,120 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"sauron/checker"
+	"sauron/client"
+
+	"go.uber.org/zap"
+)
+
+// SchedulerControl pauses and resumes health checks (implemented by checker.Scheduler)
+type SchedulerControl interface {
+	Pause(types []string, d time.Duration) ([]checker.PauseState, error)
+	Resume(types []string) ([]checker.PauseState, error)
+	Paused() []checker.PauseState
+}
+
+// Scheduler response types are shared with the Go client
+type (
+	SchedulerResponse = client.SchedulerResponse
+	PausedCheckerView = client.PausedCheckerView
+)
+
+// SetScheduler serves the /admin/scheduler endpoints
+// Must be called before SetupRoutes
+func (h *Handler) SetScheduler(scheduler SchedulerControl) {
+	h.scheduler = scheduler
+}
+
+// handleScheduler lists paused health checker types
+// GET /admin/scheduler
+func (h *Handler) handleScheduler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.writeScheduler(w, r, h.scheduler.Paused())
+}
+
+// handleSchedulerPause freezes health checks during backend maintenance; known heights keep routing
+// POST /admin/scheduler/pause?type=rpc&type=grpc&for=30m (no type = all)
+func (h *Handler) handleSchedulerPause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var d time.Duration
+	if raw := r.URL.Query().Get("for"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid for (expected a positive duration, e.g. 30m)", http.StatusBadRequest)
+			return
+		}
+		d = parsed
+	}
+
+	paused, err := h.scheduler.Pause(schedulerTypes(r), d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logger.Info("Health checks paused through the admin API",
+		zap.String("request_id", getRequestID(r)),
+	)
+	h.writeScheduler(w, r, paused)
+}
+
+// handleSchedulerResume restarts paused health checks
+// POST /admin/scheduler/resume?type=rpc (no type = all)
+func (h *Handler) handleSchedulerResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	paused, err := h.scheduler.Resume(schedulerTypes(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeScheduler(w, r, paused)
+}
+
+// schedulerTypes reads repeated or comma-separated ?type values
+func schedulerTypes(r *http.Request) []string {
+	var types []string
+	for _, value := range r.URL.Query()["type"] {
+		for _, checkerType := range strings.Split(value, ",") {
+			if checkerType = strings.TrimSpace(checkerType); checkerType != "" {
+				types = append(types, checkerType)
+			}
+		}
+	}
+	return types
+}
+
+// writeScheduler writes the paused checker types
+func (h *Handler) writeScheduler(w http.ResponseWriter, r *http.Request, paused []checker.PauseState) {
+	resp := SchedulerResponse{Paused: []PausedCheckerView{}}
+	for _, state := range paused {
+		resp.Paused = append(resp.Paused, PausedCheckerView{
+			Type:  state.Type,
+			Since: state.Since,
+			Until: timePtr(state.Until),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode scheduler response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,84 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sauron/checker"
+	"sauron/client"
+
+	"go.uber.org/zap"
+)
+
+// SelfCheckTimeout bounds a whole self-check across all networks
+const SelfCheckTimeout = 30 * time.Second
+
+// SelfCheckResponse reports whether peer rings could use this tower
+type SelfCheckResponse = client.SelfCheckResponse
+
+// handleSelfCheck builds the status a peer would receive for every network and probes the
+// advertised endpoints from the outside, catching broken URLs before peers do
+// GET /admin/self-check
+func (h *Handler) handleSelfCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := h.configLoader.Get()
+	ctx, cancel := context.WithTimeout(r.Context(), SelfCheckTimeout)
+	defer cancel()
+
+	// A throwaway checker keeps probe connections out of the real external checks
+	probe := checker.NewExternalChecker(nil, nil, cfg.Transport.Checker, checker.NewIdentity(cfg), h.logger)
+	defer probe.Close()
+
+	// Peers with full permissions see every globally enabled endpoint type
+	enabledTypes := cfg.GetEnabledTypes()
+
+	resp := SelfCheckResponse{Usable: len(cfg.Networks) > 0, Networks: []client.NetworkSelfCheck{}}
+	for _, network := range cfg.Networks {
+		result := client.NetworkSelfCheck{Network: network.Name, Endpoints: []client.EndpointProbe{}}
+
+		status, ok := h.buildStatus(network.Name, enabledTypes)
+		switch {
+		case !ok:
+			result.Error = "no height data available"
+		case status.API == "" && status.RPC == "" && status.GRPC == "":
+			result.Error = "no endpoints advertised"
+		default:
+			if err := status.Validate(); err != nil {
+				result.Error = err.Error()
+				break
+			}
+			result.Endpoints = probe.ProbeAdvertised(ctx, status)
+			for _, p := range result.Endpoints {
+				// WebSockets are extras, a peer can route with any working HTTP or gRPC endpoint
+				if p.OK && (p.Type == "api" || p.Type == "rpc" || p.Type == "grpc") {
+					result.Usable = true
+				}
+			}
+		}
+
+		if !result.Usable {
+			resp.Usable = false
+		}
+		resp.Networks = append(resp.Networks, result)
+	}
+
+	h.logger.Info("Self-check completed",
+		zap.String("request_id", getRequestID(r)),
+		zap.Bool("usable", resp.Usable),
+		zap.Int("networks", len(resp.Networks)),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("Failed to encode self-check response",
+			zap.String("request_id", getRequestID(r)),
+			zap.Error(err),
+		)
+	}
+}
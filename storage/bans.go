@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// banKeyPrefix prefixes the Redis keys of banned IPs ("ban:{ip}")
+const banKeyPrefix = "ban:"
+
+// BanRecord is a banned client IP as stored in Redis
+type BanRecord struct {
+	IP     string    `json:"ip"`
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+}
+
+// SaveBan stores a ban until it expires, so other replicas and the next run refuse the IP too
+func (c *Cache) SaveBan(ctx context.Context, ban BanRecord) {
+	if c.client == nil {
+		return
+	}
+	ttl := time.Until(ban.Until)
+	if ttl <= 0 {
+		return
+	}
+
+	value, err := json.Marshal(ban)
+	if err != nil {
+		return
+	}
+	start := time.Now()
+	if err := c.client.Set(ctx, banKeyPrefix+ban.IP, value, ttl).Err(); err != nil {
+		observeCacheOperation("set", "error", start)
+		c.logger.Warn("Failed to store ban", zap.String("ip", ban.IP), zap.Error(err))
+		return
+	}
+	observeCacheOperation("set", "success", start)
+}
+
+// DeleteBan removes a stored ban
+func (c *Cache) DeleteBan(ctx context.Context, ip string) {
+	if c.client == nil {
+		return
+	}
+
+	start := time.Now()
+	if err := c.client.Del(ctx, banKeyPrefix+ip).Err(); err != nil {
+		observeCacheOperation("delete", "error", start)
+		c.logger.Warn("Failed to delete ban", zap.String("ip", ip), zap.Error(err))
+		return
+	}
+	observeCacheOperation("delete", "success", start)
+}
+
+// LoadBans returns every stored ban; ok is false when Redis is disabled or unreachable
+func (c *Cache) LoadBans(ctx context.Context) ([]BanRecord, bool) {
+	if c.client == nil {
+		return nil, false
+	}
+
+	start := time.Now()
+	var bans []BanRecord
+	iter := c.client.Scan(ctx, 0, banKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		value, err := c.client.Get(ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			continue // expired between SCAN and GET
+		}
+		if err != nil {
+			observeCacheOperation("get", "error", start)
+			c.logger.Warn("Failed to load ban", zap.String("key", iter.Val()), zap.Error(err))
+			return nil, false
+		}
+		var ban BanRecord
+		if err := json.Unmarshal(value, &ban); err != nil {
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	if err := iter.Err(); err != nil {
+		observeCacheOperation("get", "error", start)
+		c.logger.Warn("Failed to list bans", zap.Error(err))
+		return nil, false
+	}
+	observeCacheOperation("get", "hit", start)
+
+	return bans, true
+}
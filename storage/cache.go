@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"sauron/metrics"
+
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -65,9 +67,13 @@ func (c *Cache) SetHeight(ctx context.Context, network, node, endpointType strin
 	}
 
 	key := fmt.Sprintf("height:%s:%s:%s", network, node, endpointType)
+	start := time.Now()
 	if err := c.client.Set(ctx, key, height, ttl).Err(); err != nil {
+		observeCacheOperation("set", "error", start)
 		c.logger.Warn("Failed to set cache", zap.String("key", key), zap.Error(err))
+		return
 	}
+	observeCacheOperation("set", "success", start)
 }
 
 // GetHeight retrieves a cached height value
@@ -77,13 +83,18 @@ func (c *Cache) GetHeight(ctx context.Context, network, node, endpointType strin
 	}
 
 	key := fmt.Sprintf("height:%s:%s:%s", network, node, endpointType)
+	start := time.Now()
 	val, err := c.client.Get(ctx, key).Int64()
 	if err != nil {
 		if err != redis.Nil {
+			observeCacheOperation("get", "error", start)
 			c.logger.Warn("Failed to get cache", zap.String("key", key), zap.Error(err))
+		} else {
+			observeCacheOperation("get", "miss", start)
 		}
 		return 0, false
 	}
+	observeCacheOperation("get", "hit", start)
 
 	return val, true
 }
@@ -95,9 +106,64 @@ func (c *Cache) SetLatency(ctx context.Context, network, node, endpointType stri
 	}
 
 	key := fmt.Sprintf("latency:%s:%s:%s", network, node, endpointType)
+	start := time.Now()
 	if err := c.client.Set(ctx, key, latency.Milliseconds(), ttl).Err(); err != nil {
+		observeCacheOperation("set", "error", start)
 		c.logger.Warn("Failed to set latency cache", zap.String("key", key), zap.Error(err))
+		return
+	}
+	observeCacheOperation("set", "success", start)
+}
+
+// GetLatency retrieves a cached latency value
+func (c *Cache) GetLatency(ctx context.Context, network, node, endpointType string) (time.Duration, bool) {
+	if c.client == nil {
+		return 0, false
+	}
+
+	key := fmt.Sprintf("latency:%s:%s:%s", network, node, endpointType)
+	start := time.Now()
+	val, err := c.client.Get(ctx, key).Int64()
+	if err != nil {
+		if err != redis.Nil {
+			observeCacheOperation("get", "error", start)
+			c.logger.Warn("Failed to get latency cache", zap.String("key", key), zap.Error(err))
+		} else {
+			observeCacheOperation("get", "miss", start)
+		}
+		return 0, false
+	}
+	observeCacheOperation("get", "hit", start)
+
+	return time.Duration(val) * time.Millisecond, true
+}
+
+// ReadThrough loads a cached height and latency into the store when the store has no data
+// for the node yet, so a restarted replica can route before its first check completes
+// Returns true if an entry was loaded
+func (c *Cache) ReadThrough(ctx context.Context, store *HeightStore, network, node, endpointType string) bool {
+	if c.client == nil {
+		return false
 	}
+	if _, exists := store.Get(network, node, endpointType); exists {
+		return false
+	}
+
+	height, ok := c.GetHeight(ctx, network, node, endpointType)
+	if !ok {
+		return false
+	}
+	latency, _ := c.GetLatency(ctx, network, node, endpointType)
+
+	store.Update(network, node, endpointType, height, latency, "internal")
+	c.logger.Debug("Loaded height from cache",
+		zap.String("network", network),
+		zap.String("node", node),
+		zap.String("type", endpointType),
+		zap.Int64("height", height),
+	)
+
+	return true
 }
 
 // Close closes the Redis connection
@@ -112,3 +178,9 @@ func (c *Cache) Close() error {
 func (c *Cache) IsEnabled() bool {
 	return c.client != nil
 }
+
+// observeCacheOperation records the result and duration of a cache operation
+func observeCacheOperation(operation, result string, start time.Time) {
+	metrics.CacheOperations.WithLabelValues(operation, result).Inc()
+	metrics.CacheOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -23,7 +24,12 @@ type ExternalEndpoint struct {
 	ErrorCount         int       // Consecutive proxy errors (5xx only)
 	LastValidated      time.Time // Last successful validation
 	LastError          time.Time // Last error timestamp
-	WebSocketAvailable bool      // Whether WebSocket endpoint is working (RPC only)
+	WebSocketAvailable bool      // Whether WebSocket endpoint is working
+	WebSocketURL       string    // Advertised ws(s):// URL, if different from the HTTP URL (API only)
+
+	// Reputation (persisted across restarts when a reputation path is configured)
+	Quarantines      int       // Consecutive times the endpoint was taken out of rotation
+	QuarantinedUntil time.Time // Recovery is not trusted before this time (backoff)
 
 	// Metrics
 	Height  int64         // Latest height
@@ -33,16 +39,20 @@ type ExternalEndpoint struct {
 // ExternalEndpointStore manages external Sauron endpoints
 // Thread-safe storage for tracking advertised endpoints and their validation state
 type ExternalEndpointStore struct {
-	mu        sync.RWMutex
-	endpoints map[string]*ExternalEndpoint // key: "{externalName}:{ring}:{network}:{type}:{url}"
-	logger    *zap.Logger
+	mu         sync.RWMutex
+	endpoints  map[string]*ExternalEndpoint // key: "{externalName}:{ring}:{network}:{type}:{url}"
+	reputation map[string]reputationRecord  // persisted records for endpoints not yet re-advertised
+	rings      map[string]*RingStatus       // key: "{externalName}:{ring}:{network}"
+	logger     *zap.Logger
 }
 
 // NewExternalEndpointStore creates a new external endpoint store
 func NewExternalEndpointStore(logger *zap.Logger) *ExternalEndpointStore {
 	return &ExternalEndpointStore{
-		endpoints: make(map[string]*ExternalEndpoint),
-		logger:    logger,
+		endpoints:  make(map[string]*ExternalEndpoint),
+		reputation: make(map[string]reputationRecord),
+		rings:      make(map[string]*RingStatus),
+		logger:     logger,
 	}
 }
 
@@ -73,7 +83,7 @@ func (s *ExternalEndpointStore) StoreAdvertised(externalName, ringURL, network,
 	}
 
 	// Create new endpoint
-	s.endpoints[key] = &ExternalEndpoint{
+	ep := &ExternalEndpoint{
 		URL:          url,
 		Network:      network,
 		Type:         endpointType,
@@ -83,6 +93,21 @@ func (s *ExternalEndpointStore) StoreAdvertised(externalName, ringURL, network,
 		IsWorking:    false, // Not working until validated
 		ErrorCount:   0,
 	}
+	s.endpoints[key] = ep
+
+	// Restore reputation from a previous run so failing endpoints are not re-trusted immediately
+	if record, ok := s.reputation[key]; ok {
+		record.apply(ep)
+		delete(s.reputation, key)
+		s.logger.Info("Restored endpoint reputation",
+			zap.String("external", externalName),
+			zap.String("network", network),
+			zap.String("type", endpointType),
+			zap.String("url", url),
+			zap.Int("error_count", ep.ErrorCount),
+			zap.Time("quarantined_until", ep.QuarantinedUntil),
+		)
+	}
 
 	s.logger.Info("Stored new advertised endpoint",
 		zap.String("external", externalName),
@@ -110,14 +135,33 @@ func (s *ExternalEndpointStore) MarkValidated(externalName, ringURL, network, en
 		return
 	}
 
+	now := time.Now()
 	wasValidated := ep.IsValidated
 	ep.IsValidated = true
-	ep.IsWorking = true
-	ep.ErrorCount = 0
-	ep.LastValidated = time.Now()
+	ep.LastValidated = now
 	ep.Height = height
 	ep.Latency = latency
 
+	// Quarantined endpoints stay out of rotation until their backoff expires
+	if !ep.IsWorking && now.Before(ep.QuarantinedUntil) {
+		s.logger.Debug("Endpoint healthy but still quarantined",
+			zap.String("external", externalName),
+			zap.String("network", network),
+			zap.String("type", endpointType),
+			zap.String("url", url),
+			zap.Time("quarantined_until", ep.QuarantinedUntil),
+		)
+		return
+	}
+
+	ep.IsWorking = true
+	ep.ErrorCount = 0
+
+	// Forget past quarantines once the endpoint has stayed healthy past the longest backoff
+	if ep.Quarantines > 0 && now.Sub(ep.QuarantinedUntil) > QuarantineMaxBackoff {
+		ep.Quarantines = 0
+	}
+
 	if !wasValidated {
 		s.logger.Info("Endpoint validated successfully",
 			zap.String("external", externalName),
@@ -187,6 +231,7 @@ func (s *ExternalEndpointStore) IncrementErrorCount(externalName, ringURL, netwo
 
 	if ep.ErrorCount >= 3 && ep.IsWorking {
 		ep.IsWorking = false
+		quarantine(ep)
 		s.logger.Warn("Endpoint marked as not working due to errors",
 			zap.String("external", externalName),
 			zap.String("ring", ringURL),
@@ -216,6 +261,23 @@ func (s *ExternalEndpointStore) RemoveEndpoint(externalName, ringURL, network, e
 	}
 }
 
+// RemoveAdvertised removes every endpoint a ring advertised for a network, returning how many were removed
+// Used when the ring turns out to serve a different network than configured
+func (s *ExternalEndpointStore) RemoveAdvertised(externalName, ringURL, network string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for key, ep := range s.endpoints {
+		if ep.ExternalName == externalName && ep.RingURL == ringURL && ep.Network == network {
+			delete(s.endpoints, key)
+			removed++
+		}
+	}
+
+	return removed
+}
+
 // GetValidatedEndpoints returns all validated+working endpoints for a network/type
 func (s *ExternalEndpointStore) GetValidatedEndpoints(network, endpointType string) []*ExternalEndpoint {
 	s.mu.RLock()
@@ -266,6 +328,28 @@ func (s *ExternalEndpointStore) GetAllAdvertised(externalName, ringURL, network
 	return endpoints
 }
 
+// GetAll returns a copy of every stored endpoint (validated or not)
+func (s *ExternalEndpointStore) GetAll() []*ExternalEndpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	endpoints := make([]*ExternalEndpoint, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		epCopy := *ep
+		endpoints = append(endpoints, &epCopy)
+	}
+
+	return endpoints
+}
+
+// Len returns the number of stored endpoints
+func (s *ExternalEndpointStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.endpoints)
+}
+
 // TrackProxyError tracks a proxy error for an endpoint identified by URL
 // Returns true if the endpoint was found and error was tracked
 func (s *ExternalEndpointStore) TrackProxyError(network, endpointType, url string) bool {
@@ -280,6 +364,7 @@ func (s *ExternalEndpointStore) TrackProxyError(network, endpointType, url strin
 
 			if ep.ErrorCount >= 3 && ep.IsWorking {
 				ep.IsWorking = false
+				quarantine(ep)
 				s.logger.Warn("External endpoint marked as not working due to proxy errors",
 					zap.String("external", ep.ExternalName),
 					zap.String("ring", ep.RingURL),
@@ -309,7 +394,7 @@ func (s *ExternalEndpointStore) TrackProxyError(network, endpointType, url strin
 	return false
 }
 
-// UpdateWebSocketAvailability updates the WebSocket availability status for an RPC endpoint
+// UpdateWebSocketAvailability updates the WebSocket availability status for an endpoint
 func (s *ExternalEndpointStore) UpdateWebSocketAvailability(externalName, ringURL, network, endpointType, url string, available bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -331,6 +416,45 @@ func (s *ExternalEndpointStore) UpdateWebSocketAvailability(externalName, ringUR
 	)
 }
 
+// SetWebSocketURL records the advertised WebSocket URL for an endpoint
+func (s *ExternalEndpointStore) SetWebSocketURL(externalName, ringURL, network, endpointType, url, wsURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.makeKey(externalName, ringURL, network, endpointType, url)
+	if ep, exists := s.endpoints[key]; exists {
+		ep.WebSocketURL = wsURL
+	}
+}
+
+// GetWebSocketURL returns the advertised WebSocket URL for an endpoint identified by URL
+// Returns empty string if the endpoint is unknown or advertised no dedicated WebSocket URL
+func (s *ExternalEndpointStore) GetWebSocketURL(endpointType, url string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ep := range s.endpoints {
+		if ep.Type == endpointType && ep.URL == url && ep.WebSocketURL != "" {
+			return ep.WebSocketURL
+		}
+	}
+	return ""
+}
+
+// GetExternalName returns the external that advertised an endpoint identified by URL
+// Returns empty string if the endpoint is unknown
+func (s *ExternalEndpointStore) GetExternalName(network, endpointType, url string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ep := range s.endpoints {
+		if ep.Network == network && ep.Type == endpointType && ep.URL == url {
+			return ep.ExternalName
+		}
+	}
+	return ""
+}
+
 // UpdateAggregateMetrics updates aggregate endpoint count metrics
 // Should be called periodically (e.g., every 10 seconds) to avoid overhead
 func (s *ExternalEndpointStore) UpdateAggregateMetrics() {
@@ -370,3 +494,128 @@ func (s *ExternalEndpointStore) UpdateAggregateMetrics() {
 		metrics.ExternalEndpointsWorking.WithLabelValues(k.network, k.typ, k.external).Set(float64(count.working))
 	}
 }
+
+// RingStatus is the result of the most recent status query to an external ring
+type RingStatus struct {
+	ExternalName string
+	RingURL      string
+	Network      string
+	Healthy      bool          // Last query succeeded
+	Height       int64         // Height reported by the ring
+	Latency      time.Duration // Latency of the last query
+	LastChecked  time.Time
+	LastSuccess  time.Time
+	LastError    string // Error from the last failed query (empty if healthy)
+
+	ProtocolVersion int // Federation protocol version the ring reported (0 = before versioning)
+
+	// Scoring (exponentially weighted over recent queries)
+	SuccessRate float64       // 0.0-1.0
+	AvgLatency  time.Duration // Average latency of successful queries
+}
+
+// ringScoreWeight is the weight of the newest query in the ring success rate and latency averages
+const ringScoreWeight = 0.2
+
+// RecordRingCheck stores the outcome of a ring status query
+func (s *ExternalEndpointStore) RecordRingCheck(externalName, ringURL, network string, height int64, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := externalName + ":" + ringURL + ":" + network
+	rs, exists := s.rings[key]
+	if !exists {
+		rs = &RingStatus{
+			ExternalName: externalName,
+			RingURL:      ringURL,
+			Network:      network,
+		}
+		s.rings[key] = rs
+	}
+
+	success := 0.0
+	if err == nil {
+		success = 1.0
+	}
+	if rs.LastChecked.IsZero() {
+		rs.SuccessRate = success
+	} else {
+		rs.SuccessRate = ringScoreWeight*success + (1-ringScoreWeight)*rs.SuccessRate
+	}
+
+	rs.LastChecked = time.Now()
+	rs.Latency = latency
+	if err != nil {
+		rs.Healthy = false
+		rs.LastError = err.Error()
+		return
+	}
+	if rs.AvgLatency == 0 {
+		rs.AvgLatency = latency
+	} else {
+		rs.AvgLatency = time.Duration(ringScoreWeight*float64(latency) + (1-ringScoreWeight)*float64(rs.AvgLatency))
+	}
+	rs.Healthy = true
+	rs.Height = height
+	rs.LastSuccess = rs.LastChecked
+	rs.LastError = ""
+}
+
+// SetRingProtocolVersion records the protocol version a ring reported and returns the previous one
+func (s *ExternalEndpointStore) SetRingProtocolVersion(externalName, ringURL, network string, version int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := externalName + ":" + ringURL + ":" + network
+	rs, exists := s.rings[key]
+	if !exists {
+		rs = &RingStatus{
+			ExternalName: externalName,
+			RingURL:      ringURL,
+			Network:      network,
+		}
+		s.rings[key] = rs
+	}
+
+	previous := rs.ProtocolVersion
+	rs.ProtocolVersion = version
+	return previous
+}
+
+// GetRingStatuses returns a copy of the latest status of every queried ring
+func (s *ExternalEndpointStore) GetRingStatuses() []RingStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]RingStatus, 0, len(s.rings))
+	for _, rs := range s.rings {
+		statuses = append(statuses, *rs)
+	}
+
+	return statuses
+}
+
+// RankRings orders an external's rings for a network from healthiest to least healthy
+// Higher success rate wins, then lower average latency; rings never queried keep config order
+// and rank as healthy so they get a chance to build a score
+func (s *ExternalEndpointStore) RankRings(externalName, network string, rings []string) []RingStatus {
+	s.mu.RLock()
+	ranked := make([]RingStatus, len(rings))
+	for i, ringURL := range rings {
+		if rs, ok := s.rings[externalName+":"+ringURL+":"+network]; ok {
+			ranked[i] = *rs
+		} else {
+			ranked[i] = RingStatus{ExternalName: externalName, RingURL: ringURL, Network: network, SuccessRate: 1}
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].SuccessRate != ranked[j].SuccessRate {
+			return ranked[i].SuccessRate > ranked[j].SuccessRate
+		}
+		return ranked[i].AvgLatency < ranked[j].AvgLatency
+	})
+
+	return ranked
+}
@@ -0,0 +1,40 @@
+package storage
+
+import "slices"
+
+// EstimateHead returns a robust estimate of a network's head height
+// It is the median of every independent source: each internal node (its highest endpoint)
+// and each healthy external ring (advertised endpoints carry their ring's height)
+// A single source reporting a bogus huge height can't drag the estimate up
+// Returns 0 when no source has a height
+func EstimateHead(heights *HeightStore, endpoints *ExternalEndpointStore, network string) int64 {
+	perNode := make(map[string]int64)
+	for _, entry := range heights.GetAll() {
+		if entry.Network != network || entry.Metrics.Height <= 0 {
+			continue
+		}
+		if entry.Metrics.Height > perNode[entry.Node] {
+			perNode[entry.Node] = entry.Metrics.Height
+		}
+	}
+
+	samples := make([]int64, 0, len(perNode))
+	for _, height := range perNode {
+		samples = append(samples, height)
+	}
+	if endpoints != nil {
+		for _, rs := range endpoints.GetRingStatuses() {
+			if rs.Network == network && rs.Healthy && rs.Height > 0 {
+				samples = append(samples, rs.Height)
+			}
+		}
+	}
+
+	if len(samples) == 0 {
+		return 0
+	}
+
+	// Lower median for even counts: with two disagreeing sources, trust the more conservative one
+	slices.Sort(samples)
+	return samples[(len(samples)-1)/2]
+}
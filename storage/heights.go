@@ -2,16 +2,25 @@ package storage
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
 )
 
 const (
-	// LatencyHistorySize is the number of latency measurements to keep for averaging
+	// LatencyHistorySize is the number of latency measurements kept per node
 	LatencyHistorySize = 10
+	// DefaultLatencyHalfLife is how many measurements it takes for one to weigh half as much in AvgLatency
+	DefaultLatencyHalfLife = 2.0
+	// DefaultLatencyWindow is how far back the latency percentiles of a node look
+	DefaultLatencyWindow = 5 * time.Minute
+	// latencySampleLimit bounds the measurements kept per node for percentiles; the oldest go first
+	latencySampleLimit = 1024
 )
 
 // NodeMetrics stores height and latency information for a node
@@ -21,15 +30,27 @@ type NodeMetrics struct {
 	Timestamp          time.Time
 	Source             string // "internal" or "external"
 	LatencyHistory     []time.Duration
-	AvgLatency         time.Duration
+	AvgLatency         time.Duration // exponentially weighted moving average of the measurements
+	P50Latency         time.Duration // percentiles of the measurements within the latency window
+	P95Latency         time.Duration
+	P99Latency         time.Duration
 	WebSocketAvailable bool // Whether WebSocket endpoint is working
+	samples            []latencySample
 	mu                 sync.Mutex
 }
 
+// latencySample is a latency measurement and when it was taken
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
 // HeightStore manages all node metrics using xsync for thread-safe access
 // The archives of Barad-dûr
 type HeightStore struct {
-	data *xsync.Map[string, *NodeMetrics]
+	data     *xsync.Map[string, *NodeMetrics]
+	halfLife atomic.Uint64 // latency half-life in measurements, as float64 bits (0 = DefaultLatencyHalfLife)
+	window   atomic.Int64  // latency percentile window (0 = DefaultLatencyWindow)
 }
 
 // NewHeightStore creates a new height store
@@ -39,6 +60,39 @@ func NewHeightStore() *HeightStore {
 	}
 }
 
+// SetLatencyHalfLife sets how many measurements it takes for one to weigh half as much in AvgLatency
+// A shorter half-life follows latency spikes sooner; 0 restores DefaultLatencyHalfLife
+func (s *HeightStore) SetLatencyHalfLife(halfLife float64) {
+	s.halfLife.Store(math.Float64bits(halfLife))
+}
+
+// latencyWeight returns the weight of a new measurement in AvgLatency
+func (s *HeightStore) latencyWeight() float64 {
+	halfLife := math.Float64frombits(s.halfLife.Load())
+	if halfLife == 0 {
+		halfLife = DefaultLatencyHalfLife
+	}
+	return 1 - math.Exp2(-1/halfLife)
+}
+
+// SetLatencyWindow sets how far back the latency percentiles of a node look; 0 restores DefaultLatencyWindow
+func (s *HeightStore) SetLatencyWindow(window time.Duration) {
+	s.window.Store(int64(window))
+}
+
+// latencyWindow returns how far back the latency percentiles look
+func (s *HeightStore) latencyWindow() time.Duration {
+	if window := time.Duration(s.window.Load()); window > 0 {
+		return window
+	}
+	return DefaultLatencyWindow
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)*p+99)/100-1]
+}
+
 // makeKey creates a unique key for a node and endpoint type
 // Format: "network:node:type"
 func makeKey(network, node, endpointType string) string {
@@ -68,12 +122,32 @@ func (s *HeightStore) Update(network, node, endpointType string, height int64, l
 		metrics.LatencyHistory = metrics.LatencyHistory[1:]
 	}
 
-	// Calculate simple moving average
-	var sum time.Duration
-	for _, l := range metrics.LatencyHistory {
-		sum += l
+	// Percentiles over the measurements within the window
+	cutoff := metrics.Timestamp.Add(-s.latencyWindow())
+	start := 0
+	for start < len(metrics.samples) && !metrics.samples[start].at.After(cutoff) {
+		start++
+	}
+	metrics.samples = append(metrics.samples[start:], latencySample{at: metrics.Timestamp, latency: latency})
+	if len(metrics.samples) > latencySampleLimit {
+		metrics.samples = metrics.samples[len(metrics.samples)-latencySampleLimit:]
 	}
-	metrics.AvgLatency = sum / time.Duration(len(metrics.LatencyHistory))
+	sorted := make([]time.Duration, len(metrics.samples))
+	for i, sample := range metrics.samples {
+		sorted[i] = sample.latency
+	}
+	slices.Sort(sorted)
+	metrics.P50Latency = percentile(sorted, 50)
+	metrics.P95Latency = percentile(sorted, 95)
+	metrics.P99Latency = percentile(sorted, 99)
+
+	// Exponentially weighted moving average, seeded by the first measurement
+	if len(metrics.LatencyHistory) == 1 {
+		metrics.AvgLatency = latency
+		return
+	}
+	weight := s.latencyWeight()
+	metrics.AvgLatency = time.Duration(weight*float64(latency) + (1-weight)*float64(metrics.AvgLatency))
 }
 
 // Get retrieves the metrics for a specific node
@@ -85,20 +159,66 @@ func (s *HeightStore) Get(network, node, endpointType string) (*NodeMetrics, boo
 	}
 
 	// Return a copy to prevent external modifications
-	metrics.mu.Lock()
-	defer metrics.mu.Unlock()
+	return metrics.snapshot(), true
+}
+
+// NodeEntry is a stored node endpoint with a copy of its metrics
+type NodeEntry struct {
+	Network string
+	Node    string
+	Type    string
+	Metrics *NodeMetrics
+}
+
+// GetAll returns a copy of every stored node endpoint
+func (s *HeightStore) GetAll() []NodeEntry {
+	entries := make([]NodeEntry, 0, s.data.Size())
+
+	s.data.Range(func(keyStr string, metrics *NodeMetrics) bool {
+		network, node, endpointType := parseKey(keyStr)
+		entries = append(entries, NodeEntry{
+			Network: network,
+			Node:    node,
+			Type:    endpointType,
+			Metrics: metrics.snapshot(),
+		})
+		return true
+	})
+
+	return entries
+}
+
+// Delete removes a node endpoint (e.g. a node dropped from config)
+// Returns true if the entry existed
+func (s *HeightStore) Delete(network, node, endpointType string) bool {
+	_, existed := s.data.LoadAndDelete(makeKey(network, node, endpointType))
+	return existed
+}
+
+// Len returns the number of stored node endpoints
+func (s *HeightStore) Len() int {
+	return s.data.Size()
+}
+
+// snapshot returns a copy of the metrics safe to hand out
+func (m *NodeMetrics) snapshot() *NodeMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	copy := &NodeMetrics{
-		Height:             metrics.Height,
-		Timestamp:          metrics.Timestamp,
-		Source:             metrics.Source,
-		LatencyHistory:     make([]time.Duration, len(metrics.LatencyHistory)),
-		AvgLatency:         metrics.AvgLatency,
-		WebSocketAvailable: metrics.WebSocketAvailable,
+		Height:             m.Height,
+		Timestamp:          m.Timestamp,
+		Source:             m.Source,
+		LatencyHistory:     make([]time.Duration, len(m.LatencyHistory)),
+		AvgLatency:         m.AvgLatency,
+		P50Latency:         m.P50Latency,
+		P95Latency:         m.P95Latency,
+		P99Latency:         m.P99Latency,
+		WebSocketAvailable: m.WebSocketAvailable,
 	}
-	copyDurations(copy.LatencyHistory, metrics.LatencyHistory)
+	copyDurations(copy.LatencyHistory, m.LatencyHistory)
 
-	return copy, true
+	return copy
 }
 
 // GetByNetwork returns all nodes for a given network and endpoint type
@@ -108,19 +228,7 @@ func (s *HeightStore) GetByNetwork(network, endpointType string) map[string]*Nod
 	s.data.Range(func(keyStr string, metrics *NodeMetrics) bool {
 		// Parse key: "network:node:type"
 		if keyNetwork, keyNode, keyType := parseKey(keyStr); keyNetwork == network && keyType == endpointType {
-			metrics.mu.Lock()
-			copy := &NodeMetrics{
-				Height:             metrics.Height,
-				Timestamp:          metrics.Timestamp,
-				Source:             metrics.Source,
-				LatencyHistory:     make([]time.Duration, len(metrics.LatencyHistory)),
-				AvgLatency:         metrics.AvgLatency,
-				WebSocketAvailable: metrics.WebSocketAvailable,
-			}
-			copyDurations(copy.LatencyHistory, metrics.LatencyHistory)
-			metrics.mu.Unlock()
-
-			result[keyNode] = copy
+			result[keyNode] = metrics.snapshot()
 		}
 		return true
 	})
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TestHeightStoreDeleteGetAllLen tests enumerating and removing node endpoints
+func TestHeightStoreDeleteGetAllLen(t *testing.T) {
+	store := NewHeightStore()
+	store.Update("pocket", "node-1", "api", 100, 10*time.Millisecond, "internal")
+	store.Update("pocket", "node-1", "rpc", 101, 10*time.Millisecond, "internal")
+	store.Update("pocket", "node-2", "api", 99, 10*time.Millisecond, "internal")
+
+	if store.Len() != 3 {
+		t.Fatalf("Expected 3 entries, got %d", store.Len())
+	}
+
+	entries := store.GetAll()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries from GetAll, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Network != "pocket" || entry.Metrics == nil {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+		if entry.Node == "node-1" && entry.Type == "rpc" && entry.Metrics.Height != 101 {
+			t.Errorf("Expected height 101 for node-1 rpc, got %d", entry.Metrics.Height)
+		}
+	}
+
+	if !store.Delete("pocket", "node-1", "api") {
+		t.Error("Expected Delete to report an existing entry")
+	}
+	if store.Delete("pocket", "node-1", "api") {
+		t.Error("Expected second Delete to report a missing entry")
+	}
+	if _, ok := store.Get("pocket", "node-1", "api"); ok {
+		t.Error("Expected node-1 api to be deleted")
+	}
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 entries after delete, got %d", store.Len())
+	}
+}
+
+// TestHeightStoreLatencyEWMA tests that a latency spike shows in the average right away and fades by half-life
+func TestHeightStoreLatencyEWMA(t *testing.T) {
+	store := NewHeightStore()
+	store.SetLatencyHalfLife(1)
+
+	store.Update("pocket", "node-1", "rpc", 100, 100*time.Millisecond, "internal")
+	store.Update("pocket", "node-1", "rpc", 100, 300*time.Millisecond, "internal")
+	if m, _ := store.Get("pocket", "node-1", "rpc"); m.AvgLatency != 200*time.Millisecond {
+		t.Fatalf("Expected the spike to weigh half with a half-life of 1, got %s", m.AvgLatency)
+	}
+	store.Update("pocket", "node-1", "rpc", 100, 100*time.Millisecond, "internal")
+	if m, _ := store.Get("pocket", "node-1", "rpc"); m.AvgLatency != 150*time.Millisecond {
+		t.Errorf("Expected the spike to fade by half, got %s", m.AvgLatency)
+	}
+
+	// A longer half-life smooths the same spike more
+	store.SetLatencyHalfLife(0)
+	store.Update("pocket", "node-2", "rpc", 100, 100*time.Millisecond, "internal")
+	store.Update("pocket", "node-2", "rpc", 100, 300*time.Millisecond, "internal")
+	if m, _ := store.Get("pocket", "node-2", "rpc"); m.AvgLatency <= 150*time.Millisecond || m.AvgLatency >= 200*time.Millisecond {
+		t.Errorf("Expected the default half-life to weigh the spike between a quarter and a half, got %s", m.AvgLatency)
+	}
+}
+
+// TestHeightStoreLatencyPercentiles tests p50/p95/p99 over the measurements and the window they cover
+func TestHeightStoreLatencyPercentiles(t *testing.T) {
+	store := NewHeightStore()
+	for i := 1; i <= 100; i++ {
+		store.Update("pocket", "node-1", "rpc", 100, time.Duration(i)*time.Millisecond, "internal")
+	}
+
+	m, _ := store.Get("pocket", "node-1", "rpc")
+	if m.P50Latency != 50*time.Millisecond || m.P95Latency != 95*time.Millisecond || m.P99Latency != 99*time.Millisecond {
+		t.Errorf("Expected p50/p95/p99 of 50ms/95ms/99ms, got %s/%s/%s", m.P50Latency, m.P95Latency, m.P99Latency)
+	}
+
+	// Measurements older than the window drop out
+	store.SetLatencyWindow(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	store.Update("pocket", "node-1", "rpc", 100, 7*time.Millisecond, "internal")
+	if m, _ := store.Get("pocket", "node-1", "rpc"); m.P99Latency != 7*time.Millisecond {
+		t.Errorf("Expected only the latest measurement within the window, got p99 %s", m.P99Latency)
+	}
+}
+
+// TestExternalEndpointStoreGetAllLen tests enumerating external endpoints
+func TestExternalEndpointStoreGetAllLen(t *testing.T) {
+	store := NewExternalEndpointStore(zap.NewNop())
+	store.StoreAdvertised("pnf", "https://ring.example.com", "pocket", "api", "https://api.example.com")
+	store.StoreAdvertised("pnf", "https://ring.example.com", "pocket", "rpc", "https://rpc.example.com")
+
+	if store.Len() != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", store.Len())
+	}
+	if len(store.GetAll()) != 2 {
+		t.Fatalf("Expected 2 endpoints from GetAll, got %d", len(store.GetAll()))
+	}
+
+	store.RemoveEndpoint("pnf", "https://ring.example.com", "pocket", "api", "https://api.example.com")
+	if store.Len() != 1 {
+		t.Errorf("Expected 1 endpoint after removal, got %d", store.Len())
+	}
+}
+
+// TestExternalEndpointStoreRemoveAdvertised tests dropping every endpoint a ring advertised for a network
+func TestExternalEndpointStoreRemoveAdvertised(t *testing.T) {
+	store := NewExternalEndpointStore(zap.NewNop())
+	store.StoreAdvertised("pnf", "https://ring.example.com", "pocket", "api", "https://api.example.com")
+	store.StoreAdvertised("pnf", "https://ring.example.com", "pocket", "rpc", "https://rpc.example.com")
+	store.StoreAdvertised("pnf", "https://ring.example.com", "pocket-beta", "api", "https://beta.example.com")
+
+	if removed := store.RemoveAdvertised("pnf", "https://ring.example.com", "pocket"); removed != 2 {
+		t.Fatalf("Expected 2 endpoints removed, got %d", removed)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected the other network's endpoint to remain, got %d endpoints", store.Len())
+	}
+}
+
+// TestExternalEndpointReputationSurvivesRestart tests that a quarantined endpoint is
+// restored as quarantined by a fresh store loading the persisted reputation
+func TestExternalEndpointReputationSurvivesRestart(t *testing.T) {
+	path := t.TempDir() + "/reputation.json"
+	ring, url := "https://ring.example.com", "https://api.example.com"
+
+	store := NewExternalEndpointStore(zap.NewNop())
+	store.StoreAdvertised("pnf", ring, "pocket", "api", url)
+	store.MarkValidated("pnf", ring, "pocket", "api", url, 100, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		store.TrackProxyError("pocket", "api", url)
+	}
+	if err := store.SaveReputation(path); err != nil {
+		t.Fatalf("SaveReputation failed: %v", err)
+	}
+
+	restarted := NewExternalEndpointStore(zap.NewNop())
+	if err := restarted.LoadReputation(path); err != nil {
+		t.Fatalf("LoadReputation failed: %v", err)
+	}
+	restarted.StoreAdvertised("pnf", ring, "pocket", "api", url)
+	restarted.MarkValidated("pnf", ring, "pocket", "api", url, 101, 10*time.Millisecond)
+
+	if len(restarted.GetValidatedEndpoints("pocket", "api")) != 0 {
+		t.Error("Expected quarantined endpoint not to be trusted after restart")
+	}
+	failed := restarted.GetFailedEndpoints()
+	if len(failed) != 1 || failed[0].ErrorCount != 3 {
+		t.Errorf("Expected restored failed endpoint with 3 errors, got %+v", failed)
+	}
+}
+
+// TestRankRingsPrefersHealthiest tests that rings are ordered by success rate, then latency
+func TestRankRingsPrefersHealthiest(t *testing.T) {
+	store := NewExternalEndpointStore(zap.NewNop())
+	rings := []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"}
+
+	store.RecordRingCheck("pnf", rings[0], "pocket", 0, 0, errors.New("timeout"))
+	store.RecordRingCheck("pnf", rings[1], "pocket", 100, 80*time.Millisecond, nil)
+	store.RecordRingCheck("pnf", rings[2], "pocket", 100, 20*time.Millisecond, nil)
+
+	ranked := store.RankRings("pnf", "pocket", rings)
+	got := []string{ranked[0].RingURL, ranked[1].RingURL, ranked[2].RingURL}
+	want := []string{rings[2], rings[1], rings[0]}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected order %v, got %v", want, got)
+		}
+	}
+}
+
+// TestEstimateHeadIgnoresBogusHeight tests that one source reporting a huge height
+// doesn't move the network head estimate
+func TestEstimateHeadIgnoresBogusHeight(t *testing.T) {
+	heights := NewHeightStore()
+	endpoints := NewExternalEndpointStore(zap.NewNop())
+
+	heights.Update("pocket", "node-1", "api", 100, 10*time.Millisecond, "internal")
+	heights.Update("pocket", "node-1", "rpc", 101, 10*time.Millisecond, "internal")
+	heights.Update("pocket", "node-2", "api", 100, 10*time.Millisecond, "internal")
+	heights.Update("pocket", "node-3", "api", 999999, 10*time.Millisecond, "internal")
+	endpoints.RecordRingCheck("pnf", "https://ring.example.com", "pocket", 102, 10*time.Millisecond, nil)
+
+	// Sources: node-1=101, node-2=100, node-3=999999, ring=102
+	if head := EstimateHead(heights, endpoints, "pocket"); head != 101 {
+		t.Errorf("Expected head 101, got %d", head)
+	}
+	if head := EstimateHead(heights, endpoints, "other"); head != 0 {
+		t.Errorf("Expected head 0 for unknown network, got %d", head)
+	}
+}
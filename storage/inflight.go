@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"sauron/metrics"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// InflightTracker counts requests currently proxied to each node, per network and endpoint type
+// Both proxies feed it; load-aware selection (egress overflow, least connections, load shedding) reads it
+type InflightTracker struct {
+	counts *xsync.Map[string, *inflightCounts]
+}
+
+// inflightCounts is what the proxies hold open to one node endpoint
+type inflightCounts struct {
+	requests   atomic.Int64 // API/RPC requests and gRPC calls, streams included
+	websockets atomic.Int64 // proxied WebSocket connections
+}
+
+// NewInflightTracker creates an empty in-flight tracker
+func NewInflightTracker() *InflightTracker {
+	return &InflightTracker{
+		counts: xsync.NewMap[string, *inflightCounts](),
+	}
+}
+
+// inflightKey builds "network:type:node"; the node goes last since external names ("ext:{url}") contain colons
+func inflightKey(network, node, endpointType string) string {
+	return network + ":" + endpointType + ":" + node
+}
+
+// counter returns the counts of a node endpoint, creating them on first use
+func (t *InflightTracker) counter(network, node, endpointType string) *inflightCounts {
+	key := inflightKey(network, node, endpointType)
+	if c, ok := t.counts.Load(key); ok {
+		return c
+	}
+	c, _ := t.counts.LoadOrStore(key, new(inflightCounts))
+	return c
+}
+
+// Increment marks a request as in flight to a node and returns the new count
+func (t *InflightTracker) Increment(network, node, endpointType string) int64 {
+	c := t.counter(network, node, endpointType)
+	n := c.requests.Add(1)
+	metrics.NodeInFlightRequests.WithLabelValues(network, node, endpointType).Set(float64(n))
+	metrics.ProxyActiveConnections.WithLabelValues(network, node, endpointType).Set(float64(n + c.websockets.Load()))
+	return n
+}
+
+// Decrement marks a request to a node as completed and returns the new count
+func (t *InflightTracker) Decrement(network, node, endpointType string) int64 {
+	c := t.counter(network, node, endpointType)
+	n := c.requests.Add(-1)
+	metrics.NodeInFlightRequests.WithLabelValues(network, node, endpointType).Set(float64(n))
+	metrics.ProxyActiveConnections.WithLabelValues(network, node, endpointType).Set(float64(n + c.websockets.Load()))
+	return n
+}
+
+// OpenWebSocket counts a proxied WebSocket connection to a node until CloseWebSocket
+// WebSockets are connections, not requests: they don't count towards Load or Total
+func (t *InflightTracker) OpenWebSocket(network, node, endpointType string) {
+	c := t.counter(network, node, endpointType)
+	n := c.websockets.Add(1)
+	metrics.ProxyActiveConnections.WithLabelValues(network, node, endpointType).Set(float64(c.requests.Load() + n))
+}
+
+// CloseWebSocket marks a proxied WebSocket connection to a node as closed
+func (t *InflightTracker) CloseWebSocket(network, node, endpointType string) {
+	c := t.counter(network, node, endpointType)
+	n := c.websockets.Add(-1)
+	metrics.ProxyActiveConnections.WithLabelValues(network, node, endpointType).Set(float64(c.requests.Load() + n))
+}
+
+// Load returns the number of requests in flight to a node
+func (t *InflightTracker) Load(network, node, endpointType string) int64 {
+	if c, ok := t.counts.Load(inflightKey(network, node, endpointType)); ok {
+		return c.requests.Load()
+	}
+	return 0
+}
+
+// Connections returns the requests in flight plus the WebSocket connections open to a node
+func (t *InflightTracker) Connections(network, node, endpointType string) int64 {
+	if c, ok := t.counts.Load(inflightKey(network, node, endpointType)); ok {
+		return c.requests.Load() + c.websockets.Load()
+	}
+	return 0
+}
+
+// Total returns the number of requests in flight to all nodes of a network endpoint type
+func (t *InflightTracker) Total(network, endpointType string) int64 {
+	prefix := network + ":" + endpointType + ":"
+	var total int64
+	t.counts.Range(func(key string, c *inflightCounts) bool {
+		if strings.HasPrefix(key, prefix) {
+			total += c.requests.Load()
+		}
+		return true
+	})
+	return total
+}
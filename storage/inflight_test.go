@@ -0,0 +1,40 @@
+package storage
+
+import "testing"
+
+// TestInflightTracker tests counting requests per node and totals per network endpoint type
+func TestInflightTracker(t *testing.T) {
+	tracker := NewInflightTracker()
+
+	tracker.Increment("pocket", "node-1", "api")
+	tracker.Increment("pocket", "node-1", "api")
+	tracker.Increment("pocket", "ext:https://ext1.example.com", "api")
+	tracker.Increment("pocket", "node-1", "rpc")
+
+	if n := tracker.Load("pocket", "node-1", "api"); n != 2 {
+		t.Errorf("Expected 2 in flight to node-1 api, got %d", n)
+	}
+	if n := tracker.Total("pocket", "api"); n != 3 {
+		t.Errorf("Expected 3 in flight to pocket api, got %d", n)
+	}
+
+	if n := tracker.Decrement("pocket", "node-1", "api"); n != 1 {
+		t.Errorf("Expected 1 in flight after Decrement, got %d", n)
+	}
+	if n := tracker.Load("pocket", "node-2", "api"); n != 0 {
+		t.Errorf("Expected 0 in flight to an unknown node, got %d", n)
+	}
+
+	// WebSockets are connections but not requests
+	tracker.OpenWebSocket("pocket", "node-1", "api")
+	if n := tracker.Connections("pocket", "node-1", "api"); n != 2 {
+		t.Errorf("Expected 2 connections to node-1 api, got %d", n)
+	}
+	if n := tracker.Load("pocket", "node-1", "api"); n != 1 {
+		t.Errorf("Expected WebSockets not to count as requests, got %d", n)
+	}
+	tracker.CloseWebSocket("pocket", "node-1", "api")
+	if n := tracker.Connections("pocket", "node-1", "api"); n != 1 {
+		t.Errorf("Expected 1 connection after CloseWebSocket, got %d", n)
+	}
+}
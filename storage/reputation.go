@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// QuarantineBaseBackoff is how long an endpoint stays out of rotation after its first quarantine
+	QuarantineBaseBackoff = 10 * time.Second
+	// QuarantineMaxBackoff caps the doubling backoff for repeatedly failing endpoints
+	QuarantineMaxBackoff = 5 * time.Minute
+	// ReputationRetention is how long records of endpoints no longer advertised are kept
+	ReputationRetention = 24 * time.Hour
+)
+
+// reputationRecord is the persisted reputation of an external endpoint
+// The Eye remembers who failed it
+type reputationRecord struct {
+	IsWorking        bool      `json:"is_working"`
+	ErrorCount       int       `json:"error_count"`
+	LastError        time.Time `json:"last_error"`
+	LastValidated    time.Time `json:"last_validated"`
+	Quarantines      int       `json:"quarantines"`
+	QuarantinedUntil time.Time `json:"quarantined_until"`
+}
+
+// reputationFile is the on-disk format of the reputation store
+type reputationFile struct {
+	SavedAt   time.Time                   `json:"saved_at"`
+	Endpoints map[string]reputationRecord `json:"endpoints"`
+}
+
+// quarantine takes an endpoint out of rotation with a doubling backoff
+// Callers must hold the store lock
+func quarantine(ep *ExternalEndpoint) {
+	ep.Quarantines++
+	backoff := QuarantineBaseBackoff
+	for i := 1; i < ep.Quarantines && backoff < QuarantineMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > QuarantineMaxBackoff {
+		backoff = QuarantineMaxBackoff
+	}
+	ep.QuarantinedUntil = time.Now().Add(backoff)
+}
+
+// recordOf captures the reputation of an endpoint
+func recordOf(ep *ExternalEndpoint) reputationRecord {
+	return reputationRecord{
+		IsWorking:        ep.IsWorking,
+		ErrorCount:       ep.ErrorCount,
+		LastError:        ep.LastError,
+		LastValidated:    ep.LastValidated,
+		Quarantines:      ep.Quarantines,
+		QuarantinedUntil: ep.QuarantinedUntil,
+	}
+}
+
+// apply restores a persisted reputation onto a freshly advertised endpoint
+// An endpoint that was not working comes back quarantined until its backoff expires,
+// even if the restart took longer than that
+func (r reputationRecord) apply(ep *ExternalEndpoint) {
+	ep.ErrorCount = r.ErrorCount
+	ep.LastError = r.LastError
+	ep.LastValidated = r.LastValidated
+	ep.Quarantines = r.Quarantines
+	ep.QuarantinedUntil = r.QuarantinedUntil
+
+	if !r.IsWorking && !r.LastError.IsZero() && ep.QuarantinedUntil.Before(time.Now()) {
+		// Still failing moments before shutdown: require one more backoff period
+		quarantine(ep)
+	}
+}
+
+// lastSeen returns the most recent activity of a record
+func (r reputationRecord) lastSeen() time.Time {
+	if r.LastError.After(r.LastValidated) {
+		return r.LastError
+	}
+	return r.LastValidated
+}
+
+// LoadReputation loads persisted endpoint reputation from a local file
+// Records are applied as endpoints are advertised again; a missing file is not an error
+func (s *ExternalEndpointStore) LoadReputation(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read reputation file: %w", err)
+	}
+
+	var file reputationFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse reputation file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-ReputationRetention)
+	loaded := 0
+	for key, record := range file.Endpoints {
+		if record.lastSeen().Before(cutoff) {
+			continue
+		}
+		if ep, exists := s.endpoints[key]; exists {
+			record.apply(ep)
+		} else {
+			s.reputation[key] = record
+		}
+		loaded++
+	}
+
+	s.logger.Info("Endpoint reputation loaded",
+		zap.String("path", path),
+		zap.Int("endpoints", loaded),
+		zap.Time("saved_at", file.SavedAt),
+	)
+
+	return nil
+}
+
+// SaveReputation persists endpoint reputation to a local file
+// The file is written atomically (temp file + rename) so a crash never leaves it truncated
+func (s *ExternalEndpointStore) SaveReputation(path string) error {
+	s.mu.RLock()
+	file := reputationFile{
+		SavedAt:   time.Now(),
+		Endpoints: make(map[string]reputationRecord, len(s.endpoints)+len(s.reputation)),
+	}
+	cutoff := file.SavedAt.Add(-ReputationRetention)
+	for key, record := range s.reputation {
+		if !record.lastSeen().Before(cutoff) {
+			file.Endpoints[key] = record
+		}
+	}
+	for key, ep := range s.endpoints {
+		file.Endpoints[key] = recordOf(ep)
+	}
+	s.mu.RUnlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode reputation: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create reputation temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write reputation file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync reputation file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close reputation file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace reputation file: %w", err)
+	}
+
+	return nil
+}
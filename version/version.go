@@ -0,0 +1,9 @@
+// Package version holds the Sauron release of this build and how Sauron's own requests name it
+package version
+
+// Version is the Sauron release of this build
+const Version = "1.0.0"
+
+// UserAgent identifies Sauron's own requests (health checks, endpoint validations, ring queries)
+// so backend operators and peer rings can tell them from client traffic
+const UserAgent = "sauron/" + Version